@@ -11,11 +11,16 @@ import (
 
 // App config struct
 type Config struct {
-	Port       int
-	Env        string
-	PostgreSQL PostgreSQL
-	Kafka      Kafka
-	Timeout    Timeout
+	Port               int
+	Env                string
+	AuthURL            string
+	JWKSURL            string
+	RevokedSessionsURL string
+	PostgreSQL         PostgreSQL
+	Kafka              Kafka
+	AuthBreaker        AuthBreaker
+	ServiceAuth        ServiceAuth
+	Timeout            Timeout
 }
 
 // PostgreSQL config struct
@@ -33,9 +38,24 @@ type PostgreSQL struct {
 
 // Kafka config struct
 type Kafka struct {
-	Brokers []string
-	Topics  map[string]string
-	GroupID string
+	Brokers      []string
+	Topics       map[string]string
+	GroupID      string
+	BatchSize    int
+	BatchTimeout time.Duration
+}
+
+// Service-to-service authentication config struct
+type ServiceAuth struct {
+	ClientID     string // name of this service, also the audience of tokens for its internal routes
+	ClientSecret string
+	TokenURL     string
+}
+
+// Auth service circuit breaker config struct
+type AuthBreaker struct {
+	Threshold int
+	Cooldown  time.Duration
 }
 
 // Timeouts config struct
@@ -46,6 +66,10 @@ type Timeout struct {
 	ServerRead       time.Duration
 	ServerWrite      time.Duration
 	ServerShutdown   time.Duration
+	JWKSCache        time.Duration
+	AuthRequest      time.Duration
+	AuthCache        time.Duration
+	RevocationsCache time.Duration
 }
 
 // Load config file from given path
@@ -78,6 +102,14 @@ func ParseConfig(v *viper.Viper) (*Config, error) {
 	// Server config
 	c.Port = v.GetInt("port")
 	c.Env = v.GetString("env")
+	c.AuthURL = v.GetString("auth_url")
+	c.JWKSURL = v.GetString("jwks_url")
+	c.RevokedSessionsURL = v.GetString("revoked_sessions_url")
+
+	// Service auth config
+	c.ServiceAuth.ClientID = v.GetString("service_client_id")
+	c.ServiceAuth.ClientSecret = v.GetString("service_client_secret")
+	c.ServiceAuth.TokenURL = v.GetString("service_token_url")
 
 	// PostgreSQL config
 	c.PostgreSQL.Host = v.GetString("postgresql_host")
@@ -105,6 +137,11 @@ func ParseConfig(v *viper.Viper) (*Config, error) {
 		}
 	}
 	c.Kafka.GroupID = v.GetString("kafka_group_id")
+	c.Kafka.BatchSize = v.GetInt("kafka_batch_size")
+	c.Kafka.BatchTimeout, err = parseTimeout(v, "kafka_batch_timeout")
+	if err != nil {
+		return nil, err
+	}
 
 	// Timeout config
 	c.Timeout.PostgreSQLConn, err = parseTimeout(v, "timeout_postgresql_conn")
@@ -131,6 +168,29 @@ func ParseConfig(v *viper.Viper) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	c.Timeout.JWKSCache, err = parseTimeout(v, "timeout_jwks_cache")
+	if err != nil {
+		return nil, err
+	}
+	c.Timeout.AuthRequest, err = parseTimeout(v, "timeout_auth_request")
+	if err != nil {
+		return nil, err
+	}
+	c.Timeout.AuthCache, err = parseTimeout(v, "timeout_auth_cache")
+	if err != nil {
+		return nil, err
+	}
+	c.Timeout.RevocationsCache, err = parseTimeout(v, "timeout_revocations_cache")
+	if err != nil {
+		return nil, err
+	}
+
+	// Auth service circuit breaker config
+	c.AuthBreaker.Threshold = v.GetInt("auth_breaker_threshold")
+	c.AuthBreaker.Cooldown, err = parseTimeout(v, "auth_breaker_cooldown")
+	if err != nil {
+		return nil, err
+	}
 
 	return &c, nil
 }
@@ -1,8 +1,17 @@
 package analytics
 
-import "github.com/segmentio/kafka-go"
+import (
+	"net/http"
+
+	"github.com/segmentio/kafka-go"
+)
 
 // Analytics handlers interface
+type Handlers interface {
+	GetActions() http.HandlerFunc
+}
+
+// Analytics kafka handlers interface
 type KafkaHandlers interface {
-	HandleMessage(msg kafka.Message) error
+	HandleBatch(msgs []kafka.Message) error
 }
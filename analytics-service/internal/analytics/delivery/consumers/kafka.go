@@ -2,6 +2,7 @@ package consumers
 
 import (
 	"encoding/json"
+	"strconv"
 	"strings"
 	"time"
 
@@ -29,38 +30,114 @@ func NewKafkaMessageHandlers(cfg *config.Config, analyticsUC analytics.UseCase,
 	}
 }
 
-// Kafka message handler
-func (h *KafkaMessageHandlers) HandleMessage(msg kafka.Message) error {
+// Kafka batch handler, messages that can't be parsed are logged and dropped so the rest of the batch is stored and committed
+func (h *KafkaMessageHandlers) HandleBatch(msgs []kafka.Message) error {
+	actions := make([]models.Action, 0, len(msgs))
+
+	for _, msg := range msgs {
+		action, err := h.parseMessage(msg)
+		if err != nil {
+			h.logger.Warn("dropping unparsable Kafka message",
+				zap.String("topic", msg.Topic),
+				zap.Int("partition", msg.Partition),
+				zap.Int64("offset", msg.Offset),
+				zap.ByteString("value", msg.Value),
+			)
+			continue
+		}
+		actions = append(actions, *action)
+	}
+
+	if len(actions) == 0 {
+		return nil
+	}
+
+	err := h.analyticsUC.InsertBatch(actions)
+	if err != nil {
+		h.logger.Error("failed to insert actions", zap.Error(err))
+		return err
+	}
+
+	h.logger.Info("kafka batch stored", zap.Int("size", len(actions)))
+
+	return nil
+}
+
+// Accepted layouts for the event time, tried in order
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+}
+
+// Parse a single Kafka message into an action
+func (h *KafkaMessageHandlers) parseMessage(msg kafka.Message) (*models.Action, error) {
 	var payload models.KafkaMessageDTO
 
 	err := json.Unmarshal(msg.Value, &payload)
 	if err != nil {
 		h.logger.Error("failed to unmarshal Kafka message", zap.Error(err))
-		return err
+		return nil, err
 	}
 
-	h.logger.Info("kafka message received",
+	h.logger.Debug("kafka message received",
+		zap.String("topic", msg.Topic),
 		zap.ByteString("key", msg.Key),
 		zap.String("action", payload.Action),
 		zap.Strings("tags", payload.Tags),
-		zap.String("time", payload.Time),
+		zap.ByteString("time", payload.Time),
 	)
 	payload.Action = strings.TrimSpace(strings.ToLower(strings.TrimRight(payload.Action, "\n\r")))
 
-	objectID := string(msg.Key)
-	action := payload.Action
-	actionTime := payload.Time
-	parsedTime, err := time.Parse(time.RFC3339, actionTime)
-	if err != nil {
-		h.logger.Error("couldn't parse time", zap.Error(err))
-		return err
+	actionTime, ok := parseActionTime(payload.Time)
+	if !ok {
+		actionTime = msg.Time
+		if actionTime.IsZero() {
+			actionTime = time.Now()
+		}
+		h.logger.Warn("couldn't parse time, using message timestamp",
+			zap.ByteString("time", payload.Time),
+			zap.Time("fallback", actionTime),
+		)
 	}
 
-	err = h.analyticsUC.Insert(action, objectID, parsedTime)
-	if err != nil {
-		h.logger.Error("failed to insert action", zap.Error(err))
-		return err
+	return &models.Action{
+		Action:    payload.Action,
+		ObjectID:  string(msg.Key),
+		Time:      actionTime.UTC(),
+		Payload:   json.RawMessage(msg.Value),
+		Topic:     msg.Topic,
+		Partition: msg.Partition,
+		Offset:    msg.Offset,
+	}, nil
+}
+
+// Parse event time given either as a string in one of the known layouts or as a unix timestamp
+func parseActionTime(raw json.RawMessage) (time.Time, bool) {
+	if len(raw) == 0 {
+		return time.Time{}, false
 	}
 
-	return nil
+	var str string
+	if err := json.Unmarshal(raw, &str); err != nil {
+		str = string(raw)
+	}
+	str = strings.TrimSpace(str)
+
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, str); err == nil {
+			return t, true
+		}
+	}
+
+	if unix, err := strconv.ParseInt(str, 10, 64); err == nil && unix > 0 {
+		// Values this large can only be milliseconds
+		if unix > 1e12 {
+			return time.UnixMilli(unix), true
+		}
+		return time.Unix(unix, 0), true
+	}
+
+	return time.Time{}, false
 }
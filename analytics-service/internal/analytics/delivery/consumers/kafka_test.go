@@ -1,8 +1,10 @@
 package consumers
 
 import (
+	"encoding/json"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/segmentio/kafka-go"
@@ -11,9 +13,10 @@ import (
 
 	"cyansnbrst/analytics-service/config"
 	mock_analytics "cyansnbrst/analytics-service/internal/analytics/mock"
+	"cyansnbrst/analytics-service/internal/models"
 )
 
-func TestKafkaMessageHandlers_HandleMessage(t *testing.T) {
+func TestKafkaMessageHandlers_HandleBatch(t *testing.T) {
 	cfg := &config.Config{}
 
 	logger := zap.NewNop()
@@ -27,47 +30,128 @@ func TestKafkaMessageHandlers_HandleMessage(t *testing.T) {
 
 	tests := []struct {
 		name         string
-		message      kafka.Message
+		messages     []kafka.Message
 		mockBehavior func(mockAnalyticsUC *mock_analytics.MockUseCase)
 		wantErr      bool
 	}{
 		{
 			name: "valid message",
-			message: kafka.Message{
-				Key:   []byte("1234"),
-				Value: []byte(`{"action":"click","tags":["tag1"],"time":"2023-01-01T12:00:00Z"}`),
+			messages: []kafka.Message{
+				{
+					Topic:     "product_updates",
+					Partition: 1,
+					Offset:    42,
+					Key:       []byte("1234"),
+					Value:     []byte(`{"action":"click","tags":["tag1"],"time":"2023-01-01T12:00:00Z"}`),
+				},
 			},
 			mockBehavior: func(mockAnalyticsUC *mock_analytics.MockUseCase) {
-				mockAnalyticsUC.EXPECT().Insert("click", "1234", gomock.Any()).Return(nil)
+				mockAnalyticsUC.EXPECT().InsertBatch([]models.Action{
+					{
+						Action:    "click",
+						ObjectID:  "1234",
+						Time:      time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC),
+						Payload:   json.RawMessage(`{"action":"click","tags":["tag1"],"time":"2023-01-01T12:00:00Z"}`),
+						Topic:     "product_updates",
+						Partition: 1,
+						Offset:    42,
+					},
+				}).Return(nil)
 			},
 			wantErr: false,
 		},
 		{
-			name: "invalid JSON format",
-			message: kafka.Message{
-				Key:   []byte("1234"),
-				Value: []byte("invalid_json"),
+			name: "invalid JSON format is dropped",
+			messages: []kafka.Message{
+				{
+					Key:   []byte("1234"),
+					Value: []byte("invalid_json"),
+				},
 			},
 			mockBehavior: func(mockAnalyticsUC *mock_analytics.MockUseCase) {},
-			wantErr:      true,
+			wantErr:      false,
 		},
 		{
-			name: "time parsing error",
-			message: kafka.Message{
-				Key:   []byte("1234"),
-				Value: []byte(`{"action":"click","tags":["tag1"],"time":"invalid_time"}`),
+			name: "invalid message does not fail the batch",
+			messages: []kafka.Message{
+				{
+					Key:   []byte("1234"),
+					Value: []byte("invalid_json"),
+				},
+				{
+					Key:   []byte("5678"),
+					Value: []byte(`{"action":"user_update","tags":["music"],"time":"2023-01-01T12:00:01Z"}`),
+				},
 			},
-			mockBehavior: func(mockAnalyticsUC *mock_analytics.MockUseCase) {},
-			wantErr:      true,
+			mockBehavior: func(mockAnalyticsUC *mock_analytics.MockUseCase) {
+				mockAnalyticsUC.EXPECT().InsertBatch(gomock.Any()).DoAndReturn(func(actions []models.Action) error {
+					require.Len(t, actions, 1)
+					require.Equal(t, "5678", actions[0].ObjectID)
+					return nil
+				})
+			},
+			wantErr: false,
+		},
+		{
+			name: "unparsable time falls back to message timestamp",
+			messages: []kafka.Message{
+				{
+					Key:   []byte("1234"),
+					Value: []byte(`{"action":"click","tags":["tag1"],"time":"invalid_time"}`),
+					Time:  time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC),
+				},
+			},
+			mockBehavior: func(mockAnalyticsUC *mock_analytics.MockUseCase) {
+				mockAnalyticsUC.EXPECT().InsertBatch(gomock.Any()).DoAndReturn(func(actions []models.Action) error {
+					require.Equal(t, time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), actions[0].Time)
+					return nil
+				})
+			},
+			wantErr: false,
+		},
+		{
+			name: "unix timestamp",
+			messages: []kafka.Message{
+				{
+					Key:   []byte("1234"),
+					Value: []byte(`{"action":"click","time":1672574400}`),
+				},
+			},
+			mockBehavior: func(mockAnalyticsUC *mock_analytics.MockUseCase) {
+				mockAnalyticsUC.EXPECT().InsertBatch(gomock.Any()).DoAndReturn(func(actions []models.Action) error {
+					require.Equal(t, time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC), actions[0].Time)
+					return nil
+				})
+			},
+			wantErr: false,
+		},
+		{
+			name: "multiple messages",
+			messages: []kafka.Message{
+				{
+					Key:   []byte("1234"),
+					Value: []byte(`{"action":"view_products","tags":null,"time":"2023-01-01T12:00:00Z"}`),
+				},
+				{
+					Key:   []byte("5678"),
+					Value: []byte(`{"action":"user_update","tags":["music"],"time":"2023-01-01T12:00:01Z"}`),
+				},
+			},
+			mockBehavior: func(mockAnalyticsUC *mock_analytics.MockUseCase) {
+				mockAnalyticsUC.EXPECT().InsertBatch(gomock.Len(2)).Return(nil)
+			},
+			wantErr: false,
 		},
 		{
 			name: "insert error",
-			message: kafka.Message{
-				Key:   []byte("1234"),
-				Value: []byte(`{"action":"click","tags":["tag1"],"time":"2023-01-01T12:00:00Z"}`),
+			messages: []kafka.Message{
+				{
+					Key:   []byte("1234"),
+					Value: []byte(`{"action":"click","tags":["tag1"],"time":"2023-01-01T12:00:00Z"}`),
+				},
 			},
 			mockBehavior: func(mockAnalyticsUC *mock_analytics.MockUseCase) {
-				mockAnalyticsUC.EXPECT().Insert("click", "1234", gomock.Any()).Return(errors.New("db error"))
+				mockAnalyticsUC.EXPECT().InsertBatch(gomock.Any()).Return(errors.New("db error"))
 			},
 			wantErr: true,
 		},
@@ -78,7 +162,7 @@ func TestKafkaMessageHandlers_HandleMessage(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mockBehavior(mockAnalyticsUC)
 
-			err := analyticsHandlers.HandleMessage(tt.message)
+			err := analyticsHandlers.HandleBatch(tt.messages)
 
 			if tt.wantErr {
 				require.Error(t, err)
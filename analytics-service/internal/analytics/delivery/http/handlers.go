@@ -0,0 +1,100 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+
+	"cyansnbrst/analytics-service/config"
+	"cyansnbrst/analytics-service/internal/analytics"
+	"cyansnbrst/analytics-service/internal/models"
+	erp "cyansnbrst/analytics-service/pkg/error_responses"
+	"cyansnbrst/analytics-service/pkg/utils"
+)
+
+// Validation errors
+var (
+	errInvalidPayload = errors.New("payload must be a JSON object")
+	errInvalidFrom    = errors.New("from must be an RFC3339 timestamp")
+	errInvalidTo      = errors.New("to must be an RFC3339 timestamp")
+	errInvalidLimit   = errors.New("limit must be a positive integer")
+)
+
+// Analytics handlers
+type analyticsHandlers struct {
+	cfg         *config.Config
+	analyticsUC analytics.UseCase
+	logger      *zap.Logger
+}
+
+// Analytics handlers constructor
+func NewAnalyticsHandlers(cfg *config.Config, analyticsUC analytics.UseCase, logger *zap.Logger) analytics.Handlers {
+	return &analyticsHandlers{cfg: cfg, analyticsUC: analyticsUC, logger: logger}
+}
+
+// Get actions filtered by object, action, time range and payload fields.
+// The payload parameter is a JSON object matched by containment,
+// e.g. ?object_id=42&action=product_update&payload={"tags":["music"]}
+func (h *analyticsHandlers) GetActions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		qs := r.URL.Query()
+
+		filter := models.ActionFilterDTO{
+			ObjectID: qs.Get("object_id"),
+			Action:   qs.Get("action"),
+		}
+
+		if payload := qs.Get("payload"); payload != "" {
+			var fields map[string]interface{}
+			if err := json.Unmarshal([]byte(payload), &fields); err != nil {
+				erp.BadRequestResponse(w, r, h.logger, errInvalidPayload)
+				return
+			}
+			filter.Payload = json.RawMessage(payload)
+		}
+
+		if from := qs.Get("from"); from != "" {
+			t, err := time.Parse(time.RFC3339, from)
+			if err != nil {
+				erp.BadRequestResponse(w, r, h.logger, errInvalidFrom)
+				return
+			}
+			filter.From = &t
+		}
+
+		if to := qs.Get("to"); to != "" {
+			t, err := time.Parse(time.RFC3339, to)
+			if err != nil {
+				erp.BadRequestResponse(w, r, h.logger, errInvalidTo)
+				return
+			}
+			filter.To = &t
+		}
+
+		if limit := qs.Get("limit"); limit != "" {
+			n, err := strconv.Atoi(limit)
+			if err != nil || n < 1 {
+				erp.BadRequestResponse(w, r, h.logger, errInvalidLimit)
+				return
+			}
+			filter.Limit = n
+		}
+
+		actions, err := h.analyticsUC.FindActions(filter)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+			return
+		}
+
+		err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+			"actions": actions,
+		}, nil)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+		}
+	}
+}
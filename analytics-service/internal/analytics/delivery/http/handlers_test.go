@@ -0,0 +1,92 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"cyansnbrst/analytics-service/config"
+	mock_analytics "cyansnbrst/analytics-service/internal/analytics/mock"
+	"cyansnbrst/analytics-service/internal/models"
+)
+
+func TestAnalyticsHandlers_GetActions(t *testing.T) {
+	cfg := &config.Config{}
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAnalyticsUC := mock_analytics.NewMockUseCase(ctrl)
+	analyticsHandlers := NewAnalyticsHandlers(cfg, mockAnalyticsUC, logger)
+
+	tests := []struct {
+		name         string
+		query        url.Values
+		mockBehavior func(mockAnalyticsUC *mock_analytics.MockUseCase)
+		wantStatus   int
+	}{
+		{
+			name: "filter by payload",
+			query: url.Values{
+				"object_id": {"42"},
+				"action":    {"product_update"},
+				"payload":   {`{"tags":["music"]}`},
+			},
+			mockBehavior: func(mockAnalyticsUC *mock_analytics.MockUseCase) {
+				mockAnalyticsUC.EXPECT().FindActions(models.ActionFilterDTO{
+					ObjectID: "42",
+					Action:   "product_update",
+					Payload:  json.RawMessage(`{"tags":["music"]}`),
+				}).Return([]models.Action{{ID: 1, ObjectID: "42"}}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:         "payload is not an object",
+			query:        url.Values{"payload": {`["music"]`}},
+			mockBehavior: func(mockAnalyticsUC *mock_analytics.MockUseCase) {},
+			wantStatus:   http.StatusBadRequest,
+		},
+		{
+			name:         "invalid from",
+			query:        url.Values{"from": {"yesterday"}},
+			mockBehavior: func(mockAnalyticsUC *mock_analytics.MockUseCase) {},
+			wantStatus:   http.StatusBadRequest,
+		},
+		{
+			name:         "invalid limit",
+			query:        url.Values{"limit": {"-1"}},
+			mockBehavior: func(mockAnalyticsUC *mock_analytics.MockUseCase) {},
+			wantStatus:   http.StatusBadRequest,
+		},
+		{
+			name:  "use case error",
+			query: url.Values{},
+			mockBehavior: func(mockAnalyticsUC *mock_analytics.MockUseCase) {
+				mockAnalyticsUC.EXPECT().FindActions(gomock.Any()).Return(nil, errors.New("db error"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockAnalyticsUC)
+
+			req := httptest.NewRequest(http.MethodGet, "/analytics/actions?"+tt.query.Encode(), nil)
+			rr := httptest.NewRecorder()
+
+			analyticsHandlers.GetActions().ServeHTTP(rr, req)
+			require.Equal(t, tt.wantStatus, rr.Code)
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"cyansnbrst/analytics-service/internal/analytics"
+	"cyansnbrst/analytics-service/internal/middleware"
+)
+
+// Register analytics routes
+func RegisterAnalyticsRoutes(router *httprouter.Router, h analytics.Handlers, mw *middleware.MiddlewareManager) {
+	router.HandlerFunc(http.MethodGet, "/analytics/actions", mw.RequirePermission("analytics:read")(h.GetActions()))
+}
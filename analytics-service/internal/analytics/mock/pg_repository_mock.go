@@ -5,8 +5,8 @@
 package mock_analytics
 
 import (
+	models "cyansnbrst/analytics-service/internal/models"
 	reflect "reflect"
-	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -34,16 +34,31 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 	return m.recorder
 }
 
-// Insert mocks base method.
-func (m *MockRepository) Insert(action, objectID string, actionTime time.Time) error {
+// FindActions mocks base method.
+func (m *MockRepository) FindActions(filter models.ActionFilterDTO) ([]models.Action, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Insert", action, objectID, actionTime)
+	ret := m.ctrl.Call(m, "FindActions", filter)
+	ret0, _ := ret[0].([]models.Action)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindActions indicates an expected call of FindActions.
+func (mr *MockRepositoryMockRecorder) FindActions(filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindActions", reflect.TypeOf((*MockRepository)(nil).FindActions), filter)
+}
+
+// InsertBatch mocks base method.
+func (m *MockRepository) InsertBatch(actions []models.Action) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertBatch", actions)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Insert indicates an expected call of Insert.
-func (mr *MockRepositoryMockRecorder) Insert(action, objectID, actionTime interface{}) *gomock.Call {
+// InsertBatch indicates an expected call of InsertBatch.
+func (mr *MockRepositoryMockRecorder) InsertBatch(actions interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockRepository)(nil).Insert), action, objectID, actionTime)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertBatch", reflect.TypeOf((*MockRepository)(nil).InsertBatch), actions)
 }
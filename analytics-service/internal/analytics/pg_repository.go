@@ -1,8 +1,9 @@
 package analytics
 
-import "time"
+import "cyansnbrst/analytics-service/internal/models"
 
 // Recommendations repository interface
 type Repository interface {
-	Insert(action string, objectID string, actionTime time.Time) error
+	InsertBatch(actions []models.Action) error
+	FindActions(filter models.ActionFilterDTO) ([]models.Action, error)
 }
@@ -3,10 +3,12 @@ package repository
 import (
 	"context"
 	"database/sql"
-	"time"
+
+	"github.com/lib/pq"
 
 	"cyansnbrst/analytics-service/config"
 	"cyansnbrst/analytics-service/internal/analytics"
+	"cyansnbrst/analytics-service/internal/models"
 )
 
 // Analytics repository
@@ -20,21 +22,111 @@ func NewAnalyticsRepository(cfg *config.Config, db *sql.DB) analytics.Repository
 	return &analyticsRepo{cfg: cfg, db: db}
 }
 
-// Insert a new analytics log
-func (r *analyticsRepo) Insert(action string, objectID string, actionTime time.Time) error {
+// Insert a batch of analytics logs with COPY in a single transaction
+func (r *analyticsRepo) InsertBatch(actions []models.Action) error {
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("actions", "action", "object_id", "time", "payload", "kafka_topic", "kafka_partition", "kafka_offset"))
+	if err != nil {
+		return err
+	}
+
+	for _, action := range actions {
+		args := []interface{}{
+			action.Action,
+			action.ObjectID,
+			action.Time,
+			string(action.Payload),
+			action.Topic,
+			action.Partition,
+			action.Offset,
+		}
+
+		if _, err = stmt.ExecContext(ctx, args...); err != nil {
+			stmt.Close()
+			return err
+		}
+	}
+
+	if _, err = stmt.ExecContext(ctx); err != nil {
+		stmt.Close()
+		return err
+	}
+
+	if err = stmt.Close(); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Find analytics logs matching the filter, newest first
+func (r *analyticsRepo) FindActions(filter models.ActionFilterDTO) ([]models.Action, error) {
 	query := `
-		INSERT INTO actions (action, object_id, time)
-		VALUES ($1, $2, $3)`
+		SELECT id, action, object_id, time, payload, kafka_topic, kafka_partition, kafka_offset
+		FROM actions
+		WHERE ($1 = '' OR object_id = $1)
+		AND ($2 = '' OR action = $2)
+		AND ($3::jsonb IS NULL OR payload @> $3::jsonb)
+		AND ($4::timestamptz IS NULL OR time >= $4)
+		AND ($5::timestamptz IS NULL OR time < $5)
+		ORDER BY time DESC, id DESC
+		LIMIT $6`
 
-	args := []interface{}{action, objectID, actionTime}
+	var payload sql.NullString
+	if len(filter.Payload) > 0 {
+		payload = sql.NullString{String: string(filter.Payload), Valid: true}
+	}
+
+	args := []interface{}{
+		filter.ObjectID,
+		filter.Action,
+		payload,
+		filter.From,
+		filter.To,
+		filter.Limit,
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
 	defer cancel()
 
-	_, err := r.db.ExecContext(ctx, query, args...)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	defer rows.Close()
+
+	actions := []models.Action{}
+	for rows.Next() {
+		var action models.Action
+		var payload []byte
+		err := rows.Scan(
+			&action.ID,
+			&action.Action,
+			&action.ObjectID,
+			&action.Time,
+			&payload,
+			&action.Topic,
+			&action.Partition,
+			&action.Offset,
+		)
+		if err != nil {
+			return nil, err
+		}
+		action.Payload = payload
+		actions = append(actions, action)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return actions, nil
 }
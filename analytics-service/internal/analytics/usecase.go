@@ -1,8 +1,9 @@
 package analytics
 
-import "time"
+import "cyansnbrst/analytics-service/internal/models"
 
 // Analytics use case interface
 type UseCase interface {
-	Insert(action string, objectID string, actionTime time.Time) error
+	InsertBatch(actions []models.Action) error
+	FindActions(filter models.ActionFilterDTO) ([]models.Action, error)
 }
@@ -1,12 +1,11 @@
 package usecase
 
 import (
-	"time"
-
 	"go.uber.org/zap"
 
 	"cyansnbrst/analytics-service/config"
 	"cyansnbrst/analytics-service/internal/analytics"
+	"cyansnbrst/analytics-service/internal/models"
 )
 
 // Analytics UseCase struct
@@ -16,12 +15,34 @@ type analyticsUC struct {
 	logger        *zap.Logger
 }
 
+// Default and maximum number of actions returned by a single query
+const (
+	defaultActionsLimit = 100
+	maxActionsLimit     = 1000
+)
+
 // New analytics constructor
 func NewAnalyticsUseCase(cfg *config.Config, analyticsRepo analytics.Repository, logger *zap.Logger) analytics.UseCase {
 	return &analyticsUC{cfg: cfg, analyticsRepo: analyticsRepo, logger: logger}
 }
 
-// Generate recommendations for user
-func (u *analyticsUC) Insert(action string, objectID string, actionTime time.Time) error {
-	return u.analyticsRepo.Insert(action, objectID, actionTime)
+// Store a batch of actions
+func (u *analyticsUC) InsertBatch(actions []models.Action) error {
+	if len(actions) == 0 {
+		return nil
+	}
+
+	return u.analyticsRepo.InsertBatch(actions)
+}
+
+// Find actions matching the filter
+func (u *analyticsUC) FindActions(filter models.ActionFilterDTO) ([]models.Action, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultActionsLimit
+	}
+	if filter.Limit > maxActionsLimit {
+		filter.Limit = maxActionsLimit
+	}
+
+	return u.analyticsRepo.FindActions(filter)
 }
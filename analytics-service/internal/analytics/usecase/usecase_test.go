@@ -11,9 +11,10 @@ import (
 
 	"cyansnbrst/analytics-service/config"
 	mock_analytics "cyansnbrst/analytics-service/internal/analytics/mock"
+	"cyansnbrst/analytics-service/internal/models"
 )
 
-func TestAnalyticsUseCase_Insert(t *testing.T) {
+func TestAnalyticsUseCase_InsertBatch(t *testing.T) {
 	cfg := &config.Config{}
 
 	logger := zap.NewNop()
@@ -26,29 +27,88 @@ func TestAnalyticsUseCase_Insert(t *testing.T) {
 
 	tests := []struct {
 		name         string
-		action       string
-		objectID     string
-		actionTime   time.Time
+		actions      []models.Action
 		mockBehavior func(mockAnalyticsRepo *mock_analytics.MockRepository)
 		wantErr      bool
 	}{
 		{
-			name:       "success",
-			action:     "click",
-			objectID:   "1234",
-			actionTime: time.Now(),
+			name:    "success",
+			actions: []models.Action{{Action: "click", ObjectID: "1234", Time: time.Now()}},
 			mockBehavior: func(mockAnalyticsRepo *mock_analytics.MockRepository) {
-				mockAnalyticsRepo.EXPECT().Insert("click", "1234", gomock.Any()).Return(nil)
+				mockAnalyticsRepo.EXPECT().InsertBatch(gomock.Len(1)).Return(nil)
 			},
 			wantErr: false,
 		},
 		{
-			name:       "insert error",
-			action:     "view",
-			objectID:   "5678",
-			actionTime: time.Now(),
+			name:    "insert error",
+			actions: []models.Action{{Action: "view", ObjectID: "5678", Time: time.Now()}},
 			mockBehavior: func(mockAnalyticsRepo *mock_analytics.MockRepository) {
-				mockAnalyticsRepo.EXPECT().Insert("view", "5678", gomock.Any()).Return(errors.New("db error"))
+				mockAnalyticsRepo.EXPECT().InsertBatch(gomock.Len(1)).Return(errors.New("db error"))
+			},
+			wantErr: true,
+		},
+		{
+			name:         "empty batch",
+			actions:      nil,
+			mockBehavior: func(mockAnalyticsRepo *mock_analytics.MockRepository) {},
+			wantErr:      false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockAnalyticsRepo)
+
+			err := analyticsUC.InsertBatch(tt.actions)
+
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAnalyticsUseCase_FindActions(t *testing.T) {
+	cfg := &config.Config{}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAnalyticsRepo := mock_analytics.NewMockRepository(ctrl)
+	analyticsUC := NewAnalyticsUseCase(cfg, mockAnalyticsRepo, logger)
+
+	tests := []struct {
+		name         string
+		filter       models.ActionFilterDTO
+		mockBehavior func(mockAnalyticsRepo *mock_analytics.MockRepository)
+		wantErr      bool
+	}{
+		{
+			name:   "default limit",
+			filter: models.ActionFilterDTO{ObjectID: "1234"},
+			mockBehavior: func(mockAnalyticsRepo *mock_analytics.MockRepository) {
+				mockAnalyticsRepo.EXPECT().FindActions(models.ActionFilterDTO{ObjectID: "1234", Limit: 100}).Return([]models.Action{}, nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:   "limit is capped",
+			filter: models.ActionFilterDTO{Limit: 5000},
+			mockBehavior: func(mockAnalyticsRepo *mock_analytics.MockRepository) {
+				mockAnalyticsRepo.EXPECT().FindActions(models.ActionFilterDTO{Limit: 1000}).Return([]models.Action{}, nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:   "repository error",
+			filter: models.ActionFilterDTO{Limit: 10},
+			mockBehavior: func(mockAnalyticsRepo *mock_analytics.MockRepository) {
+				mockAnalyticsRepo.EXPECT().FindActions(gomock.Any()).Return(nil, errors.New("db error"))
 			},
 			wantErr: true,
 		},
@@ -59,7 +119,7 @@ func TestAnalyticsUseCase_Insert(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mockBehavior(mockAnalyticsRepo)
 
-			err := analyticsUC.Insert(tt.action, tt.objectID, tt.actionTime)
+			_, err := analyticsUC.FindActions(tt.filter)
 
 			if tt.wantErr {
 				require.Error(t, err)
@@ -6,6 +6,7 @@ import (
 	"os/signal"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/segmentio/kafka-go"
 	"go.uber.org/zap"
@@ -27,7 +28,7 @@ type KafkaClient struct {
 // Kafka reader group struct
 type readerGroup struct {
 	reader  *kafka.Reader
-	handler func(kafka.Message) error
+	handler func([]kafka.Message) error
 }
 
 // New kafka client constructor
@@ -45,7 +46,7 @@ func NewKafkaClient(cfg *config.Config, logger *zap.Logger) *KafkaClient {
 }
 
 // Add new kafka reader
-func (kc *KafkaClient) AddReader(topicKey, groupID string, handler func(kafka.Message) error) error {
+func (kc *KafkaClient) AddReader(topicKey, groupID string, handler func([]kafka.Message) error) error {
 	reader, err := kf.InitKafkaReader(kc.config, topicKey, groupID)
 	if err != nil {
 		return err
@@ -63,7 +64,14 @@ func (kc *KafkaClient) Run() {
 		kc.wg.Add(1)
 		go func(rg *readerGroup) {
 			defer kc.wg.Done()
-			err := kf.ConsumeMessages(kc.ctx, rg.reader, rg.handler)
+			err := kf.ConsumeBatches(kc.ctx, rg.reader, kc.config.Kafka.BatchSize, kc.config.Kafka.BatchTimeout, rg.handler,
+				func(err error, backoff time.Duration) {
+					kc.logger.Warn("failed to handle batch, retrying",
+						zap.String("topic", rg.reader.Config().Topic),
+						zap.Duration("backoff", backoff),
+						zap.Error(err),
+					)
+				})
 			if err != nil {
 				kc.logger.Error("error consuming messages", zap.Error(err))
 			}
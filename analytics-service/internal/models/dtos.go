@@ -1,8 +1,33 @@
 package models
 
+import (
+	"encoding/json"
+	"time"
+)
+
 // Kafka message DTO
 type KafkaMessageDTO struct {
-	Action string   `json:"action"`
-	Time   string   `json:"time"`
-	Tags   []string `json:"tags"`
+	Action string          `json:"action"`
+	Time   json.RawMessage `json:"time"`
+	Tags   []string        `json:"tags"`
+}
+
+// Actions filter DTO
+type ActionFilterDTO struct {
+	ObjectID string
+	Action   string
+	Payload  json.RawMessage
+	From     *time.Time
+	To       *time.Time
+	Limit    int
+}
+
+// Actions response
+type ActionsResponse struct {
+	Actions []Action `json:"actions"`
+}
+
+// Error response
+type ErrorResponse struct {
+	Error string `json:"error"`
 }
@@ -1,11 +1,18 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // Action model
 type Action struct {
-	ID       int64     `json:"id,omitempty"`
-	Action   string    `json:"action"`
-	ObjectID string    `json:"object_id"`
-	Time     time.Time `json:"time"`
+	ID        int64           `json:"id,omitempty"`
+	Action    string          `json:"action"`
+	ObjectID  string          `json:"object_id"`
+	Time      time.Time       `json:"time"`
+	Payload   json.RawMessage `json:"payload"`
+	Topic     string          `json:"topic"`
+	Partition int             `json:"partition"`
+	Offset    int64           `json:"offset"`
 }
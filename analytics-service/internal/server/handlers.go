@@ -6,9 +6,11 @@ import (
 	"github.com/julienschmidt/httprouter"
 
 	"cyansnbrst/analytics-service/internal/analytics/delivery/consumers"
+	analyticsHttp "cyansnbrst/analytics-service/internal/analytics/delivery/http"
 	analyticsRepository "cyansnbrst/analytics-service/internal/analytics/repository"
 	analyticsUseCase "cyansnbrst/analytics-service/internal/analytics/usecase"
 	"cyansnbrst/analytics-service/internal/client"
+	"cyansnbrst/analytics-service/internal/middleware"
 )
 
 // Register server handlers
@@ -21,13 +23,23 @@ func (s *Server) RegisterHandlers() http.Handler {
 	// Init use case
 	analyticsUC := analyticsUseCase.NewAnalyticsUseCase(s.config, analyticsRepo, s.logger)
 
+	// Init handlers
+	analyticsHandlers := analyticsHttp.NewAnalyticsHandlers(s.config, analyticsUC, s.logger)
+
+	// Init middleware
+	mw := middleware.NewMiddlewareManager(s.config, s.logger)
+
+	// Register analytics routes
+	analyticsHttp.RegisterAnalyticsRoutes(router, analyticsHandlers, mw)
+
 	// Init kafka consumers
 	kafkaClient := client.NewKafkaClient(s.config, s.logger)
 	kafkaHandlers := consumers.NewKafkaMessageHandlers(s.config, analyticsUC, s.logger)
 
-	kafkaClient.AddReader("product", s.config.Kafka.GroupID, kafkaHandlers.HandleMessage)
-	kafkaClient.AddReader("user", s.config.Kafka.GroupID, kafkaHandlers.HandleMessage)
+	kafkaClient.AddReader("product", s.config.Kafka.GroupID, kafkaHandlers.HandleBatch)
+	kafkaClient.AddReader("user", s.config.Kafka.GroupID, kafkaHandlers.HandleBatch)
+	kafkaClient.AddReader("tag", s.config.Kafka.GroupID, kafkaHandlers.HandleBatch)
 	kafkaClient.Run()
 
-	return router
+	return mw.RecoverPanic(mw.Authenticate(router))
 }
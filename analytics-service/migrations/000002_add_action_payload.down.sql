@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS actions_object_id_time_idx;
+DROP INDEX IF EXISTS actions_payload_idx;
+
+ALTER TABLE actions
+    DROP COLUMN IF EXISTS kafka_offset,
+    DROP COLUMN IF EXISTS kafka_partition,
+    DROP COLUMN IF EXISTS kafka_topic,
+    DROP COLUMN IF EXISTS payload;
@@ -0,0 +1,8 @@
+ALTER TABLE actions
+    ADD COLUMN payload JSONB NOT NULL DEFAULT '{}'::jsonb,
+    ADD COLUMN kafka_topic TEXT NOT NULL DEFAULT '',
+    ADD COLUMN kafka_partition INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN kafka_offset BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS actions_payload_idx ON actions USING GIN (payload jsonb_path_ops);
+CREATE INDEX IF NOT EXISTS actions_object_id_time_idx ON actions (object_id, time);
@@ -0,0 +1,63 @@
+package authclient
+
+import (
+	"sync"
+	"time"
+)
+
+// Consecutive failures circuit breaker.
+// After threshold failures the circuit opens for cooldown, then a single probe request is let through:
+// its success closes the circuit, its failure opens it again.
+type breaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openedAt  time.Time
+	probing   bool
+}
+
+// Circuit breaker constructor
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &breaker{threshold: threshold, cooldown: cooldown}
+}
+
+// Check whether a request may be sent
+func (b *breaker) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return true
+	}
+	if now.Sub(b.openedAt) < b.cooldown || b.probing {
+		return false
+	}
+
+	b.probing = true
+	return true
+}
+
+// Record a successful request
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.probing = false
+}
+
+// Record a failed request
+func (b *breaker) failure(now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.failures >= b.threshold {
+		b.openedAt = now
+	}
+}
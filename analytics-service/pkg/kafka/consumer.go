@@ -2,7 +2,9 @@ package kafka
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/segmentio/kafka-go"
 
@@ -25,18 +27,120 @@ func InitKafkaReader(cfg *config.Config, topicKey string, groupID string) (*kafk
 	return reader, nil
 }
 
-// ConsumeMessages listens for messages from the Kafka topic
-func ConsumeMessages(ctx context.Context, reader *kafka.Reader, handler func(kafka.Message) error) error {
+// Backoff between attempts to handle a failed batch
+var (
+	retryBackoffMin = 500 * time.Millisecond
+	retryBackoffMax = 30 * time.Second
+)
+
+// ConsumeBatches listens for messages from the Kafka topic and passes them to the handler
+// in batches, flushing when the batch is full or the flush interval elapses.
+// A failed batch is retried with backoff until it is handled or the context is cancelled, onRetry is called before each retry.
+// Offsets are committed only after the handler has successfully stored the batch,
+// so a batch that was not flushed before shutdown is redelivered on restart.
+func ConsumeBatches(ctx context.Context, reader *kafka.Reader, batchSize int, flushInterval time.Duration, handler func([]kafka.Message) error, onRetry func(err error, backoff time.Duration)) error {
 	defer reader.Close()
 
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	if flushInterval <= 0 {
+		return errors.New("flush interval must be positive")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	messages := make(chan kafka.Message)
+	fetchErr := make(chan error, 1)
+
+	go func() {
+		defer close(messages)
+		for {
+			m, err := reader.FetchMessage(ctx)
+			if err != nil {
+				fetchErr <- err
+				return
+			}
+
+			select {
+			case messages <- m:
+			case <-ctx.Done():
+				fetchErr <- ctx.Err()
+				return
+			}
+		}
+	}()
+
+	batch := make([]kafka.Message, 0, batchSize)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		if err := handleWithRetry(ctx, batch, handler, onRetry); err != nil {
+			return fmt.Errorf("error handling batch: %w", err)
+		}
+
+		if reader.Config().GroupID != "" {
+			if err := reader.CommitMessages(context.Background(), batch...); err != nil {
+				return fmt.Errorf("error committing offsets: %w", err)
+			}
+		}
+
+		batch = make([]kafka.Message, 0, batchSize)
+		return nil
+	}
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
 	for {
-		m, err := reader.ReadMessage(ctx)
-		if err != nil {
-			return fmt.Errorf("error reading message: %w", err)
+		select {
+		case m, ok := <-messages:
+			if !ok {
+				return fmt.Errorf("error reading message: %w", <-fetchErr)
+			}
+
+			batch = append(batch, m)
+			if len(batch) >= batchSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+		case <-ticker.C:
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Call the handler until it succeeds, doubling the wait between attempts up to the maximum backoff
+func handleWithRetry(ctx context.Context, batch []kafka.Message, handler func([]kafka.Message) error, onRetry func(err error, backoff time.Duration)) error {
+	backoff := retryBackoffMin
+	for {
+		err := handler(batch)
+		if err == nil {
+			return nil
+		}
+
+		if onRetry != nil {
+			onRetry(err, backoff)
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+		case <-timer.C:
 		}
 
-		if err := handler(m); err != nil {
-			return fmt.Errorf("error handling message: %w", err)
+		backoff *= 2
+		if backoff > retryBackoffMax {
+			backoff = retryBackoffMax
 		}
 	}
 }
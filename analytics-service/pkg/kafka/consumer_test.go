@@ -0,0 +1,50 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleWithRetry(t *testing.T) {
+	retryBackoffMin, retryBackoffMax = time.Millisecond, 4*time.Millisecond
+	defer func() {
+		retryBackoffMin, retryBackoffMax = 500*time.Millisecond, 30*time.Second
+	}()
+
+	batch := []kafka.Message{{Value: []byte(`{}`)}}
+
+	t.Run("retries until the batch is handled", func(t *testing.T) {
+		calls := 0
+		var backoffs []time.Duration
+
+		err := handleWithRetry(context.Background(), batch, func([]kafka.Message) error {
+			calls++
+			if calls < 5 {
+				return errors.New("db error")
+			}
+			return nil
+		}, func(_ error, backoff time.Duration) {
+			backoffs = append(backoffs, backoff)
+		})
+
+		require.NoError(t, err)
+		require.Equal(t, 5, calls)
+		require.Equal(t, []time.Duration{time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond, 4 * time.Millisecond}, backoffs)
+	})
+
+	t.Run("stops when the context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		err := handleWithRetry(ctx, batch, func([]kafka.Message) error {
+			cancel()
+			return errors.New("db error")
+		}, nil)
+
+		require.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"log"
 
 	"go.uber.org/zap"
@@ -8,6 +9,10 @@ import (
 	"cyansnbrst/auth-service/config"
 	"cyansnbrst/auth-service/internal/server"
 	"cyansnbrst/auth-service/pkg/db/postgres"
+	"cyansnbrst/auth-service/pkg/db/redis"
+	"cyansnbrst/auth-service/pkg/jwks"
+	"cyansnbrst/auth-service/pkg/kafka"
+	"cyansnbrst/auth-service/pkg/mailer"
 )
 
 //	@title			Auth Service API
@@ -21,6 +26,10 @@ import (
 // @securityDefinitions.apikey	cookieAuth
 // @in							cookie
 // @name						token
+
+// @securityDefinitions.apikey	serviceAuth
+// @in							header
+// @name						Authorization
 func main() {
 	log.Println("starting auth server")
 
@@ -57,7 +66,45 @@ func main() {
 	}()
 	logger.Info("database connected")
 
-	s := server.NewServer(cfg, logger, psqlDB)
+	keys, err := jwks.Load(cfg.JWT.KeysDir, cfg.JWT.ActiveKeyID)
+	if errors.Is(err, jwks.ErrNoKeys) && cfg.Env != "production" {
+		logger.Warn("no signing keys found, using an ephemeral key",
+			zap.String("dir", cfg.JWT.KeysDir),
+		)
+		keys, err = jwks.Generate()
+	}
+	if err != nil {
+		logger.Fatal("failed to load signing keys",
+			zap.String("error", err.Error()),
+		)
+	}
+	logger.Info("signing keys loaded", zap.String("active_kid", keys.Active().ID))
+
+	kafkaWriter, err := kafka.InitKafkaWriter(cfg, "user")
+	if err != nil {
+		logger.Fatal("failed to init kafka producer",
+			zap.String("error", err.Error()),
+		)
+	}
+	logger.Info("kafka producer connected")
+
+	redisClient := redis.NewRedisClient(cfg)
+	defer func() {
+		if err := redisClient.Close(); err != nil {
+			logger.Warn("failed to close redis client", zap.String("error", err.Error()))
+		}
+	}()
+	logger.Info("redis client initialized")
+
+	mail, err := mailer.New(cfg, logger)
+	if err != nil {
+		logger.Fatal("failed to init mailer",
+			zap.String("error", err.Error()),
+		)
+	}
+	logger.Info("mailer initialized", zap.String("driver", cfg.Mail.Driver))
+
+	s := server.NewServer(cfg, logger, psqlDB, keys, mail, kafkaWriter, redisClient)
 	if err = s.Run(); err != nil {
 		logger.Fatal("an error occured",
 			zap.String("error", err.Error()),
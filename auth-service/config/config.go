@@ -13,12 +13,43 @@ import (
 type Config struct {
 	Port        int
 	Env         string
-	ProfileLink string
 	PostgreSQL  PostgreSQL
-	SecretKey   string
+	Kafka       Kafka
+	Redis       Redis
+	JWT         JWT
+	RateLimit   RateLimit
+	Outbox      Outbox
+	ServiceAuth ServiceAuth
+	OIDC        OIDC
+	Mail        Mail
 	Timeout     Timeout
 }
 
+// JWT signing keys config struct
+type JWT struct {
+	KeysDir     string
+	ActiveKeyID string
+}
+
+// Mail config struct
+type Mail struct {
+	Driver           string // smtp, file or log
+	From             string
+	Dir              string // messages directory of the file driver
+	SMTP             SMTP
+	ConfirmEmailURL  string
+	VerifyEmailURL   string
+	ResetPasswordURL string
+}
+
+// SMTP server config struct
+type SMTP struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+}
+
 // PostgreSQL config struct
 type PostgreSQL struct {
 	Host         string
@@ -32,6 +63,60 @@ type PostgreSQL struct {
 	MaxIdleTime  time.Duration
 }
 
+// Kafka config struct
+type Kafka struct {
+	Brokers     []string
+	Topics      map[string]string
+	MaxAttempts int
+}
+
+// Redis config struct
+type Redis struct {
+	RedisAddr    string
+	MinIdleConns int
+	PoolSize     int
+	PoolTimeout  int
+	Password     string
+	DB           int
+}
+
+// Rate limiting and account lockout config struct
+type RateLimit struct {
+	IPRequests      int
+	IPWindow        time.Duration
+	AccountRequests int
+	AccountWindow   time.Duration
+	LockoutFailures int
+	LockoutDuration time.Duration
+	TrustProxy      bool // take client IP from X-Forwarded-For
+}
+
+// Service-to-service authentication config struct
+type ServiceAuth struct {
+	Clients map[string]string // secrets of internal callers by service name
+}
+
+// OpenID Connect login config struct
+type OIDC struct {
+	Providers    map[string]OIDCProvider // external identity providers by name
+	PostLoginURL string                  // browser is redirected here after login, JSON response if empty
+}
+
+// OpenID Connect provider config struct
+type OIDCProvider struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Outbox relay config struct
+type Outbox struct {
+	Interval  time.Duration
+	BatchSize int
+}
+
 // Timeouts config struct
 type Timeout struct {
 	Cookie           time.Duration
@@ -42,6 +127,16 @@ type Timeout struct {
 	ServerWrite      time.Duration
 	ServerShutdown   time.Duration
 	Token            time.Duration
+	RefreshToken     time.Duration
+	EmailToken       time.Duration
+	ResetToken       time.Duration
+	MailSend         time.Duration
+	ServiceToken     time.Duration
+	RedisAction      time.Duration
+	OutboxPublish    time.Duration
+	OIDCRequest      time.Duration
+	OIDCState        time.Duration
+	Reauth           time.Duration
 }
 
 // Load config file from given path
@@ -75,7 +170,6 @@ func ParseConfig(v *viper.Viper) (*Config, error) {
 	// Server config
 	c.Port = v.GetInt("port")
 	c.Env = v.GetString("env")
-	c.ProfileLink = v.GetString("profile_link")
 
 	// PostgreSQL config
 	c.PostgreSQL.Host = v.GetString("postgresql_host")
@@ -91,8 +185,99 @@ func ParseConfig(v *viper.Viper) (*Config, error) {
 		return nil, err
 	}
 
-	// Secret config
-	c.SecretKey = v.GetString("secret_key")
+	// Kafka config
+	if brokers := v.GetString("kafka_brokers"); brokers != "" {
+		c.Kafka.Brokers = strings.Split(brokers, ",")
+	}
+	c.Kafka.Topics = make(map[string]string)
+	for _, key := range v.AllKeys() {
+		if strings.HasPrefix(key, "kafka_topic_") {
+			topicName := strings.TrimPrefix(key, "kafka_topic_")
+			c.Kafka.Topics[topicName] = v.GetString(key)
+		}
+	}
+	c.Kafka.MaxAttempts = v.GetInt("kafka_max_attempts")
+
+	// Service auth config
+	c.ServiceAuth.Clients = make(map[string]string)
+	for _, key := range v.AllKeys() {
+		if strings.HasPrefix(key, "service_client_") {
+			clientID := strings.TrimPrefix(key, "service_client_")
+			c.ServiceAuth.Clients[clientID] = v.GetString(key)
+		}
+	}
+
+	// OIDC config
+	c.OIDC.Providers = make(map[string]OIDCProvider)
+	for _, key := range v.AllKeys() {
+		name, ok := strings.CutPrefix(key, "oidc_provider_")
+		if !ok || !strings.HasSuffix(name, "_issuer") {
+			continue
+		}
+		name = strings.TrimSuffix(name, "_issuer")
+		prefix := "oidc_provider_" + name + "_"
+
+		provider := OIDCProvider{
+			Issuer:       v.GetString(key),
+			ClientID:     v.GetString(prefix + "client_id"),
+			ClientSecret: v.GetString(prefix + "client_secret"),
+			RedirectURL:  v.GetString(prefix + "redirect_url"),
+		}
+		if scopes := v.GetString(prefix + "scopes"); scopes != "" {
+			provider.Scopes = strings.Split(scopes, ",")
+		}
+		c.OIDC.Providers[name] = provider
+	}
+	c.OIDC.PostLoginURL = v.GetString("oidc_post_login_url")
+
+	// Outbox config
+	c.Outbox.Interval, err = parseTimeout(v, "outbox_interval")
+	if err != nil {
+		return nil, err
+	}
+	c.Outbox.BatchSize = v.GetInt("outbox_batch_size")
+
+	// Redis config
+	c.Redis.RedisAddr = v.GetString("redis_addr")
+	c.Redis.MinIdleConns = v.GetInt("redis_min_idle_conns")
+	c.Redis.PoolSize = v.GetInt("redis_pool_size")
+	c.Redis.PoolTimeout = v.GetInt("redis_pool_timeout")
+	c.Redis.Password = v.GetString("redis_password")
+	c.Redis.DB = v.GetInt("redis_db")
+
+	// Rate limit config
+	c.RateLimit.IPRequests = v.GetInt("rate_limit_ip_requests")
+	c.RateLimit.IPWindow, err = parseTimeout(v, "rate_limit_ip_window")
+	if err != nil {
+		return nil, err
+	}
+	c.RateLimit.AccountRequests = v.GetInt("rate_limit_account_requests")
+	c.RateLimit.AccountWindow, err = parseTimeout(v, "rate_limit_account_window")
+	if err != nil {
+		return nil, err
+	}
+	c.RateLimit.LockoutFailures = v.GetInt("lockout_failures")
+	c.RateLimit.LockoutDuration, err = parseTimeout(v, "lockout_duration")
+	if err != nil {
+		return nil, err
+	}
+	c.RateLimit.TrustProxy = v.GetBool("rate_limit_trust_proxy")
+
+	// Mail config
+	c.Mail.Driver = v.GetString("mail_driver")
+	c.Mail.From = v.GetString("mail_from")
+	c.Mail.Dir = v.GetString("mail_dir")
+	c.Mail.SMTP.Host = v.GetString("mail_smtp_host")
+	c.Mail.SMTP.Port = v.GetInt("mail_smtp_port")
+	c.Mail.SMTP.Username = v.GetString("mail_smtp_username")
+	c.Mail.SMTP.Password = v.GetString("mail_smtp_password")
+	c.Mail.ConfirmEmailURL = v.GetString("mail_confirm_email_url")
+	c.Mail.VerifyEmailURL = v.GetString("mail_verify_email_url")
+	c.Mail.ResetPasswordURL = v.GetString("mail_reset_password_url")
+
+	// JWT config
+	c.JWT.KeysDir = v.GetString("jwt_keys_dir")
+	c.JWT.ActiveKeyID = v.GetString("jwt_active_key_id")
 
 	// Timeout config
 	c.Timeout.Cookie, err = parseTimeout(v, "timeout_cookie")
@@ -127,6 +312,46 @@ func ParseConfig(v *viper.Viper) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	c.Timeout.RefreshToken, err = parseTimeout(v, "timeout_refresh_token")
+	if err != nil {
+		return nil, err
+	}
+	c.Timeout.EmailToken, err = parseTimeout(v, "timeout_email_token")
+	if err != nil {
+		return nil, err
+	}
+	c.Timeout.ResetToken, err = parseTimeout(v, "timeout_reset_token")
+	if err != nil {
+		return nil, err
+	}
+	c.Timeout.MailSend, err = parseTimeout(v, "timeout_mail_send")
+	if err != nil {
+		return nil, err
+	}
+	c.Timeout.ServiceToken, err = parseTimeout(v, "timeout_service_token")
+	if err != nil {
+		return nil, err
+	}
+	c.Timeout.RedisAction, err = parseTimeout(v, "timeout_redis_action")
+	if err != nil {
+		return nil, err
+	}
+	c.Timeout.OutboxPublish, err = parseTimeout(v, "timeout_outbox_publish")
+	if err != nil {
+		return nil, err
+	}
+	c.Timeout.OIDCRequest, err = parseTimeout(v, "timeout_oidc_request")
+	if err != nil {
+		return nil, err
+	}
+	c.Timeout.OIDCState, err = parseTimeout(v, "timeout_oidc_state")
+	if err != nil {
+		return nil, err
+	}
+	c.Timeout.Reauth, err = parseTimeout(v, "timeout_reauth")
+	if err != nil {
+		return nil, err
+	}
 
 	return &c, nil
 }
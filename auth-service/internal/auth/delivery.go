@@ -7,4 +7,28 @@ type Handlers interface {
 	Register() http.HandlerFunc
 	Login() http.HandlerFunc
 	TokenValidation() http.HandlerFunc
+	Refresh() http.HandlerFunc
+	Logout() http.HandlerFunc
+	JWKS() http.HandlerFunc
+	RevokedSessions() http.HandlerFunc
+	ServiceToken() http.HandlerFunc
+	ListRoles() http.HandlerFunc
+	GetUser() http.HandlerFunc
+	UserRoles() http.HandlerFunc
+	AssignRole() http.HandlerFunc
+	RemoveRole() http.HandlerFunc
+	CreateAPIKey() http.HandlerFunc
+	ListAPIKeys() http.HandlerFunc
+	RevokeAPIKey() http.HandlerFunc
+	OIDCLogin() http.HandlerFunc
+	OIDCCallback() http.HandlerFunc
+	Me() http.HandlerFunc
+	ChangePassword() http.HandlerFunc
+	ChangeEmail() http.HandlerFunc
+	ConfirmEmail() http.HandlerFunc
+	DeleteAccount() http.HandlerFunc
+	VerifyEmail() http.HandlerFunc
+	ResendVerification() http.HandlerFunc
+	ForgotPassword() http.HandlerFunc
+	ResetPassword() http.HandlerFunc
 }
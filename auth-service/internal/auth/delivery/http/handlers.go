@@ -1,20 +1,36 @@
 package http
 
 import (
-	"fmt"
+	"crypto/subtle"
+	"errors"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/julienschmidt/httprouter"
 	"go.uber.org/zap"
 
 	"cyansnbrst/auth-service/config"
 	"cyansnbrst/auth-service/internal/auth"
+	"cyansnbrst/auth-service/internal/middleware"
 	"cyansnbrst/auth-service/internal/models"
 	"cyansnbrst/auth-service/pkg/db"
 	erp "cyansnbrst/auth-service/pkg/error_responses"
 	"cyansnbrst/auth-service/pkg/utils"
 )
 
+// Cookie names
+const (
+	accessCookieName  = "token"
+	refreshCookieName = "refresh_token"
+)
+
+// Cookie binding the identity provider callback to the browser that started the login
+const oidcStateCookieName = "oidc_state"
+
+// Header carrying an API key on token validation requests
+const apiKeyHeader = "X-API-Key"
+
 // Auth handlers
 type authHandlers struct {
 	cfg    *config.Config
@@ -28,12 +44,13 @@ func NewAuthHandlers(cfg *config.Config, authUC auth.UseCase, logger *zap.Logger
 }
 
 // @Summary		Register user
-// @Description	Creates a new user.
+// @Description	Creates a new user, the profile is created asynchronously from the user_registered event.
 // @Tags			auth
 // @Accept			json
 // @Produce		json
 // @Success		200	{object}	models.SuccessResponse	"succesful registration"
 // @Failure		400	{object}	models.ErrorResponse	"bad request error"
+// @Failure		429	{object}	models.ErrorResponse	"rate limit exceeded"
 // @Failure		500	{object}	models.ErrorResponse	"internal server error"
 // @Router			/register [post]
 func (h *authHandlers) Register() http.HandlerFunc {
@@ -45,8 +62,12 @@ func (h *authHandlers) Register() http.HandlerFunc {
 			return
 		}
 
-		token, userUID, err := h.authUC.Create(requestBody.Email, requestBody.Password)
-		fmt.Println(token, userUID, err)
+		if requestBody.Name == "" {
+			erp.BadRequestResponse(w, r, h.logger, errors.New("name must be provided"))
+			return
+		}
+
+		tokens, _, err := h.authUC.Create(requestBody.Email, requestBody.Password, requestBody.Name)
 		if err != nil {
 			switch err {
 			case db.ErrDuplicateEmail:
@@ -57,22 +78,7 @@ func (h *authHandlers) Register() http.HandlerFunc {
 			return
 		}
 
-		cookie := &http.Cookie{
-			Name:     "token",
-			Value:    token,
-			Path:     "/",
-			HttpOnly: true,
-			Secure:   h.cfg.Env == "production",
-			SameSite: http.SameSiteStrictMode,
-			Expires:  time.Now().Add(h.cfg.Timeout.Cookie),
-		}
-
-		if err = h.authUC.CreateProfile(userUID, requestBody.Name, cookie); err != nil {
-			erp.ServerErrorResponse(w, r, h.logger, err)
-			return
-		}
-
-		http.SetCookie(w, cookie)
+		h.setAuthCookies(w, tokens)
 
 		err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{
 			"message": "successful registration",
@@ -91,6 +97,7 @@ func (h *authHandlers) Register() http.HandlerFunc {
 // @Success		200	{object}	models.SuccessResponse	"successful login"
 // @Failure		400	{object}	models.ErrorResponse	"bad request error"
 // @Failure		401	{object}	models.ErrorResponse	"invalid authentication credentials"
+// @Failure		429	{object}	models.ErrorResponse	"rate limit exceeded"
 // @Failure		500	{object}	models.ErrorResponse	"internal server error"
 // @Router			/login [post]
 func (h *authHandlers) Login() http.HandlerFunc {
@@ -104,25 +111,25 @@ func (h *authHandlers) Login() http.HandlerFunc {
 
 		user, err := h.authUC.ValidateCredentials(requestBody.Email, requestBody.Password)
 		if err != nil {
-			erp.InvalidCredentialsResponse(w, r, h.logger)
+			var rateLimitErr *auth.RateLimitError
+			switch {
+			case errors.As(err, &rateLimitErr):
+				erp.RateLimitExceededResponse(w, r, h.logger, rateLimitErr.RetryAfter)
+			case errors.Is(err, auth.ErrInvalidCredentials):
+				erp.InvalidCredentialsResponse(w, r, h.logger)
+			default:
+				erp.ServerErrorResponse(w, r, h.logger, err)
+			}
 			return
 		}
 
-		token, err := h.authUC.GenerateJWT(*user)
+		tokens, err := h.authUC.CreateSession(*user)
 		if err != nil {
 			erp.ServerErrorResponse(w, r, h.logger, err)
 			return
 		}
 
-		http.SetCookie(w, &http.Cookie{
-			Name:     "token",
-			Value:    token,
-			Path:     "/",
-			HttpOnly: true,
-			Secure:   h.cfg.Env == "production",
-			SameSite: http.SameSiteStrictMode,
-			Expires:  time.Now().Add(h.cfg.Timeout.Cookie),
-		})
+		h.setAuthCookies(w, tokens)
 
 		err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{
 			"message": "successful login",
@@ -134,33 +141,1111 @@ func (h *authHandlers) Login() http.HandlerFunc {
 }
 
 // @Summary		Authenticate user
-// @Description	Validates and retrieves user's information about token.
+// @Description	Validates and retrieves user's information about token, or about the API key passed in the X-API-Key header. Internal, requires a service token of products, profiles, recommendations or analytics service.
 // @Tags			auth
 // @Produce		json
 // @Security		cookieAuth
-// @Success		200	{object}	models.UserResponse		"successful login"
-// @Failure		401	{object}	models.ErrorResponse	"invalid or missing authentication token"
-// @Failure		500	{object}	models.ErrorResponse	"internal server error"
+// @Security		serviceAuth
+// @Param			X-API-Key	header		string					false	"API key to validate instead of the token cookie"
+// @Success		200			{object}	models.UserResponse		"successful login"
+// @Failure		401			{object}	models.ErrorResponse	"invalid or missing authentication token"
+// @Failure		403			{object}	models.ErrorResponse	"missing or invalid service token"
+// @Failure		500			{object}	models.ErrorResponse	"internal server error"
 // @Router			/authenticate [get]
 func (h *authHandlers) TokenValidation() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		cookie, err := r.Cookie("token")
+		var identity *models.Identity
+		var err error
+
+		if apiKey := r.Header.Get(apiKeyHeader); apiKey != "" {
+			identity, err = h.authUC.AuthenticateAPIKey(apiKey)
+			if err != nil {
+				if !errors.Is(err, auth.ErrInvalidAPIKey) {
+					erp.ServerErrorResponse(w, r, h.logger, err)
+					return
+				}
+				erp.InvalidAuthenticationTokenResponse(w, r, h.logger)
+				return
+			}
+		} else {
+			cookie, err := r.Cookie(accessCookieName)
+			if err != nil {
+				erp.InvalidAuthenticationTokenResponse(w, r, h.logger)
+				return
+			}
+
+			identity, err = h.authUC.ValidateToken(cookie.Value)
+			if err != nil {
+				erp.InvalidAuthenticationTokenResponse(w, r, h.logger)
+				return
+			}
+		}
+
+		err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+			"user_uid":    identity.UserUID,
+			"is_admin":    identity.IsAdmin,
+			"roles":       identity.Roles,
+			"permissions": identity.Permissions,
+		}, nil)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+		}
+	}
+}
+
+// @Summary		Refresh tokens
+// @Description	Exchanges the refresh token cookie for a new access and refresh token pair. Reusing a refresh token revokes its session.
+// @Tags			auth
+// @Produce		json
+// @Success		200	{object}	models.SuccessResponse	"tokens refreshed"
+// @Failure		401	{object}	models.ErrorResponse	"invalid or missing authentication token"
+// @Failure		500	{object}	models.ErrorResponse	"internal server error"
+// @Router			/refresh [post]
+func (h *authHandlers) Refresh() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(refreshCookieName)
 		if err != nil {
 			erp.InvalidAuthenticationTokenResponse(w, r, h.logger)
 			return
 		}
 
-		tokenString := cookie.Value
+		tokens, err := h.authUC.Refresh(cookie.Value)
+		if err != nil {
+			switch {
+			case errors.Is(err, db.ErrRecordNotFound), errors.Is(err, db.ErrTokenReused):
+				h.clearAuthCookies(w)
+				erp.InvalidAuthenticationTokenResponse(w, r, h.logger)
+			default:
+				erp.ServerErrorResponse(w, r, h.logger, err)
+			}
+			return
+		}
+
+		h.setAuthCookies(w, tokens)
 
-		userID, isAdmin, err := h.authUC.ValidateToken(tokenString)
+		err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+			"message": "tokens refreshed",
+		}, nil)
 		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+		}
+	}
+}
+
+// @Summary		Logout user
+// @Description	Revokes the current session and clears auth cookies.
+// @Tags			auth
+// @Produce		json
+// @Security		cookieAuth
+// @Success		200	{object}	models.SuccessResponse	"successful logout"
+// @Failure		401	{object}	models.ErrorResponse	"invalid or missing authentication token"
+// @Failure		500	{object}	models.ErrorResponse	"internal server error"
+// @Router			/logout [post]
+func (h *authHandlers) Logout() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var accessToken, refreshToken string
+		if cookie, err := r.Cookie(accessCookieName); err == nil {
+			accessToken = cookie.Value
+		}
+		if cookie, err := r.Cookie(refreshCookieName); err == nil {
+			refreshToken = cookie.Value
+		}
+
+		if accessToken == "" && refreshToken == "" {
 			erp.InvalidAuthenticationTokenResponse(w, r, h.logger)
 			return
 		}
 
+		if err := h.authUC.Logout(accessToken, refreshToken); err != nil {
+			h.logger.Warn("failed to revoke session", zap.Error(err))
+		}
+
+		h.clearAuthCookies(w)
+
+		err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+			"message": "successful logout",
+		}, nil)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+		}
+	}
+}
+
+// @Summary		Get signing keys
+// @Description	Returns the public keys used to sign access tokens as a JSON Web Key Set, services verify tokens locally with it.
+// @Tags			auth
+// @Produce		json
+// @Success		200	{object}	models.JWKSResponse	"key set"
+// @Failure		500	{object}	models.ErrorResponse	"internal server error"
+// @Router			/.well-known/jwks.json [get]
+func (h *authHandlers) JWKS() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		headers := make(http.Header)
+		headers.Set("Cache-Control", "public, max-age=300")
+
+		err := utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+			"keys": h.authUC.JWKS().Keys,
+		}, headers)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+		}
+	}
+}
+
+// @Summary		List revoked sessions
+// @Description	Returns IDs of sessions revoked within the access token lifetime, including sessions of deleted accounts. Services reject access tokens of these sessions after verifying them locally. Internal, requires a service token of products, profiles, recommendations or analytics service.
+// @Tags			auth
+// @Produce		json
+// @Security		serviceAuth
+// @Success		200	{object}	models.RevokedSessionsResponse	"revoked session IDs"
+// @Failure		403	{object}	models.ErrorResponse			"missing or invalid service token"
+// @Failure		500	{object}	models.ErrorResponse			"internal server error"
+// @Router			/sessions/revoked [get]
+func (h *authHandlers) RevokedSessions() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sessionIDs, err := h.authUC.RevokedSessions()
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+			return
+		}
+
+		err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+			"session_ids": sessionIDs,
+		}, nil)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+		}
+	}
+}
+
+// @Summary		Issue service token
+// @Description	Issues a short-lived token for calls between internal services, the audience is the called service.
+// @Tags			auth
+// @Accept			json
+// @Produce		json
+// @Param			body	body		models.ServiceTokenDTO			true	"client credentials and audience"
+// @Success		200		{object}	models.ServiceTokenResponse	"service token"
+// @Failure		400		{object}	models.ErrorResponse		"bad request error"
+// @Failure		401		{object}	models.ErrorResponse		"invalid client credentials"
+// @Failure		429		{object}	models.ErrorResponse		"rate limit exceeded"
+// @Failure		500		{object}	models.ErrorResponse		"internal server error"
+// @Router			/service-token [post]
+func (h *authHandlers) ServiceToken() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var requestBody models.ServiceTokenDTO
+
+		if err := utils.ReadJSON(w, r, &requestBody); err != nil {
+			erp.BadRequestResponse(w, r, h.logger, err)
+			return
+		}
+
+		token, expiresAt, err := h.authUC.IssueServiceToken(requestBody.ClientID, requestBody.ClientSecret, requestBody.Audience)
+		if err != nil {
+			switch {
+			case errors.Is(err, auth.ErrInvalidClient):
+				erp.InvalidCredentialsResponse(w, r, h.logger)
+			case errors.Is(err, auth.ErrInvalidAudience):
+				erp.BadRequestResponse(w, r, h.logger, err)
+			default:
+				erp.ServerErrorResponse(w, r, h.logger, err)
+			}
+			return
+		}
+
+		headers := make(http.Header)
+		headers.Set("Cache-Control", "no-store")
+
+		err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+			"token":      token,
+			"expires_at": expiresAt,
+		}, headers)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+		}
+	}
+}
+
+// @Summary		List roles
+// @Description	Returns all roles with their permissions (requires roles:manage).
+// @Tags			roles
+// @Produce		json
+// @Security		cookieAuth
+// @Success		200	{object}	models.RolesResponse	"roles"
+// @Failure		401	{object}	models.ErrorResponse	"authentication required"
+// @Failure		403	{object}	models.ErrorResponse	"not permitted"
+// @Failure		500	{object}	models.ErrorResponse	"internal server error"
+// @Router			/roles [get]
+func (h *authHandlers) ListRoles() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		roles, err := h.authUC.ListRoles()
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+			return
+		}
+
+		err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+			"roles": roles,
+		}, nil)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+		}
+	}
+}
+
+// @Summary		Get user
+// @Description	Returns the account of a user (requires users:read).
+// @Tags			roles
+// @Produce		json
+// @Security		cookieAuth
+// @Param			id	path		int						true	"User ID"
+// @Success		200	{object}	models.AccountResponse	"user account"
+// @Failure		401	{object}	models.ErrorResponse	"authentication required"
+// @Failure		403	{object}	models.ErrorResponse	"not permitted"
+// @Failure		404	{object}	models.ErrorResponse	"not found error"
+// @Failure		500	{object}	models.ErrorResponse	"internal server error"
+// @Router			/users/{id} [get]
+func (h *authHandlers) GetUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := utils.ReadIDParam(r)
+		if err != nil {
+			erp.NotFoundResponse(w, r, h.logger)
+			return
+		}
+
+		user, err := h.authUC.GetUser(id)
+		if err != nil {
+			switch {
+			case errors.Is(err, db.ErrRecordNotFound):
+				erp.NotFoundResponse(w, r, h.logger)
+			default:
+				erp.ServerErrorResponse(w, r, h.logger, err)
+			}
+			return
+		}
+
+		err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+			"user_uid":       user.ID,
+			"email":          user.Email,
+			"email_verified": user.Verified,
+			"is_admin":       user.IsAdmin,
+			"roles":          user.Roles,
+			"permissions":    user.Permissions,
+			"created_at":     user.CreatedAt,
+		}, nil)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+		}
+	}
+}
+
+// @Summary		Get user roles
+// @Description	Returns roles assigned to a user (requires roles:manage).
+// @Tags			roles
+// @Produce		json
+// @Security		cookieAuth
+// @Param			id	path		int						true	"User ID"
+// @Success		200	{object}	models.RolesResponse	"user roles"
+// @Failure		401	{object}	models.ErrorResponse	"authentication required"
+// @Failure		403	{object}	models.ErrorResponse	"not permitted"
+// @Failure		404	{object}	models.ErrorResponse	"not found error"
+// @Failure		500	{object}	models.ErrorResponse	"internal server error"
+// @Router			/users/{id}/roles [get]
+func (h *authHandlers) UserRoles() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := utils.ReadIDParam(r)
+		if err != nil {
+			erp.NotFoundResponse(w, r, h.logger)
+			return
+		}
+
+		roles, err := h.authUC.GetUserRoles(id)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+			return
+		}
+
+		err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+			"roles": roles,
+		}, nil)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+		}
+	}
+}
+
+// @Summary		Assign role
+// @Description	Assigns a role to a user (requires roles:manage). The role is added to the user's tokens on the next refresh.
+// @Tags			roles
+// @Produce		json
+// @Security		cookieAuth
+// @Param			id		path		int						true	"User ID"
+// @Param			role	path		string					true	"Role name"
+// @Success		200		{object}	models.SuccessResponse	"role assigned"
+// @Failure		401		{object}	models.ErrorResponse	"authentication required"
+// @Failure		403		{object}	models.ErrorResponse	"not permitted"
+// @Failure		404		{object}	models.ErrorResponse	"user or role not found"
+// @Failure		500		{object}	models.ErrorResponse	"internal server error"
+// @Router			/users/{id}/roles/{role} [put]
+func (h *authHandlers) AssignRole() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := utils.ReadIDParam(r)
+		if err != nil {
+			erp.NotFoundResponse(w, r, h.logger)
+			return
+		}
+
+		role := httprouter.ParamsFromContext(r.Context()).ByName("role")
+
+		err = h.authUC.AssignRole(id, role)
+		if err != nil {
+			switch {
+			case errors.Is(err, db.ErrRecordNotFound):
+				erp.NotFoundResponse(w, r, h.logger)
+			default:
+				erp.ServerErrorResponse(w, r, h.logger, err)
+			}
+			return
+		}
+
+		h.logger.Info("role assigned",
+			zap.String("user_uid", id),
+			zap.String("role", role),
+			zap.String("by", middleware.ContextGetIdentity(r).UserUID),
+		)
+
+		err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+			"message": "role assigned",
+		}, nil)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+		}
+	}
+}
+
+// @Summary		Remove role
+// @Description	Removes a role from a user (requires roles:manage). The role is removed from the user's tokens on the next refresh.
+// @Tags			roles
+// @Produce		json
+// @Security		cookieAuth
+// @Param			id		path		int						true	"User ID"
+// @Param			role	path		string					true	"Role name"
+// @Success		200		{object}	models.SuccessResponse	"role removed"
+// @Failure		401		{object}	models.ErrorResponse	"authentication required"
+// @Failure		403		{object}	models.ErrorResponse	"not permitted"
+// @Failure		404		{object}	models.ErrorResponse	"role is not assigned"
+// @Failure		500		{object}	models.ErrorResponse	"internal server error"
+// @Router			/users/{id}/roles/{role} [delete]
+func (h *authHandlers) RemoveRole() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := utils.ReadIDParam(r)
+		if err != nil {
+			erp.NotFoundResponse(w, r, h.logger)
+			return
+		}
+
+		role := httprouter.ParamsFromContext(r.Context()).ByName("role")
+
+		err = h.authUC.RemoveRole(id, role)
+		if err != nil {
+			switch {
+			case errors.Is(err, db.ErrRecordNotFound):
+				erp.NotFoundResponse(w, r, h.logger)
+			default:
+				erp.ServerErrorResponse(w, r, h.logger, err)
+			}
+			return
+		}
+
+		h.logger.Info("role removed",
+			zap.String("user_uid", id),
+			zap.String("role", role),
+			zap.String("by", middleware.ContextGetIdentity(r).UserUID),
+		)
+
+		err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+			"message": "role removed",
+		}, nil)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+		}
+	}
+}
+
+// @Summary		Get current user
+// @Description	Returns the account of the authenticated user.
+// @Tags			account
+// @Produce		json
+// @Security		cookieAuth
+// @Success		200	{object}	models.AccountResponse	"current user"
+// @Failure		401	{object}	models.ErrorResponse	"authentication required"
+// @Failure		500	{object}	models.ErrorResponse	"internal server error"
+// @Router			/me [get]
+func (h *authHandlers) Me() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		identity := middleware.ContextGetIdentity(r)
+
+		user, err := h.authUC.Me(identity.UserUID)
+		if err != nil {
+			switch {
+			case errors.Is(err, db.ErrRecordNotFound):
+				erp.InvalidAuthenticationTokenResponse(w, r, h.logger)
+			default:
+				erp.ServerErrorResponse(w, r, h.logger, err)
+			}
+			return
+		}
+
 		err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{
-			"user_uid": userID,
-			"is_admin": isAdmin,
+			"user_uid":       user.ID,
+			"email":          user.Email,
+			"email_verified": user.Verified,
+			"is_admin":       identity.IsAdmin,
+			"roles":          user.Roles,
+			"permissions":    user.Permissions,
+			"created_at":     user.CreatedAt,
+		}, nil)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+		}
+	}
+}
+
+// @Summary		Change password
+// @Description	Changes the password of the authenticated user and revokes all other sessions. Accounts without a password set one from a session signed in within TIMEOUT_REAUTH.
+// @Tags			account
+// @Accept			json
+// @Produce		json
+// @Security		cookieAuth
+// @Param			request	body		models.ChangePasswordDTO	true	"Current and new password"
+// @Success		200		{object}	models.SuccessResponse		"password changed"
+// @Failure		400		{object}	models.ErrorResponse		"bad request error"
+// @Failure		401		{object}	models.ErrorResponse		"invalid password, or sign-in required for accounts without a password"
+// @Failure		500		{object}	models.ErrorResponse		"internal server error"
+// @Router			/password [put]
+func (h *authHandlers) ChangePassword() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var requestBody models.ChangePasswordDTO
+
+		if err := utils.ReadJSON(w, r, &requestBody); err != nil {
+			erp.BadRequestResponse(w, r, h.logger, err)
+			return
+		}
+
+		if requestBody.NewPassword == "" {
+			erp.BadRequestResponse(w, r, h.logger, errors.New("new password must be provided"))
+			return
+		}
+
+		identity := middleware.ContextGetIdentity(r)
+
+		err := h.authUC.ChangePassword(identity.UserUID, identity.SessionID, requestBody.CurrentPassword, requestBody.NewPassword)
+		if err != nil {
+			switch {
+			case errors.Is(err, auth.ErrInvalidPassword):
+				erp.InvalidCredentialsResponse(w, r, h.logger)
+			case errors.Is(err, auth.ErrReauthRequired):
+				erp.ReauthenticationRequiredResponse(w, r, h.logger)
+			default:
+				erp.ServerErrorResponse(w, r, h.logger, err)
+			}
+			return
+		}
+
+		err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+			"message": "password changed",
+		}, nil)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+		}
+	}
+}
+
+// @Summary		Change email
+// @Description	Sends a confirmation link to the new email, the email is changed once the link is used.
+// @Tags			account
+// @Accept			json
+// @Produce		json
+// @Security		cookieAuth
+// @Param			request	body		models.ChangeEmailDTO	true	"New email and current password"
+// @Success		202		{object}	models.SuccessResponse	"confirmation email sent"
+// @Failure		400		{object}	models.ErrorResponse	"bad request error"
+// @Failure		401		{object}	models.ErrorResponse	"invalid password, or sign-in required for accounts without a password"
+// @Failure		500		{object}	models.ErrorResponse	"internal server error"
+// @Router			/email [put]
+func (h *authHandlers) ChangeEmail() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var requestBody models.ChangeEmailDTO
+
+		if err := utils.ReadJSON(w, r, &requestBody); err != nil {
+			erp.BadRequestResponse(w, r, h.logger, err)
+			return
+		}
+
+		if requestBody.Email == "" {
+			erp.BadRequestResponse(w, r, h.logger, errors.New("email must be provided"))
+			return
+		}
+
+		identity := middleware.ContextGetIdentity(r)
+
+		err := h.authUC.RequestEmailChange(identity.UserUID, identity.SessionID, requestBody.Password, requestBody.Email)
+		if err != nil {
+			switch {
+			case errors.Is(err, auth.ErrInvalidPassword):
+				erp.InvalidCredentialsResponse(w, r, h.logger)
+			case errors.Is(err, auth.ErrReauthRequired):
+				erp.ReauthenticationRequiredResponse(w, r, h.logger)
+			case errors.Is(err, db.ErrDuplicateEmail):
+				erp.BadRequestResponse(w, r, h.logger, err)
+			default:
+				erp.ServerErrorResponse(w, r, h.logger, err)
+			}
+			return
+		}
+
+		err = utils.WriteJSON(w, http.StatusAccepted, utils.Envelope{
+			"message": "confirmation email sent",
+		}, nil)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+		}
+	}
+}
+
+// @Summary		Confirm email
+// @Description	Changes the email using the token from the confirmation link.
+// @Tags			account
+// @Accept			json
+// @Produce		json
+// @Param			request	body		models.ConfirmEmailDTO	true	"Confirmation token"
+// @Success		200		{object}	models.SuccessResponse	"email changed"
+// @Failure		400		{object}	models.ErrorResponse	"invalid or expired token"
+// @Failure		500		{object}	models.ErrorResponse	"internal server error"
+// @Router			/email/confirm [post]
+func (h *authHandlers) ConfirmEmail() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var requestBody models.ConfirmEmailDTO
+
+		if err := utils.ReadJSON(w, r, &requestBody); err != nil {
+			erp.BadRequestResponse(w, r, h.logger, err)
+			return
+		}
+
+		err := h.authUC.ConfirmEmailChange(requestBody.Token)
+		if err != nil {
+			switch {
+			case errors.Is(err, auth.ErrInvalidToken), errors.Is(err, db.ErrDuplicateEmail):
+				erp.BadRequestResponse(w, r, h.logger, err)
+			default:
+				erp.ServerErrorResponse(w, r, h.logger, err)
+			}
+			return
+		}
+
+		err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+			"message": "email changed",
+		}, nil)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+		}
+	}
+}
+
+// @Summary		Delete account
+// @Description	Deletes the account of the authenticated user and notifies other services to purge the user's data.
+// @Tags			account
+// @Accept			json
+// @Produce		json
+// @Security		cookieAuth
+// @Param			request	body		models.DeleteAccountDTO	true	"Current password"
+// @Success		200		{object}	models.SuccessResponse	"account deleted"
+// @Failure		400		{object}	models.ErrorResponse	"bad request error"
+// @Failure		401		{object}	models.ErrorResponse	"invalid password, or sign-in required for accounts without a password"
+// @Failure		500		{object}	models.ErrorResponse	"internal server error"
+// @Router			/account [delete]
+func (h *authHandlers) DeleteAccount() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var requestBody models.DeleteAccountDTO
+
+		if err := utils.ReadJSON(w, r, &requestBody); err != nil {
+			erp.BadRequestResponse(w, r, h.logger, err)
+			return
+		}
+
+		identity := middleware.ContextGetIdentity(r)
+
+		err := h.authUC.DeleteAccount(identity.UserUID, identity.SessionID, requestBody.Password)
+		if err != nil {
+			switch {
+			case errors.Is(err, auth.ErrInvalidPassword):
+				erp.InvalidCredentialsResponse(w, r, h.logger)
+			case errors.Is(err, auth.ErrReauthRequired):
+				erp.ReauthenticationRequiredResponse(w, r, h.logger)
+			default:
+				erp.ServerErrorResponse(w, r, h.logger, err)
+			}
+			return
+		}
+
+		h.clearAuthCookies(w)
+
+		err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+			"message": "account deleted",
+		}, nil)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+		}
+	}
+}
+
+// @Summary		Verify email
+// @Description	Marks the email as verified using the token from the verification link.
+// @Tags			account
+// @Accept			json
+// @Produce		json
+// @Param			request	body		models.VerifyEmailDTO	true	"Verification token"
+// @Success		200		{object}	models.SuccessResponse	"email verified"
+// @Failure		400		{object}	models.ErrorResponse	"invalid or expired token"
+// @Failure		500		{object}	models.ErrorResponse	"internal server error"
+// @Router			/email/verify [post]
+func (h *authHandlers) VerifyEmail() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var requestBody models.VerifyEmailDTO
+
+		if err := utils.ReadJSON(w, r, &requestBody); err != nil {
+			erp.BadRequestResponse(w, r, h.logger, err)
+			return
+		}
+
+		err := h.authUC.VerifyEmail(requestBody.Token)
+		if err != nil {
+			switch {
+			case errors.Is(err, auth.ErrInvalidToken):
+				erp.BadRequestResponse(w, r, h.logger, err)
+			default:
+				erp.ServerErrorResponse(w, r, h.logger, err)
+			}
+			return
+		}
+
+		err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+			"message": "email verified",
+		}, nil)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+		}
+	}
+}
+
+// @Summary		Resend verification email
+// @Description	Sends a new email verification link to the authenticated user.
+// @Tags			account
+// @Produce		json
+// @Security		cookieAuth
+// @Success		202	{object}	models.SuccessResponse	"verification email sent"
+// @Failure		400	{object}	models.ErrorResponse	"email is already verified"
+// @Failure		401	{object}	models.ErrorResponse	"authentication required"
+// @Failure		500	{object}	models.ErrorResponse	"internal server error"
+// @Router			/email/verify/resend [post]
+func (h *authHandlers) ResendVerification() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := h.authUC.SendVerificationEmail(middleware.ContextGetIdentity(r).UserUID)
+		if err != nil {
+			switch {
+			case errors.Is(err, auth.ErrEmailVerified):
+				erp.BadRequestResponse(w, r, h.logger, err)
+			default:
+				erp.ServerErrorResponse(w, r, h.logger, err)
+			}
+			return
+		}
+
+		err = utils.WriteJSON(w, http.StatusAccepted, utils.Envelope{
+			"message": "verification email sent",
+		}, nil)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+		}
+	}
+}
+
+// @Summary		Forgot password
+// @Description	Sends a password reset link if an account with the email exists. The response does not reveal whether it does.
+// @Tags			account
+// @Accept			json
+// @Produce		json
+// @Param			request	body		models.ForgotPasswordDTO	true	"Account email"
+// @Success		202		{object}	models.SuccessResponse		"reset link sent if the account exists"
+// @Failure		400		{object}	models.ErrorResponse		"bad request error"
+// @Failure		429		{object}	models.ErrorResponse		"rate limit exceeded"
+// @Failure		500		{object}	models.ErrorResponse		"internal server error"
+// @Router			/password/forgot [post]
+func (h *authHandlers) ForgotPassword() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var requestBody models.ForgotPasswordDTO
+
+		if err := utils.ReadJSON(w, r, &requestBody); err != nil {
+			erp.BadRequestResponse(w, r, h.logger, err)
+			return
+		}
+
+		if requestBody.Email == "" {
+			erp.BadRequestResponse(w, r, h.logger, errors.New("email must be provided"))
+			return
+		}
+
+		// The reply is the same whether the account exists or not
+		if err := h.authUC.ForgotPassword(requestBody.Email); err != nil {
+			h.logger.Error("failed to create password reset link", zap.Error(err))
+		}
+
+		err := utils.WriteJSON(w, http.StatusAccepted, utils.Envelope{
+			"message": "if the account exists, a reset link has been sent",
+		}, nil)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+		}
+	}
+}
+
+// @Summary		Reset password
+// @Description	Sets a new password using the token from the reset link and revokes all sessions of the user.
+// @Tags			account
+// @Accept			json
+// @Produce		json
+// @Param			request	body		models.ResetPasswordDTO	true	"Reset token and new password"
+// @Success		200		{object}	models.SuccessResponse	"password reset"
+// @Failure		400		{object}	models.ErrorResponse	"invalid or expired token"
+// @Failure		500		{object}	models.ErrorResponse	"internal server error"
+// @Router			/password/reset [post]
+func (h *authHandlers) ResetPassword() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var requestBody models.ResetPasswordDTO
+
+		if err := utils.ReadJSON(w, r, &requestBody); err != nil {
+			erp.BadRequestResponse(w, r, h.logger, err)
+			return
+		}
+
+		if requestBody.NewPassword == "" {
+			erp.BadRequestResponse(w, r, h.logger, errors.New("new password must be provided"))
+			return
+		}
+
+		err := h.authUC.ResetPassword(requestBody.Token, requestBody.NewPassword)
+		if err != nil {
+			switch {
+			case errors.Is(err, auth.ErrInvalidToken):
+				erp.BadRequestResponse(w, r, h.logger, err)
+			default:
+				erp.ServerErrorResponse(w, r, h.logger, err)
+			}
+			return
+		}
+
+		err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+			"message": "password reset",
+		}, nil)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+		}
+	}
+}
+
+// Build access token cookie
+func (h *authHandlers) accessCookie(token string) *http.Cookie {
+	return &http.Cookie{
+		Name:     accessCookieName,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   h.cfg.Env == "production",
+		SameSite: http.SameSiteStrictMode,
+		Expires:  time.Now().Add(h.cfg.Timeout.Cookie),
+	}
+}
+
+// Set access and refresh token cookies, the refresh token is only sent to auth endpoints
+func (h *authHandlers) setAuthCookies(w http.ResponseWriter, tokens *models.Tokens) {
+	http.SetCookie(w, h.accessCookie(tokens.Access))
+	http.SetCookie(w, &http.Cookie{
+		Name:     refreshCookieName,
+		Value:    tokens.Refresh,
+		Path:     "/auth",
+		HttpOnly: true,
+		Secure:   h.cfg.Env == "production",
+		SameSite: http.SameSiteStrictMode,
+		Expires:  tokens.RefreshExpiresAt,
+	})
+}
+
+// Remove access and refresh token cookies
+func (h *authHandlers) clearAuthCookies(w http.ResponseWriter) {
+	for name, path := range map[string]string{accessCookieName: "/", refreshCookieName: "/auth"} {
+		http.SetCookie(w, &http.Cookie{
+			Name:     name,
+			Value:    "",
+			Path:     path,
+			HttpOnly: true,
+			Secure:   h.cfg.Env == "production",
+			SameSite: http.SameSiteStrictMode,
+			MaxAge:   -1,
+		})
+	}
+}
+
+// @Summary		Create API key
+// @Description	Creates an API key for server-side integrations, limited to the given scopes of the user's permissions. The key is only returned once.
+// @Tags			api-keys
+// @Accept			json
+// @Produce		json
+// @Security		cookieAuth
+// @Param			request	body		models.CreateAPIKeyDTO			true	"Key name, scopes and optional lifetime in days"
+// @Success		201		{object}	models.CreateAPIKeyResponse	"created key"
+// @Failure		400		{object}	models.ErrorResponse			"bad request error"
+// @Failure		401		{object}	models.ErrorResponse			"authentication required"
+// @Failure		500		{object}	models.ErrorResponse			"internal server error"
+// @Router			/api-keys [post]
+func (h *authHandlers) CreateAPIKey() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var requestBody models.CreateAPIKeyDTO
+
+		if err := utils.ReadJSON(w, r, &requestBody); err != nil {
+			erp.BadRequestResponse(w, r, h.logger, err)
+			return
+		}
+
+		switch {
+		case strings.TrimSpace(requestBody.Name) == "":
+			erp.BadRequestResponse(w, r, h.logger, errors.New("name must be provided"))
+			return
+		case len(requestBody.Scopes) == 0:
+			erp.BadRequestResponse(w, r, h.logger, errors.New("at least one scope must be provided"))
+			return
+		case requestBody.ExpiresInDays < 0:
+			erp.BadRequestResponse(w, r, h.logger, errors.New("expires_in_days must not be negative"))
+			return
+		}
+
+		identity := middleware.ContextGetIdentity(r)
+		ttl := time.Duration(requestBody.ExpiresInDays) * 24 * time.Hour
+
+		key, plainKey, err := h.authUC.CreateAPIKey(identity.UserUID, strings.TrimSpace(requestBody.Name), requestBody.Scopes, ttl)
+		if err != nil {
+			switch {
+			case errors.Is(err, auth.ErrInvalidScope):
+				erp.BadRequestResponse(w, r, h.logger, err)
+			default:
+				erp.ServerErrorResponse(w, r, h.logger, err)
+			}
+			return
+		}
+
+		h.logger.Info("api key created",
+			zap.String("user_uid", identity.UserUID),
+			zap.String("prefix", key.Prefix),
+		)
+
+		headers := make(http.Header)
+		headers.Set("Cache-Control", "no-store")
+
+		err = utils.WriteJSON(w, http.StatusCreated, utils.Envelope{
+			"api_key": key,
+			"key":     plainKey,
+		}, headers)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+		}
+	}
+}
+
+// @Summary		List API keys
+// @Description	Returns API keys of the authenticated user including revoked and expired ones.
+// @Tags			api-keys
+// @Produce		json
+// @Security		cookieAuth
+// @Success		200	{object}	models.APIKeysResponse	"api keys"
+// @Failure		401	{object}	models.ErrorResponse	"authentication required"
+// @Failure		500	{object}	models.ErrorResponse	"internal server error"
+// @Router			/api-keys [get]
+func (h *authHandlers) ListAPIKeys() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		keys, err := h.authUC.ListAPIKeys(middleware.ContextGetIdentity(r).UserUID)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+			return
+		}
+
+		err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+			"api_keys": keys,
+		}, nil)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+		}
+	}
+}
+
+// @Summary		Revoke API key
+// @Description	Revokes an API key of the authenticated user.
+// @Tags			api-keys
+// @Produce		json
+// @Security		cookieAuth
+// @Param			id	path		int						true	"API key ID"
+// @Success		200	{object}	models.SuccessResponse	"api key revoked"
+// @Failure		401	{object}	models.ErrorResponse	"authentication required"
+// @Failure		404	{object}	models.ErrorResponse	"not found error"
+// @Failure		500	{object}	models.ErrorResponse	"internal server error"
+// @Router			/api-keys/{id} [delete]
+func (h *authHandlers) RevokeAPIKey() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := utils.ReadIDParam(r)
+		if err != nil {
+			erp.NotFoundResponse(w, r, h.logger)
+			return
+		}
+
+		identity := middleware.ContextGetIdentity(r)
+
+		err = h.authUC.RevokeAPIKey(identity.UserUID, id)
+		if err != nil {
+			switch {
+			case errors.Is(err, db.ErrRecordNotFound):
+				erp.NotFoundResponse(w, r, h.logger)
+			default:
+				erp.ServerErrorResponse(w, r, h.logger, err)
+			}
+			return
+		}
+
+		h.logger.Info("api key revoked",
+			zap.String("user_uid", identity.UserUID),
+			zap.String("api_key_id", id),
+		)
+
+		err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+			"message": "api key revoked",
+		}, nil)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+		}
+	}
+}
+
+// @Summary		Login with identity provider
+// @Description	Redirects the browser to the external identity provider (authorization code flow with PKCE).
+// @Tags			oidc
+// @Param			provider	path	string	true	"Identity provider name"
+// @Success		302			"redirect to the identity provider"
+// @Failure		404			{object}	models.ErrorResponse	"unknown identity provider"
+// @Failure		429			{object}	models.ErrorResponse	"rate limit exceeded"
+// @Failure		500			{object}	models.ErrorResponse	"internal server error"
+// @Router			/oidc/{provider}/login [get]
+func (h *authHandlers) OIDCLogin() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		provider := httprouter.ParamsFromContext(r.Context()).ByName("provider")
+
+		authURL, state, err := h.authUC.StartOIDCLogin(provider)
+		if err != nil {
+			switch {
+			case errors.Is(err, auth.ErrUnknownProvider):
+				erp.NotFoundResponse(w, r, h.logger)
+			default:
+				erp.ServerErrorResponse(w, r, h.logger, err)
+			}
+			return
+		}
+
+		// Binds the callback to this browser, lax so it is sent on the redirect back from the provider
+		http.SetCookie(w, &http.Cookie{
+			Name:     oidcStateCookieName,
+			Value:    state,
+			Path:     "/auth/oidc",
+			HttpOnly: true,
+			Secure:   h.cfg.Env == "production",
+			SameSite: http.SameSiteLaxMode,
+			MaxAge:   int(h.cfg.Timeout.OIDCState.Seconds()),
+		})
+
+		http.Redirect(w, r, authURL, http.StatusFound)
+	}
+}
+
+// @Summary		Identity provider callback
+// @Description	Completes login with the external identity provider. The identity is linked to the account with the same verified email or to a new account, and the same auth cookies as on password login are set.
+// @Tags			oidc
+// @Produce		json
+// @Param			provider	path		string					true	"Identity provider name"
+// @Param			code		query		string					true	"Authorization code"
+// @Param			state		query		string					true	"State from the login redirect"
+// @Success		200			{object}	models.SuccessResponse	"successful login"
+// @Success		303			"redirect to OIDC_POST_LOGIN_URL if configured"
+// @Failure		400			{object}	models.ErrorResponse	"bad request error or email not verified by the provider"
+// @Failure		401			{object}	models.ErrorResponse	"invalid state or failed login with the provider"
+// @Failure		404			{object}	models.ErrorResponse	"unknown identity provider"
+// @Failure		409			{object}	models.ErrorResponse	"unverified account with the same email exists"
+// @Failure		500			{object}	models.ErrorResponse	"internal server error"
+// @Router			/oidc/{provider}/callback [get]
+func (h *authHandlers) OIDCCallback() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		provider := httprouter.ParamsFromContext(r.Context()).ByName("provider")
+		query := r.URL.Query()
+
+		// The state cookie is single use
+		http.SetCookie(w, &http.Cookie{
+			Name:     oidcStateCookieName,
+			Value:    "",
+			Path:     "/auth/oidc",
+			HttpOnly: true,
+			Secure:   h.cfg.Env == "production",
+			SameSite: http.SameSiteLaxMode,
+			MaxAge:   -1,
+		})
+
+		if query.Get("error") != "" {
+			erp.InvalidCredentialsResponse(w, r, h.logger)
+			return
+		}
+
+		state, code := query.Get("state"), query.Get("code")
+		if state == "" || code == "" {
+			erp.BadRequestResponse(w, r, h.logger, errors.New("code and state must be provided"))
+			return
+		}
+
+		cookie, err := r.Cookie(oidcStateCookieName)
+		if err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+			erp.InvalidAuthenticationTokenResponse(w, r, h.logger)
+			return
+		}
+
+		tokens, err := h.authUC.CompleteOIDCLogin(provider, state, code)
+		if err != nil {
+			switch {
+			case errors.Is(err, auth.ErrUnknownProvider):
+				erp.NotFoundResponse(w, r, h.logger)
+			case errors.Is(err, auth.ErrInvalidToken):
+				erp.InvalidAuthenticationTokenResponse(w, r, h.logger)
+			case errors.Is(err, auth.ErrExternalLogin):
+				erp.InvalidCredentialsResponse(w, r, h.logger)
+			case errors.Is(err, auth.ErrEmailNotVerified):
+				erp.BadRequestResponse(w, r, h.logger, err)
+			case errors.Is(err, auth.ErrAccountConflict):
+				erp.ConflictResponse(w, r, h.logger, err)
+			default:
+				erp.ServerErrorResponse(w, r, h.logger, err)
+			}
+			return
+		}
+
+		h.setAuthCookies(w, tokens)
+
+		if h.cfg.OIDC.PostLoginURL != "" {
+			http.Redirect(w, r, h.cfg.OIDC.PostLoginURL, http.StatusSeeOther)
+			return
+		}
+
+		err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+			"message": "successful login",
 		}, nil)
 		if err != nil {
 			erp.ServerErrorResponse(w, r, h.logger, err)
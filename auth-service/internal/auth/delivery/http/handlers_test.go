@@ -2,21 +2,27 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/julienschmidt/httprouter"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 
 	"cyansnbrst/auth-service/config"
+	"cyansnbrst/auth-service/internal/auth"
 	mock_auth "cyansnbrst/auth-service/internal/auth/mock"
+	"cyansnbrst/auth-service/internal/middleware"
 	"cyansnbrst/auth-service/internal/models"
 	"cyansnbrst/auth-service/pkg/db"
+	"cyansnbrst/auth-service/pkg/jwks"
 )
 
 func TestAuthHandlers_Register(t *testing.T) {
@@ -25,8 +31,7 @@ func TestAuthHandlers_Register(t *testing.T) {
 			Token:  time.Hour,
 			Cookie: time.Hour,
 		},
-		SecretKey: "secret",
-		Env:       "development",
+		Env: "development",
 	}
 
 	logger := zap.NewNop()
@@ -52,9 +57,8 @@ func TestAuthHandlers_Register(t *testing.T) {
 				Name:     "user",
 			},
 			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
-				token := "token"
-				mockAuthUC.EXPECT().Create("test@test.com", "test").Return(token, "12345", nil)
-				mockAuthUC.EXPECT().CreateProfile("12345", "user", gomock.Any()).Return(nil)
+				tokens := &models.Tokens{Access: "token", Refresh: "refresh"}
+				mockAuthUC.EXPECT().Create("test@test.com", "test", "user").Return(tokens, "12345", nil)
 			},
 			wantStatus: http.StatusOK,
 		},
@@ -66,7 +70,7 @@ func TestAuthHandlers_Register(t *testing.T) {
 				Name:     "user",
 			},
 			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
-				mockAuthUC.EXPECT().Create("test@test.com", "test").Return("", "", db.ErrDuplicateEmail)
+				mockAuthUC.EXPECT().Create("test@test.com", "test", "user").Return(nil, "", db.ErrDuplicateEmail)
 			},
 			wantStatus: http.StatusBadRequest,
 		},
@@ -78,23 +82,18 @@ func TestAuthHandlers_Register(t *testing.T) {
 				Name:     "user",
 			},
 			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
-				mockAuthUC.EXPECT().Create("test@test.com", "test").Return("", "", errors.New("error"))
+				mockAuthUC.EXPECT().Create("test@test.com", "test", "user").Return(nil, "", errors.New("error"))
 			},
 			wantStatus: http.StatusInternalServerError,
 		},
 		{
-			name: "profile creation error",
+			name: "missing name",
 			body: models.RegisterUserDTO{
 				Email:    "test@test.com",
 				Password: "test",
-				Name:     "user",
-			},
-			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
-				token := "token"
-				mockAuthUC.EXPECT().Create("test@test.com", "test").Return(token, "12345", nil)
-				mockAuthUC.EXPECT().CreateProfile("12345", "user", gomock.Any()).Return(errors.New("profile creation error"))
 			},
-			wantStatus: http.StatusInternalServerError,
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {},
+			wantStatus:   http.StatusBadRequest,
 		},
 	}
 
@@ -116,7 +115,7 @@ func TestAuthHandlers_Register(t *testing.T) {
 
 			if tt.wantStatus == http.StatusOK {
 				cookies := rr.Result().Cookies()
-				require.NotEmpty(t, cookies)
+				require.Len(t, cookies, 2)
 			}
 		})
 	}
@@ -128,8 +127,7 @@ func TestAuthHandlers_Login(t *testing.T) {
 			Token:  time.Hour,
 			Cookie: time.Hour,
 		},
-		SecretKey: "secret",
-		Env:       "development",
+		Env: "development",
 	}
 
 	logger := zap.NewNop()
@@ -141,10 +139,11 @@ func TestAuthHandlers_Login(t *testing.T) {
 	authHandler := NewAuthHandlers(cfg, mockAuthUC, logger)
 
 	tests := []struct {
-		name         string
-		body         models.LoginUserDTO
-		mockBehavior func(mockAuthUC *mock_auth.MockUseCase)
-		wantStatus   int
+		name           string
+		body           models.LoginUserDTO
+		mockBehavior   func(mockAuthUC *mock_auth.MockUseCase)
+		wantStatus     int
+		wantRetryAfter string
 	}{
 		{
 			name: "successful login",
@@ -154,7 +153,7 @@ func TestAuthHandlers_Login(t *testing.T) {
 			},
 			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
 				mockAuthUC.EXPECT().ValidateCredentials("test@test.com", "correctpassword").Return(&models.User{ID: "12345"}, nil)
-				mockAuthUC.EXPECT().GenerateJWT(gomock.Any()).Return("token", nil)
+				mockAuthUC.EXPECT().CreateSession(gomock.Any()).Return(&models.Tokens{Access: "token", Refresh: "refresh"}, nil)
 			},
 			wantStatus: http.StatusOK,
 		},
@@ -165,19 +164,42 @@ func TestAuthHandlers_Login(t *testing.T) {
 				Password: "wrongpassword",
 			},
 			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
-				mockAuthUC.EXPECT().ValidateCredentials("test@test.com", "wrongpassword").Return(nil, errors.New("invalid credentials"))
+				mockAuthUC.EXPECT().ValidateCredentials("test@test.com", "wrongpassword").Return(nil, auth.ErrInvalidCredentials)
 			},
 			wantStatus: http.StatusUnauthorized,
 		},
 		{
-			name: "error generating JWT",
+			name: "too many attempts",
+			body: models.LoginUserDTO{
+				Email:    "test@test.com",
+				Password: "wrongpassword",
+			},
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().ValidateCredentials("test@test.com", "wrongpassword").Return(nil, &auth.RateLimitError{RetryAfter: 90500 * time.Millisecond})
+			},
+			wantStatus:     http.StatusTooManyRequests,
+			wantRetryAfter: "91",
+		},
+		{
+			name: "limiter error",
+			body: models.LoginUserDTO{
+				Email:    "test@test.com",
+				Password: "correctpassword",
+			},
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().ValidateCredentials("test@test.com", "correctpassword").Return(nil, errors.New("limiter error"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name: "error creating session",
 			body: models.LoginUserDTO{
 				Email:    "test@test.com",
 				Password: "correctpassword",
 			},
 			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
 				mockAuthUC.EXPECT().ValidateCredentials("test@test.com", "correctpassword").Return(&models.User{ID: "12345"}, nil)
-				mockAuthUC.EXPECT().GenerateJWT(gomock.Any()).Return("", errors.New("jwt error"))
+				mockAuthUC.EXPECT().CreateSession(gomock.Any()).Return(nil, errors.New("session error"))
 			},
 			wantStatus: http.StatusInternalServerError,
 		},
@@ -197,6 +219,7 @@ func TestAuthHandlers_Login(t *testing.T) {
 			authHandler.Login().ServeHTTP(rr, req)
 
 			require.Equal(t, tt.wantStatus, rr.Code)
+			require.Equal(t, tt.wantRetryAfter, rr.Header().Get("Retry-After"))
 		})
 	}
 }
@@ -206,8 +229,7 @@ func TestAuthHandlers_TokenValidation(t *testing.T) {
 		Timeout: config.Timeout{
 			Token: time.Hour,
 		},
-		SecretKey: "secret",
-		Env:       "development",
+		Env: "development",
 	}
 
 	logger := zap.NewNop()
@@ -221,6 +243,7 @@ func TestAuthHandlers_TokenValidation(t *testing.T) {
 	tests := []struct {
 		name         string
 		token        string
+		apiKey       string
 		mockBehavior func(mockAuthUC *mock_auth.MockUseCase)
 		wantStatus   int
 	}{
@@ -228,7 +251,7 @@ func TestAuthHandlers_TokenValidation(t *testing.T) {
 			name:  "valid token",
 			token: "token",
 			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
-				mockAuthUC.EXPECT().ValidateToken("token").Return("12345", true, nil)
+				mockAuthUC.EXPECT().ValidateToken("token").Return(&models.Identity{UserUID: "12345", IsAdmin: true}, nil)
 			},
 			wantStatus: http.StatusOK,
 		},
@@ -236,7 +259,24 @@ func TestAuthHandlers_TokenValidation(t *testing.T) {
 			name:  "invalid token",
 			token: "invalid_token",
 			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
-				mockAuthUC.EXPECT().ValidateToken("invalid_token").Return("", false, errors.New("invalid token"))
+				mockAuthUC.EXPECT().ValidateToken("invalid_token").Return(nil, errors.New("invalid token"))
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:   "valid api key",
+			token:  "token",
+			apiKey: "rk_key",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().AuthenticateAPIKey("rk_key").Return(&models.Identity{UserUID: "12345", Permissions: []string{"products:write"}}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "invalid api key",
+			apiKey: "rk_revoked",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().AuthenticateAPIKey("rk_revoked").Return(nil, auth.ErrInvalidAPIKey)
 			},
 			wantStatus: http.StatusUnauthorized,
 		},
@@ -252,6 +292,9 @@ func TestAuthHandlers_TokenValidation(t *testing.T) {
 				Name:  "token",
 				Value: tt.token,
 			})
+			if tt.apiKey != "" {
+				req.Header.Set("X-API-Key", tt.apiKey)
+			}
 			rr := httptest.NewRecorder()
 
 			authHandler.TokenValidation().ServeHTTP(rr, req)
@@ -260,3 +303,1280 @@ func TestAuthHandlers_TokenValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthHandlers_Refresh(t *testing.T) {
+	cfg := &config.Config{
+		Timeout: config.Timeout{
+			Token:        time.Hour,
+			Cookie:       time.Hour,
+			RefreshToken: 24 * time.Hour,
+		},
+		Env: "development",
+	}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthUC := mock_auth.NewMockUseCase(ctrl)
+	authHandler := NewAuthHandlers(cfg, mockAuthUC, logger)
+
+	tests := []struct {
+		name         string
+		refreshToken string
+		mockBehavior func(mockAuthUC *mock_auth.MockUseCase)
+		wantStatus   int
+	}{
+		{
+			name:         "successful refresh",
+			refreshToken: "refresh",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().Refresh("refresh").Return(&models.Tokens{Access: "new_token", Refresh: "new_refresh"}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:         "missing refresh token",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {},
+			wantStatus:   http.StatusUnauthorized,
+		},
+		{
+			name:         "unknown refresh token",
+			refreshToken: "unknown",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().Refresh("unknown").Return(nil, db.ErrRecordNotFound)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:         "reused refresh token",
+			refreshToken: "used",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().Refresh("used").Return(nil, db.ErrTokenReused)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:         "refresh error",
+			refreshToken: "refresh",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().Refresh("refresh").Return(nil, errors.New("error"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockAuthUC)
+
+			req := httptest.NewRequest(http.MethodPost, "/auth/refresh", nil)
+			if tt.refreshToken != "" {
+				req.AddCookie(&http.Cookie{
+					Name:  "refresh_token",
+					Value: tt.refreshToken,
+				})
+			}
+			rr := httptest.NewRecorder()
+
+			authHandler.Refresh().ServeHTTP(rr, req)
+
+			require.Equal(t, tt.wantStatus, rr.Code)
+
+			if tt.wantStatus == http.StatusOK {
+				cookies := rr.Result().Cookies()
+				require.Len(t, cookies, 2)
+			}
+		})
+	}
+}
+
+func TestAuthHandlers_Logout(t *testing.T) {
+	cfg := &config.Config{
+		Timeout: config.Timeout{
+			Token: time.Hour,
+		},
+		Env: "development",
+	}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthUC := mock_auth.NewMockUseCase(ctrl)
+	authHandler := NewAuthHandlers(cfg, mockAuthUC, logger)
+
+	tests := []struct {
+		name         string
+		cookies      []*http.Cookie
+		mockBehavior func(mockAuthUC *mock_auth.MockUseCase)
+		wantStatus   int
+	}{
+		{
+			name: "successful logout",
+			cookies: []*http.Cookie{
+				{Name: "token", Value: "token"},
+				{Name: "refresh_token", Value: "refresh"},
+			},
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().Logout("token", "refresh").Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "logout with revoked session",
+			cookies: []*http.Cookie{
+				{Name: "token", Value: "token"},
+			},
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().Logout("token", "").Return(db.ErrRecordNotFound)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:         "no cookies",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {},
+			wantStatus:   http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockAuthUC)
+
+			req := httptest.NewRequest(http.MethodPost, "/auth/logout", nil)
+			for _, c := range tt.cookies {
+				req.AddCookie(c)
+			}
+			rr := httptest.NewRecorder()
+
+			authHandler.Logout().ServeHTTP(rr, req)
+
+			require.Equal(t, tt.wantStatus, rr.Code)
+
+			if tt.wantStatus == http.StatusOK {
+				for _, c := range rr.Result().Cookies() {
+					require.Equal(t, -1, c.MaxAge)
+				}
+			}
+		})
+	}
+}
+
+func TestAuthHandlers_JWKS(t *testing.T) {
+	cfg := &config.Config{}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthUC := mock_auth.NewMockUseCase(ctrl)
+	authHandler := NewAuthHandlers(cfg, mockAuthUC, logger)
+
+	keys, err := jwks.Generate()
+	require.NoError(t, err)
+	mockAuthUC.EXPECT().JWKS().Return(keys.JWKS())
+
+	req := httptest.NewRequest(http.MethodGet, "/auth/.well-known/jwks.json", nil)
+	rr := httptest.NewRecorder()
+
+	authHandler.JWKS().ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NotEmpty(t, rr.Header().Get("Cache-Control"))
+
+	var body models.JWKSResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&body))
+	require.Len(t, body.Keys, 1)
+	require.Equal(t, keys.Active().ID, body.Keys[0].Kid)
+	require.Equal(t, "OKP", body.Keys[0].Kty)
+}
+
+func TestAuthHandlers_RevokedSessions(t *testing.T) {
+	cfg := &config.Config{}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthUC := mock_auth.NewMockUseCase(ctrl)
+	authHandler := NewAuthHandlers(cfg, mockAuthUC, logger)
+
+	tests := []struct {
+		name         string
+		mockBehavior func(mockAuthUC *mock_auth.MockUseCase)
+		wantStatus   int
+		wantIDs      []string
+	}{
+		{
+			name: "revoked sessions",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().RevokedSessions().Return([]string{"sid1", "sid2"}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantIDs:    []string{"sid1", "sid2"},
+		},
+		{
+			name: "no revoked sessions",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().RevokedSessions().Return([]string{}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantIDs:    []string{},
+		},
+		{
+			name: "repository error",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().RevokedSessions().Return(nil, errors.New("db error"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockAuthUC)
+
+			req := httptest.NewRequest(http.MethodGet, "/auth/sessions/revoked", nil)
+			rr := httptest.NewRecorder()
+
+			authHandler.RevokedSessions().ServeHTTP(rr, req)
+
+			require.Equal(t, tt.wantStatus, rr.Code)
+
+			if tt.wantStatus == http.StatusOK {
+				var body models.RevokedSessionsResponse
+				require.NoError(t, json.NewDecoder(rr.Body).Decode(&body))
+				require.Equal(t, tt.wantIDs, body.SessionIDs)
+			}
+		})
+	}
+}
+
+func TestAuthHandlers_ServiceToken(t *testing.T) {
+	cfg := &config.Config{}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthUC := mock_auth.NewMockUseCase(ctrl)
+	authHandler := NewAuthHandlers(cfg, mockAuthUC, logger)
+
+	tests := []struct {
+		name         string
+		body         string
+		mockBehavior func(mockAuthUC *mock_auth.MockUseCase)
+		wantStatus   int
+	}{
+		{
+			name: "success",
+			body: `{"client_id":"products","client_secret":"secret","audience":"auth"}`,
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().IssueServiceToken("products", "secret", "auth").Return("token", time.Now().Add(time.Minute), nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "invalid client",
+			body: `{"client_id":"products","client_secret":"wrong","audience":"auth"}`,
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().IssueServiceToken("products", "wrong", "auth").Return("", time.Time{}, auth.ErrInvalidClient)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "unknown audience",
+			body: `{"client_id":"products","client_secret":"secret","audience":"billing"}`,
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().IssueServiceToken("products", "secret", "billing").Return("", time.Time{}, auth.ErrInvalidAudience)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid body",
+			body:         `{"client_id":`,
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {},
+			wantStatus:   http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockAuthUC)
+
+			req := httptest.NewRequest(http.MethodPost, "/auth/service-token", strings.NewReader(tt.body))
+			rr := httptest.NewRecorder()
+
+			authHandler.ServiceToken().ServeHTTP(rr, req)
+
+			require.Equal(t, tt.wantStatus, rr.Code)
+
+			if tt.wantStatus == http.StatusOK {
+				var body models.ServiceTokenResponse
+				require.NoError(t, json.NewDecoder(rr.Body).Decode(&body))
+				require.Equal(t, "token", body.Token)
+			}
+		})
+	}
+}
+
+func TestAuthHandlers_AssignRole(t *testing.T) {
+	cfg := &config.Config{}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthUC := mock_auth.NewMockUseCase(ctrl)
+	authHandler := NewAuthHandlers(cfg, mockAuthUC, logger)
+
+	tests := []struct {
+		name         string
+		id           string
+		role         string
+		mockBehavior func(mockAuthUC *mock_auth.MockUseCase)
+		wantStatus   int
+	}{
+		{
+			name: "role assigned",
+			id:   "12345",
+			role: "catalog-editor",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().AssignRole("12345", "catalog-editor").Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "unknown user or role",
+			id:   "12345",
+			role: "unknown",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().AssignRole("12345", "unknown").Return(db.ErrRecordNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:         "invalid id",
+			id:           "abc",
+			role:         "catalog-editor",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {},
+			wantStatus:   http.StatusNotFound,
+		},
+		{
+			name: "assign error",
+			id:   "12345",
+			role: "catalog-editor",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().AssignRole("12345", "catalog-editor").Return(errors.New("error"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockAuthUC)
+
+			req := httptest.NewRequest(http.MethodPut, "/auth/users/"+tt.id+"/roles/"+tt.role, nil)
+			ctx := context.WithValue(req.Context(), httprouter.ParamsKey, httprouter.Params{
+				{Key: "id", Value: tt.id},
+				{Key: "role", Value: tt.role},
+			})
+			req = middleware.ContextSetIdentity(req.WithContext(ctx), &models.Identity{UserUID: "1"})
+			rr := httptest.NewRecorder()
+
+			authHandler.AssignRole().ServeHTTP(rr, req)
+
+			require.Equal(t, tt.wantStatus, rr.Code)
+		})
+	}
+}
+
+func TestAuthHandlers_RemoveRole(t *testing.T) {
+	cfg := &config.Config{}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthUC := mock_auth.NewMockUseCase(ctrl)
+	authHandler := NewAuthHandlers(cfg, mockAuthUC, logger)
+
+	tests := []struct {
+		name         string
+		mockBehavior func(mockAuthUC *mock_auth.MockUseCase)
+		wantStatus   int
+	}{
+		{
+			name: "role removed",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().RemoveRole("12345", "analyst").Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "role not assigned",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().RemoveRole("12345", "analyst").Return(db.ErrRecordNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockAuthUC)
+
+			req := httptest.NewRequest(http.MethodDelete, "/auth/users/12345/roles/analyst", nil)
+			ctx := context.WithValue(req.Context(), httprouter.ParamsKey, httprouter.Params{
+				{Key: "id", Value: "12345"},
+				{Key: "role", Value: "analyst"},
+			})
+			req = middleware.ContextSetIdentity(req.WithContext(ctx), &models.Identity{UserUID: "1"})
+			rr := httptest.NewRecorder()
+
+			authHandler.RemoveRole().ServeHTTP(rr, req)
+
+			require.Equal(t, tt.wantStatus, rr.Code)
+		})
+	}
+}
+
+func TestAuthHandlers_Me(t *testing.T) {
+	cfg := &config.Config{}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthUC := mock_auth.NewMockUseCase(ctrl)
+	authHandler := NewAuthHandlers(cfg, mockAuthUC, logger)
+
+	mockAuthUC.EXPECT().Me("12345").Return(&models.User{
+		ID:    "12345",
+		Email: "test@test.com",
+		Roles: []string{"support"},
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/auth/me", nil)
+	req = middleware.ContextSetIdentity(req, &models.Identity{UserUID: "12345"})
+	rr := httptest.NewRecorder()
+
+	authHandler.Me().ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var body models.AccountResponse
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&body))
+	require.Equal(t, "12345", body.UserUID)
+	require.Equal(t, "test@test.com", body.Email)
+	require.Equal(t, []string{"support"}, body.Roles)
+}
+
+func TestAuthHandlers_GetUser(t *testing.T) {
+	cfg := &config.Config{}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthUC := mock_auth.NewMockUseCase(ctrl)
+	authHandler := NewAuthHandlers(cfg, mockAuthUC, logger)
+
+	tests := []struct {
+		name         string
+		id           string
+		mockBehavior func(mockAuthUC *mock_auth.MockUseCase)
+		wantStatus   int
+	}{
+		{
+			name: "user found",
+			id:   "12345",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().GetUser("12345").Return(&models.User{
+					ID:    "12345",
+					Email: "test@test.com",
+					Roles: []string{"analyst"},
+				}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "unknown user",
+			id:   "67890",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().GetUser("67890").Return(nil, db.ErrRecordNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:         "invalid id",
+			id:           "abc",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {},
+			wantStatus:   http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockAuthUC)
+
+			req := httptest.NewRequest(http.MethodGet, "/auth/users/"+tt.id, nil)
+			ctx := context.WithValue(req.Context(), httprouter.ParamsKey, httprouter.Params{
+				{Key: "id", Value: tt.id},
+			})
+			req = middleware.ContextSetIdentity(req.WithContext(ctx), &models.Identity{UserUID: "1"})
+			rr := httptest.NewRecorder()
+
+			authHandler.GetUser().ServeHTTP(rr, req)
+
+			require.Equal(t, tt.wantStatus, rr.Code)
+			if tt.wantStatus == http.StatusOK {
+				var body models.AccountResponse
+				require.NoError(t, json.NewDecoder(rr.Body).Decode(&body))
+				require.Equal(t, "12345", body.UserUID)
+				require.Equal(t, []string{"analyst"}, body.Roles)
+			}
+		})
+	}
+}
+
+func TestAuthHandlers_ChangePassword(t *testing.T) {
+	cfg := &config.Config{}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthUC := mock_auth.NewMockUseCase(ctrl)
+	authHandler := NewAuthHandlers(cfg, mockAuthUC, logger)
+
+	tests := []struct {
+		name         string
+		body         models.ChangePasswordDTO
+		mockBehavior func(mockAuthUC *mock_auth.MockUseCase)
+		wantStatus   int
+	}{
+		{
+			name: "password changed",
+			body: models.ChangePasswordDTO{CurrentPassword: "old", NewPassword: "new"},
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().ChangePassword("12345", "sid", "old", "new").Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "wrong current password",
+			body: models.ChangePasswordDTO{CurrentPassword: "wrong", NewPassword: "new"},
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().ChangePassword("12345", "sid", "wrong", "new").Return(auth.ErrInvalidPassword)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:         "empty new password",
+			body:         models.ChangePasswordDTO{CurrentPassword: "old"},
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {},
+			wantStatus:   http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockAuthUC)
+
+			body, err := json.Marshal(tt.body)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPut, "/auth/password", bytes.NewReader(body))
+			req = middleware.ContextSetIdentity(req, &models.Identity{UserUID: "12345", SessionID: "sid"})
+			rr := httptest.NewRecorder()
+
+			authHandler.ChangePassword().ServeHTTP(rr, req)
+
+			require.Equal(t, tt.wantStatus, rr.Code)
+		})
+	}
+}
+
+func TestAuthHandlers_ChangeEmail(t *testing.T) {
+	cfg := &config.Config{}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthUC := mock_auth.NewMockUseCase(ctrl)
+	authHandler := NewAuthHandlers(cfg, mockAuthUC, logger)
+
+	tests := []struct {
+		name         string
+		body         models.ChangeEmailDTO
+		mockBehavior func(mockAuthUC *mock_auth.MockUseCase)
+		wantStatus   int
+	}{
+		{
+			name: "confirmation sent",
+			body: models.ChangeEmailDTO{Email: "new@test.com", Password: "pass"},
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().RequestEmailChange("12345", "sid", "pass", "new@test.com").Return(nil)
+			},
+			wantStatus: http.StatusAccepted,
+		},
+		{
+			name: "email taken",
+			body: models.ChangeEmailDTO{Email: "taken@test.com", Password: "pass"},
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().RequestEmailChange("12345", "sid", "pass", "taken@test.com").Return(db.ErrDuplicateEmail)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "wrong password",
+			body: models.ChangeEmailDTO{Email: "new@test.com", Password: "wrong"},
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().RequestEmailChange("12345", "sid", "wrong", "new@test.com").Return(auth.ErrInvalidPassword)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockAuthUC)
+
+			body, err := json.Marshal(tt.body)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPut, "/auth/email", bytes.NewReader(body))
+			req = middleware.ContextSetIdentity(req, &models.Identity{UserUID: "12345", SessionID: "sid"})
+			rr := httptest.NewRecorder()
+
+			authHandler.ChangeEmail().ServeHTTP(rr, req)
+
+			require.Equal(t, tt.wantStatus, rr.Code)
+		})
+	}
+}
+
+func TestAuthHandlers_ConfirmEmail(t *testing.T) {
+	cfg := &config.Config{}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthUC := mock_auth.NewMockUseCase(ctrl)
+	authHandler := NewAuthHandlers(cfg, mockAuthUC, logger)
+
+	tests := []struct {
+		name         string
+		token        string
+		mockBehavior func(mockAuthUC *mock_auth.MockUseCase)
+		wantStatus   int
+	}{
+		{
+			name:  "email changed",
+			token: "token",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().ConfirmEmailChange("token").Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:  "invalid token",
+			token: "used",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().ConfirmEmailChange("used").Return(auth.ErrInvalidToken)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockAuthUC)
+
+			body, err := json.Marshal(models.ConfirmEmailDTO{Token: tt.token})
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/auth/email/confirm", bytes.NewReader(body))
+			rr := httptest.NewRecorder()
+
+			authHandler.ConfirmEmail().ServeHTTP(rr, req)
+
+			require.Equal(t, tt.wantStatus, rr.Code)
+		})
+	}
+}
+
+func TestAuthHandlers_DeleteAccount(t *testing.T) {
+	cfg := &config.Config{}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthUC := mock_auth.NewMockUseCase(ctrl)
+	authHandler := NewAuthHandlers(cfg, mockAuthUC, logger)
+
+	tests := []struct {
+		name         string
+		password     string
+		mockBehavior func(mockAuthUC *mock_auth.MockUseCase)
+		wantStatus   int
+	}{
+		{
+			name:     "account deleted",
+			password: "pass",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().DeleteAccount("12345", "sid", "pass").Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:     "wrong password",
+			password: "wrong",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().DeleteAccount("12345", "sid", "wrong").Return(auth.ErrInvalidPassword)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name: "sign-in required",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().DeleteAccount("12345", "sid", "").Return(auth.ErrReauthRequired)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockAuthUC)
+
+			body, err := json.Marshal(models.DeleteAccountDTO{Password: tt.password})
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodDelete, "/auth/account", bytes.NewReader(body))
+			req = middleware.ContextSetIdentity(req, &models.Identity{UserUID: "12345", SessionID: "sid"})
+			rr := httptest.NewRecorder()
+
+			authHandler.DeleteAccount().ServeHTTP(rr, req)
+
+			require.Equal(t, tt.wantStatus, rr.Code)
+
+			if tt.wantStatus == http.StatusOK {
+				for _, c := range rr.Result().Cookies() {
+					require.Equal(t, -1, c.MaxAge)
+				}
+			}
+		})
+	}
+}
+
+func TestAuthHandlers_ForgotPassword(t *testing.T) {
+	cfg := &config.Config{}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthUC := mock_auth.NewMockUseCase(ctrl)
+	authHandler := NewAuthHandlers(cfg, mockAuthUC, logger)
+
+	tests := []struct {
+		name         string
+		email        string
+		mockBehavior func(mockAuthUC *mock_auth.MockUseCase)
+		wantStatus   int
+	}{
+		{
+			name:  "reset link sent",
+			email: "test@test.com",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().ForgotPassword("test@test.com").Return(nil)
+			},
+			wantStatus: http.StatusAccepted,
+		},
+		{
+			name:         "empty email",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {},
+			wantStatus:   http.StatusBadRequest,
+		},
+		{
+			name:  "usecase error",
+			email: "test@test.com",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().ForgotPassword("test@test.com").Return(errors.New("db error"))
+			},
+			wantStatus: http.StatusAccepted,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockAuthUC)
+
+			body, err := json.Marshal(models.ForgotPasswordDTO{Email: tt.email})
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/auth/password/forgot", bytes.NewReader(body))
+			rr := httptest.NewRecorder()
+
+			authHandler.ForgotPassword().ServeHTTP(rr, req)
+
+			require.Equal(t, tt.wantStatus, rr.Code)
+		})
+	}
+}
+
+func TestAuthHandlers_ResetPassword(t *testing.T) {
+	cfg := &config.Config{}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthUC := mock_auth.NewMockUseCase(ctrl)
+	authHandler := NewAuthHandlers(cfg, mockAuthUC, logger)
+
+	tests := []struct {
+		name         string
+		body         models.ResetPasswordDTO
+		mockBehavior func(mockAuthUC *mock_auth.MockUseCase)
+		wantStatus   int
+	}{
+		{
+			name: "password reset",
+			body: models.ResetPasswordDTO{Token: "token", NewPassword: "new"},
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().ResetPassword("token", "new").Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "invalid token",
+			body: models.ResetPasswordDTO{Token: "used", NewPassword: "new"},
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().ResetPassword("used", "new").Return(auth.ErrInvalidToken)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:         "empty new password",
+			body:         models.ResetPasswordDTO{Token: "token"},
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {},
+			wantStatus:   http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockAuthUC)
+
+			body, err := json.Marshal(tt.body)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/auth/password/reset", bytes.NewReader(body))
+			rr := httptest.NewRecorder()
+
+			authHandler.ResetPassword().ServeHTTP(rr, req)
+
+			require.Equal(t, tt.wantStatus, rr.Code)
+		})
+	}
+}
+
+func TestAuthHandlers_VerifyEmail(t *testing.T) {
+	cfg := &config.Config{}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthUC := mock_auth.NewMockUseCase(ctrl)
+	authHandler := NewAuthHandlers(cfg, mockAuthUC, logger)
+
+	tests := []struct {
+		name         string
+		token        string
+		mockBehavior func(mockAuthUC *mock_auth.MockUseCase)
+		wantStatus   int
+	}{
+		{
+			name:  "email verified",
+			token: "token",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().VerifyEmail("token").Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:  "invalid token",
+			token: "expired",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().VerifyEmail("expired").Return(auth.ErrInvalidToken)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockAuthUC)
+
+			body, err := json.Marshal(models.VerifyEmailDTO{Token: tt.token})
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/auth/email/verify", bytes.NewReader(body))
+			rr := httptest.NewRecorder()
+
+			authHandler.VerifyEmail().ServeHTTP(rr, req)
+
+			require.Equal(t, tt.wantStatus, rr.Code)
+		})
+	}
+}
+
+func TestAuthHandlers_CreateAPIKey(t *testing.T) {
+	cfg := &config.Config{}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthUC := mock_auth.NewMockUseCase(ctrl)
+	authHandler := NewAuthHandlers(cfg, mockAuthUC, logger)
+
+	tests := []struct {
+		name         string
+		body         models.CreateAPIKeyDTO
+		mockBehavior func(mockAuthUC *mock_auth.MockUseCase)
+		wantStatus   int
+	}{
+		{
+			name: "key created",
+			body: models.CreateAPIKeyDTO{Name: "storefront", Scopes: []string{"products:write"}, ExpiresInDays: 30},
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().CreateAPIKey("12345", "storefront", []string{"products:write"}, 30*24*time.Hour).
+					Return(&models.APIKey{ID: "1", Prefix: "rk_0a1b2c3d"}, "rk_0a1b2c3d_secret", nil)
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name: "scope not granted",
+			body: models.CreateAPIKeyDTO{Name: "storefront", Scopes: []string{"roles:manage"}},
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().CreateAPIKey("12345", "storefront", []string{"roles:manage"}, time.Duration(0)).
+					Return(nil, "", auth.ErrInvalidScope)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:         "empty name",
+			body:         models.CreateAPIKeyDTO{Scopes: []string{"products:write"}},
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {},
+			wantStatus:   http.StatusBadRequest,
+		},
+		{
+			name:         "no scopes",
+			body:         models.CreateAPIKeyDTO{Name: "storefront"},
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {},
+			wantStatus:   http.StatusBadRequest,
+		},
+		{
+			name:         "negative lifetime",
+			body:         models.CreateAPIKeyDTO{Name: "storefront", Scopes: []string{"products:write"}, ExpiresInDays: -1},
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {},
+			wantStatus:   http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockAuthUC)
+
+			body, err := json.Marshal(tt.body)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/auth/api-keys", bytes.NewReader(body))
+			req = middleware.ContextSetIdentity(req, &models.Identity{UserUID: "12345"})
+			rr := httptest.NewRecorder()
+
+			authHandler.CreateAPIKey().ServeHTTP(rr, req)
+
+			require.Equal(t, tt.wantStatus, rr.Code)
+			if tt.wantStatus == http.StatusCreated {
+				require.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+				require.Contains(t, rr.Body.String(), "rk_0a1b2c3d_secret")
+			}
+		})
+	}
+}
+
+func TestAuthHandlers_RevokeAPIKey(t *testing.T) {
+	cfg := &config.Config{}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthUC := mock_auth.NewMockUseCase(ctrl)
+	authHandler := NewAuthHandlers(cfg, mockAuthUC, logger)
+
+	tests := []struct {
+		name         string
+		id           string
+		mockBehavior func(mockAuthUC *mock_auth.MockUseCase)
+		wantStatus   int
+	}{
+		{
+			name: "key revoked",
+			id:   "1",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().RevokeAPIKey("12345", "1").Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "key of another user or already revoked",
+			id:   "2",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().RevokeAPIKey("12345", "2").Return(db.ErrRecordNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockAuthUC)
+
+			req := httptest.NewRequest(http.MethodDelete, "/auth/api-keys/"+tt.id, nil)
+			req = req.WithContext(context.WithValue(req.Context(), httprouter.ParamsKey, httprouter.Params{{Key: "id", Value: tt.id}}))
+			req = middleware.ContextSetIdentity(req, &models.Identity{UserUID: "12345"})
+			rr := httptest.NewRecorder()
+
+			authHandler.RevokeAPIKey().ServeHTTP(rr, req)
+
+			require.Equal(t, tt.wantStatus, rr.Code)
+		})
+	}
+}
+
+func TestAuthHandlers_OIDCLogin(t *testing.T) {
+	cfg := &config.Config{
+		Timeout: config.Timeout{
+			OIDCState: 10 * time.Minute,
+		},
+	}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthUC := mock_auth.NewMockUseCase(ctrl)
+	authHandler := NewAuthHandlers(cfg, mockAuthUC, logger)
+
+	tests := []struct {
+		name         string
+		provider     string
+		mockBehavior func(mockAuthUC *mock_auth.MockUseCase)
+		wantStatus   int
+	}{
+		{
+			name:     "redirect to provider",
+			provider: "test",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().StartOIDCLogin("test").Return("https://idp.example.com/authorize?state=state", "state", nil)
+			},
+			wantStatus: http.StatusFound,
+		},
+		{
+			name:     "unknown provider",
+			provider: "other",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().StartOIDCLogin("other").Return("", "", auth.ErrUnknownProvider)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockAuthUC)
+
+			req := httptest.NewRequest(http.MethodGet, "/auth/oidc/"+tt.provider+"/login", nil)
+			req = req.WithContext(context.WithValue(req.Context(), httprouter.ParamsKey, httprouter.Params{{Key: "provider", Value: tt.provider}}))
+			rr := httptest.NewRecorder()
+
+			authHandler.OIDCLogin().ServeHTTP(rr, req)
+
+			require.Equal(t, tt.wantStatus, rr.Code)
+			if tt.wantStatus == http.StatusFound {
+				require.Equal(t, "https://idp.example.com/authorize?state=state", rr.Header().Get("Location"))
+
+				cookies := rr.Result().Cookies()
+				require.Len(t, cookies, 1)
+				require.Equal(t, "oidc_state", cookies[0].Name)
+				require.Equal(t, "state", cookies[0].Value)
+				require.Equal(t, http.SameSiteLaxMode, cookies[0].SameSite)
+			}
+		})
+	}
+}
+
+func TestAuthHandlers_OIDCCallback(t *testing.T) {
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthUC := mock_auth.NewMockUseCase(ctrl)
+
+	tokens := &models.Tokens{Access: "access", Refresh: "refresh", RefreshExpiresAt: time.Now().Add(time.Hour)}
+
+	tests := []struct {
+		name         string
+		query        string
+		stateCookie  string
+		postLoginURL string
+		mockBehavior func(mockAuthUC *mock_auth.MockUseCase)
+		wantStatus   int
+		wantCookies  bool
+	}{
+		{
+			name:        "successful login",
+			query:       "?code=code&state=state",
+			stateCookie: "state",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().CompleteOIDCLogin("test", "state", "code").Return(tokens, nil)
+			},
+			wantStatus:  http.StatusOK,
+			wantCookies: true,
+		},
+		{
+			name:         "redirect after login",
+			query:        "?code=code&state=state",
+			stateCookie:  "state",
+			postLoginURL: "http://localhost/",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().CompleteOIDCLogin("test", "state", "code").Return(tokens, nil)
+			},
+			wantStatus:  http.StatusSeeOther,
+			wantCookies: true,
+		},
+		{
+			name:         "state doesn't match the cookie",
+			query:        "?code=code&state=state",
+			stateCookie:  "other",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {},
+			wantStatus:   http.StatusUnauthorized,
+		},
+		{
+			name:         "login denied at the provider",
+			query:        "?error=access_denied&state=state",
+			stateCookie:  "state",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {},
+			wantStatus:   http.StatusUnauthorized,
+		},
+		{
+			name:         "missing code",
+			query:        "?state=state",
+			stateCookie:  "state",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {},
+			wantStatus:   http.StatusBadRequest,
+		},
+		{
+			name:        "unverified account with the same email",
+			query:       "?code=code&state=state",
+			stateCookie: "state",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().CompleteOIDCLogin("test", "state", "code").Return(nil, auth.ErrAccountConflict)
+			},
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:        "failed code exchange",
+			query:       "?code=code&state=state",
+			stateCookie: "state",
+			mockBehavior: func(mockAuthUC *mock_auth.MockUseCase) {
+				mockAuthUC.EXPECT().CompleteOIDCLogin("test", "state", "code").Return(nil, auth.ErrExternalLogin)
+			},
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockAuthUC)
+
+			cfg := &config.Config{
+				OIDC:    config.OIDC{PostLoginURL: tt.postLoginURL},
+				Timeout: config.Timeout{Cookie: time.Hour},
+			}
+			authHandler := NewAuthHandlers(cfg, mockAuthUC, logger)
+
+			req := httptest.NewRequest(http.MethodGet, "/auth/oidc/test/callback"+tt.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), httprouter.ParamsKey, httprouter.Params{{Key: "provider", Value: "test"}}))
+			req.AddCookie(&http.Cookie{Name: "oidc_state", Value: tt.stateCookie})
+			rr := httptest.NewRecorder()
+
+			authHandler.OIDCCallback().ServeHTTP(rr, req)
+
+			require.Equal(t, tt.wantStatus, rr.Code)
+
+			cookies := make(map[string]string)
+			for _, cookie := range rr.Result().Cookies() {
+				cookies[cookie.Name] = cookie.Value
+			}
+			require.Contains(t, cookies, "oidc_state")
+			require.Empty(t, cookies["oidc_state"])
+			if tt.wantCookies {
+				require.Equal(t, "access", cookies["token"])
+				require.Equal(t, "refresh", cookies["refresh_token"])
+			} else {
+				require.NotContains(t, cookies, "token")
+			}
+		})
+	}
+}
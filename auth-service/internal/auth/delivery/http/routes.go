@@ -6,11 +6,43 @@ import (
 	"github.com/julienschmidt/httprouter"
 
 	"cyansnbrst/auth-service/internal/auth"
+	"cyansnbrst/auth-service/internal/middleware"
 )
 
 // Register auth routes
-func RegisterAuthRoutes(router *httprouter.Router, h auth.Handlers) {
-	router.HandlerFunc(http.MethodPost, "/auth/login", h.Login())
-	router.HandlerFunc(http.MethodPost, "/auth/register", h.Register())
-	router.HandlerFunc(http.MethodGet, "/auth/authenticate", h.TokenValidation())
+func RegisterAuthRoutes(router *httprouter.Router, h auth.Handlers, mw *middleware.MiddlewareManager) {
+	internal := mw.RequireService("products", "profiles", "recommendations", "analytics")
+
+	router.HandlerFunc(http.MethodPost, "/auth/login", mw.RateLimit("login")(h.Login()))
+	router.HandlerFunc(http.MethodPost, "/auth/register", mw.RateLimit("register")(h.Register()))
+	router.HandlerFunc(http.MethodGet, "/auth/authenticate", internal(h.TokenValidation()))
+	router.HandlerFunc(http.MethodGet, "/auth/sessions/revoked", internal(h.RevokedSessions()))
+	router.HandlerFunc(http.MethodPost, "/auth/service-token", mw.RateLimit("service-token")(h.ServiceToken()))
+	router.HandlerFunc(http.MethodPost, "/auth/refresh", h.Refresh())
+	router.HandlerFunc(http.MethodPost, "/auth/logout", h.Logout())
+	router.HandlerFunc(http.MethodGet, "/auth/.well-known/jwks.json", h.JWKS())
+	router.HandlerFunc(http.MethodGet, "/auth/oidc/:provider/login", mw.RateLimit("oidc")(h.OIDCLogin()))
+	router.HandlerFunc(http.MethodGet, "/auth/oidc/:provider/callback", mw.RateLimit("oidc")(h.OIDCCallback()))
+
+	router.HandlerFunc(http.MethodGet, "/auth/me", mw.RequireAuthenticatedUser(h.Me()))
+	router.HandlerFunc(http.MethodPut, "/auth/password", mw.RequireAuthenticatedUser(h.ChangePassword()))
+	router.HandlerFunc(http.MethodPut, "/auth/email", mw.RequireAuthenticatedUser(h.ChangeEmail()))
+	router.HandlerFunc(http.MethodPost, "/auth/email/confirm", h.ConfirmEmail())
+	router.HandlerFunc(http.MethodDelete, "/auth/account", mw.RequireAuthenticatedUser(h.DeleteAccount()))
+	router.HandlerFunc(http.MethodPost, "/auth/email/verify", h.VerifyEmail())
+	router.HandlerFunc(http.MethodPost, "/auth/email/verify/resend", mw.RequireAuthenticatedUser(h.ResendVerification()))
+	router.HandlerFunc(http.MethodPost, "/auth/password/forgot", mw.RateLimit("forgot")(h.ForgotPassword()))
+	router.HandlerFunc(http.MethodPost, "/auth/password/reset", h.ResetPassword())
+
+	router.HandlerFunc(http.MethodPost, "/auth/api-keys", mw.RequireAuthenticatedUser(h.CreateAPIKey()))
+	router.HandlerFunc(http.MethodGet, "/auth/api-keys", mw.RequireAuthenticatedUser(h.ListAPIKeys()))
+	router.HandlerFunc(http.MethodDelete, "/auth/api-keys/:id", mw.RequireAuthenticatedUser(h.RevokeAPIKey()))
+
+	router.HandlerFunc(http.MethodGet, "/auth/users/:id", mw.RequirePermission("users:read")(h.GetUser()))
+
+	manageRoles := mw.RequirePermission("roles:manage")
+	router.HandlerFunc(http.MethodGet, "/auth/roles", manageRoles(h.ListRoles()))
+	router.HandlerFunc(http.MethodGet, "/auth/users/:id/roles", manageRoles(h.UserRoles()))
+	router.HandlerFunc(http.MethodPut, "/auth/users/:id/roles/:role", manageRoles(h.AssignRole()))
+	router.HandlerFunc(http.MethodDelete, "/auth/users/:id/roles/:role", manageRoles(h.RemoveRole()))
 }
@@ -0,0 +1,59 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+
+	"cyansnbrst/auth-service/config"
+	"cyansnbrst/auth-service/internal/auth"
+)
+
+// Outbox relay struct, publishes stored events to kafka
+type Relay struct {
+	cfg         *config.Config
+	authUC      auth.UseCase
+	kafkaWriter *kafka.Writer
+	logger      *zap.Logger
+}
+
+// Outbox relay constructor
+func NewRelay(cfg *config.Config, authUC auth.UseCase, kafkaWriter *kafka.Writer, logger *zap.Logger) *Relay {
+	return &Relay{cfg: cfg, authUC: authUC, kafkaWriter: kafkaWriter, logger: logger}
+}
+
+// Run relay until the context is cancelled
+func (r *Relay) Run(ctx context.Context) {
+	r.logger.Info("starting outbox relay")
+
+	ticker := time.NewTicker(r.cfg.Outbox.Interval)
+	defer ticker.Stop()
+
+	for {
+		r.publishPending()
+
+		select {
+		case <-ctx.Done():
+			r.logger.Info("outbox relay stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Publish batches until the outbox is drained or publishing fails
+func (r *Relay) publishPending() {
+	for {
+		published, err := r.authUC.PublishOutbox(r.kafkaWriter)
+		if err != nil {
+			r.logger.Warn("failed to publish outbox events", zap.Error(err))
+			return
+		}
+
+		if published < r.cfg.Outbox.BatchSize {
+			return
+		}
+	}
+}
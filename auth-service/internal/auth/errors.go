@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"errors"
+	"time"
+)
+
+// Auth usecase errors
+var (
+	ErrInvalidPassword    = errors.New("invalid password")
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrInvalidToken       = errors.New("invalid or expired token")
+	ErrEmailVerified      = errors.New("email is already verified")
+	ErrInvalidClient      = errors.New("invalid client credentials")
+	ErrInvalidAudience    = errors.New("unknown audience")
+	ErrInvalidScope       = errors.New("scope is not granted to the user")
+	ErrInvalidAPIKey      = errors.New("invalid, expired or revoked api key")
+	ErrUnknownProvider    = errors.New("unknown identity provider")
+	ErrExternalLogin      = errors.New("login with the identity provider failed")
+	ErrEmailNotVerified   = errors.New("identity provider did not confirm a verified email")
+	ErrAccountConflict    = errors.New("an unverified account with this email exists, verify it to link the identity provider")
+	ErrReauthRequired     = errors.New("the account has no password, sign in again to confirm the action")
+)
+
+// Too many attempts error, the caller may retry after RetryAfter
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return "too many attempts, retry after " + e.RetryAfter.String()
+}
@@ -6,10 +6,12 @@ package mock_auth
 
 import (
 	models "cyansnbrst/auth-service/internal/models"
-	http "net/http"
+	jwks "cyansnbrst/auth-service/pkg/jwks"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
+	kafka "github.com/segmentio/kafka-go"
 )
 
 // MockUseCase is a mock of UseCase interface.
@@ -35,49 +37,430 @@ func (m *MockUseCase) EXPECT() *MockUseCaseMockRecorder {
 	return m.recorder
 }
 
+// AssignRole mocks base method.
+func (m *MockUseCase) AssignRole(userID, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AssignRole", userID, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AssignRole indicates an expected call of AssignRole.
+func (mr *MockUseCaseMockRecorder) AssignRole(userID, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignRole", reflect.TypeOf((*MockUseCase)(nil).AssignRole), userID, role)
+}
+
+// AuthenticateAPIKey mocks base method.
+func (m *MockUseCase) AuthenticateAPIKey(key string) (*models.Identity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AuthenticateAPIKey", key)
+	ret0, _ := ret[0].(*models.Identity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AuthenticateAPIKey indicates an expected call of AuthenticateAPIKey.
+func (mr *MockUseCaseMockRecorder) AuthenticateAPIKey(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AuthenticateAPIKey", reflect.TypeOf((*MockUseCase)(nil).AuthenticateAPIKey), key)
+}
+
+// ChangePassword mocks base method.
+func (m *MockUseCase) ChangePassword(userID, sessionID, currentPassword, newPassword string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", userID, sessionID, currentPassword, newPassword)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockUseCaseMockRecorder) ChangePassword(userID, sessionID, currentPassword, newPassword interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockUseCase)(nil).ChangePassword), userID, sessionID, currentPassword, newPassword)
+}
+
+// CompleteOIDCLogin mocks base method.
+func (m *MockUseCase) CompleteOIDCLogin(provider, state, code string) (*models.Tokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteOIDCLogin", provider, state, code)
+	ret0, _ := ret[0].(*models.Tokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompleteOIDCLogin indicates an expected call of CompleteOIDCLogin.
+func (mr *MockUseCaseMockRecorder) CompleteOIDCLogin(provider, state, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteOIDCLogin", reflect.TypeOf((*MockUseCase)(nil).CompleteOIDCLogin), provider, state, code)
+}
+
+// ConfirmEmailChange mocks base method.
+func (m *MockUseCase) ConfirmEmailChange(token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfirmEmailChange", token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ConfirmEmailChange indicates an expected call of ConfirmEmailChange.
+func (mr *MockUseCaseMockRecorder) ConfirmEmailChange(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfirmEmailChange", reflect.TypeOf((*MockUseCase)(nil).ConfirmEmailChange), token)
+}
+
 // Create mocks base method.
-func (m *MockUseCase) Create(email, password string) (string, string, error) {
+func (m *MockUseCase) Create(email, password, name string) (*models.Tokens, string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", email, password)
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "Create", email, password, name)
+	ret0, _ := ret[0].(*models.Tokens)
 	ret1, _ := ret[1].(string)
 	ret2, _ := ret[2].(error)
 	return ret0, ret1, ret2
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockUseCaseMockRecorder) Create(email, password interface{}) *gomock.Call {
+func (mr *MockUseCaseMockRecorder) Create(email, password, name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUseCase)(nil).Create), email, password, name)
+}
+
+// CreateAPIKey mocks base method.
+func (m *MockUseCase) CreateAPIKey(userID, name string, scopes []string, ttl time.Duration) (*models.APIKey, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateAPIKey", userID, name, scopes, ttl)
+	ret0, _ := ret[0].(*models.APIKey)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// CreateAPIKey indicates an expected call of CreateAPIKey.
+func (mr *MockUseCaseMockRecorder) CreateAPIKey(userID, name, scopes, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAPIKey", reflect.TypeOf((*MockUseCase)(nil).CreateAPIKey), userID, name, scopes, ttl)
+}
+
+// CreateSession mocks base method.
+func (m *MockUseCase) CreateSession(user models.User) (*models.Tokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateSession", user)
+	ret0, _ := ret[0].(*models.Tokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateSession indicates an expected call of CreateSession.
+func (mr *MockUseCaseMockRecorder) CreateSession(user interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateSession", reflect.TypeOf((*MockUseCase)(nil).CreateSession), user)
+}
+
+// DeleteAccount mocks base method.
+func (m *MockUseCase) DeleteAccount(userID, sessionID, password string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAccount", userID, sessionID, password)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAccount indicates an expected call of DeleteAccount.
+func (mr *MockUseCaseMockRecorder) DeleteAccount(userID, sessionID, password interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUseCase)(nil).Create), email, password)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAccount", reflect.TypeOf((*MockUseCase)(nil).DeleteAccount), userID, sessionID, password)
 }
 
-// CreateProfile mocks base method.
-func (m *MockUseCase) CreateProfile(uid, name string, cookie *http.Cookie) error {
+// ForgotPassword mocks base method.
+func (m *MockUseCase) ForgotPassword(email string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateProfile", uid, name, cookie)
+	ret := m.ctrl.Call(m, "ForgotPassword", email)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// CreateProfile indicates an expected call of CreateProfile.
-func (mr *MockUseCaseMockRecorder) CreateProfile(uid, name, cookie interface{}) *gomock.Call {
+// ForgotPassword indicates an expected call of ForgotPassword.
+func (mr *MockUseCaseMockRecorder) ForgotPassword(email interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateProfile", reflect.TypeOf((*MockUseCase)(nil).CreateProfile), uid, name, cookie)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForgotPassword", reflect.TypeOf((*MockUseCase)(nil).ForgotPassword), email)
 }
 
 // GenerateJWT mocks base method.
-func (m *MockUseCase) GenerateJWT(user models.User) (string, error) {
+func (m *MockUseCase) GenerateJWT(user models.User, sessionID string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GenerateJWT", user)
+	ret := m.ctrl.Call(m, "GenerateJWT", user, sessionID)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GenerateJWT indicates an expected call of GenerateJWT.
-func (mr *MockUseCaseMockRecorder) GenerateJWT(user interface{}) *gomock.Call {
+func (mr *MockUseCaseMockRecorder) GenerateJWT(user, sessionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateJWT", reflect.TypeOf((*MockUseCase)(nil).GenerateJWT), user, sessionID)
+}
+
+// GetUser mocks base method.
+func (m *MockUseCase) GetUser(userID string) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", userID)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockUseCaseMockRecorder) GetUser(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockUseCase)(nil).GetUser), userID)
+}
+
+// GetUserRoles mocks base method.
+func (m *MockUseCase) GetUserRoles(userID string) ([]models.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserRoles", userID)
+	ret0, _ := ret[0].([]models.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserRoles indicates an expected call of GetUserRoles.
+func (mr *MockUseCaseMockRecorder) GetUserRoles(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserRoles", reflect.TypeOf((*MockUseCase)(nil).GetUserRoles), userID)
+}
+
+// IssueServiceToken mocks base method.
+func (m *MockUseCase) IssueServiceToken(clientID, clientSecret, audience string) (string, time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IssueServiceToken", clientID, clientSecret, audience)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(time.Time)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// IssueServiceToken indicates an expected call of IssueServiceToken.
+func (mr *MockUseCaseMockRecorder) IssueServiceToken(clientID, clientSecret, audience interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IssueServiceToken", reflect.TypeOf((*MockUseCase)(nil).IssueServiceToken), clientID, clientSecret, audience)
+}
+
+// JWKS mocks base method.
+func (m *MockUseCase) JWKS() jwks.JWKS {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JWKS")
+	ret0, _ := ret[0].(jwks.JWKS)
+	return ret0
+}
+
+// JWKS indicates an expected call of JWKS.
+func (mr *MockUseCaseMockRecorder) JWKS() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JWKS", reflect.TypeOf((*MockUseCase)(nil).JWKS))
+}
+
+// ListAPIKeys mocks base method.
+func (m *MockUseCase) ListAPIKeys(userID string) ([]models.APIKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAPIKeys", userID)
+	ret0, _ := ret[0].([]models.APIKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAPIKeys indicates an expected call of ListAPIKeys.
+func (mr *MockUseCaseMockRecorder) ListAPIKeys(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAPIKeys", reflect.TypeOf((*MockUseCase)(nil).ListAPIKeys), userID)
+}
+
+// ListRoles mocks base method.
+func (m *MockUseCase) ListRoles() ([]models.Role, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListRoles")
+	ret0, _ := ret[0].([]models.Role)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListRoles indicates an expected call of ListRoles.
+func (mr *MockUseCaseMockRecorder) ListRoles() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListRoles", reflect.TypeOf((*MockUseCase)(nil).ListRoles))
+}
+
+// Logout mocks base method.
+func (m *MockUseCase) Logout(accessToken, refreshToken string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Logout", accessToken, refreshToken)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Logout indicates an expected call of Logout.
+func (mr *MockUseCaseMockRecorder) Logout(accessToken, refreshToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Logout", reflect.TypeOf((*MockUseCase)(nil).Logout), accessToken, refreshToken)
+}
+
+// Me mocks base method.
+func (m *MockUseCase) Me(userID string) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Me", userID)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Me indicates an expected call of Me.
+func (mr *MockUseCaseMockRecorder) Me(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Me", reflect.TypeOf((*MockUseCase)(nil).Me), userID)
+}
+
+// PublishOutbox mocks base method.
+func (m *MockUseCase) PublishOutbox(writer *kafka.Writer) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PublishOutbox", writer)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PublishOutbox indicates an expected call of PublishOutbox.
+func (mr *MockUseCaseMockRecorder) PublishOutbox(writer interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PublishOutbox", reflect.TypeOf((*MockUseCase)(nil).PublishOutbox), writer)
+}
+
+// Refresh mocks base method.
+func (m *MockUseCase) Refresh(refreshToken string) (*models.Tokens, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Refresh", refreshToken)
+	ret0, _ := ret[0].(*models.Tokens)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Refresh indicates an expected call of Refresh.
+func (mr *MockUseCaseMockRecorder) Refresh(refreshToken interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refresh", reflect.TypeOf((*MockUseCase)(nil).Refresh), refreshToken)
+}
+
+// RemoveRole mocks base method.
+func (m *MockUseCase) RemoveRole(userID, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveRole", userID, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveRole indicates an expected call of RemoveRole.
+func (mr *MockUseCaseMockRecorder) RemoveRole(userID, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveRole", reflect.TypeOf((*MockUseCase)(nil).RemoveRole), userID, role)
+}
+
+// RequestEmailChange mocks base method.
+func (m *MockUseCase) RequestEmailChange(userID, sessionID, password, newEmail string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RequestEmailChange", userID, sessionID, password, newEmail)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RequestEmailChange indicates an expected call of RequestEmailChange.
+func (mr *MockUseCaseMockRecorder) RequestEmailChange(userID, sessionID, password, newEmail interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestEmailChange", reflect.TypeOf((*MockUseCase)(nil).RequestEmailChange), userID, sessionID, password, newEmail)
+}
+
+// ResetPassword mocks base method.
+func (m *MockUseCase) ResetPassword(token, newPassword string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", token, newPassword)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockUseCaseMockRecorder) ResetPassword(token, newPassword interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockUseCase)(nil).ResetPassword), token, newPassword)
+}
+
+// RevokeAPIKey mocks base method.
+func (m *MockUseCase) RevokeAPIKey(userID, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeAPIKey", userID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeAPIKey indicates an expected call of RevokeAPIKey.
+func (mr *MockUseCaseMockRecorder) RevokeAPIKey(userID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeAPIKey", reflect.TypeOf((*MockUseCase)(nil).RevokeAPIKey), userID, id)
+}
+
+// RevokeUserSessions mocks base method.
+func (m *MockUseCase) RevokeUserSessions(userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeUserSessions", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RevokeUserSessions indicates an expected call of RevokeUserSessions.
+func (mr *MockUseCaseMockRecorder) RevokeUserSessions(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeUserSessions", reflect.TypeOf((*MockUseCase)(nil).RevokeUserSessions), userID)
+}
+
+// RevokedSessions mocks base method.
+func (m *MockUseCase) RevokedSessions() ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokedSessions")
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokedSessions indicates an expected call of RevokedSessions.
+func (mr *MockUseCaseMockRecorder) RevokedSessions() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateJWT", reflect.TypeOf((*MockUseCase)(nil).GenerateJWT), user)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokedSessions", reflect.TypeOf((*MockUseCase)(nil).RevokedSessions))
+}
+
+// SendVerificationEmail mocks base method.
+func (m *MockUseCase) SendVerificationEmail(userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendVerificationEmail", userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendVerificationEmail indicates an expected call of SendVerificationEmail.
+func (mr *MockUseCaseMockRecorder) SendVerificationEmail(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendVerificationEmail", reflect.TypeOf((*MockUseCase)(nil).SendVerificationEmail), userID)
+}
+
+// StartOIDCLogin mocks base method.
+func (m *MockUseCase) StartOIDCLogin(provider string) (string, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartOIDCLogin", provider)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// StartOIDCLogin indicates an expected call of StartOIDCLogin.
+func (mr *MockUseCaseMockRecorder) StartOIDCLogin(provider interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartOIDCLogin", reflect.TypeOf((*MockUseCase)(nil).StartOIDCLogin), provider)
 }
 
 // ValidateCredentials mocks base method.
@@ -95,14 +478,28 @@ func (mr *MockUseCaseMockRecorder) ValidateCredentials(email, password interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateCredentials", reflect.TypeOf((*MockUseCase)(nil).ValidateCredentials), email, password)
 }
 
+// ValidateServiceToken mocks base method.
+func (m *MockUseCase) ValidateServiceToken(tokenString string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateServiceToken", tokenString)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ValidateServiceToken indicates an expected call of ValidateServiceToken.
+func (mr *MockUseCaseMockRecorder) ValidateServiceToken(tokenString interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateServiceToken", reflect.TypeOf((*MockUseCase)(nil).ValidateServiceToken), tokenString)
+}
+
 // ValidateToken mocks base method.
-func (m *MockUseCase) ValidateToken(tokenString string) (string, bool, error) {
+func (m *MockUseCase) ValidateToken(tokenString string) (*models.Identity, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ValidateToken", tokenString)
-	ret0, _ := ret[0].(string)
-	ret1, _ := ret[1].(bool)
-	ret2, _ := ret[2].(error)
-	return ret0, ret1, ret2
+	ret0, _ := ret[0].(*models.Identity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // ValidateToken indicates an expected call of ValidateToken.
@@ -110,3 +507,17 @@ func (mr *MockUseCaseMockRecorder) ValidateToken(tokenString interface{}) *gomoc
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateToken", reflect.TypeOf((*MockUseCase)(nil).ValidateToken), tokenString)
 }
+
+// VerifyEmail mocks base method.
+func (m *MockUseCase) VerifyEmail(token string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "VerifyEmail", token)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// VerifyEmail indicates an expected call of VerifyEmail.
+func (mr *MockUseCaseMockRecorder) VerifyEmail(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyEmail", reflect.TypeOf((*MockUseCase)(nil).VerifyEmail), token)
+}
@@ -1,9 +1,44 @@
 package auth
 
-import "cyansnbrst/auth-service/internal/models"
+import (
+	"context"
+	"time"
+
+	"cyansnbrst/auth-service/internal/models"
+)
 
 // Auth repository interface
 type Repository interface {
-	Insert(user *models.User) (string, error)
+	Insert(user *models.User, event *models.OutboxEvent) (string, error)
 	GetByEmail(email string) (*models.User, error)
+	GetByID(id string) (*models.User, error)
+	UpdatePassword(id, passwordHash string) error
+	UpdateEmail(id, email string) error
+	VerifyEmail(id, email string) error
+	Delete(id string, event *models.OutboxEvent) error
+	CreateSession(session *models.Session, token *models.RefreshToken) error
+	GetSession(id string) (*models.Session, error)
+	RotateRefreshToken(hash []byte, newToken *models.RefreshToken) (*models.Session, error)
+	RevokeSession(id string) error
+	RevokeSessionByRefreshToken(hash []byte) error
+	RevokeUserSessions(userID string) error
+	RevokeOtherSessions(userID, keepSessionID string) error
+	ListRevokedSessions(since time.Time) ([]string, error)
+	CreateVerificationToken(token *models.VerificationToken) error
+	UseVerificationToken(hash []byte, purpose string) (*models.VerificationToken, error)
+	DeleteVerificationTokens(userID, purpose string) error
+	ListRoles() ([]models.Role, error)
+	GetUserRoles(userID string) ([]models.Role, error)
+	AssignRole(userID, role string) error
+	RemoveRole(userID, role string) error
+	CreateAPIKey(key *models.APIKey) error
+	ListAPIKeys(userID string) ([]models.APIKey, error)
+	GetAPIKeyByHash(hash []byte) (*models.APIKey, error)
+	RevokeAPIKey(userID, id string) error
+	TouchAPIKey(id string, usedAt time.Time) error
+	CreateOIDCRequest(req *models.OIDCRequest) error
+	UseOIDCRequest(stateHash []byte, provider string) (*models.OIDCRequest, error)
+	GetByIdentity(provider, subject string) (*models.User, error)
+	LinkIdentity(identity *models.UserIdentity) error
+	ProcessOutbox(limit int, publish func(ctx context.Context, events []models.OutboxEvent) error) (int, error)
 }
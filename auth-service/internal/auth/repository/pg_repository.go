@@ -4,6 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
+
+	"github.com/lib/pq"
 
 	"cyansnbrst/auth-service/config"
 	"cyansnbrst/auth-service/internal/auth"
@@ -11,6 +14,12 @@ import (
 	"cyansnbrst/auth-service/pkg/db"
 )
 
+// PostgreSQL error codes
+const (
+	foreignKeyViolation = "23503"
+	uniqueViolation     = "23505"
+)
+
 // Auth repository
 type authRepo struct {
 	cfg *config.Config
@@ -23,18 +32,24 @@ func NewAuthRepository(cfg *config.Config, db *sql.DB) auth.Repository {
 }
 
 // Insert a new user
-func (r *authRepo) Insert(user *models.User) (string, error) {
-	query := `
-		INSERT INTO users (email, password_hash)
-		VALUES ($1, $2)
-		RETURNING id, created_at`
-
-	args := []interface{}{user.Email, user.PasswordHash}
-
+func (r *authRepo) Insert(user *models.User, event *models.OutboxEvent) (string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
 	defer cancel()
 
-	err := r.db.QueryRowContext(ctx, query, args...).Scan(&user.ID, &user.CreatedAt)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	queryUser := `
+		INSERT INTO users (email, password_hash, email_verified)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at`
+
+	args := []interface{}{user.Email, user.PasswordHash, user.Verified}
+
+	err = tx.QueryRowContext(ctx, queryUser, args...).Scan(&user.ID, &user.CreatedAt)
 	if err != nil {
 		switch {
 		case err.Error() == `pq: duplicate key value violates unique constraint "users_email_key"`:
@@ -44,12 +59,29 @@ func (r *authRepo) Insert(user *models.User) (string, error) {
 		}
 	}
 
+	queryEvent := `
+		INSERT INTO outbox (key, payload)
+		VALUES ($1, $2)
+		RETURNING id, created_at`
+
+	event.Key = user.ID
+
+	err = tx.QueryRowContext(ctx, queryEvent, event.Key, event.Payload).Scan(&event.ID, &event.CreatedAt)
+	if err != nil {
+		return "", err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return "", err
+	}
+
 	return user.ID, nil
 }
 
+// Get user by email
 func (r *authRepo) GetByEmail(email string) (*models.User, error) {
 	query := `
-		SELECT id, created_at, email, is_admin, password_hash
+		SELECT id, created_at, email, email_verified, is_admin, password_hash
 		FROM users
 		WHERE email = $1`
 
@@ -62,6 +94,7 @@ func (r *authRepo) GetByEmail(email string) (*models.User, error) {
 		&user.ID,
 		&user.CreatedAt,
 		&user.Email,
+		&user.Verified,
 		&user.IsAdmin,
 		&user.PasswordHash,
 	)
@@ -77,3 +110,923 @@ func (r *authRepo) GetByEmail(email string) (*models.User, error) {
 
 	return &user, nil
 }
+
+// Get user by ID
+func (r *authRepo) GetByID(id string) (*models.User, error) {
+	query := `
+		SELECT id, created_at, email, email_verified, is_admin, password_hash
+		FROM users
+		WHERE id = $1`
+
+	var user models.User
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&user.ID,
+		&user.CreatedAt,
+		&user.Email,
+		&user.Verified,
+		&user.IsAdmin,
+		&user.PasswordHash,
+	)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, db.ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &user, nil
+}
+
+// Update user's password hash
+func (r *authRepo) UpdatePassword(id, passwordHash string) error {
+	query := `
+		UPDATE users
+		SET password_hash = $1
+		WHERE id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, query, passwordHash, id)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(result)
+}
+
+// Update user's email
+func (r *authRepo) UpdateEmail(id, email string) error {
+	query := `
+		UPDATE users
+		SET email = $1, email_verified = TRUE
+		WHERE id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, query, email, id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return db.ErrDuplicateEmail
+		}
+		return err
+	}
+
+	return checkAffected(result)
+}
+
+// Mark user's email as verified if it was not changed since the token was sent
+func (r *authRepo) VerifyEmail(id, email string) error {
+	query := `
+		UPDATE users
+		SET email_verified = TRUE
+		WHERE id = $1 AND email = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, query, id, email)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(result)
+}
+
+// Delete user together with saving the user_delete event to the outbox,
+// sessions, roles and tokens are removed by cascade. Active sessions are kept in revoked_sessions
+// while their access tokens may still be valid, so services can reject them.
+func (r *authRepo) Delete(id string, event *models.OutboxEvent) error {
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	queryCleanup := `
+		DELETE FROM revoked_sessions
+		WHERE revoked_at < $1`
+
+	_, err = tx.ExecContext(ctx, queryCleanup, time.Now().Add(-r.cfg.Timeout.Token))
+	if err != nil {
+		return err
+	}
+
+	querySessions := `
+		INSERT INTO revoked_sessions (id)
+		SELECT id FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		ON CONFLICT DO NOTHING`
+
+	_, err = tx.ExecContext(ctx, querySessions, id)
+	if err != nil {
+		return err
+	}
+
+	queryUser := `
+		DELETE FROM users
+		WHERE id = $1`
+
+	result, err := tx.ExecContext(ctx, queryUser, id)
+	if err != nil {
+		return err
+	}
+
+	if err = checkAffected(result); err != nil {
+		return err
+	}
+
+	queryEvent := `
+		INSERT INTO outbox (key, payload)
+		VALUES ($1, $2)
+		RETURNING id, created_at`
+
+	event.Key = id
+
+	err = tx.QueryRowContext(ctx, queryEvent, event.Key, event.Payload).Scan(&event.ID, &event.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Insert a new session together with its first refresh token
+func (r *authRepo) CreateSession(session *models.Session, token *models.RefreshToken) error {
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	querySession := `
+		INSERT INTO sessions (id, user_id, expires_at)
+		VALUES ($1, $2, $3)
+		RETURNING created_at`
+
+	err = tx.QueryRowContext(ctx, querySession, session.ID, session.UserID, session.ExpiresAt).Scan(&session.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	queryToken := `
+		INSERT INTO refresh_tokens (hash, session_id, expires_at)
+		VALUES ($1, $2, $3)`
+
+	token.SessionID = session.ID
+
+	_, err = tx.ExecContext(ctx, queryToken, token.Hash, token.SessionID, token.ExpiresAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Get session by ID
+func (r *authRepo) GetSession(id string) (*models.Session, error) {
+	query := `
+		SELECT id, user_id, created_at, expires_at, revoked_at
+		FROM sessions
+		WHERE id = $1`
+
+	var session models.Session
+	var revokedAt sql.NullTime
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
+		&session.ID,
+		&session.UserID,
+		&session.CreatedAt,
+		&session.ExpiresAt,
+		&revokedAt,
+	)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, db.ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	if revokedAt.Valid {
+		session.RevokedAt = &revokedAt.Time
+	}
+
+	return &session, nil
+}
+
+// List IDs of sessions revoked since the given time, including sessions of deleted users
+func (r *authRepo) ListRevokedSessions(since time.Time) ([]string, error) {
+	query := `
+		SELECT id FROM sessions
+		WHERE revoked_at > $1
+		UNION ALL
+		SELECT id FROM revoked_sessions
+		WHERE revoked_at > $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// Exchange a refresh token for a new one within the same session.
+// Presenting an already used token revokes the whole session.
+func (r *authRepo) RotateRefreshToken(hash []byte, newToken *models.RefreshToken) (*models.Session, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	querySelect := `
+		SELECT s.id, s.user_id, s.created_at, s.expires_at, s.revoked_at, t.expires_at, t.used_at
+		FROM refresh_tokens t
+		JOIN sessions s ON s.id = t.session_id
+		WHERE t.hash = $1
+		FOR UPDATE`
+
+	var session models.Session
+	var revokedAt, usedAt sql.NullTime
+	var tokenExpiresAt time.Time
+
+	err = tx.QueryRowContext(ctx, querySelect, hash).Scan(
+		&session.ID,
+		&session.UserID,
+		&session.CreatedAt,
+		&session.ExpiresAt,
+		&revokedAt,
+		&tokenExpiresAt,
+		&usedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, db.ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	if usedAt.Valid {
+		queryRevoke := `
+			UPDATE sessions
+			SET revoked_at = NOW()
+			WHERE id = $1 AND revoked_at IS NULL`
+
+		if _, err = tx.ExecContext(ctx, queryRevoke, session.ID); err != nil {
+			return nil, err
+		}
+		if err = tx.Commit(); err != nil {
+			return nil, err
+		}
+		return nil, db.ErrTokenReused
+	}
+
+	now := time.Now()
+	if revokedAt.Valid || now.After(tokenExpiresAt) || now.After(session.ExpiresAt) {
+		return nil, db.ErrRecordNotFound
+	}
+
+	queryUse := `
+		UPDATE refresh_tokens
+		SET used_at = NOW()
+		WHERE hash = $1`
+
+	if _, err = tx.ExecContext(ctx, queryUse, hash); err != nil {
+		return nil, err
+	}
+
+	queryInsert := `
+		INSERT INTO refresh_tokens (hash, session_id, expires_at)
+		VALUES ($1, $2, $3)`
+
+	newToken.SessionID = session.ID
+
+	if _, err = tx.ExecContext(ctx, queryInsert, newToken.Hash, newToken.SessionID, newToken.ExpiresAt); err != nil {
+		return nil, err
+	}
+
+	queryExtend := `
+		UPDATE sessions
+		SET expires_at = $1
+		WHERE id = $2`
+
+	if _, err = tx.ExecContext(ctx, queryExtend, newToken.ExpiresAt, session.ID); err != nil {
+		return nil, err
+	}
+	session.ExpiresAt = newToken.ExpiresAt
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// Revoke a session by ID
+func (r *authRepo) RevokeSession(id string) error {
+	query := `
+		UPDATE sessions
+		SET revoked_at = NOW()
+		WHERE id = $1 AND revoked_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Revoke the session a refresh token belongs to
+func (r *authRepo) RevokeSessionByRefreshToken(hash []byte) error {
+	query := `
+		UPDATE sessions
+		SET revoked_at = NOW()
+		WHERE id = (SELECT session_id FROM refresh_tokens WHERE hash = $1) AND revoked_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, query, hash)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Revoke all sessions of a user
+func (r *authRepo) RevokeUserSessions(userID string) error {
+	query := `
+		UPDATE sessions
+		SET revoked_at = NOW()
+		WHERE user_id = $1 AND revoked_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, query, userID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Revoke all sessions of a user except the given one
+func (r *authRepo) RevokeOtherSessions(userID, keepSessionID string) error {
+	query := `
+		UPDATE sessions
+		SET revoked_at = NOW()
+		WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, query, userID, keepSessionID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Insert a new verification token
+func (r *authRepo) CreateVerificationToken(token *models.VerificationToken) error {
+	query := `
+		INSERT INTO verification_tokens (hash, user_id, purpose, payload, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at`
+
+	args := []interface{}{token.Hash, token.UserID, token.Purpose, token.Payload, token.ExpiresAt}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	return r.db.QueryRowContext(ctx, query, args...).Scan(&token.CreatedAt)
+}
+
+// Mark an unused and unexpired verification token as used and return it
+func (r *authRepo) UseVerificationToken(hash []byte, purpose string) (*models.VerificationToken, error) {
+	query := `
+		UPDATE verification_tokens
+		SET used_at = NOW()
+		WHERE hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING user_id, payload, created_at, expires_at, used_at`
+
+	token := models.VerificationToken{Hash: hash, Purpose: purpose}
+	var usedAt time.Time
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	err := r.db.QueryRowContext(ctx, query, hash, purpose).Scan(
+		&token.UserID,
+		&token.Payload,
+		&token.CreatedAt,
+		&token.ExpiresAt,
+		&usedAt,
+	)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, db.ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+	token.UsedAt = &usedAt
+
+	return &token, nil
+}
+
+// Delete unused verification tokens of a user with the given purpose
+func (r *authRepo) DeleteVerificationTokens(userID, purpose string) error {
+	query := `
+		DELETE FROM verification_tokens
+		WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, query, userID, purpose)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// List all roles with their permissions
+func (r *authRepo) ListRoles() ([]models.Role, error) {
+	query := `
+		SELECT r.name, r.description, COALESCE(array_agg(rp.permission ORDER BY rp.permission) FILTER (WHERE rp.permission IS NOT NULL), '{}')
+		FROM roles r
+		LEFT JOIN role_permissions rp ON rp.role = r.name
+		GROUP BY r.name
+		ORDER BY r.name`
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRoles(rows)
+}
+
+// Get roles assigned to a user
+func (r *authRepo) GetUserRoles(userID string) ([]models.Role, error) {
+	query := `
+		SELECT r.name, r.description, COALESCE(array_agg(rp.permission ORDER BY rp.permission) FILTER (WHERE rp.permission IS NOT NULL), '{}')
+		FROM user_roles ur
+		JOIN roles r ON r.name = ur.role
+		LEFT JOIN role_permissions rp ON rp.role = r.name
+		WHERE ur.user_id = $1
+		GROUP BY r.name
+		ORDER BY r.name`
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRoles(rows)
+}
+
+// Assign a role to a user
+func (r *authRepo) AssignRole(userID, role string) error {
+	query := `
+		INSERT INTO user_roles (user_id, role)
+		VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, query, userID, role)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation {
+			return db.ErrRecordNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Remove a role from a user
+func (r *authRepo) RemoveRole(userID, role string) error {
+	query := `
+		DELETE FROM user_roles
+		WHERE user_id = $1 AND role = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, query, userID, role)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return db.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Create an API key
+func (r *authRepo) CreateAPIKey(key *models.APIKey) error {
+	query := `
+		INSERT INTO api_keys (user_id, name, prefix, hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	return r.db.QueryRowContext(ctx, query, key.UserID, key.Name, key.Prefix, key.Hash, pq.Array(key.Scopes), key.ExpiresAt).
+		Scan(&key.ID, &key.CreatedAt)
+}
+
+// List API keys of a user, newest first
+func (r *authRepo) ListAPIKeys(userID string) ([]models.APIKey, error) {
+	query := `
+		SELECT id, user_id, name, prefix, hash, scopes, created_at, expires_at, last_used_at, revoked_at
+		FROM api_keys
+		WHERE user_id = $1
+		ORDER BY id DESC`
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]models.APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// Get API key by hash
+func (r *authRepo) GetAPIKeyByHash(hash []byte) (*models.APIKey, error) {
+	query := `
+		SELECT id, user_id, name, prefix, hash, scopes, created_at, expires_at, last_used_at, revoked_at
+		FROM api_keys
+		WHERE hash = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx, query, hash))
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, db.ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return key, nil
+}
+
+// Revoke an API key of a user
+func (r *authRepo) RevokeAPIKey(userID, id string) error {
+	query := `
+		UPDATE api_keys
+		SET revoked_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, query, id, userID)
+	if err != nil {
+		return err
+	}
+
+	return checkAffected(result)
+}
+
+// Record API key usage
+func (r *authRepo) TouchAPIKey(id string, usedAt time.Time) error {
+	query := `
+		UPDATE api_keys
+		SET last_used_at = $2
+		WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, query, id, usedAt)
+	return err
+}
+
+// Store an OpenID Connect authorization request, expired requests are removed on the way
+func (r *authRepo) CreateOIDCRequest(req *models.OIDCRequest) error {
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, `DELETE FROM oidc_requests WHERE expires_at <= NOW()`)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO oidc_requests (state_hash, provider, nonce, code_verifier, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at`
+
+	return r.db.QueryRowContext(ctx, query, req.StateHash, req.Provider, req.Nonce, req.CodeVerifier, req.ExpiresAt).
+		Scan(&req.CreatedAt)
+}
+
+// Take an unexpired OpenID Connect authorization request, each request can be used once
+func (r *authRepo) UseOIDCRequest(stateHash []byte, provider string) (*models.OIDCRequest, error) {
+	query := `
+		DELETE FROM oidc_requests
+		WHERE state_hash = $1 AND provider = $2 AND expires_at > NOW()
+		RETURNING nonce, code_verifier, created_at, expires_at`
+
+	req := models.OIDCRequest{StateHash: stateHash, Provider: provider}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	err := r.db.QueryRowContext(ctx, query, stateHash, provider).Scan(
+		&req.Nonce,
+		&req.CodeVerifier,
+		&req.CreatedAt,
+		&req.ExpiresAt,
+	)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, db.ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &req, nil
+}
+
+// Get user by a linked external identity
+func (r *authRepo) GetByIdentity(provider, subject string) (*models.User, error) {
+	query := `
+		SELECT u.id, u.created_at, u.email, u.email_verified, u.is_admin, u.password_hash
+		FROM user_identities ui
+		JOIN users u ON u.id = ui.user_id
+		WHERE ui.provider = $1 AND ui.subject = $2`
+
+	var user models.User
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	err := r.db.QueryRowContext(ctx, query, provider, subject).Scan(
+		&user.ID,
+		&user.CreatedAt,
+		&user.Email,
+		&user.Verified,
+		&user.IsAdmin,
+		&user.PasswordHash,
+	)
+
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, db.ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &user, nil
+}
+
+// Link an external identity to a user
+func (r *authRepo) LinkIdentity(identity *models.UserIdentity) error {
+	query := `
+		INSERT INTO user_identities (provider, subject, user_id, email)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (provider, subject) DO NOTHING`
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, query, identity.Provider, identity.Subject, identity.UserID, identity.Email)
+	return err
+}
+
+// Return ErrRecordNotFound if the statement did not affect any rows
+func checkAffected(result sql.Result) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return db.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Scan role rows
+func scanRoles(rows *sql.Rows) ([]models.Role, error) {
+	roles := make([]models.Role, 0)
+	for rows.Next() {
+		var role models.Role
+		err := rows.Scan(&role.Name, &role.Description, pq.Array(&role.Permissions))
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+// Row of sql.Row or sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// Scan an API key row
+func scanAPIKey(row rowScanner) (*models.APIKey, error) {
+	var key models.APIKey
+	var expiresAt, lastUsedAt, revokedAt sql.NullTime
+
+	err := row.Scan(
+		&key.ID,
+		&key.UserID,
+		&key.Name,
+		&key.Prefix,
+		&key.Hash,
+		pq.Array(&key.Scopes),
+		&key.CreatedAt,
+		&expiresAt,
+		&lastUsedAt,
+		&revokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if expiresAt.Valid {
+		key.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+
+	return &key, nil
+}
+
+// Lock a batch of the oldest outbox events, publish them and delete them once published.
+// Locked rows are skipped, so several instances can process the outbox at once.
+func (r *authRepo) ProcessOutbox(limit int, publish func(ctx context.Context, events []models.OutboxEvent) error) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.OutboxPublish)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT id, key, payload, created_at
+		FROM outbox
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED`
+
+	rows, err := tx.QueryContext(ctx, query, limit)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var events []models.OutboxEvent
+	var ids []int64
+	for rows.Next() {
+		var event models.OutboxEvent
+		if err := rows.Scan(&event.ID, &event.Key, &event.Payload, &event.CreatedAt); err != nil {
+			return 0, err
+		}
+		events = append(events, event)
+		ids = append(ids, event.ID)
+	}
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	if err = publish(ctx, events); err != nil {
+		return 0, err
+	}
+
+	queryDelete := `
+		DELETE FROM outbox
+		WHERE id = ANY($1)`
+
+	if _, err = tx.ExecContext(ctx, queryDelete, pq.Array(ids)); err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return len(events), nil
+}
@@ -1,16 +1,47 @@
 package auth
 
 import (
-	"net/http"
+	"time"
+
+	"github.com/segmentio/kafka-go"
 
 	"cyansnbrst/auth-service/internal/models"
+	"cyansnbrst/auth-service/pkg/jwks"
 )
 
 // Auth usecase interface
 type UseCase interface {
-	Create(email, password string) (string, string, error)
-	ValidateToken(tokenString string) (string, bool, error)
-	GenerateJWT(user models.User) (string, error)
+	Create(email, password, name string) (*models.Tokens, string, error)
+	ValidateToken(tokenString string) (*models.Identity, error)
+	GenerateJWT(user models.User, sessionID string) (string, error)
 	ValidateCredentials(email, password string) (*models.User, error)
-	CreateProfile(uid string, name string, cookie *http.Cookie) error
+	CreateSession(user models.User) (*models.Tokens, error)
+	Refresh(refreshToken string) (*models.Tokens, error)
+	Logout(accessToken, refreshToken string) error
+	RevokeUserSessions(userID string) error
+	RevokedSessions() ([]string, error)
+	JWKS() jwks.JWKS
+	IssueServiceToken(clientID, clientSecret, audience string) (string, time.Time, error)
+	ValidateServiceToken(tokenString string) (string, error)
+	ListRoles() ([]models.Role, error)
+	GetUser(userID string) (*models.User, error)
+	GetUserRoles(userID string) ([]models.Role, error)
+	AssignRole(userID, role string) error
+	RemoveRole(userID, role string) error
+	CreateAPIKey(userID, name string, scopes []string, ttl time.Duration) (*models.APIKey, string, error)
+	ListAPIKeys(userID string) ([]models.APIKey, error)
+	RevokeAPIKey(userID, id string) error
+	AuthenticateAPIKey(key string) (*models.Identity, error)
+	StartOIDCLogin(provider string) (string, string, error)
+	CompleteOIDCLogin(provider, state, code string) (*models.Tokens, error)
+	Me(userID string) (*models.User, error)
+	ChangePassword(userID, sessionID, currentPassword, newPassword string) error
+	RequestEmailChange(userID, sessionID, password, newEmail string) error
+	ConfirmEmailChange(token string) error
+	DeleteAccount(userID, sessionID, password string) error
+	SendVerificationEmail(userID string) error
+	VerifyEmail(token string) error
+	ForgotPassword(email string) error
+	ResetPassword(token, newPassword string) error
+	PublishOutbox(writer *kafka.Writer) (int, error)
 }
@@ -1,47 +1,92 @@
 package usecase
 
 import (
-	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"net/http"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/segmentio/kafka-go"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 
 	"cyansnbrst/auth-service/config"
 	"cyansnbrst/auth-service/internal/auth"
 	"cyansnbrst/auth-service/internal/models"
+	"cyansnbrst/auth-service/pkg/db"
+	"cyansnbrst/auth-service/pkg/jwks"
+	kf "cyansnbrst/auth-service/pkg/kafka"
+	"cyansnbrst/auth-service/pkg/mailer"
+	"cyansnbrst/auth-service/pkg/oidc"
+	"cyansnbrst/auth-service/pkg/ratelimit"
 )
 
+// Token validation errors
+var (
+	errInvalidToken = errors.New("invalid token")
+	errTokenRevoked = errors.New("token revoked")
+)
+
+// Hash compared against when the user doesn't exist, so unknown emails take as long as wrong passwords
+const dummyPasswordHash = "$2a$10$bi.5pYgWxSwDTclEO85DgOL1ERYz4wBL0J3ppzG/R7jhDmH0ni4/q"
+
+// Minimal interval between last_used_at updates of an API key
+const apiKeyTouchInterval = time.Minute
+
 // Auth usecase struct
 type authUC struct {
-	cfg      *config.Config
-	authRepo auth.Repository
-	logger   *zap.Logger
+	cfg       *config.Config
+	authRepo  auth.Repository
+	keys      *jwks.KeySet
+	mailer    mailer.Mailer
+	limiter   ratelimit.Limiter
+	providers map[string]*oidc.Provider
+	logger    *zap.Logger
 }
 
 // Auth usecase constructor
-func NewAuthUseCase(cfg *config.Config, authRepo auth.Repository, logger *zap.Logger) auth.UseCase {
-	return &authUC{cfg: cfg, authRepo: authRepo, logger: logger}
+func NewAuthUseCase(cfg *config.Config, authRepo auth.Repository, keys *jwks.KeySet, mailer mailer.Mailer, limiter ratelimit.Limiter, providers map[string]*oidc.Provider, logger *zap.Logger) auth.UseCase {
+	return &authUC{cfg: cfg, authRepo: authRepo, keys: keys, mailer: mailer, limiter: limiter, providers: providers, logger: logger}
 }
 
 // Custom JWT claims
 type CustomClaims struct {
-	UserUID string `json:"user_uid"`
-	IsAdmin bool   `json:"is_admin"`
+	UserUID     string   `json:"user_uid"`
+	IsAdmin     bool     `json:"is_admin"`
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	SessionID   string   `json:"sid"`
+	TokenUse    string   `json:"token_use,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// Service token claims, the subject is the calling service and the audience is the called one
+type ServiceClaims struct {
+	TokenUse string `json:"token_use"`
 	jwt.RegisteredClaims
 }
 
-// Create a user
-func (u *authUC) Create(email, password string) (string, string, error) {
+// Token use claim of service tokens, they are never accepted as user tokens
+const serviceTokenUse = "service"
+
+// Audience of service tokens for calls to this service
+const authAudience = "auth"
+
+// Create a user, the user_registered event is stored in the outbox in the same transaction
+func (u *authUC) Create(email, password, name string) (*models.Tokens, string, error) {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		u.logger.Error("failed to hash password", zap.Error(err))
-		return "", "", err
+		return nil, "", err
 	}
 
 	newUser := &models.User{
@@ -49,107 +94,941 @@ func (u *authUC) Create(email, password string) (string, string, error) {
 		PasswordHash: string(hashedPassword),
 	}
 
-	newUID, err := u.authRepo.Insert(newUser)
+	event, err := registeredEvent(name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	newUID, err := u.authRepo.Insert(newUser, event)
 	if err != nil {
 		u.logger.Error("failed to create user", zap.Error(err))
-		return "", "", err
+		return nil, "", err
 	}
+	newUser.ID = newUID
 
-	token, err := u.GenerateJWT(*newUser)
+	tokens, err := u.CreateSession(*newUser)
 	if err != nil {
-		u.logger.Error("failed to generate token",
+		u.logger.Error("failed to generate tokens",
 			zap.String("error", err.Error()),
 		)
-		return "", "", err
+		return nil, "", err
+	}
+
+	// The link can be requested again, registration does not fail because of the mailer
+	if err = u.sendVerificationLink(newUID, email); err != nil {
+		u.logger.Warn("failed to send verification email", zap.String("user_uid", newUID), zap.Error(err))
+	}
+
+	return tokens, newUID, nil
+}
+
+// Build the user_registered outbox event, the profile is created from it
+func registeredEvent(name string) (*models.OutboxEvent, error) {
+	payload, err := json.Marshal(kf.KafkaMessage{
+		Action: "user_registered",
+		Time:   time.Now().Format(time.RFC3339),
+		Name:   name,
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return token, newUID, nil
+	return &models.OutboxEvent{Payload: payload}, nil
+}
+
+// Build the user_delete outbox event, profiles and recommendations remove the user data from it
+func deletedEvent() (*models.OutboxEvent, error) {
+	payload, err := json.Marshal(kf.KafkaMessage{
+		Action: "user_delete",
+		Time:   time.Now().Format(time.RFC3339),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.OutboxEvent{Payload: payload}, nil
 }
 
 // Generate JWT token
-func (u *authUC) GenerateJWT(user models.User) (string, error) {
+func (u *authUC) GenerateJWT(user models.User, sessionID string) (string, error) {
+	now := time.Now()
 	claims := CustomClaims{
-		UserUID: user.ID,
-		IsAdmin: user.IsAdmin,
+		UserUID:     user.ID,
+		IsAdmin:     user.IsAdmin || slices.Contains(user.Roles, models.AdminRole),
+		Roles:       user.Roles,
+		Permissions: user.Permissions,
+		SessionID:   sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(u.cfg.Timeout.Token)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(u.cfg.Timeout.Token)),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(u.cfg.SecretKey))
+	key := u.keys.Active()
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
 }
 
-// Validate JWT token
-func (u *authUC) ValidateToken(tokenString string) (string, bool, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &CustomClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
+// Validate JWT token and check that its session was not revoked
+func (u *authUC) ValidateToken(tokenString string) (*models.Identity, error) {
+	claims, err := u.parseToken(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := u.authRepo.GetSession(claims.SessionID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil, errInvalidToken
 		}
-		return []byte(u.cfg.SecretKey), nil
-	})
+		return nil, err
+	}
+
+	if session.RevokedAt != nil {
+		return nil, errTokenRevoked
+	}
+
+	return &models.Identity{
+		UserUID:     claims.UserUID,
+		IsAdmin:     claims.IsAdmin,
+		Roles:       claims.Roles,
+		Permissions: claims.Permissions,
+		SessionID:   claims.SessionID,
+	}, nil
+}
+
+// Parse JWT token and verify its signature
+func (u *authUC) parseToken(tokenString string) (*CustomClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &CustomClaims{}, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return u.keys.PublicKey(kid)
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg(), jwt.SigningMethodRS256.Alg()}))
 
 	if err != nil || !token.Valid {
-		return "", false, errors.New("invalid token")
+		return nil, errInvalidToken
 	}
 
 	claims, ok := token.Claims.(*CustomClaims)
 	if !ok {
-		return "", false, errors.New("invalid claims structure")
+		return nil, errors.New("invalid claims structure")
 	}
 
-	return claims.UserUID, claims.IsAdmin, nil
+	if claims.TokenUse == serviceTokenUse {
+		return nil, errInvalidToken
+	}
+
+	return claims, nil
 }
 
-// Validate  user's credentials
-func (u *authUC) ValidateCredentials(email, password string) (*models.User, error) {
-	user, err := u.authRepo.GetByEmail(email)
+// Issue a short-lived token for calls from the client service to the audience service
+func (u *authUC) IssueServiceToken(clientID, clientSecret, audience string) (string, time.Time, error) {
+	secret, ok := u.cfg.ServiceAuth.Clients[clientID]
+	if !ok || !secretsEqual(secret, clientSecret) {
+		return "", time.Time{}, auth.ErrInvalidClient
+	}
+
+	if _, ok := u.cfg.ServiceAuth.Clients[audience]; !ok && audience != authAudience {
+		return "", time.Time{}, auth.ErrInvalidAudience
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(u.cfg.Timeout.ServiceToken)
+	claims := ServiceClaims{
+		TokenUse: serviceTokenUse,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   clientID,
+			Audience:  jwt.ClaimStrings{audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	key := u.keys.Active()
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+
+	signed, err := token.SignedString(key.Private)
 	if err != nil {
-		return nil, errors.New("user not found")
+		return "", time.Time{}, err
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
+	return signed, expiresAt, nil
+}
+
+// Validate a service token issued for calls to this service and return the calling service
+func (u *authUC) ValidateServiceToken(tokenString string) (string, error) {
+	claims := &ServiceClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return u.keys.PublicKey(kid)
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg(), jwt.SigningMethodRS256.Alg()}),
+		jwt.WithAudience(authAudience),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil || !token.Valid || claims.TokenUse != serviceTokenUse || claims.Subject == "" {
+		return "", errInvalidToken
+	}
+
+	return claims.Subject, nil
+}
+
+// Compare secrets in constant time regardless of their lengths
+func secretsEqual(a, b string) bool {
+	hashA := sha256.Sum256([]byte(a))
+	hashB := sha256.Sum256([]byte(b))
+	return subtle.ConstantTimeCompare(hashA[:], hashB[:]) == 1
+}
+
+// Start a new session and issue its first token pair
+func (u *authUC) CreateSession(user models.User) (*models.Tokens, error) {
+	sessionID, err := randomString(16)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := randomString(32)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(u.cfg.Timeout.RefreshToken)
+
+	session := &models.Session{
+		ID:        sessionID,
+		UserID:    user.ID,
+		ExpiresAt: expiresAt,
+	}
+	token := &models.RefreshToken{
+		Hash:      hashToken(refreshToken),
+		ExpiresAt: expiresAt,
+	}
+
+	if err = u.authRepo.CreateSession(session, token); err != nil {
+		return nil, err
+	}
+
+	if err = u.loadRoles(&user); err != nil {
+		return nil, err
+	}
+
+	return u.issueTokens(user, sessionID, refreshToken, expiresAt)
+}
+
+// Exchange a refresh token for a new token pair
+func (u *authUC) Refresh(refreshToken string) (*models.Tokens, error) {
+	newRefreshToken, err := randomString(32)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(u.cfg.Timeout.RefreshToken)
+	newToken := &models.RefreshToken{
+		Hash:      hashToken(newRefreshToken),
+		ExpiresAt: expiresAt,
+	}
+
+	session, err := u.authRepo.RotateRefreshToken(hashToken(refreshToken), newToken)
+	if err != nil {
+		if errors.Is(err, db.ErrTokenReused) {
+			u.logger.Warn("refresh token reuse detected, session revoked")
+		}
+		return nil, err
+	}
+
+	user, err := u.authRepo.GetByID(session.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = u.loadRoles(user); err != nil {
+		return nil, err
+	}
+
+	return u.issueTokens(*user, session.ID, newRefreshToken, expiresAt)
+}
+
+// Revoke the session identified by the refresh token or, if it is missing, by the access token
+func (u *authUC) Logout(accessToken, refreshToken string) error {
+	if refreshToken != "" {
+		return u.authRepo.RevokeSessionByRefreshToken(hashToken(refreshToken))
+	}
+
+	claims, err := u.parseToken(accessToken)
+	if err != nil {
+		return err
+	}
+
+	return u.authRepo.RevokeSession(claims.SessionID)
+}
+
+// Revoke all sessions of a user
+func (u *authUC) RevokeUserSessions(userID string) error {
+	return u.authRepo.RevokeUserSessions(userID)
+}
+
+// List sessions revoked while their access tokens may still be valid
+func (u *authUC) RevokedSessions() ([]string, error) {
+	return u.authRepo.ListRevokedSessions(time.Now().Add(-u.cfg.Timeout.Token))
+}
+
+// List all roles
+func (u *authUC) ListRoles() ([]models.Role, error) {
+	return u.authRepo.ListRoles()
+}
+
+// Get roles assigned to a user
+func (u *authUC) GetUserRoles(userID string) ([]models.Role, error) {
+	return u.authRepo.GetUserRoles(userID)
+}
+
+// Assign a role to a user, it is added to the user's tokens on the next refresh
+func (u *authUC) AssignRole(userID, role string) error {
+	return u.authRepo.AssignRole(userID, role)
+}
+
+// Remove a role from a user, it is removed from the user's tokens on the next refresh
+func (u *authUC) RemoveRole(userID, role string) error {
+	return u.authRepo.RemoveRole(userID, role)
+}
+
+// Create an API key limited to scopes granted to the user, the key itself is only returned here
+func (u *authUC) CreateAPIKey(userID, name string, scopes []string, ttl time.Duration) (*models.APIKey, string, error) {
+	user, err := u.Me(userID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	owner := models.Identity{Permissions: user.Permissions}
+	for _, scope := range scopes {
+		if !owner.HasPermission(scope) {
+			return nil, "", auth.ErrInvalidScope
+		}
+	}
+
+	prefixBytes := make([]byte, 4)
+	if _, err = rand.Read(prefixBytes); err != nil {
+		return nil, "", err
+	}
+	prefix := models.APIKeyPrefix + hex.EncodeToString(prefixBytes)
+
+	secret, err := randomString(32)
+	if err != nil {
+		return nil, "", err
+	}
+	plainKey := prefix + "_" + secret
+
+	scopes = slices.Clone(scopes)
+	slices.Sort(scopes)
+
+	key := &models.APIKey{
+		UserID: userID,
+		Name:   name,
+		Prefix: prefix,
+		Hash:   hashToken(plainKey),
+		Scopes: slices.Compact(scopes),
+	}
+	if ttl > 0 {
+		expiresAt := time.Now().Add(ttl)
+		key.ExpiresAt = &expiresAt
+	}
+
+	if err = u.authRepo.CreateAPIKey(key); err != nil {
+		return nil, "", err
+	}
+
+	return key, plainKey, nil
+}
+
+// List API keys of a user
+func (u *authUC) ListAPIKeys(userID string) ([]models.APIKey, error) {
+	return u.authRepo.ListAPIKeys(userID)
+}
+
+// Revoke an API key of a user
+func (u *authUC) RevokeAPIKey(userID, id string) error {
+	return u.authRepo.RevokeAPIKey(userID, id)
+}
+
+// Authenticate an API key. The key grants its scopes that the owner still has,
+// so removing a role from the user narrows their keys as well.
+func (u *authUC) AuthenticateAPIKey(plainKey string) (*models.Identity, error) {
+	if !strings.HasPrefix(plainKey, models.APIKeyPrefix) {
+		return nil, auth.ErrInvalidAPIKey
+	}
+
+	key, err := u.authRepo.GetAPIKeyByHash(hashToken(plainKey))
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil, auth.ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && now.After(*key.ExpiresAt)) {
+		return nil, auth.ErrInvalidAPIKey
+	}
+
+	owner := models.User{ID: key.UserID}
+	if err = u.loadRoles(&owner); err != nil {
+		return nil, err
+	}
+
+	ownerIdentity := models.Identity{Permissions: owner.Permissions}
+	permissions := make([]string, 0, len(key.Scopes))
+	for _, scope := range key.Scopes {
+		if ownerIdentity.HasPermission(scope) {
+			permissions = append(permissions, scope)
+		}
+	}
+
+	// Usage is recorded at most once per interval to keep writes off the hot path
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		if err = u.authRepo.TouchAPIKey(key.ID, now); err != nil {
+			u.logger.Warn("failed to record api key usage", zap.String("api_key_id", key.ID), zap.Error(err))
+		}
+	}
+
+	return &models.Identity{
+		UserUID:     key.UserID,
+		Roles:       []string{},
+		Permissions: permissions,
+	}, nil
+}
+
+// Start login with an external identity provider, returns the provider authorization URL and the state
+// the callback must present. The nonce and PKCE code verifier are kept server side until the callback.
+func (u *authUC) StartOIDCLogin(providerName string) (string, string, error) {
+	provider, ok := u.providers[providerName]
+	if !ok {
+		return "", "", auth.ErrUnknownProvider
+	}
+
+	state, err := randomString(32)
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := randomString(32)
+	if err != nil {
+		return "", "", err
+	}
+	codeVerifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		return "", "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), u.cfg.Timeout.OIDCRequest)
+	defer cancel()
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, codeVerifier)
+	if err != nil {
+		return "", "", err
+	}
+
+	err = u.authRepo.CreateOIDCRequest(&models.OIDCRequest{
+		StateHash:    hashToken(state),
+		Provider:     providerName,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		ExpiresAt:    time.Now().Add(u.cfg.Timeout.OIDCState),
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	return authURL, state, nil
+}
+
+// Complete login with an external identity provider and start a session for the linked user
+func (u *authUC) CompleteOIDCLogin(providerName, state, code string) (*models.Tokens, error) {
+	provider, ok := u.providers[providerName]
+	if !ok {
+		return nil, auth.ErrUnknownProvider
+	}
+
+	req, err := u.authRepo.UseOIDCRequest(hashToken(state), providerName)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil, auth.ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), u.cfg.Timeout.OIDCRequest)
+	defer cancel()
+
+	claims, err := provider.Exchange(ctx, code, req.CodeVerifier, req.Nonce)
+	if err != nil {
+		u.logger.Warn("oidc code exchange failed", zap.String("provider", providerName), zap.Error(err))
+		return nil, auth.ErrExternalLogin
+	}
+
+	user, err := u.oidcUser(providerName, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.CreateSession(*user)
+}
+
+// Find the user linked to the external identity. An unlinked identity is linked to the user with
+// the same email if both the provider and the account verified it, or to a new user otherwise.
+func (u *authUC) oidcUser(providerName string, claims *oidc.Claims) (*models.User, error) {
+	user, err := u.authRepo.GetByIdentity(providerName, claims.Subject)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, db.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, auth.ErrEmailNotVerified
+	}
+
+	user, err = u.authRepo.GetByEmail(claims.Email)
+	switch {
+	case err == nil:
+		// Anyone could have registered an unverified account with this email
+		if !user.Verified {
+			return nil, auth.ErrAccountConflict
+		}
+	case errors.Is(err, db.ErrRecordNotFound):
+		user, err = u.createOIDCUser(claims)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	err = u.authRepo.LinkIdentity(&models.UserIdentity{
+		Provider: providerName,
+		Subject:  claims.Subject,
+		UserID:   user.ID,
+		Email:    claims.Email,
+	})
 	if err != nil {
-		return nil, errors.New("invalid email or password")
+		return nil, err
 	}
 
+	u.logger.Info("external identity linked",
+		zap.String("user_uid", user.ID),
+		zap.String("provider", providerName),
+	)
+
 	return user, nil
 }
 
-// Send request for create user's profile
-func (u *authUC) CreateProfile(uid string, name string, cookie *http.Cookie) error {
-	profileRequestBody := models.CreateProfileDTO{Name: name}
+// Create a user for an external identity, the user has no password until it is reset
+func (u *authUC) createOIDCUser(claims *oidc.Claims) (*models.User, error) {
+	name := claims.Name
+	if name == "" {
+		name, _, _ = strings.Cut(claims.Email, "@")
+	}
+
+	event, err := registeredEvent(name)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{
+		Email:    claims.Email,
+		Verified: true,
+	}
+
+	if _, err = u.authRepo.Insert(user, event); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// Get current user with roles
+func (u *authUC) Me(userID string) (*models.User, error) {
+	return u.GetUser(userID)
+}
+
+// Get a user with roles
+func (u *authUC) GetUser(userID string) (*models.User, error) {
+	user, err := u.authRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = u.loadRoles(user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// Change password and revoke all sessions except the current one
+func (u *authUC) ChangePassword(userID, sessionID, currentPassword, newPassword string) error {
+	if _, err := u.checkPassword(userID, sessionID, currentPassword); err != nil {
+		return err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	if err = u.authRepo.UpdatePassword(userID, string(hashedPassword)); err != nil {
+		return err
+	}
+
+	return u.authRepo.RevokeOtherSessions(userID, sessionID)
+}
+
+// Send a confirmation link to the new email, the email is changed once the link is used
+func (u *authUC) RequestEmailChange(userID, sessionID, password, newEmail string) error {
+	if _, err := u.checkPassword(userID, sessionID, password); err != nil {
+		return err
+	}
+
+	_, err := u.authRepo.GetByEmail(newEmail)
+	switch {
+	case err == nil:
+		return db.ErrDuplicateEmail
+	case !errors.Is(err, db.ErrRecordNotFound):
+		return err
+	}
 
-	data, err := json.Marshal(profileRequestBody)
+	token, err := u.createVerificationToken(userID, models.PurposeEmailChange, newEmail, u.cfg.Timeout.EmailToken)
 	if err != nil {
-		return fmt.Errorf("failed to marshal profile request: %w", err)
+		return err
 	}
 
-	url := fmt.Sprintf("%s/%s", u.cfg.ProfileLink, uid)
+	return u.sendMail(mailer.Message{
+		To:      newEmail,
+		Subject: "Confirm your new email",
+		Body:    fmt.Sprintf("Follow the link to confirm your new email: %s?token=%s", u.cfg.Mail.ConfirmEmailURL, token),
+	})
+}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(data))
+// Change the email using a confirmation token
+func (u *authUC) ConfirmEmailChange(token string) error {
+	verification, err := u.authRepo.UseVerificationToken(hashToken(token), models.PurposeEmailChange)
 	if err != nil {
-		return fmt.Errorf("failed to create HTTP request: %w", err)
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return auth.ErrInvalidToken
+		}
+		return err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.AddCookie(cookie)
+	return u.authRepo.UpdateEmail(verification.UserID, verification.Payload)
+}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+// Send an email verification link to the user
+func (u *authUC) SendVerificationEmail(userID string) error {
+	user, err := u.authRepo.GetByID(userID)
 	if err != nil {
-		return fmt.Errorf("failed to send profile creation request: %w", err)
+		return err
+	}
+
+	if user.Verified {
+		return auth.ErrEmailVerified
 	}
-	defer func() {
-		closeErr := resp.Body.Close()
-		if closeErr != nil {
-			u.logger.Error("error closing response body", zap.Error(closeErr))
+
+	return u.sendVerificationLink(user.ID, user.Email)
+}
+
+// Mark the email as verified using a verification token
+func (u *authUC) VerifyEmail(token string) error {
+	verification, err := u.authRepo.UseVerificationToken(hashToken(token), models.PurposeEmailVerification)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return auth.ErrInvalidToken
+		}
+		return err
+	}
+
+	err = u.authRepo.VerifyEmail(verification.UserID, verification.Payload)
+	if errors.Is(err, db.ErrRecordNotFound) {
+		// The email was changed after the token was sent
+		return auth.ErrInvalidToken
+	}
+
+	return err
+}
+
+// Send a password reset link, unknown emails are ignored so that accounts cannot be enumerated
+func (u *authUC) ForgotPassword(email string) error {
+	user, err := u.authRepo.GetByEmail(email)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	// Only the latest reset link stays valid
+	if err = u.authRepo.DeleteVerificationTokens(user.ID, models.PurposePasswordReset); err != nil {
+		return err
+	}
+
+	token, err := u.createVerificationToken(user.ID, models.PurposePasswordReset, user.Email, u.cfg.Timeout.ResetToken)
+	if err != nil {
+		return err
+	}
+
+	// The link is mailed in the background, so the reply takes as long for unknown emails
+	go func() {
+		err := u.sendMail(mailer.Message{
+			To:      user.Email,
+			Subject: "Reset your password",
+			Body:    fmt.Sprintf("Follow the link to reset your password: %s?token=%s\nIf you did not request a reset, ignore this email.", u.cfg.Mail.ResetPasswordURL, token),
+		})
+		if err != nil {
+			u.logger.Warn("failed to send password reset email", zap.String("user_uid", user.ID), zap.Error(err))
 		}
 	}()
 
-	if resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("failed to create profile, status: %s", resp.Status)
+	return nil
+}
+
+// Set a new password using a reset token and revoke all sessions
+func (u *authUC) ResetPassword(token, newPassword string) error {
+	verification, err := u.authRepo.UseVerificationToken(hashToken(token), models.PurposePasswordReset)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return auth.ErrInvalidToken
+		}
+		return err
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	if err = u.authRepo.UpdatePassword(verification.UserID, string(hashedPassword)); err != nil {
+		return err
+	}
+
+	return u.authRepo.RevokeUserSessions(verification.UserID)
+}
+
+// Delete the account after confirming the password
+func (u *authUC) DeleteAccount(userID, sessionID, password string) error {
+	if _, err := u.checkPassword(userID, sessionID, password); err != nil {
+		return err
+	}
+
+	event, err := deletedEvent()
+	if err != nil {
+		return err
+	}
+
+	return u.authRepo.Delete(userID, event)
+}
+
+// Publish a batch of outbox events, returns the number of published events
+func (u *authUC) PublishOutbox(writer *kafka.Writer) (int, error) {
+	return u.authRepo.ProcessOutbox(u.cfg.Outbox.BatchSize, func(ctx context.Context, events []models.OutboxEvent) error {
+		messages := make([]kafka.Message, 0, len(events))
+		for _, event := range events {
+			messages = append(messages, kafka.Message{
+				Key:   []byte(event.Key),
+				Value: event.Payload,
+			})
+		}
+
+		return writer.WriteMessages(ctx, messages...)
+	})
+}
+
+// Create an email verification token and mail the link
+func (u *authUC) sendVerificationLink(userID, email string) error {
+	token, err := u.createVerificationToken(userID, models.PurposeEmailVerification, email, u.cfg.Timeout.EmailToken)
+	if err != nil {
+		return err
+	}
+
+	return u.sendMail(mailer.Message{
+		To:      email,
+		Subject: "Verify your email",
+		Body:    fmt.Sprintf("Follow the link to verify your email: %s?token=%s", u.cfg.Mail.VerifyEmailURL, token),
+	})
+}
+
+// Store a new single-use token and return it
+func (u *authUC) createVerificationToken(userID, purpose, payload string, ttl time.Duration) (string, error) {
+	token, err := randomString(32)
+	if err != nil {
+		return "", err
+	}
+
+	err = u.authRepo.CreateVerificationToken(&models.VerificationToken{
+		Hash:      hashToken(token),
+		UserID:    userID,
+		Purpose:   purpose,
+		Payload:   payload,
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// Send an email with the configured timeout
+func (u *authUC) sendMail(msg mailer.Message) error {
+	ctx, cancel := context.WithTimeout(context.Background(), u.cfg.Timeout.MailSend)
+	defer cancel()
+
+	return u.mailer.Send(ctx, msg)
+}
+
+// Get user and compare the password with the stored hash. Accounts created with an identity
+// provider have no password, for them the action must come from a session signed in recently.
+func (u *authUC) checkPassword(userID, sessionID, password string) (*models.User, error) {
+	user, err := u.authRepo.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.PasswordHash == "" {
+		if err = u.checkRecentSession(userID, sessionID); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
+	if err != nil {
+		return nil, auth.ErrInvalidPassword
+	}
+
+	return user, nil
+}
+
+// Check that the session belongs to the user and was signed in within the reauth timeout
+func (u *authUC) checkRecentSession(userID, sessionID string) error {
+	if sessionID == "" {
+		return auth.ErrReauthRequired
+	}
+
+	session, err := u.authRepo.GetSession(sessionID)
+	if err != nil {
+		if errors.Is(err, db.ErrRecordNotFound) {
+			return auth.ErrReauthRequired
+		}
+		return err
+	}
+
+	if session.UserID != userID || session.RevokedAt != nil || time.Since(session.CreatedAt) > u.cfg.Timeout.Reauth {
+		return auth.ErrReauthRequired
+	}
+
+	return nil
+}
+
+// Fill user's roles and permissions
+func (u *authUC) loadRoles(user *models.User) error {
+	roles, err := u.authRepo.GetUserRoles(user.ID)
+	if err != nil {
+		return err
+	}
+
+	user.Roles = make([]string, 0, len(roles))
+	user.Permissions = nil
+	for _, role := range roles {
+		user.Roles = append(user.Roles, role.Name)
+		user.Permissions = append(user.Permissions, role.Permissions...)
 	}
+	slices.Sort(user.Permissions)
+	user.Permissions = slices.Compact(user.Permissions)
 
 	return nil
 }
+
+// Build a token pair for the session
+func (u *authUC) issueTokens(user models.User, sessionID, refreshToken string, refreshExpiresAt time.Time) (*models.Tokens, error) {
+	accessToken, err := u.GenerateJWT(user, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Tokens{
+		Access:           accessToken,
+		AccessExpiresAt:  time.Now().Add(u.cfg.Timeout.Token),
+		Refresh:          refreshToken,
+		RefreshExpiresAt: refreshExpiresAt,
+	}, nil
+}
+
+// Generate a random URL-safe string from n random bytes
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Hash a refresh token for storage
+func hashToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
+// Get public signing keys
+func (u *authUC) JWKS() jwks.JWKS {
+	return u.keys.JWKS()
+}
+
+// Validate user's credentials, attempts are limited per account and failures lock the account temporarily
+func (u *authUC) ValidateCredentials(email, password string) (*models.User, error) {
+	ctx := context.Background()
+	account := strings.ToLower(strings.TrimSpace(email))
+	lockoutKey := "lockout:" + account
+	lockoutLimit := ratelimit.Limit{Requests: u.cfg.RateLimit.LockoutFailures, Window: u.cfg.RateLimit.LockoutDuration}
+
+	lockout, err := u.limiter.Check(ctx, lockoutKey, lockoutLimit)
+	if err != nil {
+		return nil, err
+	}
+	if !lockout.Allowed {
+		return nil, &auth.RateLimitError{RetryAfter: lockout.RetryAfter}
+	}
+
+	attempts, err := u.limiter.Allow(ctx, "login:"+account, ratelimit.Limit{
+		Requests: u.cfg.RateLimit.AccountRequests,
+		Window:   u.cfg.RateLimit.AccountWindow,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !attempts.Allowed {
+		return nil, &auth.RateLimitError{RetryAfter: attempts.RetryAfter}
+	}
+
+	user, err := u.authRepo.GetByEmail(email)
+	if err != nil && !errors.Is(err, db.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	passwordHash := dummyPasswordHash
+	if user != nil {
+		passwordHash = user.PasswordHash
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password))
+	if err != nil || user == nil {
+		if _, err := u.limiter.Allow(ctx, lockoutKey, lockoutLimit); err != nil {
+			u.logger.Error("failed to record failed login", zap.Error(err))
+		}
+		return nil, auth.ErrInvalidCredentials
+	}
+
+	if err = u.limiter.Reset(ctx, lockoutKey); err != nil {
+		u.logger.Error("failed to reset failed logins", zap.Error(err))
+	}
+
+	return user, nil
+}
@@ -1,26 +1,44 @@
 package usecase
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/segmentio/kafka-go"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 
 	"cyansnbrst/auth-service/config"
+	"cyansnbrst/auth-service/internal/auth"
 	mock_auth "cyansnbrst/auth-service/internal/auth/mock"
 	"cyansnbrst/auth-service/internal/models"
+	"cyansnbrst/auth-service/pkg/db"
+	"cyansnbrst/auth-service/pkg/jwks"
+	kf "cyansnbrst/auth-service/pkg/kafka"
+	"cyansnbrst/auth-service/pkg/mailer"
+	"cyansnbrst/auth-service/pkg/oidc"
+	"cyansnbrst/auth-service/pkg/oidc/oidctest"
+	"cyansnbrst/auth-service/pkg/ratelimit"
 )
 
+func newTestKeys(t *testing.T) *jwks.KeySet {
+	keys, err := jwks.Generate()
+	require.NoError(t, err)
+	return keys
+}
+
 func TestAuthUseCase_Create(t *testing.T) {
 	cfg := &config.Config{
 		Timeout: config.Timeout{
-			Token: time.Hour,
+			Token:        time.Hour,
+			RefreshToken: 24 * time.Hour,
 		},
-		SecretKey: "secret",
 	}
 
 	logger := zap.NewNop()
@@ -29,7 +47,7 @@ func TestAuthUseCase_Create(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockAuthRepo := mock_auth.NewMockRepository(ctrl)
-	authUC := NewAuthUseCase(cfg, mockAuthRepo, logger)
+	authUC := NewAuthUseCase(cfg, mockAuthRepo, newTestKeys(t), mailer.NewLogMailer(logger), ratelimit.NewMemoryLimiter(), nil, logger)
 
 	tests := []struct {
 		name         string
@@ -43,7 +61,32 @@ func TestAuthUseCase_Create(t *testing.T) {
 			email:    "test@test.com",
 			password: "test",
 			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {
-				mockAuthRepo.EXPECT().Insert(gomock.Any()).Return("7543", nil)
+				mockAuthRepo.EXPECT().Insert(gomock.Any(), gomock.Any()).DoAndReturn(func(user *models.User, event *models.OutboxEvent) (string, error) {
+					var message kf.KafkaMessage
+					require.NoError(t, json.Unmarshal(event.Payload, &message))
+					require.Equal(t, "user_registered", message.Action)
+					require.Equal(t, "user", message.Name)
+					return "7543", nil
+				})
+				mockAuthRepo.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Return(nil)
+				mockAuthRepo.EXPECT().GetUserRoles("7543").Return(nil, nil)
+				mockAuthRepo.EXPECT().CreateVerificationToken(gomock.Any()).DoAndReturn(func(token *models.VerificationToken) error {
+					require.Equal(t, models.PurposeEmailVerification, token.Purpose)
+					require.Equal(t, "test@test.com", token.Payload)
+					return nil
+				})
+			},
+			wantErr: false,
+		},
+		{
+			name:     "verification email is not sent",
+			email:    "test@test.com",
+			password: "test",
+			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {
+				mockAuthRepo.EXPECT().Insert(gomock.Any(), gomock.Any()).Return("7543", nil)
+				mockAuthRepo.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Return(nil)
+				mockAuthRepo.EXPECT().GetUserRoles("7543").Return(nil, nil)
+				mockAuthRepo.EXPECT().CreateVerificationToken(gomock.Any()).Return(errors.New("db error"))
 			},
 			wantErr: false,
 		},
@@ -52,7 +95,7 @@ func TestAuthUseCase_Create(t *testing.T) {
 			email:    "test@example.com",
 			password: "test",
 			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {
-				mockAuthRepo.EXPECT().Insert(gomock.Any()).Return("", errors.New("db error"))
+				mockAuthRepo.EXPECT().Insert(gomock.Any(), gomock.Any()).Return("", errors.New("db error"))
 			},
 			wantErr: true,
 		},
@@ -63,27 +106,64 @@ func TestAuthUseCase_Create(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mockBehavior(mockAuthRepo)
 
-			token, userID, err := authUC.Create(tt.email, tt.password)
+			tokens, userID, err := authUC.Create(tt.email, tt.password, "user")
 
 			if tt.wantErr {
 				require.Error(t, err)
-				require.Empty(t, token)
+				require.Nil(t, tokens)
 				require.Empty(t, userID)
 			} else {
 				require.NoError(t, err)
-				require.NotEmpty(t, token)
+				require.NotEmpty(t, tokens.Access)
+				require.NotEmpty(t, tokens.Refresh)
 				require.NotEmpty(t, userID)
 			}
 		})
 	}
 }
 
+func TestAuthUseCase_PublishOutbox(t *testing.T) {
+	cfg := &config.Config{
+		Outbox: config.Outbox{BatchSize: 100},
+	}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthRepo := mock_auth.NewMockRepository(ctrl)
+	authUC := NewAuthUseCase(cfg, mockAuthRepo, newTestKeys(t), mailer.NewLogMailer(logger), ratelimit.NewMemoryLimiter(), nil, logger)
+
+	t.Run("kafka error keeps events", func(t *testing.T) {
+		mockAuthRepo.EXPECT().ProcessOutbox(100, gomock.Any()).DoAndReturn(
+			func(limit int, publish func(ctx context.Context, events []models.OutboxEvent) error) (int, error) {
+				events := []models.OutboxEvent{{ID: 1, Key: "7543", Payload: []byte(`{"action":"user_registered"}`)}}
+				if err := publish(context.Background(), events); err != nil {
+					return 0, err
+				}
+				return len(events), nil
+			})
+
+		published, err := authUC.PublishOutbox(&kafka.Writer{})
+		require.Error(t, err)
+		require.Zero(t, published)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		mockAuthRepo.EXPECT().ProcessOutbox(100, gomock.Any()).Return(0, errors.New("db error"))
+
+		_, err := authUC.PublishOutbox(&kafka.Writer{})
+		require.Error(t, err)
+	})
+}
+
 func TestAuthUseCase_GenerateAndValidateJWT(t *testing.T) {
 	cfg := &config.Config{
 		Timeout: config.Timeout{
-			Token: time.Hour,
+			Token:        time.Hour,
+			RefreshToken: 24 * time.Hour,
 		},
-		SecretKey: "secret",
 	}
 
 	logger := zap.NewNop()
@@ -92,31 +172,63 @@ func TestAuthUseCase_GenerateAndValidateJWT(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockAuthRepo := mock_auth.NewMockRepository(ctrl)
-	authUC := NewAuthUseCase(cfg, mockAuthRepo, logger)
+	authUC := NewAuthUseCase(cfg, mockAuthRepo, newTestKeys(t), mailer.NewLogMailer(logger), ratelimit.NewMemoryLimiter(), nil, logger)
 
 	user := models.User{
 		ID:      "5748",
 		IsAdmin: true,
 	}
 
+	revokedAt := time.Now()
+
 	tests := []struct {
-		name      string
-		token     string
-		setup     func() string
-		wantUID   string
-		wantAdmin bool
-		wantErr   bool
+		name         string
+		token        string
+		setup        func() string
+		mockBehavior func(mockAuthRepo *mock_auth.MockRepository)
+		wantUID      string
+		wantAdmin    bool
+		wantErr      bool
 	}{
 		{
 			name: "valid token",
 			setup: func() string {
-				token, _ := authUC.GenerateJWT(user)
+				token, _ := authUC.GenerateJWT(user, "sid")
 				return token
 			},
+			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {
+				mockAuthRepo.EXPECT().GetSession("sid").Return(&models.Session{ID: "sid", UserID: "5748"}, nil)
+			},
 			wantUID:   "5748",
 			wantAdmin: true,
 			wantErr:   false,
 		},
+		{
+			name: "revoked session",
+			setup: func() string {
+				token, _ := authUC.GenerateJWT(user, "sid")
+				return token
+			},
+			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {
+				mockAuthRepo.EXPECT().GetSession("sid").Return(&models.Session{ID: "sid", UserID: "5748", RevokedAt: &revokedAt}, nil)
+			},
+			wantUID:   "",
+			wantAdmin: false,
+			wantErr:   true,
+		},
+		{
+			name: "unknown session",
+			setup: func() string {
+				token, _ := authUC.GenerateJWT(user, "unknown")
+				return token
+			},
+			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {
+				mockAuthRepo.EXPECT().GetSession("unknown").Return(nil, db.ErrRecordNotFound)
+			},
+			wantUID:   "",
+			wantAdmin: false,
+			wantErr:   true,
+		},
 		{
 			name:      "invalid token format",
 			token:     "wrong token",
@@ -126,10 +238,10 @@ func TestAuthUseCase_GenerateAndValidateJWT(t *testing.T) {
 			wantErr:   true,
 		},
 		{
-			name: "wrong secret key",
+			name: "unknown signing key",
 			setup: func() string {
-				authUCWrong := NewAuthUseCase(&config.Config{SecretKey: "wrongkey"}, nil, logger)
-				token, _ := authUCWrong.GenerateJWT(user)
+				authUCWrong := NewAuthUseCase(cfg, nil, newTestKeys(t), mailer.NewLogMailer(logger), ratelimit.NewMemoryLimiter(), nil, logger)
+				token, _ := authUCWrong.GenerateJWT(user, "sid")
 				return token
 			},
 			wantUID:   "",
@@ -141,28 +253,103 @@ func TestAuthUseCase_GenerateAndValidateJWT(t *testing.T) {
 	for _, tt := range tests {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
+			if tt.mockBehavior != nil {
+				tt.mockBehavior(mockAuthRepo)
+			}
+
 			token := tt.token
 			if tt.setup != nil {
 				token = tt.setup()
 			}
 
-			uid, isAdmin, err := authUC.ValidateToken(token)
+			identity, err := authUC.ValidateToken(token)
 
 			if tt.wantErr {
 				require.Error(t, err)
-				require.Empty(t, uid)
-				require.False(t, isAdmin)
+				require.Nil(t, identity)
 			} else {
 				require.NoError(t, err)
-				require.Equal(t, tt.wantUID, uid)
-				require.Equal(t, tt.wantAdmin, isAdmin)
+				require.Equal(t, tt.wantUID, identity.UserUID)
+				require.Equal(t, tt.wantAdmin, identity.IsAdmin)
 			}
 		})
 	}
 }
 
+func TestAuthUseCase_ServiceToken(t *testing.T) {
+	cfg := &config.Config{
+		ServiceAuth: config.ServiceAuth{
+			Clients: map[string]string{"products": "products-secret", "profiles": "profiles-secret"},
+		},
+		Timeout: config.Timeout{
+			Token:        time.Hour,
+			ServiceToken: 5 * time.Minute,
+		},
+	}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthRepo := mock_auth.NewMockRepository(ctrl)
+	authUC := NewAuthUseCase(cfg, mockAuthRepo, newTestKeys(t), mailer.NewLogMailer(logger), ratelimit.NewMemoryLimiter(), nil, logger)
+
+	t.Run("valid token for auth", func(t *testing.T) {
+		token, expiresAt, err := authUC.IssueServiceToken("products", "products-secret", "auth")
+		require.NoError(t, err)
+		require.WithinDuration(t, time.Now().Add(5*time.Minute), expiresAt, time.Minute)
+
+		caller, err := authUC.ValidateServiceToken(token)
+		require.NoError(t, err)
+		require.Equal(t, "products", caller)
+
+		// Service tokens are not user tokens
+		_, err = authUC.ValidateToken(token)
+		require.Error(t, err)
+	})
+
+	t.Run("token for another service", func(t *testing.T) {
+		token, _, err := authUC.IssueServiceToken("products", "products-secret", "profiles")
+		require.NoError(t, err)
+
+		_, err = authUC.ValidateServiceToken(token)
+		require.Error(t, err)
+	})
+
+	t.Run("invalid secret", func(t *testing.T) {
+		_, _, err := authUC.IssueServiceToken("products", "profiles-secret", "auth")
+		require.ErrorIs(t, err, auth.ErrInvalidClient)
+	})
+
+	t.Run("unknown client", func(t *testing.T) {
+		_, _, err := authUC.IssueServiceToken("analytics", "", "auth")
+		require.ErrorIs(t, err, auth.ErrInvalidClient)
+	})
+
+	t.Run("unknown audience", func(t *testing.T) {
+		_, _, err := authUC.IssueServiceToken("products", "products-secret", "billing")
+		require.ErrorIs(t, err, auth.ErrInvalidAudience)
+	})
+
+	t.Run("user token is not a service token", func(t *testing.T) {
+		token, err := authUC.GenerateJWT(models.User{ID: "12345"}, "session")
+		require.NoError(t, err)
+
+		_, err = authUC.ValidateServiceToken(token)
+		require.Error(t, err)
+	})
+}
+
 func TestAuthUseCase_ValidateCredentials(t *testing.T) {
-	cfg := &config.Config{}
+	cfg := &config.Config{
+		RateLimit: config.RateLimit{
+			AccountRequests: 100,
+			AccountWindow:   time.Minute,
+			LockoutFailures: 5,
+			LockoutDuration: time.Minute,
+		},
+	}
 
 	logger := zap.NewNop()
 
@@ -170,7 +357,7 @@ func TestAuthUseCase_ValidateCredentials(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockAuthRepo := mock_auth.NewMockRepository(ctrl)
-	authUC := NewAuthUseCase(cfg, mockAuthRepo, logger)
+	authUC := NewAuthUseCase(cfg, mockAuthRepo, newTestKeys(t), mailer.NewLogMailer(logger), ratelimit.NewMemoryLimiter(), nil, logger)
 
 	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("correctpassword"), bcrypt.DefaultCost)
 
@@ -201,7 +388,7 @@ func TestAuthUseCase_ValidateCredentials(t *testing.T) {
 			email:    "noname@test.com",
 			password: "correctpassword",
 			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {
-				mockAuthRepo.EXPECT().GetByEmail("noname@test.com").Return(nil, errors.New("user not found"))
+				mockAuthRepo.EXPECT().GetByEmail("noname@test.com").Return(nil, db.ErrRecordNotFound)
 			},
 			wantErr: true,
 		},
@@ -224,7 +411,7 @@ func TestAuthUseCase_ValidateCredentials(t *testing.T) {
 			user, err := authUC.ValidateCredentials(tt.email, tt.password)
 
 			if tt.wantErr {
-				require.Error(t, err)
+				require.ErrorIs(t, err, auth.ErrInvalidCredentials)
 				require.Nil(t, user)
 			} else {
 				require.NoError(t, err)
@@ -234,3 +421,1056 @@ func TestAuthUseCase_ValidateCredentials(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthUseCase_ValidateCredentialsLockout(t *testing.T) {
+	cfg := &config.Config{
+		RateLimit: config.RateLimit{
+			AccountRequests: 100,
+			AccountWindow:   time.Minute,
+			LockoutFailures: 2,
+			LockoutDuration: time.Minute,
+		},
+	}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthRepo := mock_auth.NewMockRepository(ctrl)
+	authUC := NewAuthUseCase(cfg, mockAuthRepo, newTestKeys(t), mailer.NewLogMailer(logger), ratelimit.NewMemoryLimiter(), nil, logger)
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("correctpassword"), bcrypt.MinCost)
+	mockUser := &models.User{ID: "12345", Email: "test@test.com", PasswordHash: string(hashedPassword)}
+
+	mockAuthRepo.EXPECT().GetByEmail(gomock.Any()).Return(mockUser, nil).Times(2)
+
+	for i := 0; i < 2; i++ {
+		_, err := authUC.ValidateCredentials("test@test.com", "wrongpassword")
+		require.ErrorIs(t, err, auth.ErrInvalidCredentials)
+	}
+
+	// Locked even with the right password and a differently cased email
+	_, err := authUC.ValidateCredentials("Test@Test.com", "correctpassword")
+	var rateLimitErr *auth.RateLimitError
+	require.ErrorAs(t, err, &rateLimitErr)
+	require.Greater(t, rateLimitErr.RetryAfter, time.Duration(0))
+}
+
+func TestAuthUseCase_Refresh(t *testing.T) {
+	cfg := &config.Config{
+		Timeout: config.Timeout{
+			Token:        time.Hour,
+			RefreshToken: 24 * time.Hour,
+		},
+	}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthRepo := mock_auth.NewMockRepository(ctrl)
+	authUC := NewAuthUseCase(cfg, mockAuthRepo, newTestKeys(t), mailer.NewLogMailer(logger), ratelimit.NewMemoryLimiter(), nil, logger)
+
+	tests := []struct {
+		name         string
+		mockBehavior func(mockAuthRepo *mock_auth.MockRepository)
+		wantErr      error
+	}{
+		{
+			name: "success",
+			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {
+				mockAuthRepo.EXPECT().RotateRefreshToken(hashToken("refresh"), gomock.Any()).
+					Return(&models.Session{ID: "sid", UserID: "5748"}, nil)
+				mockAuthRepo.EXPECT().GetByID("5748").Return(&models.User{ID: "5748"}, nil)
+				mockAuthRepo.EXPECT().GetUserRoles("5748").Return(nil, nil)
+			},
+		},
+		{
+			name: "reused token",
+			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {
+				mockAuthRepo.EXPECT().RotateRefreshToken(hashToken("refresh"), gomock.Any()).Return(nil, db.ErrTokenReused)
+			},
+			wantErr: db.ErrTokenReused,
+		},
+		{
+			name: "unknown token",
+			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {
+				mockAuthRepo.EXPECT().RotateRefreshToken(hashToken("refresh"), gomock.Any()).Return(nil, db.ErrRecordNotFound)
+			},
+			wantErr: db.ErrRecordNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockAuthRepo)
+
+			tokens, err := authUC.Refresh("refresh")
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				require.Nil(t, tokens)
+			} else {
+				require.NoError(t, err)
+				require.NotEmpty(t, tokens.Access)
+				require.NotEqual(t, "refresh", tokens.Refresh)
+			}
+		})
+	}
+}
+
+func TestAuthUseCase_Logout(t *testing.T) {
+	cfg := &config.Config{
+		Timeout: config.Timeout{
+			Token: time.Hour,
+		},
+	}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthRepo := mock_auth.NewMockRepository(ctrl)
+	authUC := NewAuthUseCase(cfg, mockAuthRepo, newTestKeys(t), mailer.NewLogMailer(logger), ratelimit.NewMemoryLimiter(), nil, logger)
+
+	accessToken, err := authUC.GenerateJWT(models.User{ID: "5748"}, "sid")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		accessToken  string
+		refreshToken string
+		mockBehavior func(mockAuthRepo *mock_auth.MockRepository)
+		wantErr      bool
+	}{
+		{
+			name:         "by refresh token",
+			accessToken:  accessToken,
+			refreshToken: "refresh",
+			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {
+				mockAuthRepo.EXPECT().RevokeSessionByRefreshToken(hashToken("refresh")).Return(nil)
+			},
+		},
+		{
+			name:        "by access token",
+			accessToken: accessToken,
+			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {
+				mockAuthRepo.EXPECT().RevokeSession("sid").Return(nil)
+			},
+		},
+		{
+			name:         "invalid access token",
+			accessToken:  "wrong token",
+			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {},
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockAuthRepo)
+
+			err := authUC.Logout(tt.accessToken, tt.refreshToken)
+
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAuthUseCase_RevokedSessions(t *testing.T) {
+	cfg := &config.Config{
+		Timeout: config.Timeout{
+			Token: 15 * time.Minute,
+		},
+	}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthRepo := mock_auth.NewMockRepository(ctrl)
+	authUC := NewAuthUseCase(cfg, mockAuthRepo, newTestKeys(t), mailer.NewLogMailer(logger), ratelimit.NewMemoryLimiter(), nil, logger)
+
+	// Sessions revoked before the oldest valid access token was issued are not listed
+	mockAuthRepo.EXPECT().ListRevokedSessions(gomock.Any()).DoAndReturn(func(since time.Time) ([]string, error) {
+		require.WithinDuration(t, time.Now().Add(-15*time.Minute), since, time.Minute)
+		return []string{"sid"}, nil
+	})
+
+	ids, err := authUC.RevokedSessions()
+	require.NoError(t, err)
+	require.Equal(t, []string{"sid"}, ids)
+}
+
+func TestAuthUseCase_CreateSessionRoles(t *testing.T) {
+	cfg := &config.Config{
+		Timeout: config.Timeout{
+			Token:        time.Hour,
+			RefreshToken: 24 * time.Hour,
+		},
+	}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthRepo := mock_auth.NewMockRepository(ctrl)
+	authUC := NewAuthUseCase(cfg, mockAuthRepo, newTestKeys(t), mailer.NewLogMailer(logger), ratelimit.NewMemoryLimiter(), nil, logger)
+
+	tests := []struct {
+		name            string
+		roles           []models.Role
+		wantAdmin       bool
+		wantPermissions []string
+		checkPermission string
+		wantAllowed     bool
+	}{
+		{
+			name:            "no roles",
+			roles:           nil,
+			wantAdmin:       false,
+			wantPermissions: nil,
+			checkPermission: "products:write",
+			wantAllowed:     false,
+		},
+		{
+			name: "overlapping roles",
+			roles: []models.Role{
+				{Name: "catalog-editor", Permissions: []string{"products:write"}},
+				{Name: "merchandiser", Permissions: []string{"products:delete", "products:write"}},
+			},
+			wantAdmin:       false,
+			wantPermissions: []string{"products:delete", "products:write"},
+			checkPermission: "products:delete",
+			wantAllowed:     true,
+		},
+		{
+			name: "admin role",
+			roles: []models.Role{
+				{Name: models.AdminRole, Permissions: []string{"*"}},
+			},
+			wantAdmin:       true,
+			wantPermissions: []string{"*"},
+			checkPermission: "roles:manage",
+			wantAllowed:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			mockAuthRepo.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Return(nil)
+			mockAuthRepo.EXPECT().GetUserRoles("5748").Return(tt.roles, nil)
+
+			tokens, err := authUC.CreateSession(models.User{ID: "5748"})
+			require.NoError(t, err)
+
+			mockAuthRepo.EXPECT().GetSession(gomock.Any()).Return(&models.Session{UserID: "5748"}, nil)
+
+			identity, err := authUC.ValidateToken(tokens.Access)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantAdmin, identity.IsAdmin)
+			require.Equal(t, tt.wantPermissions, identity.Permissions)
+			require.Equal(t, tt.wantAllowed, identity.HasPermission(tt.checkPermission))
+		})
+	}
+}
+
+// Mailer that keeps sent messages
+type testMailer struct {
+	sent []mailer.Message
+}
+
+func (m *testMailer) Send(ctx context.Context, msg mailer.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+// Mailer passing messages sent in the background to the test
+type chanMailer struct {
+	sent chan mailer.Message
+	err  error
+}
+
+func (m *chanMailer) Send(ctx context.Context, msg mailer.Message) error {
+	m.sent <- msg
+	return m.err
+}
+
+// Wait for a message sent in the background
+func (m *chanMailer) wait(t *testing.T) mailer.Message {
+	t.Helper()
+
+	select {
+	case msg := <-m.sent:
+		return msg
+	case <-time.After(time.Second):
+		t.Fatal("message was not sent")
+		return mailer.Message{}
+	}
+}
+
+func TestAuthUseCase_ChangePassword(t *testing.T) {
+	cfg := &config.Config{Timeout: config.Timeout{Reauth: 5 * time.Minute}}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthRepo := mock_auth.NewMockRepository(ctrl)
+	authUC := NewAuthUseCase(cfg, mockAuthRepo, newTestKeys(t), mailer.NewLogMailer(logger), ratelimit.NewMemoryLimiter(), nil, logger)
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("correctpassword"), bcrypt.DefaultCost)
+	mockUser := &models.User{ID: "12345", PasswordHash: string(hashedPassword)}
+	oidcUser := &models.User{ID: "12345"}
+
+	tests := []struct {
+		name            string
+		currentPassword string
+		mockBehavior    func(mockAuthRepo *mock_auth.MockRepository)
+		wantErr         error
+	}{
+		{
+			name:            "success",
+			currentPassword: "correctpassword",
+			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {
+				mockAuthRepo.EXPECT().GetByID("12345").Return(mockUser, nil)
+				mockAuthRepo.EXPECT().UpdatePassword("12345", gomock.Any()).DoAndReturn(func(id, hash string) error {
+					require.NoError(t, bcrypt.CompareHashAndPassword([]byte(hash), []byte("newpassword")))
+					return nil
+				})
+				mockAuthRepo.EXPECT().RevokeOtherSessions("12345", "sid").Return(nil)
+			},
+		},
+		{
+			name:            "wrong current password",
+			currentPassword: "test",
+			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {
+				mockAuthRepo.EXPECT().GetByID("12345").Return(mockUser, nil)
+			},
+			wantErr: auth.ErrInvalidPassword,
+		},
+		{
+			name: "account without password sets one after a recent sign-in",
+			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {
+				mockAuthRepo.EXPECT().GetByID("12345").Return(oidcUser, nil)
+				mockAuthRepo.EXPECT().GetSession("sid").Return(&models.Session{ID: "sid", UserID: "12345", CreatedAt: time.Now().Add(-time.Minute)}, nil)
+				mockAuthRepo.EXPECT().UpdatePassword("12345", gomock.Any()).Return(nil)
+				mockAuthRepo.EXPECT().RevokeOtherSessions("12345", "sid").Return(nil)
+			},
+		},
+		{
+			name: "account without password signed in long ago",
+			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {
+				mockAuthRepo.EXPECT().GetByID("12345").Return(oidcUser, nil)
+				mockAuthRepo.EXPECT().GetSession("sid").Return(&models.Session{ID: "sid", UserID: "12345", CreatedAt: time.Now().Add(-time.Hour)}, nil)
+			},
+			wantErr: auth.ErrReauthRequired,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockAuthRepo)
+
+			err := authUC.ChangePassword("12345", "sid", tt.currentPassword, "newpassword")
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAuthUseCase_EmailChange(t *testing.T) {
+	cfg := &config.Config{
+		Mail: config.Mail{ConfirmEmailURL: "http://localhost/confirm"},
+		Timeout: config.Timeout{
+			EmailToken: time.Hour,
+		},
+	}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthRepo := mock_auth.NewMockRepository(ctrl)
+	mail := &testMailer{}
+	authUC := NewAuthUseCase(cfg, mockAuthRepo, newTestKeys(t), mail, ratelimit.NewMemoryLimiter(), nil, logger)
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("correctpassword"), bcrypt.DefaultCost)
+	mockUser := &models.User{ID: "12345", PasswordHash: string(hashedPassword)}
+
+	// Taken email
+	mockAuthRepo.EXPECT().GetByID("12345").Return(mockUser, nil)
+	mockAuthRepo.EXPECT().GetByEmail("taken@test.com").Return(&models.User{ID: "1"}, nil)
+
+	err := authUC.RequestEmailChange("12345", "sid", "correctpassword", "taken@test.com")
+	require.ErrorIs(t, err, db.ErrDuplicateEmail)
+	require.Empty(t, mail.sent)
+
+	// The confirmation link is mailed to the new email, only the token hash is stored
+	var stored *models.VerificationToken
+	mockAuthRepo.EXPECT().GetByID("12345").Return(mockUser, nil)
+	mockAuthRepo.EXPECT().GetByEmail("new@test.com").Return(nil, db.ErrRecordNotFound)
+	mockAuthRepo.EXPECT().CreateVerificationToken(gomock.Any()).DoAndReturn(func(token *models.VerificationToken) error {
+		stored = token
+		return nil
+	})
+
+	err = authUC.RequestEmailChange("12345", "sid", "correctpassword", "new@test.com")
+	require.NoError(t, err)
+	require.Len(t, mail.sent, 1)
+	require.Equal(t, "new@test.com", mail.sent[0].To)
+	require.Equal(t, models.PurposeEmailChange, stored.Purpose)
+	require.Equal(t, "new@test.com", stored.Payload)
+
+	_, token, found := strings.Cut(mail.sent[0].Body, "?token=")
+	require.True(t, found)
+	require.Equal(t, hashToken(token), stored.Hash)
+
+	// Confirming the token changes the email
+	mockAuthRepo.EXPECT().UseVerificationToken(stored.Hash, models.PurposeEmailChange).Return(stored, nil)
+	mockAuthRepo.EXPECT().UpdateEmail("12345", "new@test.com").Return(nil)
+
+	require.NoError(t, authUC.ConfirmEmailChange(token))
+
+	// A used or expired token is rejected
+	mockAuthRepo.EXPECT().UseVerificationToken(stored.Hash, models.PurposeEmailChange).Return(nil, db.ErrRecordNotFound)
+
+	require.ErrorIs(t, authUC.ConfirmEmailChange(token), auth.ErrInvalidToken)
+}
+
+func TestAuthUseCase_DeleteAccount(t *testing.T) {
+	cfg := &config.Config{Timeout: config.Timeout{Reauth: 5 * time.Minute}}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthRepo := mock_auth.NewMockRepository(ctrl)
+	authUC := NewAuthUseCase(cfg, mockAuthRepo, newTestKeys(t), mailer.NewLogMailer(logger), ratelimit.NewMemoryLimiter(), nil, logger)
+
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("correctpassword"), bcrypt.DefaultCost)
+	mockUser := &models.User{ID: "12345", PasswordHash: string(hashedPassword)}
+	oidcUser := &models.User{ID: "12345"}
+
+	tests := []struct {
+		name         string
+		password     string
+		mockBehavior func(mockAuthRepo *mock_auth.MockRepository)
+		wantErr      error
+	}{
+		{
+			name:     "success",
+			password: "correctpassword",
+			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {
+				mockAuthRepo.EXPECT().GetByID("12345").Return(mockUser, nil)
+				mockAuthRepo.EXPECT().Delete("12345", gomock.Any()).DoAndReturn(func(_ string, event *models.OutboxEvent) error {
+					var message kf.KafkaMessage
+					require.NoError(t, json.Unmarshal(event.Payload, &message))
+					require.Equal(t, "user_delete", message.Action)
+					return nil
+				})
+			},
+		},
+		{
+			name:     "wrong password",
+			password: "test",
+			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {
+				mockAuthRepo.EXPECT().GetByID("12345").Return(mockUser, nil)
+			},
+			wantErr: auth.ErrInvalidPassword,
+		},
+		{
+			name: "account without password after a recent sign-in",
+			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {
+				mockAuthRepo.EXPECT().GetByID("12345").Return(oidcUser, nil)
+				mockAuthRepo.EXPECT().GetSession("sid").Return(&models.Session{ID: "sid", UserID: "12345", CreatedAt: time.Now()}, nil)
+				mockAuthRepo.EXPECT().Delete("12345", gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:     "account without password ignores the password",
+			password: "anything",
+			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {
+				mockAuthRepo.EXPECT().GetByID("12345").Return(oidcUser, nil)
+				mockAuthRepo.EXPECT().GetSession("sid").Return(&models.Session{ID: "sid", UserID: "12345", CreatedAt: time.Now().Add(-time.Hour)}, nil)
+			},
+			wantErr: auth.ErrReauthRequired,
+		},
+		{
+			name: "account without password with a revoked session",
+			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {
+				revokedAt := time.Now()
+				mockAuthRepo.EXPECT().GetByID("12345").Return(oidcUser, nil)
+				mockAuthRepo.EXPECT().GetSession("sid").Return(&models.Session{ID: "sid", UserID: "12345", CreatedAt: time.Now(), RevokedAt: &revokedAt}, nil)
+			},
+			wantErr: auth.ErrReauthRequired,
+		},
+		{
+			name: "account without password with an unknown session",
+			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {
+				mockAuthRepo.EXPECT().GetByID("12345").Return(oidcUser, nil)
+				mockAuthRepo.EXPECT().GetSession("sid").Return(nil, db.ErrRecordNotFound)
+			},
+			wantErr: auth.ErrReauthRequired,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockAuthRepo)
+
+			err := authUC.DeleteAccount("12345", "sid", tt.password)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAuthUseCase_PasswordReset(t *testing.T) {
+	cfg := &config.Config{
+		Mail: config.Mail{ResetPasswordURL: "http://localhost/reset"},
+		Timeout: config.Timeout{
+			ResetToken: time.Hour,
+			MailSend:   time.Second,
+		},
+	}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthRepo := mock_auth.NewMockRepository(ctrl)
+	mail := &chanMailer{sent: make(chan mailer.Message, 1)}
+	authUC := NewAuthUseCase(cfg, mockAuthRepo, newTestKeys(t), mail, ratelimit.NewMemoryLimiter(), nil, logger)
+
+	// Unknown emails are not revealed
+	mockAuthRepo.EXPECT().GetByEmail("noname@test.com").Return(nil, db.ErrRecordNotFound)
+
+	require.NoError(t, authUC.ForgotPassword("noname@test.com"))
+	require.Empty(t, mail.sent)
+
+	// Previous links are dropped and a new one is mailed
+	var stored *models.VerificationToken
+	mockAuthRepo.EXPECT().GetByEmail("test@test.com").Return(&models.User{ID: "12345", Email: "test@test.com"}, nil)
+	mockAuthRepo.EXPECT().DeleteVerificationTokens("12345", models.PurposePasswordReset).Return(nil)
+	mockAuthRepo.EXPECT().CreateVerificationToken(gomock.Any()).DoAndReturn(func(token *models.VerificationToken) error {
+		stored = token
+		return nil
+	})
+
+	require.NoError(t, authUC.ForgotPassword("test@test.com"))
+	sent := mail.wait(t)
+	require.Equal(t, "test@test.com", sent.To)
+	require.Equal(t, models.PurposePasswordReset, stored.Purpose)
+	require.WithinDuration(t, time.Now().Add(time.Hour), stored.ExpiresAt, time.Minute)
+
+	_, rest, found := strings.Cut(sent.Body, "?token=")
+	require.True(t, found)
+	token, _, _ := strings.Cut(rest, "\n")
+	require.Equal(t, hashToken(token), stored.Hash)
+
+	// The token sets a new password and signs the user out everywhere
+	mockAuthRepo.EXPECT().UseVerificationToken(stored.Hash, models.PurposePasswordReset).Return(&models.VerificationToken{UserID: "12345"}, nil)
+	mockAuthRepo.EXPECT().UpdatePassword("12345", gomock.Any()).DoAndReturn(func(id, hash string) error {
+		require.NoError(t, bcrypt.CompareHashAndPassword([]byte(hash), []byte("newpassword")))
+		return nil
+	})
+	mockAuthRepo.EXPECT().RevokeUserSessions("12345").Return(nil)
+
+	require.NoError(t, authUC.ResetPassword(token, "newpassword"))
+
+	// The token is single-use
+	mockAuthRepo.EXPECT().UseVerificationToken(stored.Hash, models.PurposePasswordReset).Return(nil, db.ErrRecordNotFound)
+
+	require.ErrorIs(t, authUC.ResetPassword(token, "newpassword"), auth.ErrInvalidToken)
+}
+
+func TestAuthUseCase_ForgotPassword_MailError(t *testing.T) {
+	cfg := &config.Config{
+		Mail:    config.Mail{ResetPasswordURL: "http://localhost/reset"},
+		Timeout: config.Timeout{ResetToken: time.Hour, MailSend: time.Second},
+	}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthRepo := mock_auth.NewMockRepository(ctrl)
+	mail := &chanMailer{sent: make(chan mailer.Message, 1), err: errors.New("smtp error")}
+	authUC := NewAuthUseCase(cfg, mockAuthRepo, newTestKeys(t), mail, ratelimit.NewMemoryLimiter(), nil, logger)
+
+	mockAuthRepo.EXPECT().GetByEmail("test@test.com").Return(&models.User{ID: "12345", Email: "test@test.com"}, nil)
+	mockAuthRepo.EXPECT().DeleteVerificationTokens("12345", models.PurposePasswordReset).Return(nil)
+	mockAuthRepo.EXPECT().CreateVerificationToken(gomock.Any()).Return(nil)
+
+	// A failed mail is only logged, the reply does not depend on the mailer
+	require.NoError(t, authUC.ForgotPassword("test@test.com"))
+	require.Equal(t, "test@test.com", mail.wait(t).To)
+}
+
+func TestAuthUseCase_VerifyEmail(t *testing.T) {
+	cfg := &config.Config{}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthRepo := mock_auth.NewMockRepository(ctrl)
+	authUC := NewAuthUseCase(cfg, mockAuthRepo, newTestKeys(t), &testMailer{}, ratelimit.NewMemoryLimiter(), nil, logger)
+
+	verification := &models.VerificationToken{UserID: "12345", Payload: "test@test.com"}
+
+	tests := []struct {
+		name         string
+		mockBehavior func(mockAuthRepo *mock_auth.MockRepository)
+		wantErr      error
+	}{
+		{
+			name: "verified",
+			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {
+				mockAuthRepo.EXPECT().UseVerificationToken(hashToken("token"), models.PurposeEmailVerification).Return(verification, nil)
+				mockAuthRepo.EXPECT().VerifyEmail("12345", "test@test.com").Return(nil)
+			},
+		},
+		{
+			name: "used or expired token",
+			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {
+				mockAuthRepo.EXPECT().UseVerificationToken(hashToken("token"), models.PurposeEmailVerification).Return(nil, db.ErrRecordNotFound)
+			},
+			wantErr: auth.ErrInvalidToken,
+		},
+		{
+			name: "email changed since the token was sent",
+			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {
+				mockAuthRepo.EXPECT().UseVerificationToken(hashToken("token"), models.PurposeEmailVerification).Return(verification, nil)
+				mockAuthRepo.EXPECT().VerifyEmail("12345", "test@test.com").Return(db.ErrRecordNotFound)
+			},
+			wantErr: auth.ErrInvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockAuthRepo)
+
+			err := authUC.VerifyEmail("token")
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestAuthUseCase_SendVerificationEmail(t *testing.T) {
+	cfg := &config.Config{
+		Timeout: config.Timeout{
+			EmailToken: time.Hour,
+			MailSend:   time.Second,
+		},
+	}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthRepo := mock_auth.NewMockRepository(ctrl)
+	mail := &testMailer{}
+	authUC := NewAuthUseCase(cfg, mockAuthRepo, newTestKeys(t), mail, ratelimit.NewMemoryLimiter(), nil, logger)
+
+	mockAuthRepo.EXPECT().GetByID("1").Return(&models.User{ID: "1", Email: "verified@test.com", Verified: true}, nil)
+
+	require.ErrorIs(t, authUC.SendVerificationEmail("1"), auth.ErrEmailVerified)
+	require.Empty(t, mail.sent)
+
+	mockAuthRepo.EXPECT().GetByID("2").Return(&models.User{ID: "2", Email: "new@test.com"}, nil)
+	mockAuthRepo.EXPECT().CreateVerificationToken(gomock.Any()).Return(nil)
+
+	require.NoError(t, authUC.SendVerificationEmail("2"))
+	require.Len(t, mail.sent, 1)
+	require.Equal(t, "new@test.com", mail.sent[0].To)
+}
+
+func TestAuthUseCase_CreateAPIKey(t *testing.T) {
+	cfg := &config.Config{}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthRepo := mock_auth.NewMockRepository(ctrl)
+	authUC := NewAuthUseCase(cfg, mockAuthRepo, newTestKeys(t), mailer.NewLogMailer(logger), ratelimit.NewMemoryLimiter(), nil, logger)
+
+	roles := []models.Role{{Name: "catalog-editor", Permissions: []string{"products:write"}}}
+
+	tests := []struct {
+		name         string
+		scopes       []string
+		ttl          time.Duration
+		mockBehavior func(mockAuthRepo *mock_auth.MockRepository)
+		wantErr      error
+	}{
+		{
+			name:   "success",
+			scopes: []string{"products:write"},
+			ttl:    24 * time.Hour,
+			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {
+				mockAuthRepo.EXPECT().GetByID("12345").Return(&models.User{ID: "12345"}, nil)
+				mockAuthRepo.EXPECT().GetUserRoles("12345").Return(roles, nil)
+				mockAuthRepo.EXPECT().CreateAPIKey(gomock.Any()).DoAndReturn(func(key *models.APIKey) error {
+					require.Equal(t, "12345", key.UserID)
+					require.Equal(t, []string{"products:write"}, key.Scopes)
+					require.NotNil(t, key.ExpiresAt)
+					key.ID = "1"
+					return nil
+				})
+			},
+		},
+		{
+			name:   "scope not granted to the user",
+			scopes: []string{"products:delete"},
+			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {
+				mockAuthRepo.EXPECT().GetByID("12345").Return(&models.User{ID: "12345"}, nil)
+				mockAuthRepo.EXPECT().GetUserRoles("12345").Return(roles, nil)
+			},
+			wantErr: auth.ErrInvalidScope,
+		},
+		{
+			name:   "wildcard scope wider than the user's permissions",
+			scopes: []string{"products:*"},
+			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {
+				mockAuthRepo.EXPECT().GetByID("12345").Return(&models.User{ID: "12345"}, nil)
+				mockAuthRepo.EXPECT().GetUserRoles("12345").Return(roles, nil)
+			},
+			wantErr: auth.ErrInvalidScope,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockAuthRepo)
+
+			key, plainKey, err := authUC.CreateAPIKey("12345", "storefront", tt.scopes, tt.ttl)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.True(t, strings.HasPrefix(plainKey, key.Prefix+"_"))
+			require.True(t, strings.HasPrefix(key.Prefix, models.APIKeyPrefix))
+			require.Equal(t, hashToken(plainKey), key.Hash)
+		})
+	}
+}
+
+func TestAuthUseCase_AuthenticateAPIKey(t *testing.T) {
+	cfg := &config.Config{}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockAuthRepo := mock_auth.NewMockRepository(ctrl)
+	authUC := NewAuthUseCase(cfg, mockAuthRepo, newTestKeys(t), mailer.NewLogMailer(logger), ratelimit.NewMemoryLimiter(), nil, logger)
+
+	const plainKey = "rk_0a1b2c3d_secret"
+	past := time.Now().Add(-time.Hour)
+	recent := time.Now().Add(-time.Second)
+
+	newKey := func(modify func(key *models.APIKey)) *models.APIKey {
+		key := &models.APIKey{ID: "1", UserID: "12345", Scopes: []string{"products:delete", "products:write"}, LastUsedAt: &recent}
+		if modify != nil {
+			modify(key)
+		}
+		return key
+	}
+
+	tests := []struct {
+		name            string
+		key             string
+		mockBehavior    func(mockAuthRepo *mock_auth.MockRepository)
+		wantPermissions []string
+		wantErr         error
+	}{
+		{
+			name: "scopes the owner no longer has are dropped",
+			key:  plainKey,
+			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {
+				mockAuthRepo.EXPECT().GetAPIKeyByHash(hashToken(plainKey)).Return(newKey(nil), nil)
+				mockAuthRepo.EXPECT().GetUserRoles("12345").Return([]models.Role{{Name: "catalog-editor", Permissions: []string{"products:write"}}}, nil)
+			},
+			wantPermissions: []string{"products:write"},
+		},
+		{
+			name: "usage is recorded once the interval passed",
+			key:  plainKey,
+			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {
+				mockAuthRepo.EXPECT().GetAPIKeyByHash(hashToken(plainKey)).Return(newKey(func(key *models.APIKey) { key.LastUsedAt = &past }), nil)
+				mockAuthRepo.EXPECT().GetUserRoles("12345").Return([]models.Role{{Name: "admin", Permissions: []string{"*"}}}, nil)
+				mockAuthRepo.EXPECT().TouchAPIKey("1", gomock.Any()).Return(nil)
+			},
+			wantPermissions: []string{"products:delete", "products:write"},
+		},
+		{
+			name: "revoked key",
+			key:  plainKey,
+			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {
+				mockAuthRepo.EXPECT().GetAPIKeyByHash(hashToken(plainKey)).Return(newKey(func(key *models.APIKey) { key.RevokedAt = &past }), nil)
+			},
+			wantErr: auth.ErrInvalidAPIKey,
+		},
+		{
+			name: "expired key",
+			key:  plainKey,
+			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {
+				mockAuthRepo.EXPECT().GetAPIKeyByHash(hashToken(plainKey)).Return(newKey(func(key *models.APIKey) { key.ExpiresAt = &past }), nil)
+			},
+			wantErr: auth.ErrInvalidAPIKey,
+		},
+		{
+			name: "unknown key",
+			key:  plainKey,
+			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {
+				mockAuthRepo.EXPECT().GetAPIKeyByHash(hashToken(plainKey)).Return(nil, db.ErrRecordNotFound)
+			},
+			wantErr: auth.ErrInvalidAPIKey,
+		},
+		{
+			name:         "not an api key",
+			key:          "token",
+			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {},
+			wantErr:      auth.ErrInvalidAPIKey,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockAuthRepo)
+
+			identity, err := authUC.AuthenticateAPIKey(tt.key)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				require.Nil(t, identity)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, "12345", identity.UserUID)
+			require.False(t, identity.IsAdmin)
+			require.Equal(t, tt.wantPermissions, identity.Permissions)
+		})
+	}
+}
+
+func TestAuthUseCase_OIDCLogin(t *testing.T) {
+	idp := oidctest.NewProvider("auth", "secret", oidctest.User{})
+	t.Cleanup(idp.Close)
+
+	cfg := &config.Config{
+		Timeout: config.Timeout{
+			Token:        time.Hour,
+			RefreshToken: 24 * time.Hour,
+			OIDCRequest:  time.Second,
+			OIDCState:    10 * time.Minute,
+		},
+	}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	providers := map[string]*oidc.Provider{
+		"test": oidc.NewProvider(oidc.Config{
+			Issuer:       idp.Issuer(),
+			ClientID:     "auth",
+			ClientSecret: "secret",
+			RedirectURL:  "http://localhost/auth/oidc/test/callback",
+		}, time.Second),
+	}
+
+	mockAuthRepo := mock_auth.NewMockRepository(ctrl)
+	authUC := NewAuthUseCase(cfg, mockAuthRepo, newTestKeys(t), mailer.NewLogMailer(logger), ratelimit.NewMemoryLimiter(), providers, logger)
+
+	verifiedUser := oidctest.User{Subject: "sub-1", Email: "jane@example.com", EmailVerified: true, Name: "Jane"}
+
+	tests := []struct {
+		name         string
+		user         oidctest.User
+		mockBehavior func(mockAuthRepo *mock_auth.MockRepository)
+		wantErr      error
+	}{
+		{
+			name: "linked identity",
+			user: verifiedUser,
+			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {
+				mockAuthRepo.EXPECT().GetByIdentity("test", "sub-1").Return(&models.User{ID: "12345"}, nil)
+				mockAuthRepo.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Return(nil)
+				mockAuthRepo.EXPECT().GetUserRoles("12345").Return(nil, nil)
+			},
+		},
+		{
+			name: "linked to the account with the same verified email",
+			user: verifiedUser,
+			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {
+				mockAuthRepo.EXPECT().GetByIdentity("test", "sub-1").Return(nil, db.ErrRecordNotFound)
+				mockAuthRepo.EXPECT().GetByEmail("jane@example.com").Return(&models.User{ID: "12345", Verified: true}, nil)
+				mockAuthRepo.EXPECT().LinkIdentity(&models.UserIdentity{Provider: "test", Subject: "sub-1", UserID: "12345", Email: "jane@example.com"}).Return(nil)
+				mockAuthRepo.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Return(nil)
+				mockAuthRepo.EXPECT().GetUserRoles("12345").Return(nil, nil)
+			},
+		},
+		{
+			name: "new user is created",
+			user: verifiedUser,
+			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {
+				mockAuthRepo.EXPECT().GetByIdentity("test", "sub-1").Return(nil, db.ErrRecordNotFound)
+				mockAuthRepo.EXPECT().GetByEmail("jane@example.com").Return(nil, db.ErrRecordNotFound)
+				mockAuthRepo.EXPECT().Insert(gomock.Any(), gomock.Any()).DoAndReturn(func(user *models.User, event *models.OutboxEvent) (string, error) {
+					require.Equal(t, "jane@example.com", user.Email)
+					require.True(t, user.Verified)
+
+					var message kf.KafkaMessage
+					require.NoError(t, json.Unmarshal(event.Payload, &message))
+					require.Equal(t, "user_registered", message.Action)
+					require.Equal(t, "Jane", message.Name)
+
+					user.ID = "7543"
+					return user.ID, nil
+				})
+				mockAuthRepo.EXPECT().LinkIdentity(&models.UserIdentity{Provider: "test", Subject: "sub-1", UserID: "7543", Email: "jane@example.com"}).Return(nil)
+				mockAuthRepo.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Return(nil)
+				mockAuthRepo.EXPECT().GetUserRoles("7543").Return(nil, nil)
+			},
+		},
+		{
+			name: "unverified account with the same email",
+			user: verifiedUser,
+			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {
+				mockAuthRepo.EXPECT().GetByIdentity("test", "sub-1").Return(nil, db.ErrRecordNotFound)
+				mockAuthRepo.EXPECT().GetByEmail("jane@example.com").Return(&models.User{ID: "12345"}, nil)
+			},
+			wantErr: auth.ErrAccountConflict,
+		},
+		{
+			name: "email not verified by the provider",
+			user: oidctest.User{Subject: "sub-2", Email: "john@example.com"},
+			mockBehavior: func(mockAuthRepo *mock_auth.MockRepository) {
+				mockAuthRepo.EXPECT().GetByIdentity("test", "sub-2").Return(nil, db.ErrRecordNotFound)
+			},
+			wantErr: auth.ErrEmailNotVerified,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			idp.SetUser(tt.user)
+
+			var stored *models.OIDCRequest
+			mockAuthRepo.EXPECT().CreateOIDCRequest(gomock.Any()).DoAndReturn(func(req *models.OIDCRequest) error {
+				stored = req
+				return nil
+			})
+
+			authURL, state, err := authUC.StartOIDCLogin("test")
+			require.NoError(t, err)
+			require.Equal(t, hashToken(state), stored.StateHash)
+
+			code, returnedState, err := idp.Authorize(authURL)
+			require.NoError(t, err)
+			require.Equal(t, state, returnedState)
+
+			mockAuthRepo.EXPECT().UseOIDCRequest(hashToken(state), "test").Return(stored, nil)
+			tt.mockBehavior(mockAuthRepo)
+
+			tokens, err := authUC.CompleteOIDCLogin("test", state, code)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				require.Nil(t, tokens)
+			} else {
+				require.NoError(t, err)
+				require.NotEmpty(t, tokens.Access)
+				require.NotEmpty(t, tokens.Refresh)
+			}
+		})
+	}
+
+	t.Run("unknown or used state", func(t *testing.T) {
+		mockAuthRepo.EXPECT().UseOIDCRequest(hashToken("state"), "test").Return(nil, db.ErrRecordNotFound)
+
+		_, err := authUC.CompleteOIDCLogin("test", "state", "code")
+		require.ErrorIs(t, err, auth.ErrInvalidToken)
+	})
+
+	t.Run("code is not accepted twice", func(t *testing.T) {
+		idp.SetUser(verifiedUser)
+
+		var stored *models.OIDCRequest
+		mockAuthRepo.EXPECT().CreateOIDCRequest(gomock.Any()).DoAndReturn(func(req *models.OIDCRequest) error {
+			stored = req
+			return nil
+		})
+
+		authURL, state, err := authUC.StartOIDCLogin("test")
+		require.NoError(t, err)
+		code, _, err := idp.Authorize(authURL)
+		require.NoError(t, err)
+
+		mockAuthRepo.EXPECT().UseOIDCRequest(hashToken(state), "test").Return(stored, nil).Times(2)
+		mockAuthRepo.EXPECT().GetByIdentity("test", "sub-1").Return(&models.User{ID: "12345"}, nil)
+		mockAuthRepo.EXPECT().CreateSession(gomock.Any(), gomock.Any()).Return(nil)
+		mockAuthRepo.EXPECT().GetUserRoles("12345").Return(nil, nil)
+
+		_, err = authUC.CompleteOIDCLogin("test", state, code)
+		require.NoError(t, err)
+
+		_, err = authUC.CompleteOIDCLogin("test", state, code)
+		require.ErrorIs(t, err, auth.ErrExternalLogin)
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		_, _, err := authUC.StartOIDCLogin("other")
+		require.ErrorIs(t, err, auth.ErrUnknownProvider)
+	})
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+
+	erp "cyansnbrst/auth-service/pkg/error_responses"
+)
+
+// Require authenticated user middleware, validates the access token including session revocation
+func (mw *MiddlewareManager) RequireAuthenticatedUser(next http.HandlerFunc) http.HandlerFunc {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("token")
+		if err != nil {
+			erp.AuthenticationRequiredResponse(w, r, mw.logger)
+			return
+		}
+
+		identity, err := mw.authUC.ValidateToken(cookie.Value)
+		if err != nil {
+			erp.InvalidAuthenticationTokenResponse(w, r, mw.logger)
+			return
+		}
+
+		next.ServeHTTP(w, ContextSetIdentity(r, identity))
+	})
+}
+
+// Require permission middleware
+func (mw *MiddlewareManager) RequirePermission(permission string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return mw.RequireAuthenticatedUser(func(w http.ResponseWriter, r *http.Request) {
+			if !ContextGetIdentity(r).HasPermission(permission) {
+				erp.NotPermittedResponse(w, r, mw.logger)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"cyansnbrst/auth-service/internal/models"
+)
+
+type contextKey string
+
+const IdentityContextKey = contextKey("identity")
+
+// Set user's identity to context
+func ContextSetIdentity(r *http.Request, identity *models.Identity) *http.Request {
+	ctx := context.WithValue(r.Context(), IdentityContextKey, identity)
+	return r.WithContext(ctx)
+}
+
+// Get user's identity from context
+func ContextGetIdentity(r *http.Request) *models.Identity {
+	identity, ok := r.Context().Value(IdentityContextKey).(*models.Identity)
+	if !ok {
+		panic("missing identity value in request context")
+	}
+
+	return identity
+}
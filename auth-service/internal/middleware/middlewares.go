@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"go.uber.org/zap"
+
+	"cyansnbrst/auth-service/config"
+	"cyansnbrst/auth-service/internal/auth"
+	"cyansnbrst/auth-service/pkg/ratelimit"
+)
+
+// Middleware manager struct
+type MiddlewareManager struct {
+	cfg     *config.Config
+	authUC  auth.UseCase
+	limiter ratelimit.Limiter
+	logger  *zap.Logger
+}
+
+// Middleware manager constructor
+func NewMiddlewareManager(cfg *config.Config, authUC auth.UseCase, limiter ratelimit.Limiter, logger *zap.Logger) *MiddlewareManager {
+	return &MiddlewareManager{cfg: cfg, authUC: authUC, limiter: limiter, logger: logger}
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	erp "cyansnbrst/auth-service/pkg/error_responses"
+	"cyansnbrst/auth-service/pkg/ratelimit"
+)
+
+// Rate limit middleware, limits requests per client IP, name separates the counters of different routes
+func (mw *MiddlewareManager) RateLimit(name string) func(http.HandlerFunc) http.HandlerFunc {
+	limit := ratelimit.Limit{Requests: mw.cfg.RateLimit.IPRequests, Window: mw.cfg.RateLimit.IPWindow}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			result, err := mw.limiter.Allow(r.Context(), "ip:"+name+":"+mw.clientIP(r), limit)
+			if err != nil {
+				erp.ServerErrorResponse(w, r, mw.logger, err)
+				return
+			}
+
+			if !result.Allowed {
+				erp.RateLimitExceededResponse(w, r, mw.logger, result.RetryAfter)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Get client IP, behind the proxy it is the last X-Forwarded-For entry appended by the proxy itself
+func (mw *MiddlewareManager) clientIP(r *http.Request) string {
+	if mw.cfg.RateLimit.TrustProxy {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			parts := strings.Split(forwarded, ",")
+			if ip := strings.TrimSpace(parts[len(parts)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
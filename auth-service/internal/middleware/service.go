@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	erp "cyansnbrst/auth-service/pkg/error_responses"
+)
+
+// Require service middleware, restricts internal routes to the given calling services.
+// Every rejection is 403, so that 401 on these routes always refers to the user token.
+func (mw *MiddlewareManager) RequireService(callers ...string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || token == "" {
+				erp.NotPermittedResponse(w, r, mw.logger)
+				return
+			}
+
+			caller, err := mw.authUC.ValidateServiceToken(token)
+			if err != nil || !slices.Contains(callers, caller) {
+				erp.NotPermittedResponse(w, r, mw.logger)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// API key prefix, lets clients tell keys from access tokens
+const APIKeyPrefix = "rk_"
+
+// API key struct, only the hash of the key is stored and the prefix identifies it in lists
+type APIKey struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"-"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Hash       []byte     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
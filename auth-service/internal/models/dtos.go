@@ -1,5 +1,11 @@
 package models
 
+import (
+	"time"
+
+	"cyansnbrst/auth-service/pkg/jwks"
+)
+
 // Register user DTO struct
 type RegisterUserDTO struct {
 	Email    string `json:"email"`
@@ -13,9 +19,56 @@ type LoginUserDTO struct {
 	Password string `json:"password"`
 }
 
-// Create profile DTO struct
-type CreateProfileDTO struct {
-	Name string `json:"name"`
+// Change password DTO struct
+type ChangePasswordDTO struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// Change email DTO struct
+type ChangeEmailDTO struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// Confirm email DTO struct
+type ConfirmEmailDTO struct {
+	Token string `json:"token"`
+}
+
+// Verify email DTO struct
+type VerifyEmailDTO struct {
+	Token string `json:"token"`
+}
+
+// Forgot password DTO struct
+type ForgotPasswordDTO struct {
+	Email string `json:"email"`
+}
+
+// Reset password DTO struct
+type ResetPasswordDTO struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+// Delete account DTO struct
+type DeleteAccountDTO struct {
+	Password string `json:"password"`
+}
+
+// Service token request DTO struct
+type ServiceTokenDTO struct {
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	Audience     string `json:"audience"`
+}
+
+// Create API key DTO struct
+type CreateAPIKeyDTO struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"` // 0 means the key doesn't expire
 }
 
 // Error response
@@ -28,8 +81,53 @@ type SuccessResponse struct {
 	Message string `json:"message"`
 }
 
+// Signing keys response
+type JWKSResponse struct {
+	Keys []jwks.JWK `json:"keys"`
+}
+
+// Service token response
+type ServiceTokenResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Revoked sessions response
+type RevokedSessionsResponse struct {
+	SessionIDs []string `json:"session_ids"`
+}
+
 // User's data response
 type UserResponse struct {
-	UserUID string `json:"user_uid"`
-	IsAdmin bool   `json:"is_admin"`
+	UserUID     string   `json:"user_uid"`
+	IsAdmin     bool     `json:"is_admin"`
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
+
+// Current user's account response
+type AccountResponse struct {
+	UserUID     string    `json:"user_uid"`
+	Email       string    `json:"email"`
+	Verified    bool      `json:"email_verified"`
+	IsAdmin     bool      `json:"is_admin"`
+	Roles       []string  `json:"roles"`
+	Permissions []string  `json:"permissions"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Roles list response
+type RolesResponse struct {
+	Roles []Role `json:"roles"`
+}
+
+// Created API key response, the key is only shown once
+type CreateAPIKeyResponse struct {
+	APIKey APIKey `json:"api_key"`
+	Key    string `json:"key"`
+}
+
+// API keys list response
+type APIKeysResponse struct {
+	APIKeys []APIKey `json:"api_keys"`
 }
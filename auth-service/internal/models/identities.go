@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// OpenID Connect authorization request, kept from the redirect to the provider until the callback.
+// Only the hash of the state is stored.
+type OIDCRequest struct {
+	StateHash    []byte
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+}
+
+// External identity linked to a user
+type UserIdentity struct {
+	Provider  string
+	Subject   string
+	UserID    string
+	Email     string
+	CreatedAt time.Time
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// Event kept in the outbox until it is published to the user topic
+type OutboxEvent struct {
+	ID        int64
+	Key       string
+	Payload   []byte
+	CreatedAt time.Time
+}
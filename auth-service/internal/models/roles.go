@@ -0,0 +1,35 @@
+package models
+
+import "strings"
+
+// Admin role name, users with it get the is_admin token claim
+const AdminRole = "admin"
+
+// Role struct
+type Role struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// Authenticated user identity taken from an access token
+type Identity struct {
+	UserUID     string   `json:"user_uid"`
+	IsAdmin     bool     `json:"is_admin"`
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+	SessionID   string   `json:"-"`
+}
+
+// Check if the identity has the permission, "*" grants everything and "products:*" grants every products permission
+func (i *Identity) HasPermission(permission string) bool {
+	for _, p := range i.Permissions {
+		if p == "*" || p == permission {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(p, "*"); ok && strings.HasPrefix(permission, prefix) {
+			return true
+		}
+	}
+	return false
+}
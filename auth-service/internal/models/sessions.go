@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Auth session struct, shared by every refresh token issued after a single login
+type Session struct {
+	ID        string
+	UserID    string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+}
+
+// Refresh token struct, only the hash of the token is stored
+type RefreshToken struct {
+	Hash      []byte
+	SessionID string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// Issued access and refresh tokens
+type Tokens struct {
+	Access           string
+	AccessExpiresAt  time.Time
+	Refresh          string
+	RefreshExpiresAt time.Time
+}
@@ -9,5 +9,8 @@ type User struct {
 	Name         string // not stored in db
 	PasswordHash string
 	IsAdmin      bool
+	Verified     bool     // email is confirmed
+	Roles        []string // stored in user_roles
+	Permissions  []string // granted by roles
 	CreatedAt    time.Time
 }
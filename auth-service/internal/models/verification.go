@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Verification token purposes
+const (
+	PurposeEmailChange       = "email_change"
+	PurposeEmailVerification = "email_verification"
+	PurposePasswordReset     = "password_reset"
+)
+
+// Single-use token sent by email, only the hash of the token is stored
+type VerificationToken struct {
+	Hash      []byte
+	UserID    string
+	Purpose   string
+	Payload   string // the email the token was sent to
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
@@ -1,31 +1,62 @@
 package server
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/julienschmidt/httprouter"
 	httpSwagger "github.com/swaggo/http-swagger/v2"
 
 	authHttp "cyansnbrst/auth-service/internal/auth/delivery/http"
+	"cyansnbrst/auth-service/internal/auth/delivery/outbox"
 	authRepository "cyansnbrst/auth-service/internal/auth/repository"
 	authUseCase "cyansnbrst/auth-service/internal/auth/usecase"
+	"cyansnbrst/auth-service/internal/middleware"
+	"cyansnbrst/auth-service/pkg/oidc"
+	"cyansnbrst/auth-service/pkg/ratelimit"
 )
 
 // Register server handlers
-func (s *Server) RegisterHandlers() http.Handler {
+func (s *Server) RegisterHandlers(ctx context.Context) http.Handler {
 	router := httprouter.New()
 
 	// Init repository
 	authRepo := authRepository.NewAuthRepository(s.config, s.db)
 
+	// Init rate limiter, falls back to per-instance limits while redis is unavailable
+	limiter := ratelimit.NewFallbackLimiter(
+		ratelimit.NewRedisLimiter(s.redisClient, s.config.Timeout.RedisAction),
+		ratelimit.NewMemoryLimiter(),
+		s.logger,
+	)
+
+	// Init external identity providers
+	providers := make(map[string]*oidc.Provider, len(s.config.OIDC.Providers))
+	for name, provider := range s.config.OIDC.Providers {
+		providers[name] = oidc.NewProvider(oidc.Config{
+			Issuer:       provider.Issuer,
+			ClientID:     provider.ClientID,
+			ClientSecret: provider.ClientSecret,
+			RedirectURL:  provider.RedirectURL,
+			Scopes:       provider.Scopes,
+		}, s.config.Timeout.OIDCRequest)
+	}
+
 	// Init use case
-	authUC := authUseCase.NewAuthUseCase(s.config, authRepo, s.logger)
+	authUC := authUseCase.NewAuthUseCase(s.config, authRepo, s.keys, s.mailer, limiter, providers, s.logger)
 
 	// Init handlers
 	authHandlers := authHttp.NewAuthHandlers(s.config, authUC, s.logger)
 
+	// Run outbox relay
+	relay := outbox.NewRelay(s.config, authUC, s.kafkaWriter, s.logger)
+	go relay.Run(ctx)
+
+	// Init middleware
+	mw := middleware.NewMiddlewareManager(s.config, authUC, limiter, s.logger)
+
 	// Register auth routes
-	authHttp.RegisterAuthRoutes(router, authHandlers)
+	authHttp.RegisterAuthRoutes(router, authHandlers, mw)
 
 	// Swagger
 	router.ServeFiles("/auth/docs/*filepath", http.Dir("docs"))
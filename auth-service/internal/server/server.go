@@ -10,33 +10,49 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/go-redis/redis/v8"
+	"github.com/segmentio/kafka-go"
 	"go.uber.org/zap"
 
 	"cyansnbrst/auth-service/config"
+	"cyansnbrst/auth-service/pkg/jwks"
+	"cyansnbrst/auth-service/pkg/mailer"
 )
 
 // Server struct
 type Server struct {
-	config *config.Config
-	logger *zap.Logger
-	db     *sql.DB
+	config      *config.Config
+	logger      *zap.Logger
+	db          *sql.DB
+	keys        *jwks.KeySet
+	mailer      mailer.Mailer
+	kafkaWriter *kafka.Writer
+	redisClient *redis.Client
 }
 
 // New server constructor
-func NewServer(cfg *config.Config, logger *zap.Logger, db *sql.DB) *Server {
+func NewServer(cfg *config.Config, logger *zap.Logger, db *sql.DB, keys *jwks.KeySet, mailer mailer.Mailer, kafkaWriter *kafka.Writer, redisClient *redis.Client) *Server {
 	return &Server{
-		config: cfg,
-		logger: logger,
-		db:     db,
+		config:      cfg,
+		logger:      logger,
+		db:          db,
+		keys:        keys,
+		mailer:      mailer,
+		kafkaWriter: kafkaWriter,
+		redisClient: redisClient,
 	}
 }
 
 // Run server
 func (s *Server) Run() error {
+	// Background workers stop after the server has shut down
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
 	addr := fmt.Sprintf(":%d", s.config.Port)
 	server := &http.Server{
 		Addr:         addr,
-		Handler:      s.RegisterHandlers(),
+		Handler:      s.RegisterHandlers(workersCtx),
 		IdleTimeout:  s.config.Timeout.ServerIdle,
 		ReadTimeout:  s.config.Timeout.ServerRead,
 		WriteTimeout: s.config.Timeout.ServerWrite,
@@ -58,6 +74,7 @@ func (s *Server) Run() error {
 		defer cancel()
 
 		err := server.Shutdown(ctx)
+		stopWorkers()
 		if err != nil {
 			shutDownError <- err
 		}
@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS roles (
+    name TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role TEXT NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+    permission TEXT NOT NULL,
+    PRIMARY KEY (role, permission)
+);
+
+CREATE TABLE IF NOT EXISTS user_roles (
+    user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role TEXT NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, role)
+);
+
+CREATE INDEX IF NOT EXISTS user_roles_role_idx ON user_roles (role);
+
+INSERT INTO roles (name, description) VALUES
+    ('admin', 'Full access'),
+    ('catalog-editor', 'Creates and edits products'),
+    ('merchandiser', 'Manages the catalog including removals'),
+    ('analyst', 'Reads analytics'),
+    ('support', 'Reads user accounts and profiles');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', '*'),
+    ('catalog-editor', 'products:write'),
+    ('merchandiser', 'products:write'),
+    ('merchandiser', 'products:delete'),
+    ('analyst', 'analytics:read'),
+    ('support', 'users:read'),
+    ('support', 'profiles:read');
+
+INSERT INTO user_roles (user_id, role)
+SELECT id, 'admin' FROM users WHERE is_admin;
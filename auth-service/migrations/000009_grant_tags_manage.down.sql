@@ -0,0 +1,2 @@
+DELETE FROM role_permissions
+WHERE role = 'merchandiser' AND permission = 'tags:manage';
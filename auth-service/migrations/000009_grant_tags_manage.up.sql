@@ -0,0 +1,3 @@
+INSERT INTO role_permissions (role, permission) VALUES
+    ('merchandiser', 'tags:manage')
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS sessions_revoked_at_idx;
+DROP TABLE IF EXISTS revoked_sessions;
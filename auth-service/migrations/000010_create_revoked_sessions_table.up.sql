@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS revoked_sessions (
+    id TEXT PRIMARY KEY,
+    revoked_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS revoked_sessions_revoked_at_idx ON revoked_sessions (revoked_at);
+
+CREATE INDEX IF NOT EXISTS sessions_revoked_at_idx ON sessions (revoked_at);
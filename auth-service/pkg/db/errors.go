@@ -6,4 +6,5 @@ import "errors"
 var (
 	ErrRecordNotFound = errors.New("record not found")
 	ErrDuplicateEmail = errors.New("duplicate email")
+	ErrTokenReused    = errors.New("refresh token reuse detected")
 )
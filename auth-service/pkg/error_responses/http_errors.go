@@ -2,9 +2,11 @@ package erp
 
 import (
 	"net/http"
+	"time"
 
 	"go.uber.org/zap"
 
+	"cyansnbrst/auth-service/pkg/ratelimit"
 	"cyansnbrst/auth-service/pkg/utils"
 )
 
@@ -52,6 +54,11 @@ func InvalidAuthenticationTokenResponse(w http.ResponseWriter, r *http.Request,
 	errorResponse(w, r, http.StatusUnauthorized, message, l)
 }
 
+func ReauthenticationRequiredResponse(w http.ResponseWriter, r *http.Request, l *zap.Logger) {
+	message := "sign in again to confirm this action"
+	errorResponse(w, r, http.StatusUnauthorized, message, l)
+}
+
 func AuthenticationRequiredResponse(w http.ResponseWriter, r *http.Request, l *zap.Logger) {
 	message := "you must be authenticated to access this resource"
 	errorResponse(w, r, http.StatusUnauthorized, message, l)
@@ -61,3 +68,14 @@ func NotPermittedResponse(w http.ResponseWriter, r *http.Request, l *zap.Logger)
 	message := "your user account doesnt't have the necessare permissions to access this resource"
 	errorResponse(w, r, http.StatusForbidden, message, l)
 }
+
+func RateLimitExceededResponse(w http.ResponseWriter, r *http.Request, l *zap.Logger, retryAfter time.Duration) {
+	w.Header().Set("Retry-After", ratelimit.RetryAfterSeconds(retryAfter))
+
+	message := "rate limit exceeded"
+	errorResponse(w, r, http.StatusTooManyRequests, message, l)
+}
+
+func ConflictResponse(w http.ResponseWriter, r *http.Request, l *zap.Logger, err error) {
+	errorResponse(w, r, http.StatusConflict, err.Error(), l)
+}
@@ -0,0 +1,194 @@
+package jwks
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Key set errors
+var (
+	ErrNoKeys     = errors.New("no signing keys found")
+	ErrUnknownKey = errors.New("unknown key id")
+)
+
+// Signing key
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.Signer
+}
+
+// Set of signing keys, only the active key signs new tokens while every key is published for verification
+type KeySet struct {
+	keys   map[string]*Key
+	active *Key
+}
+
+// JSON Web Key
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// JSON Web Key Set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Load PEM encoded private keys from the directory, the file name without extension is used as key ID.
+// If activeID is empty the last key in lexical order becomes active.
+func Load(dir, activeID string) (*KeySet, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.pem"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, ErrNoKeys
+	}
+	sort.Strings(files)
+
+	set := &KeySet{keys: make(map[string]*Key, len(files))}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		id := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		key, err := parseKey(id, data)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", id, err)
+		}
+
+		set.keys[id] = key
+		set.active = key
+	}
+
+	if activeID != "" {
+		key, ok := set.keys[activeID]
+		if !ok {
+			return nil, fmt.Errorf("active key %s: %w", activeID, ErrUnknownKey)
+		}
+		set.active = key
+	}
+
+	return set, nil
+}
+
+// Generate a key set with a single ephemeral Ed25519 key, tokens signed with it do not survive a restart
+func Generate() (*KeySet, error) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	id := make([]byte, 8)
+	if _, err = rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	key := &Key{
+		ID:      "ephemeral-" + base64.RawURLEncoding.EncodeToString(id),
+		Method:  jwt.SigningMethodEdDSA,
+		Private: private,
+	}
+
+	return &KeySet{keys: map[string]*Key{key.ID: key}, active: key}, nil
+}
+
+// Get the key used to sign new tokens
+func (s *KeySet) Active() *Key {
+	return s.active
+}
+
+// Get public key by ID
+func (s *KeySet) PublicKey(id string) (crypto.PublicKey, error) {
+	key, ok := s.keys[id]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return key.Private.Public(), nil
+}
+
+// Build the public JSON Web Key Set
+func (s *KeySet) JWKS() JWKS {
+	ids := make([]string, 0, len(s.keys))
+	for id := range s.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	set := JWKS{Keys: make([]JWK, 0, len(ids))}
+	for _, id := range ids {
+		key := s.keys[id]
+		jwk := JWK{
+			Kid: key.ID,
+			Use: "sig",
+			Alg: key.Method.Alg(),
+		}
+
+		switch public := key.Private.Public().(type) {
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		}
+
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return set
+}
+
+// Parse a PKCS#8 or PKCS#1 PEM private key
+func parseKey(id string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var private any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch private := private.(type) {
+	case ed25519.PrivateKey:
+		return &Key{ID: id, Method: jwt.SigningMethodEdDSA, Private: private}, nil
+	case *rsa.PrivateKey:
+		return &Key{ID: id, Method: jwt.SigningMethodRS256, Private: private}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", private)
+	}
+}
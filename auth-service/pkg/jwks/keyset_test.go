@@ -0,0 +1,93 @@
+package jwks
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeKey(t *testing.T, dir, id string, key any) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	require.NoError(t, os.WriteFile(filepath.Join(dir, id+".pem"), data, 0o600))
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	writeKey(t, dir, "2024-01", rsaKey)
+	writeKey(t, dir, "2024-02", edKey)
+
+	tests := []struct {
+		name       string
+		dir        string
+		activeID   string
+		wantActive string
+		wantAlg    string
+		wantErr    error
+	}{
+		{
+			name:       "latest key is active by default",
+			dir:        dir,
+			wantActive: "2024-02",
+			wantAlg:    "EdDSA",
+		},
+		{
+			name:       "explicit active key",
+			dir:        dir,
+			activeID:   "2024-01",
+			wantActive: "2024-01",
+			wantAlg:    "RS256",
+		},
+		{
+			name:     "unknown active key",
+			dir:      dir,
+			activeID: "missing",
+			wantErr:  ErrUnknownKey,
+		},
+		{
+			name:    "empty directory",
+			dir:     t.TempDir(),
+			wantErr: ErrNoKeys,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			set, err := Load(tt.dir, tt.activeID)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.wantActive, set.Active().ID)
+			require.Equal(t, tt.wantAlg, set.Active().Method.Alg())
+
+			keys := set.JWKS().Keys
+			require.Len(t, keys, 2)
+			require.Equal(t, "RSA", keys[0].Kty)
+			require.NotEmpty(t, keys[0].N)
+			require.Equal(t, "AQAB", keys[0].E)
+			require.Equal(t, "OKP", keys[1].Kty)
+			require.Equal(t, "Ed25519", keys[1].Crv)
+			require.NotEmpty(t, keys[1].X)
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"cyansnbrst/auth-service/config"
+)
+
+// Returned for messages with line breaks in header fields
+var ErrInvalidHeader = errors.New("invalid header value")
+
+// Email message
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer interface
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Init mailer chosen by the config driver
+func New(cfg *config.Config, logger *zap.Logger) (Mailer, error) {
+	switch cfg.Mail.Driver {
+	case "smtp":
+		return NewSMTPMailer(cfg.Mail.SMTP.Host, cfg.Mail.SMTP.Port, cfg.Mail.SMTP.Username, cfg.Mail.SMTP.Password, cfg.Mail.From), nil
+	case "file":
+		return NewFileMailer(cfg.Mail.Dir, cfg.Mail.From)
+	case "log", "":
+		return NewLogMailer(logger), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver '%s'", cfg.Mail.Driver)
+	}
+}
+
+// Mailer that writes messages to the log instead of sending them, for local development
+type LogMailer struct {
+	logger *zap.Logger
+}
+
+// Log mailer constructor
+func NewLogMailer(logger *zap.Logger) *LogMailer {
+	return &LogMailer{logger: logger}
+}
+
+// Log the message
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	m.logger.Info("email message",
+		zap.String("to", msg.To),
+		zap.String("subject", msg.Subject),
+		zap.String("body", msg.Body),
+	)
+	return nil
+}
+
+// Build an RFC 5322 plain text message
+func buildMessage(from string, msg Message, now time.Time) ([]byte, error) {
+	for _, value := range []string{from, msg.To, msg.Subject} {
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, ErrInvalidHeader
+		}
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	b.WriteString("\r\n")
+
+	return b.Bytes(), nil
+}
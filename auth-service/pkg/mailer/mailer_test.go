@@ -0,0 +1,86 @@
+package mailer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"cyansnbrst/auth-service/config"
+)
+
+func TestFileMailer_Send(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+
+	m, err := NewFileMailer(dir, "no-reply@example.com")
+	require.NoError(t, err)
+
+	err = m.Send(context.Background(), Message{
+		To:      "user@example.com",
+		Subject: "Reset your password",
+		Body:    "line one\nline two",
+	})
+	require.NoError(t, err)
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	data, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	require.NoError(t, err)
+
+	headers, body, found := strings.Cut(string(data), "\r\n\r\n")
+	require.True(t, found)
+	require.Contains(t, headers, "From: no-reply@example.com\r\n")
+	require.Contains(t, headers, "To: user@example.com\r\n")
+	require.Contains(t, headers, "Subject: Reset your password\r\n")
+	require.Equal(t, "line one\r\nline two\r\n", body)
+}
+
+func TestFileMailer_HeaderInjection(t *testing.T) {
+	m, err := NewFileMailer(t.TempDir(), "no-reply@example.com")
+	require.NoError(t, err)
+
+	err = m.Send(context.Background(), Message{
+		To:      "user@example.com\r\nBcc: victim@example.com",
+		Subject: "Hello",
+	})
+	require.ErrorIs(t, err, ErrInvalidHeader)
+}
+
+func TestNew(t *testing.T) {
+	logger := zap.NewNop()
+
+	tests := []struct {
+		name    string
+		driver  string
+		want    interface{}
+		wantErr bool
+	}{
+		{name: "default", driver: "", want: &LogMailer{}},
+		{name: "log", driver: "log", want: &LogMailer{}},
+		{name: "file", driver: "file", want: &FileMailer{}},
+		{name: "smtp", driver: "smtp", want: &SMTPMailer{}},
+		{name: "unknown", driver: "carrier-pigeon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Mail: config.Mail{Driver: tt.driver, Dir: t.TempDir()}}
+
+			m, err := New(cfg, logger)
+
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.IsType(t, tt.want, m)
+			}
+		})
+	}
+}
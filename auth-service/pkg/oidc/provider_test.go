@@ -0,0 +1,109 @@
+package oidc
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"cyansnbrst/auth-service/pkg/oidc/oidctest"
+)
+
+func TestProvider_Login(t *testing.T) {
+	idp := oidctest.NewProvider("auth", "secret", oidctest.User{
+		Subject:       "248289761001",
+		Email:         "jane@example.com",
+		EmailVerified: true,
+		Name:          "Jane",
+	})
+	t.Cleanup(idp.Close)
+
+	newProvider := func(clientSecret string) *Provider {
+		return NewProvider(Config{
+			Issuer:       idp.Issuer(),
+			ClientID:     "auth",
+			ClientSecret: clientSecret,
+			RedirectURL:  "http://localhost/auth/oidc/test/callback",
+		}, time.Second)
+	}
+
+	verifier, err := NewCodeVerifier()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		provider      *Provider
+		exchangeNonce string
+		verifier      string
+		wantErr       bool
+	}{
+		{
+			name:          "success",
+			provider:      newProvider("secret"),
+			exchangeNonce: "nonce",
+			verifier:      verifier,
+		},
+		{
+			name:          "wrong code verifier",
+			provider:      newProvider("secret"),
+			exchangeNonce: "nonce",
+			verifier:      "wrong",
+			wantErr:       true,
+		},
+		{
+			name:          "nonce mismatch",
+			provider:      newProvider("secret"),
+			exchangeNonce: "other",
+			verifier:      verifier,
+			wantErr:       true,
+		},
+		{
+			name:          "wrong client secret",
+			provider:      newProvider("wrong"),
+			exchangeNonce: "nonce",
+			verifier:      verifier,
+			wantErr:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			authURL, err := tt.provider.AuthCodeURL(context.Background(), "state", "nonce", verifier)
+			require.NoError(t, err)
+
+			parsed, err := url.Parse(authURL)
+			require.NoError(t, err)
+			require.Equal(t, CodeChallenge(verifier), parsed.Query().Get("code_challenge"))
+			require.Equal(t, "S256", parsed.Query().Get("code_challenge_method"))
+
+			code, state, err := idp.Authorize(authURL)
+			require.NoError(t, err)
+			require.Equal(t, "state", state)
+
+			claims, err := tt.provider.Exchange(context.Background(), code, tt.verifier, tt.exchangeNonce)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, "248289761001", claims.Subject)
+			require.Equal(t, "jane@example.com", claims.Email)
+			require.True(t, claims.EmailVerified)
+			require.Equal(t, "Jane", claims.Name)
+		})
+	}
+}
+
+func TestProvider_IssuerMismatch(t *testing.T) {
+	idp := oidctest.NewProvider("auth", "", oidctest.User{Subject: "1"})
+	t.Cleanup(idp.Close)
+
+	provider := NewProvider(Config{Issuer: idp.Issuer() + "/", ClientID: "auth"}, time.Second)
+
+	_, err := provider.AuthCodeURL(context.Background(), "state", "nonce", "verifier")
+	require.Error(t, err)
+}
@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// At most Requests hits within a sliding Window
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// Limit check result, RetryAfter is set when the hit is not allowed
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// Sliding window rate limiter
+type Limiter interface {
+	// Record a hit if the key is under the limit
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+	// Check the key without recording a hit
+	Check(ctx context.Context, key string, limit Limit) (Result, error)
+	// Forget all hits of the key
+	Reset(ctx context.Context, key string) error
+}
+
+// Limiter that switches to the fallback limiter while the primary one fails
+type FallbackLimiter struct {
+	primary  Limiter
+	fallback Limiter
+	logger   *zap.Logger
+}
+
+// Fallback limiter constructor
+func NewFallbackLimiter(primary, fallback Limiter, logger *zap.Logger) *FallbackLimiter {
+	return &FallbackLimiter{primary: primary, fallback: fallback, logger: logger}
+}
+
+// Record a hit if the key is under the limit
+func (l *FallbackLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	result, err := l.primary.Allow(ctx, key, limit)
+	if err != nil {
+		l.logger.Warn("rate limiter unavailable, using fallback", zap.Error(err))
+		return l.fallback.Allow(ctx, key, limit)
+	}
+	return result, nil
+}
+
+// Check the key without recording a hit
+func (l *FallbackLimiter) Check(ctx context.Context, key string, limit Limit) (Result, error) {
+	result, err := l.primary.Check(ctx, key, limit)
+	if err != nil {
+		l.logger.Warn("rate limiter unavailable, using fallback", zap.Error(err))
+		return l.fallback.Check(ctx, key, limit)
+	}
+	return result, nil
+}
+
+// Forget all hits of the key in both limiters
+func (l *FallbackLimiter) Reset(ctx context.Context, key string) error {
+	if err := l.fallback.Reset(ctx, key); err != nil {
+		return err
+	}
+	if err := l.primary.Reset(ctx, key); err != nil {
+		l.logger.Warn("rate limiter unavailable, reset only in fallback", zap.Error(err))
+	}
+	return nil
+}
+
+// Format retry after as whole seconds for the Retry-After header, rounded up
+func RetryAfterSeconds(d time.Duration) string {
+	seconds := int64((d + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return strconv.FormatInt(seconds, 10)
+}
@@ -0,0 +1,109 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestMemoryLimiter_Allow(t *testing.T) {
+	ctx := context.Background()
+	limit := Limit{Requests: 2, Window: time.Minute}
+
+	now := time.Unix(1_700_000_000, 0)
+	l := NewMemoryLimiter()
+	l.nowFunc = func() time.Time { return now }
+
+	result, err := l.Allow(ctx, "key", limit)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+	require.Equal(t, 1, result.Remaining)
+
+	now = now.Add(10 * time.Second)
+	result, err = l.Allow(ctx, "key", limit)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+	require.Equal(t, 0, result.Remaining)
+
+	result, err = l.Allow(ctx, "key", limit)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+	require.Equal(t, 50*time.Second, result.RetryAfter)
+
+	// Other keys are counted separately
+	result, err = l.Allow(ctx, "other", limit)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+
+	// The first hit leaves the window
+	now = now.Add(50 * time.Second)
+	result, err = l.Allow(ctx, "key", limit)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+}
+
+func TestMemoryLimiter_CheckAndReset(t *testing.T) {
+	ctx := context.Background()
+	limit := Limit{Requests: 1, Window: time.Minute}
+
+	l := NewMemoryLimiter()
+
+	for i := 0; i < 3; i++ {
+		result, err := l.Check(ctx, "key", limit)
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+	}
+
+	result, err := l.Allow(ctx, "key", limit)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+
+	result, err = l.Check(ctx, "key", limit)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+
+	require.NoError(t, l.Reset(ctx, "key"))
+
+	result, err = l.Check(ctx, "key", limit)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+}
+
+func TestFallbackLimiter_RedisUnavailable(t *testing.T) {
+	ctx := context.Background()
+	limit := Limit{Requests: 1, Window: time.Minute}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:        "127.0.0.1:1",
+		DialTimeout: 100 * time.Millisecond,
+		MaxRetries:  -1,
+	})
+	defer client.Close()
+
+	l := NewFallbackLimiter(NewRedisLimiter(client, time.Second), NewMemoryLimiter(), zap.NewNop())
+
+	result, err := l.Allow(ctx, "key", limit)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+
+	result, err = l.Allow(ctx, "key", limit)
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+
+	require.NoError(t, l.Reset(ctx, "key"))
+
+	result, err = l.Check(ctx, "key", limit)
+	require.NoError(t, err)
+	require.True(t, result.Allowed)
+}
+
+func TestRetryAfterSeconds(t *testing.T) {
+	require.Equal(t, "1", RetryAfterSeconds(0))
+	require.Equal(t, "1", RetryAfterSeconds(300*time.Millisecond))
+	require.Equal(t, "60", RetryAfterSeconds(time.Minute))
+	require.Equal(t, "61", RetryAfterSeconds(time.Minute+time.Millisecond))
+}
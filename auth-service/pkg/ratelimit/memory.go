@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Number of calls between sweeps of expired keys
+const sweepInterval = 1000
+
+// In-memory sliding window limiter, limits are per process
+type MemoryLimiter struct {
+	mu      sync.Mutex
+	hits    map[string][]time.Time
+	windows map[string]time.Duration
+	calls   int
+	nowFunc func() time.Time
+}
+
+// Memory limiter constructor
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		hits:    make(map[string][]time.Time),
+		windows: make(map[string]time.Duration),
+		nowFunc: time.Now,
+	}
+}
+
+// Record a hit if the key is under the limit
+func (l *MemoryLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	return l.take(key, limit, true), nil
+}
+
+// Check the key without recording a hit
+func (l *MemoryLimiter) Check(ctx context.Context, key string, limit Limit) (Result, error) {
+	return l.take(key, limit, false), nil
+}
+
+// Forget all hits of the key
+func (l *MemoryLimiter) Reset(ctx context.Context, key string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.hits, key)
+	delete(l.windows, key)
+	return nil
+}
+
+// Drop hits outside the window and optionally record a new one
+func (l *MemoryLimiter) take(key string, limit Limit, record bool) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.nowFunc()
+	l.calls++
+	if l.calls%sweepInterval == 0 {
+		l.sweep(now)
+	}
+
+	hits := prune(l.hits[key], now.Add(-limit.Window))
+
+	if len(hits) >= limit.Requests {
+		l.hits[key] = hits
+		return Result{Allowed: false, RetryAfter: hits[0].Add(limit.Window).Sub(now)}
+	}
+
+	if record {
+		hits = append(hits, now)
+	}
+	l.hits[key] = hits
+	l.windows[key] = limit.Window
+
+	return Result{Allowed: true, Remaining: limit.Requests - len(hits)}
+}
+
+// Remove keys without hits in their window
+func (l *MemoryLimiter) sweep(now time.Time) {
+	for key, hits := range l.hits {
+		if len(prune(hits, now.Add(-l.windows[key]))) == 0 {
+			delete(l.hits, key)
+			delete(l.windows, key)
+		}
+	}
+}
+
+// Drop hits that are not after the given time, hits are sorted
+func prune(hits []time.Time, after time.Time) []time.Time {
+	i := 0
+	for i < len(hits) && !hits[i].After(after) {
+		i++
+	}
+	return hits[i:]
+}
@@ -0,0 +1,105 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// Redis keys prefix
+const keyPrefix = "ratelimit:"
+
+// Sliding window log in a sorted set scored by hit time in milliseconds.
+// Returns {allowed, remaining, retry after in milliseconds}.
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+local record = ARGV[4] == "1"
+
+redis.call("ZREMRANGEBYSCORE", key, "-inf", now - window)
+local count = redis.call("ZCARD", key)
+
+if count >= limit then
+	local oldest = redis.call("ZRANGE", key, 0, 0, "WITHSCORES")
+	local retry = window
+	if oldest[2] then
+		retry = tonumber(oldest[2]) + window - now
+	end
+	return {0, 0, retry}
+end
+
+if record then
+	redis.call("ZADD", key, now, ARGV[5])
+	redis.call("PEXPIRE", key, window)
+	count = count + 1
+end
+
+return {1, limit - count, 0}
+`)
+
+// Redis sliding window limiter, limits are shared by all service instances
+type RedisLimiter struct {
+	client  *redis.Client
+	timeout time.Duration
+}
+
+// Redis limiter constructor, timeout bounds every redis call
+func NewRedisLimiter(client *redis.Client, timeout time.Duration) *RedisLimiter {
+	return &RedisLimiter{client: client, timeout: timeout}
+}
+
+// Record a hit if the key is under the limit
+func (l *RedisLimiter) Allow(ctx context.Context, key string, limit Limit) (Result, error) {
+	return l.run(ctx, key, limit, true)
+}
+
+// Check the key without recording a hit
+func (l *RedisLimiter) Check(ctx context.Context, key string, limit Limit) (Result, error) {
+	return l.run(ctx, key, limit, false)
+}
+
+// Forget all hits of the key
+func (l *RedisLimiter) Reset(ctx context.Context, key string) error {
+	ctx, cancel := context.WithTimeout(ctx, l.timeout)
+	defer cancel()
+
+	return l.client.Del(ctx, keyPrefix+key).Err()
+}
+
+// Run the sliding window script
+func (l *RedisLimiter) run(ctx context.Context, key string, limit Limit, record bool) (Result, error) {
+	member := make([]byte, 8)
+	if _, err := rand.Read(member); err != nil {
+		return Result{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, l.timeout)
+	defer cancel()
+
+	recordArg := "0"
+	if record {
+		recordArg = "1"
+	}
+
+	values, err := slidingWindowScript.Run(ctx, l.client, []string{keyPrefix + key},
+		time.Now().UnixMilli(),
+		limit.Window.Milliseconds(),
+		limit.Requests,
+		recordArg,
+		hex.EncodeToString(member),
+	).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+
+	return Result{
+		Allowed:    values[0] == 1,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+	}, nil
+}
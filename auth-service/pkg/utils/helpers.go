@@ -6,9 +6,24 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
+
+	"github.com/julienschmidt/httprouter"
 )
 
+// Read ID param
+func ReadIDParam(r *http.Request) (string, error) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	id, err := strconv.ParseInt(params.ByName("id"), 10, 64)
+	if err != nil || id < 1 {
+		return "", errors.New("invalid id parameter")
+	}
+
+	return strconv.FormatInt(id, 10), nil
+}
+
 // JSON envelope
 type Envelope map[string]interface{}
 
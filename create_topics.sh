@@ -4,4 +4,7 @@
 echo "topic user_updates was created"
 
 /opt/bitnami/kafka/bin/kafka-topics.sh --create --topic product_updates --bootstrap-server kafka:9092
-echo "topic product_updates was created"
\ No newline at end of file
+echo "topic product_updates was created"
+
+/opt/bitnami/kafka/bin/kafka-topics.sh --create --topic tag_updates --bootstrap-server kafka:9092
+echo "topic tag_updates was created"
\ No newline at end of file
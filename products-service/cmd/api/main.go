@@ -8,6 +8,7 @@ import (
 	"cyansnbrst/products-service/config"
 	"cyansnbrst/products-service/internal/server"
 	"cyansnbrst/products-service/pkg/db/postgres"
+	"cyansnbrst/products-service/pkg/db/redis"
 	"cyansnbrst/products-service/pkg/kafka"
 )
 
@@ -22,6 +23,11 @@ import (
 // @securityDefinitions.apikey	cookieAuth
 // @in							cookie
 // @name						token
+
+// @securityDefinitions.apikey	bearerAuth
+// @in							header
+// @name						Authorization
+// @description				"Bearer <access token or API key>"
 func main() {
 	log.Println("starting products server")
 
@@ -58,6 +64,14 @@ func main() {
 	}()
 	logger.Info("database connected")
 
+	redisClient := redis.NewRedisClient(cfg)
+	defer func() {
+		if err := redisClient.Close(); err != nil {
+			logger.Warn("failed to close redis client", zap.String("error", err.Error()))
+		}
+	}()
+	logger.Info("redis client initialized")
+
 	kafkaUserWriter, err := kafka.InitKafkaWriter(cfg, "user")
 	if err != nil {
 		logger.Fatal("failed to init kafka user producer",
@@ -74,7 +88,15 @@ func main() {
 	}
 	logger.Info("kafka product producer connected")
 
-	s := server.NewServer(cfg, logger, psqlDB, kafkaUserWriter, kafkaProductWriter)
+	kafkaTagWriter, err := kafka.InitKafkaWriter(cfg, "tag")
+	if err != nil {
+		logger.Fatal("failed to init kafka tag producer",
+			zap.String("error", err.Error()),
+		)
+	}
+	logger.Info("kafka tag producer connected")
+
+	s := server.NewServer(cfg, logger, psqlDB, redisClient, kafkaUserWriter, kafkaProductWriter, kafkaTagWriter)
 	if err = s.Run(); err != nil {
 		logger.Fatal("an error occured",
 			zap.String("error", err.Error()),
@@ -11,12 +11,23 @@ import (
 
 // App config struct
 type Config struct {
-	Port       int
-	Env        string
-	AuthURL    string
-	PostgreSQL PostgreSQL
-	Kafka      Kafka
-	Timeout    Timeout
+	Port               int
+	Env                string
+	AuthURL            string
+	JWKSURL            string
+	RevokedSessionsURL string
+	DefaultCurrency    string
+	PostgreSQL         PostgreSQL
+	Redis              Redis
+	Kafka              Kafka
+	Purge              Purge
+	Import             Import
+	Views              EventBuffer
+	ViewFilter         ViewFilter
+	Metrics            Metrics
+	AuthBreaker        AuthBreaker
+	ServiceAuth        ServiceAuth
+	Timeout            Timeout
 }
 
 // PostgreSQL config struct
@@ -32,11 +43,95 @@ type PostgreSQL struct {
 	MaxIdleTime  time.Duration
 }
 
+// Redis config struct
+type Redis struct {
+	RedisAddr    string
+	MinIdleConns int
+	PoolSize     int
+	PoolTimeout  int
+	Password     string
+	DB           int
+}
+
 // Kafka config struct
 type Kafka struct {
-	Brokers     []string
-	Topics      map[string]string
-	MaxAttempts int
+	Brokers         []string
+	Topics          map[string]string
+	MaxAttempts     int
+	WriteBackoffMin time.Duration
+	WriteBackoffMax time.Duration
+	RequiredAcks    string // none, leader or all
+	BatchSize       int
+	BatchBytes      int64
+	BatchTimeout    time.Duration
+	Compression     string // none, gzip, snappy, lz4 or zstd
+	TLS             KafkaTLS
+	SASL            KafkaSASL
+}
+
+// Kafka TLS config struct, the CA file overrides the system roots and the certificate enables client authentication
+type KafkaTLS struct {
+	Enabled            bool
+	CAFile             string
+	CertFile           string
+	KeyFile            string
+	InsecureSkipVerify bool
+}
+
+// Kafka SASL config struct, an empty mechanism disables SASL
+type KafkaSASL struct {
+	Mechanism string // plain, scram-sha-256 or scram-sha-512
+	Username  string
+	Password  string
+}
+
+// Purge job config struct, soft-deleted products are removed after the retention period
+type Purge struct {
+	Interval  time.Duration
+	Retention time.Duration // zero keeps deleted products forever
+}
+
+// Bulk import and export config struct
+type Import struct {
+	BatchSize int // products per Kafka batch and per export page
+	MaxRows   int
+}
+
+// Buffered events config struct, events are published asynchronously in batches
+type EventBuffer struct {
+	Size          int // events waiting to be published
+	BatchSize     int
+	FlushInterval time.Duration
+	Overflow      string        // policy for a full buffer: "drop" drops the event, "block" waits for space up to the block timeout
+	BlockTimeout  time.Duration // wait of a request for space in a full buffer, the event is dropped after it
+	WriteTimeout  time.Duration
+}
+
+// View counting config struct, duplicate views and views of bots are not counted in popularity
+type ViewFilter struct {
+	DedupWindow   time.Duration // repeated views of a product by a user within the window are duplicates
+	RateLimit     int           // views of a user within the rate window, a user viewing more is treated as a bot
+	RateWindow    time.Duration
+	BotUserAgents []string // case-insensitive User-Agent substrings of bots, an empty User-Agent is a bot too
+}
+
+// Metrics config struct
+type Metrics struct {
+	URL         string
+	ServiceName string
+}
+
+// Service-to-service authentication config struct
+type ServiceAuth struct {
+	ClientID     string // name of this service, also the audience of tokens for its internal routes
+	ClientSecret string
+	TokenURL     string
+}
+
+// Auth service circuit breaker config struct
+type AuthBreaker struct {
+	Threshold int
+	Cooldown  time.Duration
 }
 
 // Timeouts config struct
@@ -47,6 +142,11 @@ type Timeout struct {
 	ServerRead       time.Duration
 	ServerWrite      time.Duration
 	ServerShutdown   time.Duration
+	JWKSCache        time.Duration
+	AuthRequest      time.Duration
+	AuthCache        time.Duration
+	RevocationsCache time.Duration
+	RedisAction      time.Duration
 }
 
 // Load config file from given path
@@ -80,6 +180,14 @@ func ParseConfig(v *viper.Viper) (*Config, error) {
 	c.Port = v.GetInt("port")
 	c.Env = v.GetString("env")
 	c.AuthURL = v.GetString("auth_url")
+	c.JWKSURL = v.GetString("jwks_url")
+	c.RevokedSessionsURL = v.GetString("revoked_sessions_url")
+	c.DefaultCurrency = v.GetString("default_currency")
+
+	// Service auth config
+	c.ServiceAuth.ClientID = v.GetString("service_client_id")
+	c.ServiceAuth.ClientSecret = v.GetString("service_client_secret")
+	c.ServiceAuth.TokenURL = v.GetString("service_token_url")
 
 	// PostgreSQL config
 	c.PostgreSQL.Host = v.GetString("postgresql_host")
@@ -95,6 +203,14 @@ func ParseConfig(v *viper.Viper) (*Config, error) {
 		return nil, err
 	}
 
+	// Redis config
+	c.Redis.RedisAddr = v.GetString("redis_addr")
+	c.Redis.MinIdleConns = v.GetInt("redis_min_idle_conns")
+	c.Redis.PoolSize = v.GetInt("redis_pool_size")
+	c.Redis.PoolTimeout = v.GetInt("redis_pool_timeout")
+	c.Redis.Password = v.GetString("redis_password")
+	c.Redis.DB = v.GetInt("redis_db")
+
 	// Kafka config
 	if brokers := v.GetString("kafka_brokers"); brokers != "" {
 		c.Kafka.Brokers = strings.Split(brokers, ",")
@@ -107,6 +223,97 @@ func ParseConfig(v *viper.Viper) (*Config, error) {
 		}
 	}
 	c.Kafka.MaxAttempts = v.GetInt("kafka_max_attempts")
+	c.Kafka.WriteBackoffMin, err = parseTimeout(v, "kafka_write_backoff_min")
+	if err != nil {
+		return nil, err
+	}
+	c.Kafka.WriteBackoffMax, err = parseTimeout(v, "kafka_write_backoff_max")
+	if err != nil {
+		return nil, err
+	}
+	c.Kafka.RequiredAcks = v.GetString("kafka_required_acks")
+	c.Kafka.BatchSize = v.GetInt("kafka_batch_size")
+	c.Kafka.BatchBytes = v.GetInt64("kafka_batch_bytes")
+	c.Kafka.BatchTimeout, err = parseTimeout(v, "kafka_batch_timeout")
+	if err != nil {
+		return nil, err
+	}
+	c.Kafka.Compression = v.GetString("kafka_compression")
+	c.Kafka.TLS.Enabled = v.GetBool("kafka_tls_enabled")
+	c.Kafka.TLS.CAFile = v.GetString("kafka_tls_ca_file")
+	c.Kafka.TLS.CertFile = v.GetString("kafka_tls_cert_file")
+	c.Kafka.TLS.KeyFile = v.GetString("kafka_tls_key_file")
+	c.Kafka.TLS.InsecureSkipVerify = v.GetBool("kafka_tls_insecure_skip_verify")
+	c.Kafka.SASL.Mechanism = v.GetString("kafka_sasl_mechanism")
+	c.Kafka.SASL.Username = v.GetString("kafka_sasl_username")
+	c.Kafka.SASL.Password = v.GetString("kafka_sasl_password")
+
+	// Purge config
+	c.Purge.Interval, err = parseTimeout(v, "purge_interval")
+	if err != nil {
+		return nil, err
+	}
+	if c.Purge.Interval <= 0 {
+		return nil, errors.New("purge_interval must be positive")
+	}
+	c.Purge.Retention, err = parseTimeout(v, "purge_retention")
+	if err != nil {
+		return nil, err
+	}
+	if c.Purge.Retention < 0 {
+		return nil, errors.New("purge_retention must not be negative")
+	}
+
+	// Import config
+	c.Import.BatchSize = v.GetInt("import_batch_size")
+	if c.Import.BatchSize <= 0 {
+		return nil, errors.New("import_batch_size must be positive")
+	}
+	c.Import.MaxRows = v.GetInt("import_max_rows")
+	if c.Import.MaxRows <= 0 {
+		return nil, errors.New("import_max_rows must be positive")
+	}
+
+	// View events buffer config
+	c.Views.Size = v.GetInt("views_buffer_size")
+	c.Views.BatchSize = v.GetInt("views_batch_size")
+	c.Views.FlushInterval, err = parseTimeout(v, "views_flush_interval")
+	if err != nil {
+		return nil, err
+	}
+	if c.Views.FlushInterval <= 0 {
+		return nil, errors.New("views_flush_interval must be positive")
+	}
+	c.Views.Overflow = v.GetString("views_overflow")
+	if c.Views.Overflow != "drop" && c.Views.Overflow != "block" {
+		return nil, fmt.Errorf("invalid views_overflow %q: must be drop or block", c.Views.Overflow)
+	}
+	c.Views.BlockTimeout, err = parseTimeout(v, "views_block_timeout")
+	if err != nil {
+		return nil, err
+	}
+	c.Views.WriteTimeout, err = parseTimeout(v, "views_write_timeout")
+	if err != nil {
+		return nil, err
+	}
+
+	// View counting config
+	c.ViewFilter.DedupWindow, err = parseTimeout(v, "views_dedup_window")
+	if err != nil {
+		return nil, err
+	}
+	c.ViewFilter.RateLimit = v.GetInt("views_rate_limit")
+	c.ViewFilter.RateWindow, err = parseTimeout(v, "views_rate_window")
+	if err != nil {
+		return nil, err
+	}
+	if agents := v.GetString("views_bot_user_agents"); agents != "" {
+		c.ViewFilter.BotUserAgents = strings.Split(agents, ",")
+	}
+
+	// Metrics config
+	c.Metrics.URL = v.GetString("metrics_url")
+	c.Metrics.ServiceName = v.GetString("metrics_service_name")
 
 	// Timeout config
 	c.Timeout.PostgreSQLConn, err = parseTimeout(v, "timeout_postgresql_conn")
@@ -133,6 +340,33 @@ func ParseConfig(v *viper.Viper) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	c.Timeout.JWKSCache, err = parseTimeout(v, "timeout_jwks_cache")
+	if err != nil {
+		return nil, err
+	}
+	c.Timeout.AuthRequest, err = parseTimeout(v, "timeout_auth_request")
+	if err != nil {
+		return nil, err
+	}
+	c.Timeout.AuthCache, err = parseTimeout(v, "timeout_auth_cache")
+	if err != nil {
+		return nil, err
+	}
+	c.Timeout.RevocationsCache, err = parseTimeout(v, "timeout_revocations_cache")
+	if err != nil {
+		return nil, err
+	}
+	c.Timeout.RedisAction, err = parseTimeout(v, "timeout_redis_action")
+	if err != nil {
+		return nil, err
+	}
+
+	// Auth service circuit breaker config
+	c.AuthBreaker.Threshold = v.GetInt("auth_breaker_threshold")
+	c.AuthBreaker.Cooldown, err = parseTimeout(v, "auth_breaker_cooldown")
+	if err != nil {
+		return nil, err
+	}
 
 	return &c, nil
 }
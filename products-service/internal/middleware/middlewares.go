@@ -4,15 +4,46 @@ import (
 	"go.uber.org/zap"
 
 	"cyansnbrst/products-service/config"
+	"cyansnbrst/products-service/pkg/authclient"
+	"cyansnbrst/products-service/pkg/jwtauth"
 )
 
 // Middleware manager struct
 type MiddlewareManager struct {
-	cfg    *config.Config
-	logger *zap.Logger
+	cfg        *config.Config
+	verifier   *jwtauth.Verifier
+	authClient *authclient.Client
+	logger     *zap.Logger
 }
 
 // Middleware manager constructor
 func NewMiddlewareManager(cfg *config.Config, logger *zap.Logger) *MiddlewareManager {
-	return &MiddlewareManager{cfg: cfg, logger: logger}
+	tokens := authclient.NewTokenSource(authclient.TokenSourceOptions{
+		URL:            cfg.ServiceAuth.TokenURL,
+		ClientID:       cfg.ServiceAuth.ClientID,
+		ClientSecret:   cfg.ServiceAuth.ClientSecret,
+		Audience:       "auth",
+		RequestTimeout: cfg.Timeout.AuthRequest,
+	})
+
+	revocations := authclient.NewRevocations(authclient.RevocationsOptions{
+		URL:            cfg.RevokedSessionsURL,
+		RequestTimeout: cfg.Timeout.AuthRequest,
+		CacheTTL:       cfg.Timeout.RevocationsCache,
+		Tokens:         tokens,
+	})
+
+	return &MiddlewareManager{
+		cfg:      cfg,
+		verifier: jwtauth.NewVerifier(jwtauth.NewKeySet(cfg.JWKSURL, cfg.Timeout.JWKSCache), revocations),
+		authClient: authclient.NewClient(authclient.Options{
+			URL:              cfg.AuthURL,
+			RequestTimeout:   cfg.Timeout.AuthRequest,
+			CacheTTL:         cfg.Timeout.AuthCache,
+			BreakerThreshold: cfg.AuthBreaker.Threshold,
+			BreakerCooldown:  cfg.AuthBreaker.Cooldown,
+			Tokens:           tokens,
+		}),
+		logger: logger,
+	}
 }
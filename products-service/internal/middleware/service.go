@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+
+	erp "cyansnbrst/products-service/pkg/error_responses"
+	"cyansnbrst/products-service/pkg/jwtauth"
+)
+
+// Require service middleware, restricts internal routes to the given calling services.
+// The token must be issued by the auth service for this service as the audience.
+func (mw *MiddlewareManager) RequireService(callers ...string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || token == "" {
+				erp.NotPermittedResponse(w, r, mw.logger)
+				return
+			}
+
+			claims, err := mw.verifier.VerifyService(token, mw.cfg.ServiceAuth.ClientID)
+			if errors.Is(err, jwtauth.ErrKeySetUnavailable) {
+				erp.ServerErrorResponse(w, r, mw.logger, err)
+				return
+			}
+			if err != nil {
+				mw.logger.Debug("invalid service token", zap.Error(err))
+				erp.NotPermittedResponse(w, r, mw.logger)
+				return
+			}
+
+			if !slices.Contains(callers, claims.Subject) {
+				erp.NotPermittedResponse(w, r, mw.logger)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
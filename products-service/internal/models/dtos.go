@@ -2,14 +2,87 @@ package models
 
 // Create product DTO struct
 type CreateProductDTO struct {
-	Name string   `json:"name"`
-	Tags []string `json:"tags"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Price       int64    `json:"price"`
+	Currency    string   `json:"currency"`
+	Category    string   `json:"category"`
+	Stock       *int64   `json:"stock"`
+	Images      []string `json:"images"`
+	Status      string   `json:"status"`
+	Tags        []string `json:"tags"`
 }
 
 // Update product DTO struct
 type UpdateProductDTO struct {
-	Name *string  `json:"name"`
-	Tags []string `json:"tags"`
+	Name        *string  `json:"name"`
+	Description *string  `json:"description"`
+	Price       *int64   `json:"price"`
+	Currency    *string  `json:"currency"`
+	Category    *string  `json:"category"`
+	Stock       *int64   `json:"stock"`
+	Images      []string `json:"images"`
+	Status      *string  `json:"status"`
+	Tags        []string `json:"tags"`
+}
+
+// Patch product DTO struct, the editable fields of a product a JSON merge patch applies to.
+// A field set to null in the patch decodes to its zero value: no stock tracking, no images, no tags.
+type PatchProductDTO struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Price       int64    `json:"price"`
+	Currency    string   `json:"currency"`
+	Category    string   `json:"category"`
+	Stock       *int64   `json:"stock"`
+	Images      []string `json:"images"`
+	Status      string   `json:"status"`
+	Tags        []string `json:"tags"`
+}
+
+// Import product DTO struct, a row with an ID updates the product and only the fields present in the row are changed,
+// other rows create products
+type ImportProductDTO struct {
+	ID int64 `json:"id,omitempty"`
+	UpdateProductDTO
+}
+
+// Import row error
+type ImportError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// Import report, the errors hold the rows that were skipped
+type ImportReport struct {
+	DryRun  bool          `json:"dry_run"`
+	Created int           `json:"created"`
+	Updated int           `json:"updated"`
+	Failed  int           `json:"failed"`
+	Errors  []ImportError `json:"errors"`
+}
+
+// Create tag DTO struct
+type CreateTagDTO struct {
+	Name    string   `json:"name"`
+	Parent  *string  `json:"parent"`
+	Aliases []string `json:"aliases"`
+}
+
+// Update tag DTO struct, an empty parent makes the tag a root
+type UpdateTagDTO struct {
+	Parent  *string  `json:"parent"`
+	Aliases []string `json:"aliases"`
+}
+
+// Tag response
+type TagResponse struct {
+	Tag Tag `json:"tag"`
+}
+
+// Tags response
+type TagsResponse struct {
+	Tags []Tag `json:"tags"`
 }
 
 // Product response
@@ -17,6 +90,26 @@ type ProductResponse struct {
 	Product Product `json:"product"`
 }
 
+// Products response
+type ProductsResponse struct {
+	Products []Product `json:"products"`
+}
+
+// Product revision response
+type RevisionResponse struct {
+	Revision ProductRevision `json:"revision"`
+}
+
+// Product revisions response
+type RevisionsResponse struct {
+	Revisions []ProductRevision `json:"revisions"`
+}
+
+// Import response
+type ImportResponse struct {
+	Report ImportReport `json:"report"`
+}
+
 // Error response
 type ErrorResponse struct {
 	Error string `json:"error"`
@@ -1,9 +1,73 @@
 package models
 
+import "time"
+
+// Product statuses
+const (
+	ProductStatusDraft    = "draft"
+	ProductStatusActive   = "active"
+	ProductStatusArchived = "archived"
+)
+
 // Product model
 type Product struct {
-	ID      int64    `json:"id"`
-	Name    string   `json:"name"`
-	Tags    []string `json:"tags,omitempty"`
-	Version int64    `json:"version"`
+	ID          int64      `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Price       int64      `json:"price"` // in minor currency units
+	Currency    string     `json:"currency"`
+	Category    string     `json:"category"`
+	Stock       *int64     `json:"stock"` // nil when stock isn't tracked
+	Images      []string   `json:"images"`
+	Status      string     `json:"status"`
+	Tags        []string   `json:"tags,omitempty"`
+	Version     int64      `json:"version"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+}
+
+// Product revision model, the full state of a product after a change
+type ProductRevision struct {
+	ProductID int64     `json:"product_id"`
+	Version   int64     `json:"version"`
+	Product   Product   `json:"product"`
+	ChangedBy string    `json:"changed_by"` // UID of the user who made the change, empty for revisions recorded before history was kept
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// Change of a product made by an edit: names of the changed fields and the tags added and removed
+type ProductChange struct {
+	Fields      []string
+	AddedTags   []string
+	RemovedTags []string
+}
+
+// Check the change touches one of the fields, a nil change touches none
+func (c *ProductChange) Has(fields ...string) bool {
+	if c == nil {
+		return false
+	}
+	for _, changed := range c.Fields {
+		for _, field := range fields {
+			if changed == field {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Reasons a product view is not counted in popularity
+const (
+	ViewSkipBot       = "bot"       // User-Agent of a bot
+	ViewSkipRate      = "rate"      // the user views products faster than a person would
+	ViewSkipDuplicate = "duplicate" // the user has already viewed the product within the dedup window
+)
+
+// Product view by a user
+type ProductView struct {
+	ProductID int64
+	Viewer    string
+	UserAgent string
 }
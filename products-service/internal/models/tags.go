@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// Canonical tag of the vocabulary
+type Tag struct {
+	Name      string    `json:"name"`
+	Parent    *string   `json:"parent,omitempty"`
+	Aliases   []string  `json:"aliases"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
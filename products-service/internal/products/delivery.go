@@ -6,6 +6,14 @@ import "net/http"
 type Handlers interface {
 	Get() http.HandlerFunc
 	Update() http.HandlerFunc
+	Patch() http.HandlerFunc
 	Delete() http.HandlerFunc
 	Create() http.HandlerFunc
+	Restore() http.HandlerFunc
+	ListDeleted() http.HandlerFunc
+	History() http.HandlerFunc
+	Revision() http.HandlerFunc
+	Rollback() http.HandlerFunc
+	Import() http.HandlerFunc
+	Export() http.HandlerFunc
 }
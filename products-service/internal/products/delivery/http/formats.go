@@ -0,0 +1,346 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"cyansnbrst/products-service/internal/models"
+)
+
+// Import and export formats
+const (
+	formatCSV    = "csv"
+	formatNDJSON = "ndjson"
+)
+
+// Separator of list values in a CSV field
+const csvListSeparator = "|"
+
+// Import limits
+const (
+	maxImportBytes  = 32 << 20
+	maxNDJSONLine   = 1 << 20
+	maxImportErrors = 100
+)
+
+// CSV columns, in the order of export
+var csvColumns = []string{"id", "name", "description", "price", "currency", "category", "stock", "images", "status", "tags"}
+
+// Format errors
+var (
+	errUnsupportedFormat = errors.New("format must be csv or ndjson")
+	errInvalidRow        = errors.New("invalid row")
+)
+
+// Reader of import rows
+type rowDecoder interface {
+	// Next returns the next row and its line, io.EOF after the last row.
+	// An error wrapping errInvalidRow only skips the row, other errors stop the import.
+	Next() (*models.ImportProductDTO, int, error)
+}
+
+// Writer of exported products
+type rowEncoder interface {
+	Encode(product *models.Product) error
+	Flush() error
+}
+
+// Import format from the format query parameter or the content type
+func importFormat(format, contentType string) (string, error) {
+	if format == "" {
+		switch {
+		case strings.HasPrefix(contentType, "text/csv"):
+			format = formatCSV
+		case strings.HasPrefix(contentType, "application/x-ndjson"):
+			format = formatNDJSON
+		}
+	}
+
+	switch format {
+	case formatCSV, formatNDJSON:
+		return format, nil
+	default:
+		return "", errUnsupportedFormat
+	}
+}
+
+// Content type of a format
+func formatContentType(format string) string {
+	if format == formatCSV {
+		return "text/csv; charset=utf-8"
+	}
+	return "application/x-ndjson"
+}
+
+// Import rows decoder of the format
+func newRowDecoder(format string, r io.Reader) (rowDecoder, error) {
+	if format == formatCSV {
+		return newCSVDecoder(r)
+	}
+	return newNDJSONDecoder(r), nil
+}
+
+// Export encoder of the format
+func newRowEncoder(format string, w io.Writer) (rowEncoder, error) {
+	if format == formatCSV {
+		return newCSVEncoder(w)
+	}
+	return newNDJSONEncoder(w), nil
+}
+
+// CSV rows decoder, the header names the columns present in the rows
+type csvDecoder struct {
+	reader  *csv.Reader
+	columns []string
+}
+
+// CSV rows decoder constructor, reads and checks the header
+func newCSVDecoder(r io.Reader) (*csvDecoder, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("CSV header is missing")
+		}
+		return nil, err
+	}
+
+	columns := make([]string, len(header))
+	seen := make(map[string]bool, len(header))
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if !isCSVColumn(column) {
+			return nil, fmt.Errorf("unknown CSV column %q", column)
+		}
+		if seen[column] {
+			return nil, fmt.Errorf("duplicate CSV column %q", column)
+		}
+		seen[column] = true
+		columns[i] = column
+	}
+
+	return &csvDecoder{reader: reader, columns: columns}, nil
+}
+
+// Next CSV row
+func (d *csvDecoder) Next() (*models.ImportProductDTO, int, error) {
+	record, err := d.reader.Read()
+	if err != nil {
+		var parseError *csv.ParseError
+		if errors.As(err, &parseError) {
+			return nil, parseError.StartLine, fmt.Errorf("%w: %s", errInvalidRow, parseError.Err)
+		}
+		return nil, 0, err
+	}
+
+	line, _ := d.reader.FieldPos(0)
+
+	row := &models.ImportProductDTO{}
+	for i, column := range d.columns {
+		if err := setCSVField(row, column, record[i]); err != nil {
+			return nil, line, fmt.Errorf("%w: %s", errInvalidRow, err)
+		}
+	}
+
+	return row, line, nil
+}
+
+// Set a field of the row from a CSV value
+func setCSVField(row *models.ImportProductDTO, column, value string) error {
+	switch column {
+	case "id":
+		if value == "" {
+			return nil
+		}
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || id < 1 {
+			return errors.New("id must be a positive integer")
+		}
+		row.ID = id
+	case "name":
+		row.Name = &value
+	case "description":
+		row.Description = &value
+	case "price":
+		price, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return errors.New("price must be an integer")
+		}
+		row.Price = &price
+	case "currency":
+		row.Currency = &value
+	case "category":
+		row.Category = &value
+	case "stock":
+		if value == "" {
+			return nil
+		}
+		stock, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return errors.New("stock must be an integer")
+		}
+		row.Stock = &stock
+	case "images":
+		row.Images = splitCSVList(value)
+	case "status":
+		row.Status = &value
+	case "tags":
+		row.Tags = splitCSVList(value)
+	}
+	return nil
+}
+
+// Check the column is a known CSV column
+func isCSVColumn(column string) bool {
+	for _, known := range csvColumns {
+		if column == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Split a CSV list value, an empty value is an empty list
+func splitCSVList(value string) []string {
+	if strings.TrimSpace(value) == "" {
+		return []string{}
+	}
+	return strings.Split(value, csvListSeparator)
+}
+
+// NDJSON rows decoder, one JSON object per line
+type ndjsonDecoder struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// NDJSON rows decoder constructor
+func newNDJSONDecoder(r io.Reader) *ndjsonDecoder {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxNDJSONLine)
+	return &ndjsonDecoder{scanner: scanner}
+}
+
+// Next NDJSON row, blank lines are skipped
+func (d *ndjsonDecoder) Next() (*models.ImportProductDTO, int, error) {
+	for d.scanner.Scan() {
+		d.line++
+
+		data := bytes.TrimSpace(d.scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+
+		row := &models.ImportProductDTO{}
+		if err := dec.Decode(row); err != nil {
+			return nil, d.line, fmt.Errorf("%w: %s", errInvalidRow, err)
+		}
+		if row.ID < 0 {
+			return nil, d.line, fmt.Errorf("%w: id must be a positive integer", errInvalidRow)
+		}
+
+		return row, d.line, nil
+	}
+
+	if err := d.scanner.Err(); err != nil {
+		return nil, d.line + 1, err
+	}
+
+	return nil, d.line, io.EOF
+}
+
+// CSV products encoder
+type csvEncoder struct {
+	writer *csv.Writer
+	record []string
+}
+
+// CSV products encoder constructor, writes the header
+func newCSVEncoder(w io.Writer) (*csvEncoder, error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvColumns); err != nil {
+		return nil, err
+	}
+	return &csvEncoder{writer: writer, record: make([]string, len(csvColumns))}, nil
+}
+
+// Write a product as a CSV row
+func (e *csvEncoder) Encode(product *models.Product) error {
+	stock := ""
+	if product.Stock != nil {
+		stock = strconv.FormatInt(*product.Stock, 10)
+	}
+
+	e.record[0] = strconv.FormatInt(product.ID, 10)
+	e.record[1] = product.Name
+	e.record[2] = product.Description
+	e.record[3] = strconv.FormatInt(product.Price, 10)
+	e.record[4] = product.Currency
+	e.record[5] = product.Category
+	e.record[6] = stock
+	e.record[7] = strings.Join(product.Images, csvListSeparator)
+	e.record[8] = product.Status
+	e.record[9] = strings.Join(product.Tags, csvListSeparator)
+
+	return e.writer.Write(e.record)
+}
+
+// Flush written rows
+func (e *csvEncoder) Flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+// NDJSON products encoder, rows have the import shape so an export can be imported back
+type ndjsonEncoder struct {
+	encoder *json.Encoder
+}
+
+// NDJSON products encoder constructor
+func newNDJSONEncoder(w io.Writer) *ndjsonEncoder {
+	return &ndjsonEncoder{encoder: json.NewEncoder(w)}
+}
+
+// Write a product as an NDJSON line
+func (e *ndjsonEncoder) Encode(product *models.Product) error {
+	images := product.Images
+	if images == nil {
+		images = []string{}
+	}
+	tags := product.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	return e.encoder.Encode(models.ImportProductDTO{
+		ID: product.ID,
+		UpdateProductDTO: models.UpdateProductDTO{
+			Name:        &product.Name,
+			Description: &product.Description,
+			Price:       &product.Price,
+			Currency:    &product.Currency,
+			Category:    &product.Category,
+			Stock:       product.Stock,
+			Images:      images,
+			Status:      &product.Status,
+			Tags:        tags,
+		},
+	})
+}
+
+// Flush written rows, lines are written as they are encoded
+func (e *ndjsonEncoder) Flush() error {
+	return nil
+}
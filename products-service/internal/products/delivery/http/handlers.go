@@ -1,7 +1,10 @@
 package http
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"time"
@@ -10,14 +13,19 @@ import (
 	"go.uber.org/zap"
 
 	"cyansnbrst/products-service/config"
+	"cyansnbrst/products-service/internal/middleware"
 	"cyansnbrst/products-service/internal/models"
 	"cyansnbrst/products-service/internal/products"
 	"cyansnbrst/products-service/pkg/db"
 	erp "cyansnbrst/products-service/pkg/error_responses"
 	kf "cyansnbrst/products-service/pkg/kafka"
+	"cyansnbrst/products-service/pkg/mergepatch"
 	"cyansnbrst/products-service/pkg/utils"
 )
 
+// Product fields carried by product events, an update changing none of them publishes no event
+var productEventFields = []string{"tags", "status", "stock"}
+
 // Validation errors
 var (
 	errNameRequired = errors.New("name is required")
@@ -31,26 +39,30 @@ type productsHandlers struct {
 	logger             *zap.Logger
 	kafkaUserWriter    *kafka.Writer
 	kafkaProductWriter *kafka.Writer
+	viewEvents         *kf.Buffer
 }
 
 // Products handlers constructor
-func NewProductsHandlers(cfg *config.Config, productsUC products.UseCase, logger *zap.Logger, kafkaUserWriter *kafka.Writer, kafkaProductWriter *kafka.Writer) products.Handlers {
+func NewProductsHandlers(cfg *config.Config, productsUC products.UseCase, logger *zap.Logger, kafkaUserWriter *kafka.Writer, kafkaProductWriter *kafka.Writer, viewEvents *kf.Buffer) products.Handlers {
 	return &productsHandlers{
 		cfg:                cfg,
 		productsUC:         productsUC,
 		logger:             logger,
 		kafkaUserWriter:    kafkaUserWriter,
 		kafkaProductWriter: kafkaProductWriter,
+		viewEvents:         viewEvents,
 	}
 }
 
 //	@Summary		Get products's info
-//	@Description	Retrieves info about the product.
+//	@Description	Retrieves info about the product. The ETag header holds the product version to send in If-Match when editing it. The view is published in the background, so the product is returned even when Kafka is unavailable. Repeated views of the product by the user and views of bots are published as not counted in popularity.
 //	@Tags			products
 //	@Produce		json
 //	@Security		cookieAuth
+//	@Security		bearerAuth
 //	@Param			id	path		int						true	"Product ID"
 //	@Success		200	{object}	models.ProductResponse	"success response with product"
+//	@Header			200	{string}	ETag					"product version"
 //	@Failure		404	{object}	models.ErrorResponse	"not found error"
 //	@Failure		500	{object}	models.ErrorResponse	"internal server error"
 //	@Router			/view/{id} [get]
@@ -68,20 +80,24 @@ func (h *productsHandlers) Get() http.HandlerFunc {
 			return
 		}
 
-		messagePayload := kf.KafkaMessage{
-			Action: "view_products",
-			Time:   time.Now().Format(time.RFC3339),
-			Tags:   nil,
-		}
+		// The view is filtered and published by the buffer worker, a dropped view is counted by the buffer metrics and does not fail the request
+		h.viewEvents.Enqueue(kf.Event{
+			Key: strconv.Itoa(int(id)),
+			Message: kf.KafkaMessage{
+				Action: "view_products",
+				Time:   time.Now().Format(time.RFC3339),
+				Tags:   nil,
+			},
+			Viewer:    middleware.ContextGetUserUID(r),
+			UserAgent: r.UserAgent(),
+		})
+
+		headers := make(http.Header)
+		headers.Set("ETag", utils.VersionETag(product.Version))
 
-		err = h.productsUC.SendToKafka(r.Context(), strconv.Itoa(int(id)), messagePayload, h.kafkaProductWriter)
-		if err != nil {
-			erp.ServerErrorResponse(w, r, h.logger, err)
-			return
-		}
 		err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{
 			"product": product,
-		}, nil)
+		}, headers)
 		if err != nil {
 			erp.ServerErrorResponse(w, r, h.logger, err)
 		}
@@ -89,14 +105,16 @@ func (h *productsHandlers) Get() http.HandlerFunc {
 }
 
 //	@Summary		Create a new product
-//	@Description	Creates a new product (admin-only).
+//	@Description	Creates a new product (requires products:write). Tags are normalized and mapped to canonical tags, tags outside the vocabulary are rejected. Price is given in minor currency units, the product is active and priced in the default currency unless the request says otherwise.
 //	@Tags			products
 //	@Accept			json
 //	@Produce		json
 //	@Security		cookieAuth
-//	@Success		200	{object}	models.SuccessResponse	"success response with product"
-//	@Failure		400	{object}	models.ErrorResponse	"bad request error"
-//	@Failure		500	{object}	models.ErrorResponse	"internal server error"
+//	@Security		bearerAuth
+//	@Param			product	body		models.CreateProductDTO	true	"product"
+//	@Success		200		{object}	models.SuccessResponse	"success response with product"
+//	@Failure		400		{object}	models.ErrorResponse	"bad request error"
+//	@Failure		500		{object}	models.ErrorResponse	"internal server error"
 //	@Router			/create [post]
 func (h *productsHandlers) Create() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -117,19 +135,19 @@ func (h *productsHandlers) Create() http.HandlerFunc {
 			return
 		}
 
-		id, err := h.productsUC.Create(requestData.Name, requestData.Tags)
+		product, err := h.productsUC.Create(&requestData, middleware.ContextGetUserUID(r))
 		if err != nil {
-			erp.ServerErrorResponse(w, r, h.logger, err)
+			if errors.Is(err, products.ErrUnknownTags) || errors.Is(err, products.ErrInvalidProduct) {
+				erp.BadRequestResponse(w, r, h.logger, err)
+			} else {
+				erp.ServerErrorResponse(w, r, h.logger, err)
+			}
 			return
 		}
 
-		messagePayload := kf.KafkaMessage{
-			Action: "product_create",
-			Time:   time.Now().Format(time.RFC3339),
-			Tags:   requestData.Tags,
-		}
+		messagePayload := productMessage("product_create", product)
 
-		err = h.productsUC.SendToKafka(r.Context(), strconv.Itoa(int(id)), messagePayload, h.kafkaProductWriter)
+		err = h.productsUC.SendToKafka(r.Context(), strconv.Itoa(int(product.ID)), messagePayload, h.kafkaProductWriter)
 		if err != nil {
 			erp.ServerErrorResponse(w, r, h.logger, err)
 			return
@@ -145,16 +163,21 @@ func (h *productsHandlers) Create() http.HandlerFunc {
 }
 
 //	@Summary		Edit a product
-//	@Description	Edits an existing product (admin-only).
+//	@Description	Edits an existing product (requires products:write), only the fields present in the request are changed. Tags are normalized and mapped to canonical tags, tags outside the vocabulary are rejected. With If-Match the product is only changed if it still has the given version.
 //	@Tags			products
 //	@Accept			json
 //	@Produce		json
 //	@Security		cookieAuth
-//	@Param			id	path		int						true	"Product ID"
-//	@Success		200	{object}	models.SuccessResponse	"success response with product"
-//	@Failure		400	{object}	models.ErrorResponse	"bad request error"
-//	@Failure		404	{object}	models.ErrorResponse	"not found error"
-//	@Failure		500	{object}	models.ErrorResponse	"internal server error"
+//	@Security		bearerAuth
+//	@Param			id			path		int						true	"Product ID"
+//	@Param			If-Match	header		string					false	"ETag of the edited product version"
+//	@Param			product		body		models.UpdateProductDTO	true	"product fields to update"
+//	@Success		200			{object}	models.SuccessResponse	"success response with product"
+//	@Header			200			{string}	ETag					"new product version"
+//	@Failure		400			{object}	models.ErrorResponse	"bad request error"
+//	@Failure		404			{object}	models.ErrorResponse	"not found error"
+//	@Failure		409			{object}	models.ErrorResponse	"edit conflict error"
+//	@Failure		500		{object}	models.ErrorResponse	"internal server error"
 //	@Router			/update/{id} [put]
 func (h *productsHandlers) Update() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -164,6 +187,12 @@ func (h *productsHandlers) Update() http.HandlerFunc {
 			return
 		}
 
+		version, err := utils.ReadIfMatch(r)
+		if err != nil {
+			erp.BadRequestResponse(w, r, h.logger, err)
+			return
+		}
+
 		var requestData models.UpdateProductDTO
 
 		if err = utils.ReadJSON(w, r, &requestData); err != nil {
@@ -171,22 +200,24 @@ func (h *productsHandlers) Update() http.HandlerFunc {
 			return
 		}
 
-		err = h.productsUC.Update(id, requestData.Name, requestData.Tags)
+		product, change, err := h.productsUC.Update(id, version, &requestData, middleware.ContextGetUserUID(r))
 		if err != nil {
-			if errors.Is(err, db.ErrRecordNotFound) {
+			switch {
+			case errors.Is(err, db.ErrRecordNotFound):
 				erp.NotFoundResponse(w, r, h.logger)
-			} else {
+			case errors.Is(err, db.ErrEditConflict):
+				erp.EditConflictResponse(w, r, h.logger)
+			case errors.Is(err, products.ErrUnknownTags),
+				errors.Is(err, products.ErrInvalidProduct):
+				erp.BadRequestResponse(w, r, h.logger, err)
+			default:
 				erp.ServerErrorResponse(w, r, h.logger, err)
 			}
 			return
 		}
 
-		if requestData.Tags != nil {
-			messagePayload := kf.KafkaMessage{
-				Action: "product_update",
-				Time:   time.Now().Format(time.RFC3339),
-				Tags:   requestData.Tags,
-			}
+		if change.Has(productEventFields...) {
+			messagePayload := productUpdateMessage(product, change)
 
 			err = h.productsUC.SendToKafka(r.Context(), strconv.Itoa(int(id)), messagePayload, h.kafkaProductWriter)
 			if err != nil {
@@ -195,9 +226,95 @@ func (h *productsHandlers) Update() http.HandlerFunc {
 			}
 		}
 
+		headers := make(http.Header)
+		headers.Set("ETag", utils.VersionETag(product.Version))
+
 		err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{
 			"message": "product updated successfully",
-		}, nil)
+		}, headers)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+		}
+	}
+}
+
+//	@Summary		Patch a product
+//	@Description	Applies a JSON merge patch (RFC 7396) to a product (requires products:write). Fields present in the patch are replaced, fields set to null are cleared (stock null stops stock tracking) and other fields are kept. Unknown fields and fields of a wrong type are rejected. A patch that changes nothing creates no new version, a product event is published only when tags, status or stock change and lists the changed fields. With If-Match the product is only changed if it still has the given version.
+//	@Tags			products
+//	@Accept			application/merge-patch+json
+//	@Produce		json
+//	@Security		cookieAuth
+//	@Security		bearerAuth
+//	@Param			id			path		int						true	"Product ID"
+//	@Param			If-Match	header		string					false	"ETag of the patched product version"
+//	@Param			patch		body		models.PatchProductDTO	true	"merge patch of product fields"
+//	@Success		200			{object}	models.ProductResponse	"success response with the patched product"
+//	@Header			200			{string}	ETag					"product version"
+//	@Failure		400			{object}	models.ErrorResponse	"bad request error"
+//	@Failure		404			{object}	models.ErrorResponse	"not found error"
+//	@Failure		409			{object}	models.ErrorResponse	"edit conflict error"
+//	@Failure		415			{object}	models.ErrorResponse	"unsupported media type error"
+//	@Failure		500			{object}	models.ErrorResponse	"internal server error"
+//	@Router			/update/{id} [patch]
+func (h *productsHandlers) Patch() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := utils.ReadIDParam(r)
+		if err != nil {
+			erp.NotFoundResponse(w, r, h.logger)
+			return
+		}
+
+		if !mergepatch.IsContentType(r.Header.Get("Content-Type")) {
+			erp.UnsupportedMediaTypeResponse(w, r, h.logger)
+			return
+		}
+
+		version, err := utils.ReadIfMatch(r)
+		if err != nil {
+			erp.BadRequestResponse(w, r, h.logger, err)
+			return
+		}
+
+		var patch json.RawMessage
+
+		if err = utils.ReadJSON(w, r, &patch); err != nil {
+			erp.BadRequestResponse(w, r, h.logger, err)
+			return
+		}
+
+		product, change, err := h.productsUC.Patch(id, version, patch, middleware.ContextGetUserUID(r))
+		if err != nil {
+			switch {
+			case errors.Is(err, db.ErrRecordNotFound):
+				erp.NotFoundResponse(w, r, h.logger)
+			case errors.Is(err, db.ErrEditConflict):
+				erp.EditConflictResponse(w, r, h.logger)
+			case errors.Is(err, mergepatch.ErrInvalidPatch),
+				errors.Is(err, products.ErrUnknownTags),
+				errors.Is(err, products.ErrInvalidProduct):
+				erp.BadRequestResponse(w, r, h.logger, err)
+			default:
+				erp.ServerErrorResponse(w, r, h.logger, err)
+			}
+			return
+		}
+
+		if change.Has(productEventFields...) {
+			messagePayload := productUpdateMessage(product, change)
+
+			err = h.productsUC.SendToKafka(r.Context(), strconv.Itoa(int(id)), messagePayload, h.kafkaProductWriter)
+			if err != nil {
+				erp.ServerErrorResponse(w, r, h.logger, err)
+				return
+			}
+		}
+
+		headers := make(http.Header)
+		headers.Set("ETag", utils.VersionETag(product.Version))
+
+		err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+			"product": product,
+		}, headers)
 		if err != nil {
 			erp.ServerErrorResponse(w, r, h.logger, err)
 		}
@@ -205,14 +322,17 @@ func (h *productsHandlers) Update() http.HandlerFunc {
 }
 
 //	@Summary		Delete a product
-//	@Description	Deletes an existing product (admin-only).
+//	@Description	Soft-deletes an existing product (requires products:delete). The product can be restored until it is purged. With If-Match the product is only deleted if it still has the given version.
 //	@Tags			products
 //	@Produce		json
 //	@Security		cookieAuth
-//	@Param			id	path		int						true	"Product ID"
-//	@Success		200	{object}	models.SuccessResponse	"success response with product"
-//	@Failure		400	{object}	models.ErrorResponse	"bad request error"
-//	@Failure		404	{object}	models.ErrorResponse	"not found error"
+//	@Security		bearerAuth
+//	@Param			id			path		int						true	"Product ID"
+//	@Param			If-Match	header		string					false	"ETag of the deleted product version"
+//	@Success		200			{object}	models.SuccessResponse	"success response with product"
+//	@Failure		400			{object}	models.ErrorResponse	"bad request error"
+//	@Failure		404			{object}	models.ErrorResponse	"not found error"
+//	@Failure		409			{object}	models.ErrorResponse	"edit conflict error"
 //	@Failure		500	{object}	models.ErrorResponse	"internal server error"
 //	@Router			/delete/{id} [delete]
 func (h *productsHandlers) Delete() http.HandlerFunc {
@@ -223,11 +343,20 @@ func (h *productsHandlers) Delete() http.HandlerFunc {
 			return
 		}
 
-		err = h.productsUC.Delete(id)
+		version, err := utils.ReadIfMatch(r)
 		if err != nil {
-			if errors.Is(err, db.ErrRecordNotFound) {
+			erp.BadRequestResponse(w, r, h.logger, err)
+			return
+		}
+
+		err = h.productsUC.Delete(id, version, middleware.ContextGetUserUID(r))
+		if err != nil {
+			switch {
+			case errors.Is(err, db.ErrRecordNotFound):
 				erp.NotFoundResponse(w, r, h.logger)
-			} else {
+			case errors.Is(err, db.ErrEditConflict):
+				erp.EditConflictResponse(w, r, h.logger)
+			default:
 				erp.ServerErrorResponse(w, r, h.logger, err)
 			}
 			return
@@ -253,3 +382,447 @@ func (h *productsHandlers) Delete() http.HandlerFunc {
 		}
 	}
 }
+
+//	@Summary		Restore a product
+//	@Description	Restores a soft-deleted product (requires products:delete) and publishes it again.
+//	@Tags			products
+//	@Produce		json
+//	@Security		cookieAuth
+//	@Security		bearerAuth
+//	@Param			id	path		int						true	"Product ID"
+//	@Success		200	{object}	models.ProductResponse	"success response with product"
+//	@Header			200	{string}	ETag					"product version"
+//	@Failure		404	{object}	models.ErrorResponse	"not found error"
+//	@Failure		500	{object}	models.ErrorResponse	"internal server error"
+//	@Router			/restore/{id} [post]
+func (h *productsHandlers) Restore() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := utils.ReadIDParam(r)
+		if err != nil {
+			erp.NotFoundResponse(w, r, h.logger)
+			return
+		}
+
+		product, err := h.productsUC.Restore(id, middleware.ContextGetUserUID(r))
+		if err != nil {
+			if errors.Is(err, db.ErrRecordNotFound) {
+				erp.NotFoundResponse(w, r, h.logger)
+			} else {
+				erp.ServerErrorResponse(w, r, h.logger, err)
+			}
+			return
+		}
+
+		messagePayload := productMessage("product_restore", product)
+
+		err = h.productsUC.SendToKafka(r.Context(), strconv.Itoa(int(id)), messagePayload, h.kafkaProductWriter)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+			return
+		}
+
+		headers := make(http.Header)
+		headers.Set("ETag", utils.VersionETag(product.Version))
+
+		err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+			"product": product,
+		}, headers)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+		}
+	}
+}
+
+//	@Summary		List deleted products
+//	@Description	Retrieves soft-deleted products that are not purged yet, most recently deleted first (requires products:delete).
+//	@Tags			products
+//	@Produce		json
+//	@Security		cookieAuth
+//	@Security		bearerAuth
+//	@Success		200	{object}	models.ProductsResponse	"success response with products"
+//	@Failure		500	{object}	models.ErrorResponse	"internal server error"
+//	@Router			/deleted [get]
+func (h *productsHandlers) ListDeleted() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		productList, err := h.productsUC.ListDeleted()
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+			return
+		}
+
+		err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+			"products": productList,
+		}, nil)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+		}
+	}
+}
+
+//	@Summary		Get product history
+//	@Description	Retrieves all revisions of the product, newest first (requires products:write). Each revision holds the full state of the product after a change, who made it and when.
+//	@Tags			products
+//	@Produce		json
+//	@Security		cookieAuth
+//	@Security		bearerAuth
+//	@Param			id	path		int							true	"Product ID"
+//	@Success		200	{object}	models.RevisionsResponse	"success response with revisions"
+//	@Failure		404	{object}	models.ErrorResponse		"not found error"
+//	@Failure		500	{object}	models.ErrorResponse		"internal server error"
+//	@Router			/history/{id} [get]
+func (h *productsHandlers) History() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := utils.ReadIDParam(r)
+		if err != nil {
+			erp.NotFoundResponse(w, r, h.logger)
+			return
+		}
+
+		revisions, err := h.productsUC.History(id)
+		if err != nil {
+			if errors.Is(err, db.ErrRecordNotFound) {
+				erp.NotFoundResponse(w, r, h.logger)
+			} else {
+				erp.ServerErrorResponse(w, r, h.logger, err)
+			}
+			return
+		}
+
+		err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+			"revisions": revisions,
+		}, nil)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+		}
+	}
+}
+
+//	@Summary		Get product revision
+//	@Description	Retrieves the revision of the product with the given version (requires products:write).
+//	@Tags			products
+//	@Produce		json
+//	@Security		cookieAuth
+//	@Security		bearerAuth
+//	@Param			id		path		int						true	"Product ID"
+//	@Param			version	path		int						true	"Product version"
+//	@Success		200		{object}	models.RevisionResponse	"success response with revision"
+//	@Failure		404		{object}	models.ErrorResponse	"not found error"
+//	@Failure		500		{object}	models.ErrorResponse	"internal server error"
+//	@Router			/history/{id}/{version} [get]
+func (h *productsHandlers) Revision() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := utils.ReadIDParam(r)
+		if err != nil {
+			erp.NotFoundResponse(w, r, h.logger)
+			return
+		}
+
+		version, err := utils.ReadVersionParam(r)
+		if err != nil {
+			erp.NotFoundResponse(w, r, h.logger)
+			return
+		}
+
+		revision, err := h.productsUC.Revision(id, version)
+		if err != nil {
+			if errors.Is(err, db.ErrRecordNotFound) {
+				erp.NotFoundResponse(w, r, h.logger)
+			} else {
+				erp.ServerErrorResponse(w, r, h.logger, err)
+			}
+			return
+		}
+
+		err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+			"revision": revision,
+		}, nil)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+		}
+	}
+}
+
+//	@Summary		Roll back a product
+//	@Description	Restores the state of the product from the given version (requires products:write). The rollback is recorded as a new version, tags of the old version must still be in the vocabulary.
+//	@Tags			products
+//	@Produce		json
+//	@Security		cookieAuth
+//	@Security		bearerAuth
+//	@Param			id		path		int						true	"Product ID"
+//	@Param			version	path		int						true	"Version to roll back to"
+//	@Success		200		{object}	models.ProductResponse	"success response with product"
+//	@Header			200		{string}	ETag					"new product version"
+//	@Failure		400		{object}	models.ErrorResponse	"bad request error"
+//	@Failure		404		{object}	models.ErrorResponse	"not found error"
+//	@Failure		409		{object}	models.ErrorResponse	"edit conflict error"
+//	@Failure		500		{object}	models.ErrorResponse	"internal server error"
+//	@Router			/rollback/{id}/{version} [post]
+func (h *productsHandlers) Rollback() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := utils.ReadIDParam(r)
+		if err != nil {
+			erp.NotFoundResponse(w, r, h.logger)
+			return
+		}
+
+		version, err := utils.ReadVersionParam(r)
+		if err != nil {
+			erp.NotFoundResponse(w, r, h.logger)
+			return
+		}
+
+		product, change, err := h.productsUC.Rollback(id, version, middleware.ContextGetUserUID(r))
+		if err != nil {
+			switch {
+			case errors.Is(err, db.ErrRecordNotFound):
+				erp.NotFoundResponse(w, r, h.logger)
+			case errors.Is(err, db.ErrEditConflict):
+				erp.EditConflictResponse(w, r, h.logger)
+			case errors.Is(err, products.ErrUnknownTags),
+				errors.Is(err, products.ErrInvalidProduct):
+				erp.BadRequestResponse(w, r, h.logger, err)
+			default:
+				erp.ServerErrorResponse(w, r, h.logger, err)
+			}
+			return
+		}
+
+		if change.Has(productEventFields...) {
+			messagePayload := productUpdateMessage(product, change)
+
+			err = h.productsUC.SendToKafka(r.Context(), strconv.Itoa(int(id)), messagePayload, h.kafkaProductWriter)
+			if err != nil {
+				erp.ServerErrorResponse(w, r, h.logger, err)
+				return
+			}
+		}
+
+		headers := make(http.Header)
+		headers.Set("ETag", utils.VersionETag(product.Version))
+
+		err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+			"product": product,
+		}, headers)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+		}
+	}
+}
+
+//	@Summary		Import products
+//	@Description	Imports products from CSV (with a header naming the columns) or NDJSON (requires products:write). A row with an id updates the product and only its fields present in the row are changed, other rows create products. Rows are checked one by one and failed rows are skipped and reported with their line. In dry run mode the rows are only checked. Product events are published in batches.
+//	@Tags			products
+//	@Accept			text/csv
+//	@Accept			application/x-ndjson
+//	@Produce		json
+//	@Security		cookieAuth
+//	@Security		bearerAuth
+//	@Param			format	query		string					false	"csv or ndjson, taken from Content-Type by default"
+//	@Param			dry_run	query		bool					false	"only check the rows"
+//	@Success		200		{object}	models.ImportResponse	"import report"
+//	@Failure		400		{object}	models.ErrorResponse	"bad request error"
+//	@Failure		500		{object}	models.ErrorResponse	"internal server error"
+//	@Router			/import [post]
+func (h *productsHandlers) Import() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		format, err := importFormat(r.URL.Query().Get("format"), r.Header.Get("Content-Type"))
+		if err != nil {
+			erp.BadRequestResponse(w, r, h.logger, err)
+			return
+		}
+
+		dryRun := r.URL.Query().Get("dry_run") == "true"
+
+		r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+
+		decoder, err := newRowDecoder(format, r.Body)
+		if err != nil {
+			erp.BadRequestResponse(w, r, h.logger, err)
+			return
+		}
+
+		changedBy := middleware.ContextGetUserUID(r)
+		report := models.ImportReport{DryRun: dryRun, Errors: []models.ImportError{}}
+		events := make([]kf.Event, 0, h.cfg.Import.BatchSize)
+
+		fail := func(line int, err error) {
+			report.Failed++
+			if len(report.Errors) < maxImportErrors {
+				report.Errors = append(report.Errors, models.ImportError{Line: line, Error: importErrorMessage(err)})
+			}
+		}
+
+		for rows := 0; ; rows++ {
+			row, line, err := decoder.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				fail(line, err)
+				if errors.Is(err, errInvalidRow) {
+					continue
+				}
+				break
+			}
+
+			if h.cfg.Import.MaxRows > 0 && rows >= h.cfg.Import.MaxRows {
+				fail(line, fmt.Errorf("at most %d rows can be imported at once", h.cfg.Import.MaxRows))
+				break
+			}
+
+			product, change, err := h.productsUC.Import(row, dryRun, changedBy)
+			if err != nil {
+				switch {
+				case errors.Is(err, db.ErrRecordNotFound),
+					errors.Is(err, db.ErrEditConflict),
+					errors.Is(err, products.ErrUnknownTags),
+					errors.Is(err, products.ErrInvalidProduct):
+					fail(line, err)
+					continue
+				}
+
+				if err := h.productsUC.SendBatchToKafka(r.Context(), events, h.kafkaProductWriter); err != nil {
+					h.logger.Error("failed to publish imported products", zap.Error(err))
+				}
+				erp.ServerErrorResponse(w, r, h.logger, err)
+				return
+			}
+
+			if row.ID == 0 {
+				report.Created++
+			} else {
+				report.Updated++
+			}
+
+			if dryRun {
+				continue
+			}
+
+			switch {
+			case row.ID == 0:
+				events = append(events, kf.Event{Key: strconv.Itoa(int(product.ID)), Message: productMessage("product_create", product)})
+			case change.Has(productEventFields...):
+				events = append(events, kf.Event{Key: strconv.Itoa(int(product.ID)), Message: productUpdateMessage(product, change)})
+			}
+
+			if len(events) >= h.cfg.Import.BatchSize {
+				if err = h.productsUC.SendBatchToKafka(r.Context(), events, h.kafkaProductWriter); err != nil {
+					erp.ServerErrorResponse(w, r, h.logger, err)
+					return
+				}
+				events = events[:0]
+			}
+		}
+
+		if len(events) > 0 {
+			if err = h.productsUC.SendBatchToKafka(r.Context(), events, h.kafkaProductWriter); err != nil {
+				erp.ServerErrorResponse(w, r, h.logger, err)
+				return
+			}
+		}
+
+		err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+			"report": report,
+		}, nil)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+		}
+	}
+}
+
+//	@Summary		Export products
+//	@Description	Streams the catalog ordered by id as CSV or NDJSON (requires products:write), in the format accepted by import. Deleted products are left out.
+//	@Tags			products
+//	@Produce		text/csv
+//	@Produce		application/x-ndjson
+//	@Security		cookieAuth
+//	@Security		bearerAuth
+//	@Param			format	query		string					false	"csv or ndjson (default)"
+//	@Success		200		{string}	string					"products"
+//	@Failure		400		{object}	models.ErrorResponse	"bad request error"
+//	@Failure		500		{object}	models.ErrorResponse	"internal server error"
+//	@Router			/export [get]
+func (h *productsHandlers) Export() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = formatNDJSON
+		}
+		if format != formatCSV && format != formatNDJSON {
+			erp.BadRequestResponse(w, r, h.logger, errUnsupportedFormat)
+			return
+		}
+
+		// The first page is read before the response starts, so its failure is still reported
+		page, err := h.productsUC.ListAfter(0, h.cfg.Import.BatchSize)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", formatContentType(format))
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="products.%s"`, format))
+		w.WriteHeader(http.StatusOK)
+
+		encoder, err := newRowEncoder(format, w)
+		if err != nil {
+			h.logger.Error("failed to export products", zap.Error(err))
+			return
+		}
+
+		for len(page) > 0 {
+			for i := range page {
+				if err = encoder.Encode(&page[i]); err != nil {
+					h.logger.Error("failed to export products", zap.Error(err))
+					return
+				}
+			}
+
+			if err = encoder.Flush(); err != nil {
+				h.logger.Error("failed to export products", zap.Error(err))
+				return
+			}
+			if flusher, ok := w.(http.Flusher); ok {
+				flusher.Flush()
+			}
+
+			if len(page) < h.cfg.Import.BatchSize {
+				break
+			}
+
+			page, err = h.productsUC.ListAfter(page[len(page)-1].ID, h.cfg.Import.BatchSize)
+			if err != nil {
+				h.logger.Error("failed to export products", zap.Error(err))
+				return
+			}
+		}
+	}
+}
+
+// Import row error message
+func importErrorMessage(err error) string {
+	if errors.Is(err, db.ErrRecordNotFound) {
+		return "product not found"
+	}
+	return err.Error()
+}
+
+// Product event with the fields recommendations need: tags, status and stock
+func productMessage(action string, product *models.Product) kf.KafkaMessage {
+	return kf.KafkaMessage{
+		Action: action,
+		Time:   time.Now().Format(time.RFC3339),
+		Tags:   product.Tags,
+		Status: product.Status,
+		Stock:  product.Stock,
+	}
+}
+
+// Product update event, it also lists the changed fields and the tags added and removed
+func productUpdateMessage(product *models.Product, change *models.ProductChange) kf.KafkaMessage {
+	message := productMessage("product_update", product)
+	message.Changed = change.Fields
+	message.AddedTags = change.AddedTags
+	message.RemovedTags = change.RemovedTags
+	return message
+}
@@ -4,9 +4,14 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/julienschmidt/httprouter"
@@ -15,9 +20,13 @@ import (
 	"go.uber.org/zap"
 
 	"cyansnbrst/products-service/config"
+	"cyansnbrst/products-service/internal/middleware"
 	"cyansnbrst/products-service/internal/models"
+	"cyansnbrst/products-service/internal/products"
 	mock_products "cyansnbrst/products-service/internal/products/mock"
 	"cyansnbrst/products-service/pkg/db"
+	kf "cyansnbrst/products-service/pkg/kafka"
+	"cyansnbrst/products-service/pkg/mergepatch"
 )
 
 func TestProductsHandlers_Get(t *testing.T) {
@@ -31,30 +40,63 @@ func TestProductsHandlers_Get(t *testing.T) {
 	mockProductsUC := mock_products.NewMockUseCase(ctrl)
 	kafkaWriter := &kafka.Writer{}
 
-	productHandler := NewProductsHandlers(cfg, mockProductsUC, logger, kafkaWriter, kafkaWriter)
-
 	tests := []struct {
 		name         string
 		id           string
+		buffer       config.EventBuffer
 		mockBehavior func(mockProductsUC *mock_products.MockUseCase)
 		wantStatus   int
+		wantDropped  map[string]int
 	}{
 		{
-			name: "successful get product",
-			id:   "1",
+			name:   "successful get product",
+			id:     "1",
+			buffer: config.EventBuffer{Size: 10, BatchSize: 10, FlushInterval: time.Hour, Overflow: kf.OverflowDrop},
 			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {
 				mockProductsUC.EXPECT().Get(int64(1)).Return(&models.Product{ID: 1, Name: "product", Tags: []string{"all"}}, nil)
-				mockProductsUC.EXPECT().SendToKafka(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				mockProductsUC.EXPECT().SendViewsToKafka(gomock.Any(), gomock.Any(), kafkaWriter).
+					DoAndReturn(func(_ context.Context, events []kf.Event, _ *kafka.Writer) error {
+						require.Len(t, events, 1)
+						require.Equal(t, "1", events[0].Key)
+						require.Equal(t, "view_products", events[0].Message.Action)
+						require.Equal(t, "user", events[0].Viewer)
+						require.Equal(t, "Mozilla/5.0", events[0].UserAgent)
+						return nil
+					})
 			},
-			wantStatus: http.StatusOK,
+			wantStatus:  http.StatusOK,
+			wantDropped: map[string]int{},
 		},
 		{
-			name: "product not found",
-			id:   "2",
+			name:   "kafka unavailable",
+			id:     "1",
+			buffer: config.EventBuffer{Size: 10, BatchSize: 10, FlushInterval: time.Hour, Overflow: kf.OverflowDrop},
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {
+				mockProductsUC.EXPECT().Get(int64(1)).Return(&models.Product{ID: 1, Name: "product", Tags: []string{"all"}}, nil)
+				mockProductsUC.EXPECT().SendViewsToKafka(gomock.Any(), gomock.Any(), kafkaWriter).Return(errors.New("kafka is down"))
+			},
+			wantStatus:  http.StatusOK,
+			wantDropped: map[string]int{"publish": 1},
+		},
+		{
+			name:   "view buffer full",
+			id:     "1",
+			buffer: config.EventBuffer{Size: 0, BatchSize: 10, FlushInterval: time.Hour, Overflow: kf.OverflowDrop},
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {
+				mockProductsUC.EXPECT().Get(int64(1)).Return(&models.Product{ID: 1, Name: "product", Tags: []string{"all"}}, nil)
+			},
+			wantStatus:  http.StatusOK,
+			wantDropped: map[string]int{"overflow": 1},
+		},
+		{
+			name:   "product not found",
+			id:     "2",
+			buffer: config.EventBuffer{Size: 10, BatchSize: 10, FlushInterval: time.Hour, Overflow: kf.OverflowDrop},
 			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {
 				mockProductsUC.EXPECT().Get(int64(2)).Return(nil, db.ErrRecordNotFound)
 			},
-			wantStatus: http.StatusNotFound,
+			wantStatus:  http.StatusNotFound,
+			wantDropped: map[string]int{},
 		},
 	}
 
@@ -63,7 +105,14 @@ func TestProductsHandlers_Get(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mockBehavior(mockProductsUC)
 
+			metrics := &bufferMetrics{dropped: map[string]int{}}
+			viewEvents := kf.NewBuffer(tt.buffer, "views", func(ctx context.Context, events []kf.Event) error {
+				return mockProductsUC.SendViewsToKafka(ctx, events, kafkaWriter)
+			}, metrics, logger)
+			productHandler := NewProductsHandlers(cfg, mockProductsUC, logger, kafkaWriter, kafkaWriter, viewEvents)
+
 			req := httptest.NewRequest(http.MethodGet, "/products/"+tt.id, nil)
+			req.Header.Set("User-Agent", "Mozilla/5.0")
 
 			params := httprouter.Params{
 				httprouter.Param{
@@ -74,14 +123,34 @@ func TestProductsHandlers_Get(t *testing.T) {
 
 			ctx := context.WithValue(req.Context(), httprouter.ParamsKey, params)
 			req = req.WithContext(ctx)
+			req = middleware.ContextSetUserUID(req, "user")
 
 			rr := httptest.NewRecorder()
 			productHandler.Get().ServeHTTP(rr, req)
 			require.Equal(t, tt.wantStatus, rr.Code)
+
+			// Views are published when the buffer is flushed on shutdown
+			runCtx, stop := context.WithCancel(context.Background())
+			stop()
+			viewEvents.Run(runCtx)
+			require.Equal(t, tt.wantDropped, metrics.dropped)
 		})
 	}
 }
 
+// Buffer metrics counting dropped events by reason
+type bufferMetrics struct {
+	dropped map[string]int
+}
+
+func (m *bufferMetrics) AddDroppedEvents(_, reason string, count int) {
+	m.dropped[reason] += count
+}
+
+func (m *bufferMetrics) AddPublishedEvents(string, int) {}
+
+func (m *bufferMetrics) SetQueuedEvents(string, int) {}
+
 func TestProductsHandlers_Create(t *testing.T) {
 	cfg := &config.Config{}
 	logger := zap.NewNop()
@@ -92,7 +161,7 @@ func TestProductsHandlers_Create(t *testing.T) {
 	mockProductsUC := mock_products.NewMockUseCase(ctrl)
 	kafkaWriter := &kafka.Writer{}
 
-	productHandler := NewProductsHandlers(cfg, mockProductsUC, logger, kafkaWriter, kafkaWriter)
+	productHandler := NewProductsHandlers(cfg, mockProductsUC, logger, kafkaWriter, kafkaWriter, nil)
 
 	tests := []struct {
 		name         string
@@ -107,11 +176,35 @@ func TestProductsHandlers_Create(t *testing.T) {
 				Tags: []string{"new"},
 			},
 			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {
-				mockProductsUC.EXPECT().Create("product", []string{"new"}).Return(int64(1), nil)
-				mockProductsUC.EXPECT().SendToKafka(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				mockProductsUC.EXPECT().Create(&models.CreateProductDTO{Name: "product", Tags: []string{"new"}}, "admin").
+					Return(&models.Product{ID: 1, Name: "product", Tags: []string{"new"}, Status: models.ProductStatusActive, Version: 1}, nil)
+				mockProductsUC.EXPECT().SendToKafka(gomock.Any(), "1", kafkaMessage("product_create", []string{"new"}), gomock.Any()).Return(nil)
 			},
 			wantStatus: http.StatusCreated,
 		},
+		{
+			name: "unknown tags",
+			requestBody: models.CreateProductDTO{
+				Name: "product",
+				Tags: []string{"musics"},
+			},
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {
+				mockProductsUC.EXPECT().Create(&models.CreateProductDTO{Name: "product", Tags: []string{"musics"}}, "admin").Return(nil, products.ErrUnknownTags)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "invalid product",
+			requestBody: models.CreateProductDTO{
+				Name:  "product",
+				Price: -100,
+				Tags:  []string{"new"},
+			},
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {
+				mockProductsUC.EXPECT().Create(gomock.Any(), "admin").Return(nil, products.ErrInvalidProduct)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
 		{
 			name: "empty name",
 			requestBody: models.CreateProductDTO{
@@ -141,6 +234,7 @@ func TestProductsHandlers_Create(t *testing.T) {
 			require.NoError(t, err)
 
 			req := httptest.NewRequest(http.MethodPost, "/products/create", bytes.NewReader(jsonBody))
+			req = middleware.ContextSetUserUID(req, "admin")
 			rr := httptest.NewRecorder()
 
 			productHandler.Create().ServeHTTP(rr, req)
@@ -159,17 +253,52 @@ func TestProductsHandlers_Update(t *testing.T) {
 	mockProductsUC := mock_products.NewMockUseCase(ctrl)
 	kafkaWriter := &kafka.Writer{}
 
-	productHandler := NewProductsHandlers(cfg, mockProductsUC, logger, kafkaWriter, kafkaWriter)
+	productHandler := NewProductsHandlers(cfg, mockProductsUC, logger, kafkaWriter, kafkaWriter, nil)
 
 	updatedName := "new name"
+	archived := models.ProductStatusArchived
+	version := int64(1)
 
 	tests := []struct {
 		name         string
 		id           string
+		ifMatch      string
 		requestBody  models.UpdateProductDTO
 		mockBehavior func(mockProductsUC *mock_products.MockUseCase)
 		wantStatus   int
+		wantETag     string
 	}{
+		{
+			name:        "successful update of the edited version",
+			id:          "1",
+			ifMatch:     `"1"`,
+			requestBody: models.UpdateProductDTO{Name: &updatedName},
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {
+				product := &models.Product{ID: 1, Name: updatedName, Version: 2}
+				mockProductsUC.EXPECT().Update(int64(1), &version, &models.UpdateProductDTO{Name: &updatedName}, "admin").
+					Return(product, &models.ProductChange{Fields: []string{"name"}}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantETag:   `"2"`,
+		},
+		{
+			name:        "product changed since it was read",
+			id:          "1",
+			ifMatch:     `"1"`,
+			requestBody: models.UpdateProductDTO{Name: &updatedName},
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {
+				mockProductsUC.EXPECT().Update(int64(1), &version, &models.UpdateProductDTO{Name: &updatedName}, "admin").Return(nil, nil, db.ErrEditConflict)
+			},
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:         "invalid If-Match header",
+			id:           "1",
+			ifMatch:      `W/"1"`,
+			requestBody:  models.UpdateProductDTO{Name: &updatedName},
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {},
+			wantStatus:   http.StatusBadRequest,
+		},
 		{
 			name: "successful update product",
 			id:   "1",
@@ -178,11 +307,80 @@ func TestProductsHandlers_Update(t *testing.T) {
 				Tags: []string{"updated"},
 			},
 			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {
-				mockProductsUC.EXPECT().Update(int64(1), &updatedName, []string{"updated"}).Return(nil)
-				mockProductsUC.EXPECT().SendToKafka(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+				product := &models.Product{ID: 1, Name: updatedName, Tags: []string{"update"}, Version: 2}
+				change := &models.ProductChange{Fields: []string{"name", "tags"}, AddedTags: []string{"update"}, RemovedTags: []string{"music"}}
+				mockProductsUC.EXPECT().Update(int64(1), nil, &models.UpdateProductDTO{Name: &updatedName, Tags: []string{"updated"}}, "admin").Return(product, change, nil)
+				mockProductsUC.EXPECT().SendToKafka(gomock.Any(), "1", gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ string, message kf.KafkaMessage, _ *kafka.Writer) error {
+						require.Equal(t, "product_update", message.Action)
+						require.Equal(t, []string{"update"}, message.Tags)
+						require.Equal(t, []string{"name", "tags"}, message.Changed)
+						require.Equal(t, []string{"update"}, message.AddedTags)
+						require.Equal(t, []string{"music"}, message.RemovedTags)
+						return nil
+					})
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "status change is published",
+			id:   "1",
+			requestBody: models.UpdateProductDTO{
+				Status: &archived,
+			},
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {
+				product := &models.Product{ID: 1, Name: "product", Tags: []string{"music"}, Status: archived, Version: 2}
+				mockProductsUC.EXPECT().Update(int64(1), nil, &models.UpdateProductDTO{Status: &archived}, "admin").
+					Return(product, &models.ProductChange{Fields: []string{"status"}}, nil)
+				mockProductsUC.EXPECT().SendToKafka(gomock.Any(), "1", gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ string, message kf.KafkaMessage, _ *kafka.Writer) error {
+						require.Equal(t, "product_update", message.Action)
+						require.Equal(t, archived, message.Status)
+						require.Equal(t, []string{"music"}, message.Tags)
+						require.Equal(t, []string{"status"}, message.Changed)
+						require.Nil(t, message.AddedTags)
+						return nil
+					})
 			},
 			wantStatus: http.StatusOK,
 		},
+		{
+			name: "name change is not published",
+			id:   "1",
+			requestBody: models.UpdateProductDTO{
+				Name: &updatedName,
+			},
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {
+				product := &models.Product{ID: 1, Name: updatedName, Tags: []string{"music"}, Version: 2}
+				mockProductsUC.EXPECT().Update(int64(1), nil, &models.UpdateProductDTO{Name: &updatedName}, "admin").
+					Return(product, &models.ProductChange{Fields: []string{"name"}}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "unchanged tags are not published",
+			id:   "1",
+			requestBody: models.UpdateProductDTO{
+				Tags: []string{"Music"},
+			},
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {
+				product := &models.Product{ID: 1, Name: "product", Tags: []string{"music"}, Version: 1}
+				mockProductsUC.EXPECT().Update(int64(1), nil, &models.UpdateProductDTO{Tags: []string{"Music"}}, "admin").
+					Return(product, &models.ProductChange{}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "unknown tags",
+			id:   "1",
+			requestBody: models.UpdateProductDTO{
+				Tags: []string{"musics"},
+			},
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {
+				mockProductsUC.EXPECT().Update(int64(1), nil, &models.UpdateProductDTO{Tags: []string{"musics"}}, "admin").Return(nil, nil, products.ErrUnknownTags)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
 		{
 			name: "product not found",
 			id:   "2",
@@ -191,7 +389,7 @@ func TestProductsHandlers_Update(t *testing.T) {
 				Tags: []string{"updated"},
 			},
 			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {
-				mockProductsUC.EXPECT().Update(int64(2), &updatedName, []string{"updated"}).Return(db.ErrRecordNotFound)
+				mockProductsUC.EXPECT().Update(int64(2), nil, &models.UpdateProductDTO{Name: &updatedName, Tags: []string{"updated"}}, "admin").Return(nil, nil, db.ErrRecordNotFound)
 			},
 			wantStatus: http.StatusNotFound,
 		},
@@ -206,6 +404,9 @@ func TestProductsHandlers_Update(t *testing.T) {
 			require.NoError(t, err)
 
 			req := httptest.NewRequest(http.MethodPut, "/products/update/"+tt.id, bytes.NewReader(jsonBody))
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
 
 			params := httprouter.Params{
 				httprouter.Param{
@@ -216,10 +417,166 @@ func TestProductsHandlers_Update(t *testing.T) {
 
 			ctx := context.WithValue(req.Context(), httprouter.ParamsKey, params)
 			req = req.WithContext(ctx)
+			req = middleware.ContextSetUserUID(req, "admin")
 
 			rr := httptest.NewRecorder()
 			productHandler.Update().ServeHTTP(rr, req)
 			require.Equal(t, tt.wantStatus, rr.Code)
+			if tt.wantETag != "" {
+				require.Equal(t, tt.wantETag, rr.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestProductsHandlers_Patch(t *testing.T) {
+	cfg := &config.Config{}
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProductsUC := mock_products.NewMockUseCase(ctrl)
+	kafkaWriter := &kafka.Writer{}
+
+	productHandler := NewProductsHandlers(cfg, mockProductsUC, logger, kafkaWriter, kafkaWriter, nil)
+
+	version := int64(2)
+
+	tests := []struct {
+		name         string
+		id           string
+		ifMatch      string
+		contentType  string
+		body         string
+		mockBehavior func(mockProductsUC *mock_products.MockUseCase)
+		wantStatus   int
+		wantETag     string
+	}{
+		{
+			name:        "patch of a field outside events",
+			id:          "1",
+			contentType: "application/merge-patch+json",
+			body:        `{"description":"remastered"}`,
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {
+				product := &models.Product{ID: 1, Description: "remastered", Version: 3}
+				mockProductsUC.EXPECT().Patch(int64(1), nil, []byte(`{"description":"remastered"}`), "admin").
+					Return(product, &models.ProductChange{Fields: []string{"description"}}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantETag:   `"3"`,
+		},
+		{
+			name:        "patch of tags publishes the changed fields",
+			id:          "1",
+			ifMatch:     `"2"`,
+			contentType: "application/merge-patch+json",
+			body:        `{"tags":["jazz"],"stock":null}`,
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {
+				product := &models.Product{ID: 1, Tags: []string{"jazz"}, Status: models.ProductStatusActive, Version: 3}
+				mockProductsUC.EXPECT().Patch(int64(1), &version, gomock.Any(), "admin").
+					Return(product, &models.ProductChange{Fields: []string{"stock", "tags"}, AddedTags: []string{"jazz"}, RemovedTags: []string{"rock"}}, nil)
+				mockProductsUC.EXPECT().SendToKafka(gomock.Any(), "1", gomock.Any(), kafkaWriter).
+					DoAndReturn(func(_ context.Context, _ string, message kf.KafkaMessage, _ *kafka.Writer) error {
+						require.Equal(t, "product_update", message.Action)
+						require.Equal(t, []string{"jazz"}, message.Tags)
+						require.Equal(t, []string{"stock", "tags"}, message.Changed)
+						require.Equal(t, []string{"jazz"}, message.AddedTags)
+						require.Equal(t, []string{"rock"}, message.RemovedTags)
+						return nil
+					})
+			},
+			wantStatus: http.StatusOK,
+			wantETag:   `"3"`,
+		},
+		{
+			name:        "patch without changes",
+			id:          "1",
+			contentType: "application/json",
+			body:        `{"tags":["jazz"]}`,
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {
+				product := &models.Product{ID: 1, Tags: []string{"jazz"}, Version: 2}
+				mockProductsUC.EXPECT().Patch(int64(1), nil, gomock.Any(), "admin").Return(product, &models.ProductChange{}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantETag:   `"2"`,
+		},
+		{
+			name:        "invalid patch",
+			id:          "1",
+			contentType: "application/merge-patch+json",
+			body:        `{"colour":"black"}`,
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {
+				mockProductsUC.EXPECT().Patch(int64(1), nil, gomock.Any(), "admin").
+					Return(nil, nil, fmt.Errorf("%w: unknown field \"colour\"", mergepatch.ErrInvalidPatch))
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "edit conflict",
+			id:          "1",
+			ifMatch:     `"2"`,
+			contentType: "application/merge-patch+json",
+			body:        `{"price":100}`,
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {
+				mockProductsUC.EXPECT().Patch(int64(1), &version, gomock.Any(), "admin").Return(nil, nil, db.ErrEditConflict)
+			},
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:         "malformed JSON",
+			id:           "1",
+			contentType:  "application/merge-patch+json",
+			body:         `{"price":`,
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {},
+			wantStatus:   http.StatusBadRequest,
+		},
+		{
+			name:         "JSON Patch is not supported",
+			id:           "1",
+			contentType:  "application/json-patch+json",
+			body:         `[{"op":"remove","path":"/stock"}]`,
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {},
+			wantStatus:   http.StatusUnsupportedMediaType,
+		},
+		{
+			name:         "invalid ID",
+			id:           "abc",
+			contentType:  "application/merge-patch+json",
+			body:         `{}`,
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {},
+			wantStatus:   http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockProductsUC)
+
+			req := httptest.NewRequest(http.MethodPatch, "/products/update/"+tt.id, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+
+			params := httprouter.Params{
+				httprouter.Param{
+					Key:   "id",
+					Value: tt.id,
+				},
+			}
+
+			ctx := context.WithValue(req.Context(), httprouter.ParamsKey, params)
+			req = req.WithContext(ctx)
+			req = middleware.ContextSetUserUID(req, "admin")
+
+			rr := httptest.NewRecorder()
+			productHandler.Patch().ServeHTTP(rr, req)
+			require.Equal(t, tt.wantStatus, rr.Code)
+			if tt.wantETag != "" {
+				require.Equal(t, tt.wantETag, rr.Header().Get("ETag"))
+			}
 		})
 	}
 }
@@ -234,7 +591,7 @@ func TestProductsHandlers_Delete(t *testing.T) {
 	mockProductsUC := mock_products.NewMockUseCase(ctrl)
 	kafkaWriter := &kafka.Writer{}
 
-	productHandler := NewProductsHandlers(cfg, mockProductsUC, logger, kafkaWriter, kafkaWriter)
+	productHandler := NewProductsHandlers(cfg, mockProductsUC, logger, kafkaWriter, kafkaWriter, nil)
 
 	tests := []struct {
 		name         string
@@ -246,7 +603,7 @@ func TestProductsHandlers_Delete(t *testing.T) {
 			name: "successful delete product",
 			id:   "1",
 			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {
-				mockProductsUC.EXPECT().Delete(int64(1)).Return(nil)
+				mockProductsUC.EXPECT().Delete(int64(1), nil, "admin").Return(nil)
 				mockProductsUC.EXPECT().SendToKafka(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
 			},
 			wantStatus: http.StatusNoContent,
@@ -255,7 +612,7 @@ func TestProductsHandlers_Delete(t *testing.T) {
 			name: "product not found",
 			id:   "2",
 			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {
-				mockProductsUC.EXPECT().Delete(int64(2)).Return(db.ErrRecordNotFound)
+				mockProductsUC.EXPECT().Delete(int64(2), nil, "admin").Return(db.ErrRecordNotFound)
 			},
 			wantStatus: http.StatusNotFound,
 		},
@@ -283,6 +640,7 @@ func TestProductsHandlers_Delete(t *testing.T) {
 
 			ctx := context.WithValue(req.Context(), httprouter.ParamsKey, params)
 			req = req.WithContext(ctx)
+			req = middleware.ContextSetUserUID(req, "admin")
 
 			rr := httptest.NewRecorder()
 			productHandler.Delete().ServeHTTP(rr, req)
@@ -290,3 +648,517 @@ func TestProductsHandlers_Delete(t *testing.T) {
 		})
 	}
 }
+
+// Matcher of a product event with the given action and tags
+type kafkaMessageMatcher struct {
+	action string
+	tags   []string
+}
+
+func kafkaMessage(action string, tags []string) gomock.Matcher {
+	return kafkaMessageMatcher{action: action, tags: tags}
+}
+
+func (m kafkaMessageMatcher) Matches(x interface{}) bool {
+	message, ok := x.(kf.KafkaMessage)
+	return ok && message.Action == m.action && slices.Equal(message.Tags, m.tags)
+}
+
+func (m kafkaMessageMatcher) String() string {
+	return fmt.Sprintf("is %s event with tags %v", m.action, m.tags)
+}
+
+func TestProductsHandlers_Restore(t *testing.T) {
+	cfg := &config.Config{}
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProductsUC := mock_products.NewMockUseCase(ctrl)
+	kafkaWriter := &kafka.Writer{}
+
+	productHandler := NewProductsHandlers(cfg, mockProductsUC, logger, kafkaWriter, kafkaWriter, nil)
+
+	tests := []struct {
+		name         string
+		id           string
+		mockBehavior func(mockProductsUC *mock_products.MockUseCase)
+		wantStatus   int
+		wantETag     string
+	}{
+		{
+			name: "successful restore product",
+			id:   "1",
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {
+				product := &models.Product{ID: 1, Name: "product1", Status: models.ProductStatusActive, Tags: []string{"tag1"}, Version: 3}
+				mockProductsUC.EXPECT().Restore(int64(1), "admin").Return(product, nil)
+				mockProductsUC.EXPECT().SendToKafka(gomock.Any(), "1", kafkaMessage("product_restore", []string{"tag1"}), kafkaWriter).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+			wantETag:   `"3"`,
+		},
+		{
+			name: "product is not deleted",
+			id:   "2",
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {
+				mockProductsUC.EXPECT().Restore(int64(2), "admin").Return(nil, db.ErrRecordNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:         "invalid id parameter",
+			id:           "invalid",
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {},
+			wantStatus:   http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockProductsUC)
+
+			req := httptest.NewRequest(http.MethodPost, "/products/restore/"+tt.id, nil)
+
+			params := httprouter.Params{
+				httprouter.Param{
+					Key:   "id",
+					Value: tt.id,
+				},
+			}
+
+			ctx := context.WithValue(req.Context(), httprouter.ParamsKey, params)
+			req = req.WithContext(ctx)
+			req = middleware.ContextSetUserUID(req, "admin")
+
+			rr := httptest.NewRecorder()
+			productHandler.Restore().ServeHTTP(rr, req)
+			require.Equal(t, tt.wantStatus, rr.Code)
+			if tt.wantETag != "" {
+				require.Equal(t, tt.wantETag, rr.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestProductsHandlers_ListDeleted(t *testing.T) {
+	cfg := &config.Config{}
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProductsUC := mock_products.NewMockUseCase(ctrl)
+	kafkaWriter := &kafka.Writer{}
+
+	productHandler := NewProductsHandlers(cfg, mockProductsUC, logger, kafkaWriter, kafkaWriter, nil)
+
+	tests := []struct {
+		name         string
+		mockBehavior func(mockProductsUC *mock_products.MockUseCase)
+		wantStatus   int
+	}{
+		{
+			name: "successful list deleted products",
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {
+				mockProductsUC.EXPECT().ListDeleted().Return([]models.Product{{ID: 1, Name: "product1"}}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "internal server error",
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {
+				mockProductsUC.EXPECT().ListDeleted().Return(nil, errors.New("db error"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockProductsUC)
+
+			req := httptest.NewRequest(http.MethodGet, "/products/deleted", nil)
+			rr := httptest.NewRecorder()
+
+			productHandler.ListDeleted().ServeHTTP(rr, req)
+			require.Equal(t, tt.wantStatus, rr.Code)
+		})
+	}
+}
+
+func TestProductsHandlers_Revision(t *testing.T) {
+	cfg := &config.Config{}
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProductsUC := mock_products.NewMockUseCase(ctrl)
+	kafkaWriter := &kafka.Writer{}
+
+	productHandler := NewProductsHandlers(cfg, mockProductsUC, logger, kafkaWriter, kafkaWriter, nil)
+
+	tests := []struct {
+		name         string
+		version      string
+		mockBehavior func(mockProductsUC *mock_products.MockUseCase)
+		wantStatus   int
+	}{
+		{
+			name:    "successful get revision",
+			version: "1",
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {
+				revision := &models.ProductRevision{ProductID: 1, Version: 1, Product: models.Product{ID: 1, Name: "product1"}, ChangedBy: "admin"}
+				mockProductsUC.EXPECT().Revision(int64(1), int64(1)).Return(revision, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:    "revision not found",
+			version: "5",
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {
+				mockProductsUC.EXPECT().Revision(int64(1), int64(5)).Return(nil, db.ErrRecordNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:         "invalid version parameter",
+			version:      "0",
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {},
+			wantStatus:   http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockProductsUC)
+
+			req := httptest.NewRequest(http.MethodGet, "/products/history/1/"+tt.version, nil)
+
+			params := httprouter.Params{
+				httprouter.Param{Key: "id", Value: "1"},
+				httprouter.Param{Key: "version", Value: tt.version},
+			}
+
+			ctx := context.WithValue(req.Context(), httprouter.ParamsKey, params)
+			req = req.WithContext(ctx)
+
+			rr := httptest.NewRecorder()
+			productHandler.Revision().ServeHTTP(rr, req)
+			require.Equal(t, tt.wantStatus, rr.Code)
+		})
+	}
+}
+
+func TestProductsHandlers_Rollback(t *testing.T) {
+	cfg := &config.Config{}
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProductsUC := mock_products.NewMockUseCase(ctrl)
+	kafkaWriter := &kafka.Writer{}
+
+	productHandler := NewProductsHandlers(cfg, mockProductsUC, logger, kafkaWriter, kafkaWriter, nil)
+
+	tests := []struct {
+		name         string
+		mockBehavior func(mockProductsUC *mock_products.MockUseCase)
+		wantStatus   int
+		wantETag     string
+	}{
+		{
+			name: "successful rollback",
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {
+				product := &models.Product{ID: 1, Name: "product1", Status: models.ProductStatusActive, Tags: []string{"rock"}, Version: 4}
+				change := &models.ProductChange{Fields: []string{"name", "tags"}, AddedTags: []string{"rock"}}
+				mockProductsUC.EXPECT().Rollback(int64(1), int64(2), "admin").Return(product, change, nil)
+				mockProductsUC.EXPECT().SendToKafka(gomock.Any(), "1", kafkaMessage("product_update", []string{"rock"}), kafkaWriter).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+			wantETag:   `"4"`,
+		},
+		{
+			name: "rollback to the current state is not published",
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {
+				product := &models.Product{ID: 1, Name: "product1", Status: models.ProductStatusActive, Tags: []string{"rock"}, Version: 4}
+				mockProductsUC.EXPECT().Rollback(int64(1), int64(2), "admin").Return(product, &models.ProductChange{}, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantETag:   `"4"`,
+		},
+		{
+			name: "tags of the version are not in the vocabulary",
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {
+				mockProductsUC.EXPECT().Rollback(int64(1), int64(2), "admin").Return(nil, nil, products.ErrUnknownTags)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name: "version not found",
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {
+				mockProductsUC.EXPECT().Rollback(int64(1), int64(2), "admin").Return(nil, nil, db.ErrRecordNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockProductsUC)
+
+			req := httptest.NewRequest(http.MethodPost, "/products/rollback/1/2", nil)
+
+			params := httprouter.Params{
+				httprouter.Param{Key: "id", Value: "1"},
+				httprouter.Param{Key: "version", Value: "2"},
+			}
+
+			ctx := context.WithValue(req.Context(), httprouter.ParamsKey, params)
+			req = req.WithContext(ctx)
+			req = middleware.ContextSetUserUID(req, "admin")
+
+			rr := httptest.NewRecorder()
+			productHandler.Rollback().ServeHTTP(rr, req)
+			require.Equal(t, tt.wantStatus, rr.Code)
+			if tt.wantETag != "" {
+				require.Equal(t, tt.wantETag, rr.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestProductsHandlers_Import(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Import.BatchSize = 100
+	cfg.Import.MaxRows = 10
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProductsUC := mock_products.NewMockUseCase(ctrl)
+	kafkaWriter := &kafka.Writer{}
+
+	productHandler := NewProductsHandlers(cfg, mockProductsUC, logger, kafkaWriter, kafkaWriter, nil)
+
+	name := "vinyl"
+	price := int64(4990)
+
+	tests := []struct {
+		name         string
+		query        string
+		contentType  string
+		body         string
+		mockBehavior func(mockProductsUC *mock_products.MockUseCase)
+		wantStatus   int
+		wantReport   models.ImportReport
+	}{
+		{
+			name:        "CSV with created, rejected and malformed rows",
+			contentType: "text/csv",
+			body:        "id,name,price,tags\n,vinyl,4990,rock|jazz\n,broken,abc,rock\n7,tape,-1,\n",
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {
+				created := &models.Product{ID: 8, Name: "vinyl", Price: 4990, Status: models.ProductStatusActive, Tags: []string{"rock", "jazz"}}
+				tape, negative := "tape", int64(-1)
+
+				mockProductsUC.EXPECT().Import(&models.ImportProductDTO{
+					UpdateProductDTO: models.UpdateProductDTO{Name: &name, Price: &price, Tags: []string{"rock", "jazz"}},
+				}, false, "admin").Return(created, nil, nil)
+				mockProductsUC.EXPECT().Import(&models.ImportProductDTO{
+					ID:               7,
+					UpdateProductDTO: models.UpdateProductDTO{Name: &tape, Price: &negative, Tags: []string{}},
+				}, false, "admin").Return(nil, nil, products.ErrInvalidProduct)
+				mockProductsUC.EXPECT().SendBatchToKafka(gomock.Any(), gomock.Any(), kafkaWriter).
+					DoAndReturn(func(_ context.Context, events []kf.Event, _ *kafka.Writer) error {
+						require.Len(t, events, 1)
+						require.Equal(t, "8", events[0].Key)
+						require.Equal(t, "product_create", events[0].Message.Action)
+						require.Equal(t, []string{"rock", "jazz"}, events[0].Message.Tags)
+						return nil
+					})
+			},
+			wantStatus: http.StatusOK,
+			wantReport: models.ImportReport{
+				Created: 1,
+				Failed:  2,
+				Errors: []models.ImportError{
+					{Line: 3, Error: "invalid row: price must be an integer"},
+					{Line: 4, Error: "invalid product"},
+				},
+			},
+		},
+		{
+			name:        "NDJSON with batched events",
+			contentType: "application/x-ndjson",
+			body:        "{\"name\":\"vinyl\",\"price\":4990}\n\n{\"id\":7,\"stock\":3}\n{\"id\":9,\"description\":\"new\"}\n",
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {
+				stock := int64(3)
+				mockProductsUC.EXPECT().Import(gomock.Any(), false, "admin").Return(&models.Product{ID: 8, Name: "vinyl"}, nil, nil)
+				mockProductsUC.EXPECT().Import(gomock.Any(), false, "admin").
+					Return(&models.Product{ID: 7, Stock: &stock}, &models.ProductChange{Fields: []string{"stock"}}, nil)
+				mockProductsUC.EXPECT().Import(gomock.Any(), false, "admin").Return(nil, nil, db.ErrRecordNotFound)
+				mockProductsUC.EXPECT().SendBatchToKafka(gomock.Any(), gomock.Any(), kafkaWriter).
+					DoAndReturn(func(_ context.Context, events []kf.Event, _ *kafka.Writer) error {
+						require.Len(t, events, 2)
+						require.Equal(t, "8", events[0].Key)
+						require.Equal(t, "product_create", events[0].Message.Action)
+						require.Equal(t, "7", events[1].Key)
+						require.Equal(t, "product_update", events[1].Message.Action)
+						return nil
+					})
+			},
+			wantStatus: http.StatusOK,
+			wantReport: models.ImportReport{
+				Created: 1,
+				Updated: 1,
+				Failed:  1,
+				Errors:  []models.ImportError{{Line: 4, Error: "product not found"}},
+			},
+		},
+		{
+			name:        "dry run publishes nothing",
+			query:       "?format=ndjson&dry_run=true",
+			contentType: "application/json",
+			body:        "{\"name\":\"vinyl\"}\n{\"name\":\"vinyl\",\"colour\":\"black\"}\n",
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {
+				mockProductsUC.EXPECT().Import(&models.ImportProductDTO{
+					UpdateProductDTO: models.UpdateProductDTO{Name: &name},
+				}, true, "admin").Return(&models.Product{Name: "vinyl"}, nil, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantReport: models.ImportReport{
+				DryRun:  true,
+				Created: 1,
+				Failed:  1,
+				Errors:  []models.ImportError{{Line: 2, Error: "invalid row: json: unknown field \"colour\""}},
+			},
+		},
+		{
+			name:         "unknown CSV column",
+			contentType:  "text/csv",
+			body:         "name,colour\nvinyl,black\n",
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {},
+			wantStatus:   http.StatusBadRequest,
+		},
+		{
+			name:         "unsupported format",
+			contentType:  "application/xml",
+			body:         "<products/>",
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {},
+			wantStatus:   http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockProductsUC)
+
+			req := httptest.NewRequest(http.MethodPost, "/products/import"+tt.query, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			req = middleware.ContextSetUserUID(req, "admin")
+
+			rr := httptest.NewRecorder()
+			productHandler.Import().ServeHTTP(rr, req)
+			require.Equal(t, tt.wantStatus, rr.Code)
+
+			if tt.wantStatus == http.StatusOK {
+				var response models.ImportResponse
+				require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+				require.Equal(t, tt.wantReport, response.Report)
+			}
+		})
+	}
+}
+
+func TestProductsHandlers_Export(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Import.BatchSize = 2
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProductsUC := mock_products.NewMockUseCase(ctrl)
+	kafkaWriter := &kafka.Writer{}
+
+	productHandler := NewProductsHandlers(cfg, mockProductsUC, logger, kafkaWriter, kafkaWriter, nil)
+
+	stock := int64(3)
+	firstPage := []models.Product{
+		{ID: 1, Name: "vinyl", Price: 4990, Currency: "RUB", Stock: &stock, Status: models.ProductStatusActive, Tags: []string{"rock", "jazz"}},
+		{ID: 4, Name: "poster, A2", Currency: "RUB", Images: []string{"https://cdn.example.com/1.png"}, Status: models.ProductStatusDraft, Tags: []string{"art"}},
+	}
+	secondPage := []models.Product{
+		{ID: 5, Name: "tape", Currency: "USD", Status: models.ProductStatusArchived},
+	}
+
+	tests := []struct {
+		name         string
+		query        string
+		mockBehavior func(mockProductsUC *mock_products.MockUseCase)
+		wantStatus   int
+		wantBody     string
+	}{
+		{
+			name:  "CSV export by pages",
+			query: "?format=csv",
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {
+				mockProductsUC.EXPECT().ListAfter(int64(0), 2).Return(firstPage, nil)
+				mockProductsUC.EXPECT().ListAfter(int64(4), 2).Return(secondPage, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody: "id,name,description,price,currency,category,stock,images,status,tags\n" +
+				"1,vinyl,,4990,RUB,,3,,active,rock|jazz\n" +
+				"4,\"poster, A2\",,0,RUB,,,https://cdn.example.com/1.png,draft,art\n" +
+				"5,tape,,0,USD,,,,archived,\n",
+		},
+		{
+			name: "NDJSON export",
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {
+				mockProductsUC.EXPECT().ListAfter(int64(0), 2).Return(secondPage, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantBody: `{"id":5,"name":"tape","description":"","price":0,"currency":"USD","category":"","stock":null,` +
+				`"images":[],"status":"archived","tags":[]}` + "\n",
+		},
+		{
+			name:  "first page error",
+			query: "?format=csv",
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {
+				mockProductsUC.EXPECT().ListAfter(int64(0), 2).Return(nil, errors.New("db error"))
+			},
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:         "unsupported format",
+			query:        "?format=xml",
+			mockBehavior: func(mockProductsUC *mock_products.MockUseCase) {},
+			wantStatus:   http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockProductsUC)
+
+			req := httptest.NewRequest(http.MethodGet, "/products/export"+tt.query, nil)
+			rr := httptest.NewRecorder()
+
+			productHandler.Export().ServeHTTP(rr, req)
+			require.Equal(t, tt.wantStatus, rr.Code)
+
+			if tt.wantBody != "" {
+				require.Equal(t, tt.wantBody, rr.Body.String())
+			}
+		})
+	}
+}
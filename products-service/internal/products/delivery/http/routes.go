@@ -11,8 +11,16 @@ import (
 
 // Register products routes
 func RegisterProductsRoutes(router *httprouter.Router, h products.Handlers, mw *middleware.MiddlewareManager) {
-	router.HandlerFunc(http.MethodPost, "/products/create", mw.RequireAdminRights(h.Create()))
-	router.HandlerFunc(http.MethodDelete, "/products/delete/:id", mw.RequireAdminRights(h.Delete()))
-	router.HandlerFunc(http.MethodPut, "/products/update/:id", mw.RequireAdminRights(h.Update()))
+	router.HandlerFunc(http.MethodPost, "/products/create", mw.RequirePermission("products:write")(h.Create()))
+	router.HandlerFunc(http.MethodDelete, "/products/delete/:id", mw.RequirePermission("products:delete")(h.Delete()))
+	router.HandlerFunc(http.MethodPut, "/products/update/:id", mw.RequirePermission("products:write")(h.Update()))
+	router.HandlerFunc(http.MethodPatch, "/products/update/:id", mw.RequirePermission("products:write")(h.Patch()))
 	router.HandlerFunc(http.MethodGet, "/products/view/:id", mw.RequireAuthenticatedUser(h.Get()))
+	router.HandlerFunc(http.MethodGet, "/products/deleted", mw.RequirePermission("products:delete")(h.ListDeleted()))
+	router.HandlerFunc(http.MethodPost, "/products/restore/:id", mw.RequirePermission("products:delete")(h.Restore()))
+	router.HandlerFunc(http.MethodGet, "/products/history/:id", mw.RequirePermission("products:write")(h.History()))
+	router.HandlerFunc(http.MethodGet, "/products/history/:id/:version", mw.RequirePermission("products:write")(h.Revision()))
+	router.HandlerFunc(http.MethodPost, "/products/rollback/:id/:version", mw.RequirePermission("products:write")(h.Rollback()))
+	router.HandlerFunc(http.MethodPost, "/products/import", mw.RequirePermission("products:write")(h.Import()))
+	router.HandlerFunc(http.MethodGet, "/products/export", mw.RequirePermission("products:write")(h.Export()))
 }
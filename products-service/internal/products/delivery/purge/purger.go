@@ -0,0 +1,55 @@
+package purge
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"cyansnbrst/products-service/config"
+	"cyansnbrst/products-service/internal/products"
+)
+
+// Purger struct, permanently deletes products soft-deleted longer than the retention period ago
+type Purger struct {
+	cfg        *config.Config
+	productsUC products.UseCase
+	logger     *zap.Logger
+}
+
+// Purger constructor
+func NewPurger(cfg *config.Config, productsUC products.UseCase, logger *zap.Logger) *Purger {
+	return &Purger{cfg: cfg, productsUC: productsUC, logger: logger}
+}
+
+// Run purger until the context is cancelled
+func (p *Purger) Run(ctx context.Context) {
+	p.logger.Info("starting deleted products purger")
+
+	ticker := time.NewTicker(p.cfg.Purge.Interval)
+	defer ticker.Stop()
+
+	for {
+		p.purge()
+
+		select {
+		case <-ctx.Done():
+			p.logger.Info("deleted products purger stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Purge deleted products once
+func (p *Purger) purge() {
+	purged, err := p.productsUC.PurgeDeleted()
+	if err != nil {
+		p.logger.Warn("failed to purge deleted products", zap.Error(err))
+		return
+	}
+
+	if purged > 0 {
+		p.logger.Info("purged deleted products", zap.Int64("count", purged))
+	}
+}
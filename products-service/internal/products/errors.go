@@ -0,0 +1,9 @@
+package products
+
+import "errors"
+
+// Products usecase errors
+var (
+	ErrUnknownTags    = errors.New("tags are not in the vocabulary")
+	ErrInvalidProduct = errors.New("invalid product")
+)
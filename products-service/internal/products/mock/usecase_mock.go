@@ -38,32 +38,32 @@ func (m *MockUseCase) EXPECT() *MockUseCaseMockRecorder {
 }
 
 // Create mocks base method.
-func (m *MockUseCase) Create(name string, tags []string) (int64, error) {
+func (m *MockUseCase) Create(input *models.CreateProductDTO, changedBy string) (*models.Product, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", name, tags)
-	ret0, _ := ret[0].(int64)
+	ret := m.ctrl.Call(m, "Create", input, changedBy)
+	ret0, _ := ret[0].(*models.Product)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockUseCaseMockRecorder) Create(name, tags interface{}) *gomock.Call {
+func (mr *MockUseCaseMockRecorder) Create(input, changedBy interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUseCase)(nil).Create), name, tags)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUseCase)(nil).Create), input, changedBy)
 }
 
 // Delete mocks base method.
-func (m *MockUseCase) Delete(id int64) error {
+func (m *MockUseCase) Delete(id int64, version *int64, changedBy string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", id)
+	ret := m.ctrl.Call(m, "Delete", id, version, changedBy)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockUseCaseMockRecorder) Delete(id interface{}) *gomock.Call {
+func (mr *MockUseCaseMockRecorder) Delete(id, version, changedBy interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockUseCase)(nil).Delete), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockUseCase)(nil).Delete), id, version, changedBy)
 }
 
 // Get mocks base method.
@@ -81,6 +81,158 @@ func (mr *MockUseCaseMockRecorder) Get(id interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockUseCase)(nil).Get), id)
 }
 
+// History mocks base method.
+func (m *MockUseCase) History(id int64) ([]models.ProductRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "History", id)
+	ret0, _ := ret[0].([]models.ProductRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// History indicates an expected call of History.
+func (mr *MockUseCaseMockRecorder) History(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "History", reflect.TypeOf((*MockUseCase)(nil).History), id)
+}
+
+// Import mocks base method.
+func (m *MockUseCase) Import(input *models.ImportProductDTO, dryRun bool, changedBy string) (*models.Product, *models.ProductChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Import", input, dryRun, changedBy)
+	ret0, _ := ret[0].(*models.Product)
+	ret1, _ := ret[1].(*models.ProductChange)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Import indicates an expected call of Import.
+func (mr *MockUseCaseMockRecorder) Import(input, dryRun, changedBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Import", reflect.TypeOf((*MockUseCase)(nil).Import), input, dryRun, changedBy)
+}
+
+// ListAfter mocks base method.
+func (m *MockUseCase) ListAfter(afterID int64, limit int) ([]models.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAfter", afterID, limit)
+	ret0, _ := ret[0].([]models.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAfter indicates an expected call of ListAfter.
+func (mr *MockUseCaseMockRecorder) ListAfter(afterID, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAfter", reflect.TypeOf((*MockUseCase)(nil).ListAfter), afterID, limit)
+}
+
+// ListDeleted mocks base method.
+func (m *MockUseCase) ListDeleted() ([]models.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListDeleted")
+	ret0, _ := ret[0].([]models.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListDeleted indicates an expected call of ListDeleted.
+func (mr *MockUseCaseMockRecorder) ListDeleted() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListDeleted", reflect.TypeOf((*MockUseCase)(nil).ListDeleted))
+}
+
+// Patch mocks base method.
+func (m *MockUseCase) Patch(id int64, version *int64, patch []byte, changedBy string) (*models.Product, *models.ProductChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Patch", id, version, patch, changedBy)
+	ret0, _ := ret[0].(*models.Product)
+	ret1, _ := ret[1].(*models.ProductChange)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Patch indicates an expected call of Patch.
+func (mr *MockUseCaseMockRecorder) Patch(id, version, patch, changedBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Patch", reflect.TypeOf((*MockUseCase)(nil).Patch), id, version, patch, changedBy)
+}
+
+// PurgeDeleted mocks base method.
+func (m *MockUseCase) PurgeDeleted() (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeleted")
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDeleted indicates an expected call of PurgeDeleted.
+func (mr *MockUseCaseMockRecorder) PurgeDeleted() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeleted", reflect.TypeOf((*MockUseCase)(nil).PurgeDeleted))
+}
+
+// Restore mocks base method.
+func (m *MockUseCase) Restore(id int64, changedBy string) (*models.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", id, changedBy)
+	ret0, _ := ret[0].(*models.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockUseCaseMockRecorder) Restore(id, changedBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockUseCase)(nil).Restore), id, changedBy)
+}
+
+// Revision mocks base method.
+func (m *MockUseCase) Revision(id, version int64) (*models.ProductRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revision", id, version)
+	ret0, _ := ret[0].(*models.ProductRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Revision indicates an expected call of Revision.
+func (mr *MockUseCaseMockRecorder) Revision(id, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revision", reflect.TypeOf((*MockUseCase)(nil).Revision), id, version)
+}
+
+// Rollback mocks base method.
+func (m *MockUseCase) Rollback(id, version int64, changedBy string) (*models.Product, *models.ProductChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rollback", id, version, changedBy)
+	ret0, _ := ret[0].(*models.Product)
+	ret1, _ := ret[1].(*models.ProductChange)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Rollback indicates an expected call of Rollback.
+func (mr *MockUseCaseMockRecorder) Rollback(id, version, changedBy interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rollback", reflect.TypeOf((*MockUseCase)(nil).Rollback), id, version, changedBy)
+}
+
+// SendBatchToKafka mocks base method.
+func (m *MockUseCase) SendBatchToKafka(ctx context.Context, events []kafka.Event, writer *kafka0.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendBatchToKafka", ctx, events, writer)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendBatchToKafka indicates an expected call of SendBatchToKafka.
+func (mr *MockUseCaseMockRecorder) SendBatchToKafka(ctx, events, writer interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendBatchToKafka", reflect.TypeOf((*MockUseCase)(nil).SendBatchToKafka), ctx, events, writer)
+}
+
 // SendToKafka mocks base method.
 func (m *MockUseCase) SendToKafka(ctx context.Context, key string, message kafka.KafkaMessage, writer *kafka0.Writer) error {
 	m.ctrl.T.Helper()
@@ -95,16 +247,32 @@ func (mr *MockUseCaseMockRecorder) SendToKafka(ctx, key, message, writer interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendToKafka", reflect.TypeOf((*MockUseCase)(nil).SendToKafka), ctx, key, message, writer)
 }
 
-// Update mocks base method.
-func (m *MockUseCase) Update(id int64, name *string, tags []string) error {
+// SendViewsToKafka mocks base method.
+func (m *MockUseCase) SendViewsToKafka(ctx context.Context, events []kafka.Event, writer *kafka0.Writer) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", id, name, tags)
+	ret := m.ctrl.Call(m, "SendViewsToKafka", ctx, events, writer)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
+// SendViewsToKafka indicates an expected call of SendViewsToKafka.
+func (mr *MockUseCaseMockRecorder) SendViewsToKafka(ctx, events, writer interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendViewsToKafka", reflect.TypeOf((*MockUseCase)(nil).SendViewsToKafka), ctx, events, writer)
+}
+
+// Update mocks base method.
+func (m *MockUseCase) Update(id int64, version *int64, input *models.UpdateProductDTO, changedBy string) (*models.Product, *models.ProductChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", id, version, input, changedBy)
+	ret0, _ := ret[0].(*models.Product)
+	ret1, _ := ret[1].(*models.ProductChange)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
 // Update indicates an expected call of Update.
-func (mr *MockUseCaseMockRecorder) Update(id, name, tags interface{}) *gomock.Call {
+func (mr *MockUseCaseMockRecorder) Update(id, version, input, changedBy interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockUseCase)(nil).Update), id, name, tags)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockUseCase)(nil).Update), id, version, input, changedBy)
 }
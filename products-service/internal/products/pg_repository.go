@@ -1,11 +1,21 @@
 package products
 
-import "cyansnbrst/products-service/internal/models"
+import (
+	"time"
+
+	"cyansnbrst/products-service/internal/models"
+)
 
 // Products repository interface
 type Repository interface {
-	Create(name string, tags []string) (int64, error)
-	Update(product *models.Product) error
-	Delete(productID int64) error
+	Create(product *models.Product, changedBy string) error
+	Update(product *models.Product, changedBy string) error
+	Delete(productID, version int64, changedBy string) error
+	Restore(productID int64, changedBy string) (*models.Product, error)
+	ListDeleted() ([]models.Product, error)
+	Purge(deletedBefore time.Time) (int64, error)
 	GetByID(productID int64) (*models.Product, error)
+	ListAfter(afterID int64, limit int) ([]models.Product, error)
+	GetRevisions(productID int64) ([]models.ProductRevision, error)
+	GetRevision(productID, version int64) (*models.ProductRevision, error)
 }
@@ -0,0 +1,9 @@
+package products
+
+import "time"
+
+// Products redis repository interface, view counters shared by all service instances
+type RedisRepository interface {
+	MarkViewed(viewer string, productID int64, window time.Duration) (bool, error)
+	CountViews(viewer string, window time.Duration) (int64, error)
+}
@@ -3,6 +3,9 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
 
 	"github.com/lib/pq"
 
@@ -12,6 +15,12 @@ import (
 	"cyansnbrst/products-service/pkg/db"
 )
 
+// Product columns in the order of productFields
+const productColumns = `id, name, description, price, currency, category, stock, images, status, tags, version, created_at, updated_at, deleted_at`
+
+// Revision columns in the order of scanRevision
+const revisionColumns = `product_id, version, product, changed_by, changed_at`
+
 // Products repository
 type productsRepo struct {
 	cfg *config.Config
@@ -23,40 +32,65 @@ func NewProductsRepository(cfg *config.Config, db *sql.DB) products.Repository {
 	return &productsRepo{cfg: cfg, db: db}
 }
 
-// Insert a new product
-func (r *productsRepo) Create(name string, tags []string) (int64, error) {
+// Insert a new product and record its first revision
+func (r *productsRepo) Create(product *models.Product, changedBy string) error {
 	query := `
-		INSERT INTO products (name, tags)
-		VALUES ($1, $2)
-		RETURNING id`
+		INSERT INTO products (name, description, price, currency, category, stock, images, status, tags)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id, version, created_at, updated_at`
 
 	args := []interface{}{
-		name,
-		pq.Array(tags),
+		product.Name,
+		product.Description,
+		product.Price,
+		product.Currency,
+		product.Category,
+		product.Stock,
+		pq.Array(nonNil(product.Images)),
+		product.Status,
+		pq.Array(nonNil(product.Tags)),
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
 	defer cancel()
 
-	var id int64
-	err := r.db.QueryRowContext(ctx, query, args...).Scan(&id)
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return 0, err
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&product.ID, &product.Version, &product.CreatedAt, &product.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	if err = insertRevision(ctx, tx, product.ID, changedBy); err != nil {
+		return err
 	}
 
-	return id, nil
+	return tx.Commit()
 }
 
-// Edit an existing product
-func (r *productsRepo) Update(product *models.Product) error {
+// Edit an existing product and record the new revision, the product must still have the version it was read with
+func (r *productsRepo) Update(product *models.Product, changedBy string) error {
 	query := `
 		UPDATE products
-		SET name = $1, tags = $2, version = version + 1
-		WHERE id = $3 AND version = $4`
+		SET name = $1, description = $2, price = $3, currency = $4, category = $5, stock = $6,
+			images = $7, status = $8, tags = $9, version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $10 AND version = $11 AND deleted_at IS NULL
+		RETURNING updated_at`
 
 	args := []interface{}{
 		product.Name,
-		pq.Array(product.Tags),
+		product.Description,
+		product.Price,
+		product.Currency,
+		product.Category,
+		product.Stock,
+		pq.Array(nonNil(product.Images)),
+		product.Status,
+		pq.Array(nonNil(product.Tags)),
 		product.ID,
 		product.Version,
 	}
@@ -64,33 +98,44 @@ func (r *productsRepo) Update(product *models.Product) error {
 	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
 	defer cancel()
 
-	result, err := r.db.ExecContext(ctx, query, args...)
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	rowsAffected, err := result.RowsAffected()
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&product.UpdatedAt)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.ErrEditConflict
+		}
 		return err
 	}
 
-	if rowsAffected == 0 {
-		return db.ErrRecordNotFound
+	if err = insertRevision(ctx, tx, product.ID, changedBy); err != nil {
+		return err
 	}
 
-	return nil
+	return tx.Commit()
 }
 
-// Delete an existing product
-func (r *productsRepo) Delete(productID int64) error {
+// Soft-delete an existing product of the given version and record the new revision
+func (r *productsRepo) Delete(productID, version int64, changedBy string) error {
 	query := `
-        DELETE FROM products
-        WHERE id = $1`
+		UPDATE products
+		SET deleted_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE id = $1 AND version = $2 AND deleted_at IS NULL`
 
 	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
 	defer cancel()
 
-	result, err := r.db.ExecContext(ctx, query, productID)
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, query, productID, version)
 	if err != nil {
 		return err
 	}
@@ -101,43 +146,171 @@ func (r *productsRepo) Delete(productID int64) error {
 	}
 
 	if rowsAffected == 0 {
-		var exists bool
-		checkQuery := `
-			SELECT EXISTS(SELECT 1 FROM products WHERE id = $1)`
-		err = r.db.QueryRowContext(ctx, checkQuery, productID).Scan(&exists)
+		return db.ErrEditConflict
+	}
+
+	if err = insertRevision(ctx, tx, productID, changedBy); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Restore a soft-deleted product and record the new revision
+func (r *productsRepo) Restore(productID int64, changedBy string) (*models.Product, error) {
+	query := `
+		UPDATE products
+		SET deleted_at = NULL, version = version + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND deleted_at IS NOT NULL
+		RETURNING ` + productColumns
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	product := &models.Product{}
+	row := tx.QueryRowContext(ctx, query, productID)
+	if err := row.Scan(productFields(product)...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, db.ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	if err = insertRevision(ctx, tx, productID, changedBy); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+// Get revisions of a product, newest first
+func (r *productsRepo) GetRevisions(productID int64) ([]models.ProductRevision, error) {
+	query := `
+		SELECT ` + revisionColumns + `
+		FROM product_revisions
+		WHERE product_id = $1
+		ORDER BY version DESC`
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []models.ProductRevision{}
+	for rows.Next() {
+		revision, err := scanRevision(rows)
 		if err != nil {
-			return err
+			return nil, err
 		}
+		revisions = append(revisions, *revision)
+	}
 
-		if !exists {
-			return db.ErrRecordNotFound
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return revisions, nil
+}
+
+// Get a revision of a product by version
+func (r *productsRepo) GetRevision(productID, version int64) (*models.ProductRevision, error) {
+	query := `
+		SELECT ` + revisionColumns + `
+		FROM product_revisions
+		WHERE product_id = $1 AND version = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	revision, err := scanRevision(r.db.QueryRowContext(ctx, query, productID, version))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, db.ErrRecordNotFound
 		}
-		return db.ErrEditConflict
+		return nil, err
+	}
+
+	return revision, nil
+}
+
+// Get soft-deleted products, most recently deleted first
+func (r *productsRepo) ListDeleted() ([]models.Product, error) {
+	query := `
+		SELECT ` + productColumns + `
+		FROM products
+		WHERE deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC, id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	productList := []models.Product{}
+	for rows.Next() {
+		var product models.Product
+		if err := rows.Scan(productFields(&product)...); err != nil {
+			return nil, err
+		}
+		productList = append(productList, product)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return productList, nil
+}
+
+// Permanently delete products soft-deleted before the given time.
+// Revisions are not deleted, the history of purged products stays available as an audit trail.
+func (r *productsRepo) Purge(deletedBefore time.Time) (int64, error) {
+	query := `
+		DELETE FROM products
+		WHERE deleted_at < $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, query, deletedBefore)
+	if err != nil {
+		return 0, err
 	}
 
-	return nil
+	return result.RowsAffected()
 }
 
-// Get product by ID
+// Get product by ID, soft-deleted products are not found
 func (r *productsRepo) GetByID(productID int64) (*models.Product, error) {
 	queryGet := `
-        SELECT id, name, tags, version
-        FROM products
-        WHERE id = $1`
+		SELECT ` + productColumns + `
+		FROM products
+		WHERE id = $1 AND deleted_at IS NULL`
 
 	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
 	defer cancel()
 
 	product := &models.Product{}
-	args := []interface{}{
-		&product.ID,
-		&product.Name,
-		pq.Array(&product.Tags),
-		&product.Version,
-	}
-
 	row := r.db.QueryRowContext(ctx, queryGet, productID)
-	if err := row.Scan(args...); err != nil {
+	if err := row.Scan(productFields(product)...); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, db.ErrRecordNotFound
 		}
@@ -146,3 +319,95 @@ func (r *productsRepo) GetByID(productID int64) (*models.Product, error) {
 
 	return product, nil
 }
+
+// Record the current state of a product as a revision
+func insertRevision(ctx context.Context, tx *sql.Tx, productID int64, changedBy string) error {
+	query := `
+		INSERT INTO product_revisions (product_id, version, product, changed_by)
+		SELECT p.id, p.version, to_jsonb(p), $2
+		FROM products p
+		WHERE p.id = $1`
+
+	_, err := tx.ExecContext(ctx, query, productID, changedBy)
+	return err
+}
+
+// Scan a row of revisionColumns
+func scanRevision(row interface{ Scan(dest ...interface{}) error }) (*models.ProductRevision, error) {
+	var (
+		revision models.ProductRevision
+		product  []byte
+	)
+
+	if err := row.Scan(&revision.ProductID, &revision.Version, &product, &revision.ChangedBy, &revision.ChangedAt); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(product, &revision.Product); err != nil {
+		return nil, err
+	}
+
+	return &revision, nil
+}
+
+// Get a page of products with IDs greater than afterID ordered by ID, soft-deleted products are left out
+func (r *productsRepo) ListAfter(afterID int64, limit int) ([]models.Product, error) {
+	query := `
+		SELECT ` + productColumns + `
+		FROM products
+		WHERE id > $1 AND deleted_at IS NULL
+		ORDER BY id
+		LIMIT $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	productList := []models.Product{}
+	for rows.Next() {
+		var product models.Product
+		if err := rows.Scan(productFields(&product)...); err != nil {
+			return nil, err
+		}
+		productList = append(productList, product)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return productList, nil
+}
+
+// Scan destinations for productColumns
+func productFields(product *models.Product) []interface{} {
+	return []interface{}{
+		&product.ID,
+		&product.Name,
+		&product.Description,
+		&product.Price,
+		&product.Currency,
+		&product.Category,
+		&product.Stock,
+		pq.Array(&product.Images),
+		&product.Status,
+		pq.Array(&product.Tags),
+		&product.Version,
+		&product.CreatedAt,
+		&product.UpdatedAt,
+		&product.DeletedAt,
+	}
+}
+
+// Values for a NOT NULL array column
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"cyansnbrst/products-service/config"
+	"cyansnbrst/products-service/internal/products"
+)
+
+// Fixed window counter, the window starts with the first view and later views do not extend it
+var countViewsScript = redis.NewScript(`
+local count = redis.call("INCR", KEYS[1])
+if count == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return count
+`)
+
+// Products redis repository
+type productsRedisRepo struct {
+	cfg         *config.Config
+	redisClient *redis.Client
+}
+
+// Products redis repository constructor
+func NewProductsRedisRepository(cfg *config.Config, redisClient *redis.Client) products.RedisRepository {
+	return &productsRedisRepo{cfg: cfg, redisClient: redisClient}
+}
+
+// Mark the product viewed by the viewer for the window, false if the viewer has already viewed it within the window
+func (r *productsRedisRepo) MarkViewed(viewer string, productID int64, window time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.RedisAction)
+	defer cancel()
+
+	return r.redisClient.SetNX(ctx, fmt.Sprintf("views:seen:%s:%d", viewer, productID), 1, window).Result()
+}
+
+// Count a view of the viewer, returns the views of the viewer within the current window
+func (r *productsRedisRepo) CountViews(viewer string, window time.Duration) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.RedisAction)
+	defer cancel()
+
+	return countViewsScript.Run(ctx, r.redisClient, []string{"views:rate:" + viewer}, window.Milliseconds()).Int64()
+}
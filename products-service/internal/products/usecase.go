@@ -12,8 +12,19 @@ import (
 // Products usecase interface
 type UseCase interface {
 	Get(id int64) (*models.Product, error)
-	Update(id int64, name *string, tags []string) error
-	Create(name string, tags []string) (int64, error)
-	Delete(id int64) error
+	Update(id int64, version *int64, input *models.UpdateProductDTO, changedBy string) (*models.Product, *models.ProductChange, error)
+	Patch(id int64, version *int64, patch []byte, changedBy string) (*models.Product, *models.ProductChange, error)
+	Create(input *models.CreateProductDTO, changedBy string) (*models.Product, error)
+	Delete(id int64, version *int64, changedBy string) error
+	Restore(id int64, changedBy string) (*models.Product, error)
+	History(id int64) ([]models.ProductRevision, error)
+	Revision(id, version int64) (*models.ProductRevision, error)
+	Rollback(id, version int64, changedBy string) (*models.Product, *models.ProductChange, error)
+	ListDeleted() ([]models.Product, error)
+	Import(input *models.ImportProductDTO, dryRun bool, changedBy string) (*models.Product, *models.ProductChange, error)
+	ListAfter(afterID int64, limit int) ([]models.Product, error)
+	PurgeDeleted() (int64, error)
 	SendToKafka(ctx context.Context, key string, message kf.KafkaMessage, writer *kafka.Writer) error
+	SendBatchToKafka(ctx context.Context, events []kf.Event, writer *kafka.Writer) error
+	SendViewsToKafka(ctx context.Context, events []kf.Event, writer *kafka.Writer) error
 }
@@ -3,6 +3,13 @@ package usecase
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/segmentio/kafka-go"
 	"go.uber.org/zap"
@@ -10,19 +17,37 @@ import (
 	"cyansnbrst/products-service/config"
 	"cyansnbrst/products-service/internal/models"
 	"cyansnbrst/products-service/internal/products"
+	"cyansnbrst/products-service/internal/tags"
+	"cyansnbrst/products-service/pkg/db"
 	kf "cyansnbrst/products-service/pkg/kafka"
+	"cyansnbrst/products-service/pkg/mergepatch"
+	"cyansnbrst/products-service/pkg/taxonomy"
 )
 
+// Product limits
+const (
+	maxNameLength        = 200
+	maxDescriptionLength = 5000
+	maxCategoryLength    = 64
+	maxImages            = 10
+	maxImageURLLength    = 2048
+)
+
+// ISO 4217 currency code
+var currencyRX = regexp.MustCompile(`^[A-Z]{3}$`)
+
 // Products usecase struct
 type productsUC struct {
 	cfg          *config.Config
 	productsRepo products.Repository
+	tagsRepo     tags.Repository
+	viewsRepo    products.RedisRepository
 	logger       *zap.Logger
 }
 
 // Products usecase constructor
-func NewProductsUseCase(cfg *config.Config, productsRepo products.Repository, logger *zap.Logger) products.UseCase {
-	return &productsUC{cfg: cfg, productsRepo: productsRepo, logger: logger}
+func NewProductsUseCase(cfg *config.Config, productsRepo products.Repository, tagsRepo tags.Repository, viewsRepo products.RedisRepository, logger *zap.Logger) products.UseCase {
+	return &productsUC{cfg: cfg, productsRepo: productsRepo, tagsRepo: tagsRepo, viewsRepo: viewsRepo, logger: logger}
 }
 
 // Get a product by ID
@@ -30,31 +55,306 @@ func (u *productsUC) Get(id int64) (*models.Product, error) {
 	return u.productsRepo.GetByID(id)
 }
 
-// Update a product
-func (u *productsUC) Update(id int64, name *string, tags []string) error {
+// Update a product, only the fields present in the input are changed and tags are replaced by their canonical tags.
+// A non-nil version is the version the client edited, the update fails with an edit conflict if the product has changed since.
+// Returns the product and the change, an update that changes nothing writes no new version.
+func (u *productsUC) Update(id int64, version *int64, input *models.UpdateProductDTO, changedBy string) (*models.Product, *models.ProductChange, error) {
+	product, err := u.productsRepo.GetByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if version != nil && *version != product.Version {
+		return nil, nil, db.ErrEditConflict
+	}
+
+	before := *product
+
+	if err = u.applyUpdate(product, input); err != nil {
+		return nil, nil, err
+	}
+
+	change, err := u.save(&before, product, changedBy)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return product, change, nil
+}
+
+// Apply a JSON merge patch to a product, a field set to null is cleared.
+// Returns the product and the change, a patch that changes nothing writes no new version.
+func (u *productsUC) Patch(id int64, version *int64, patch []byte, changedBy string) (*models.Product, *models.ProductChange, error) {
+	product, err := u.productsRepo.GetByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if version != nil && *version != product.Version {
+		return nil, nil, db.ErrEditConflict
+	}
+
+	var patched models.PatchProductDTO
+	if err = mergepatch.Apply(patchFields(product), patch, &patched); err != nil {
+		return nil, nil, err
+	}
+
+	before := *product
+
+	input := &models.UpdateProductDTO{
+		Name:        &patched.Name,
+		Description: &patched.Description,
+		Price:       &patched.Price,
+		Currency:    &patched.Currency,
+		Category:    &patched.Category,
+		Images:      nonNilStrings(patched.Images),
+		Status:      &patched.Status,
+	}
+	// Tags are only resolved again when the patch touches them
+	if !slices.Equal(patched.Tags, product.Tags) {
+		input.Tags = nonNilStrings(patched.Tags)
+	}
+	product.Stock = patched.Stock
+
+	if err = u.applyUpdate(product, input); err != nil {
+		return nil, nil, err
+	}
+
+	change, err := u.save(&before, product, changedBy)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return product, change, nil
+}
+
+// Create a product, tags are replaced by their canonical tags.
+// The product is active and priced in the default currency unless the input says otherwise.
+func (u *productsUC) Create(input *models.CreateProductDTO, changedBy string) (*models.Product, error) {
+	product, err := u.newProduct(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = u.productsRepo.Create(product, changedBy); err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+// Soft-delete a product, a non-nil version must match the current version of the product
+func (u *productsUC) Delete(id int64, version *int64, changedBy string) error {
 	product, err := u.productsRepo.GetByID(id)
 	if err != nil {
 		return err
 	}
 
-	if name != nil {
-		product.Name = *name
+	if version != nil && *version != product.Version {
+		return db.ErrEditConflict
+	}
+
+	return u.productsRepo.Delete(id, product.Version, changedBy)
+}
+
+// Restore a soft-deleted product
+func (u *productsUC) Restore(id int64, changedBy string) (*models.Product, error) {
+	return u.productsRepo.Restore(id, changedBy)
+}
+
+// Get revisions of a product, newest first
+func (u *productsUC) History(id int64) ([]models.ProductRevision, error) {
+	revisions, err := u.productsRepo.GetRevisions(id)
+	if err != nil {
+		return nil, err
 	}
-	if tags != nil {
-		product.Tags = tags
+
+	if len(revisions) == 0 {
+		return nil, db.ErrRecordNotFound
 	}
 
-	return u.productsRepo.Update(product)
+	return revisions, nil
 }
 
-// Create a product
-func (u *productsUC) Create(name string, tags []string) (int64, error) {
-	return u.productsRepo.Create(name, tags)
+// Get a revision of a product by version
+func (u *productsUC) Revision(id, version int64) (*models.ProductRevision, error) {
+	return u.productsRepo.GetRevision(id, version)
+}
+
+// Roll a product back to the state of a previous version, the rollback itself is recorded as a new version.
+// Tags of the old version are mapped to canonical tags again, so tags removed from the vocabulary since then are rejected.
+func (u *productsUC) Rollback(id, version int64, changedBy string) (*models.Product, *models.ProductChange, error) {
+	product, err := u.productsRepo.GetByID(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	revision, err := u.productsRepo.GetRevision(id, version)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	before := *product
+
+	previous := revision.Product
+	product.Name = previous.Name
+	product.Description = previous.Description
+	product.Price = previous.Price
+	product.Currency = previous.Currency
+	product.Category = previous.Category
+	product.Stock = previous.Stock
+	product.Images = previous.Images
+	product.Status = previous.Status
+
+	if product.Tags, err = u.canonicalTags(previous.Tags); err != nil {
+		return nil, nil, err
+	}
+
+	if err = validateProduct(product); err != nil {
+		return nil, nil, err
+	}
+
+	change, err := u.save(&before, product, changedBy)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return product, change, nil
+}
+
+// Import a product, a row with an ID updates the product and other rows create products.
+// The change is nil for created products. A dry run only checks the row, nothing is written.
+func (u *productsUC) Import(input *models.ImportProductDTO, dryRun bool, changedBy string) (*models.Product, *models.ProductChange, error) {
+	if input.ID == 0 {
+		create := createInput(&input.UpdateProductDTO)
+
+		var product *models.Product
+		var err error
+		if dryRun {
+			product, err = u.newProduct(create)
+		} else {
+			product, err = u.Create(create, changedBy)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		return product, nil, nil
+	}
+
+	if !dryRun {
+		return u.Update(input.ID, nil, &input.UpdateProductDTO, changedBy)
+	}
+
+	product, err := u.productsRepo.GetByID(input.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	before := *product
+
+	if err = u.applyUpdate(product, &input.UpdateProductDTO); err != nil {
+		return nil, nil, err
+	}
+
+	return product, diffProducts(&before, product), nil
+}
+
+// Get a page of products with IDs greater than afterID, ordered by ID
+func (u *productsUC) ListAfter(afterID int64, limit int) ([]models.Product, error) {
+	return u.productsRepo.ListAfter(afterID, limit)
+}
+
+// Get soft-deleted products
+func (u *productsUC) ListDeleted() ([]models.Product, error) {
+	return u.productsRepo.ListDeleted()
+}
+
+// Permanently delete products soft-deleted longer than the retention period ago, zero retention keeps them
+func (u *productsUC) PurgeDeleted() (int64, error) {
+	if u.cfg.Purge.Retention <= 0 {
+		return 0, nil
+	}
+	return u.productsRepo.Purge(time.Now().Add(-u.cfg.Purge.Retention))
+}
+
+// Decide which buffered views count in the product popularity and publish them.
+// Views are filtered by the buffer worker, so product reads never wait for Redis.
+func (u *productsUC) SendViewsToKafka(ctx context.Context, events []kf.Event, writer *kafka.Writer) error {
+	u.filterViews(events)
+	return u.SendBatchToKafka(ctx, events, writer)
+}
+
+// Mark each view as counted or skipped with a reason.
+// When Redis fails the rest of the batch is only checked for bots, so a Redis outage does not stall publishing.
+func (u *productsUC) filterViews(events []kf.Event) {
+	redisDown := false
+	for i := range events {
+		productID, _ := strconv.ParseInt(events[i].Key, 10, 64)
+		view := &models.ProductView{ProductID: productID, Viewer: events[i].Viewer, UserAgent: events[i].UserAgent}
+
+		counted, skipReason := true, ""
+		switch {
+		case u.isBotView(view):
+			counted, skipReason = false, models.ViewSkipBot
+		case !redisDown:
+			var err error
+			counted, skipReason, err = u.countView(view)
+			if err != nil {
+				u.logger.Warn("failed to filter product views, counting them", zap.Error(err))
+				counted, skipReason, redisDown = true, "", true
+			}
+		}
+
+		events[i].Message.Counted, events[i].Message.SkipReason = &counted, skipReason
+	}
+}
+
+// Check whether a view of a person counts in the product popularity, returns the reason a view is not counted.
+// Views of a user over the rate limit and repeated views within the dedup window are not counted.
+func (u *productsUC) countView(view *models.ProductView) (bool, string, error) {
+	filter := u.cfg.ViewFilter
+
+	if filter.RateLimit > 0 {
+		views, err := u.viewsRepo.CountViews(view.Viewer, filter.RateWindow)
+		if err != nil {
+			return false, "", err
+		}
+		if views > int64(filter.RateLimit) {
+			return false, models.ViewSkipRate, nil
+		}
+	}
+
+	if filter.DedupWindow > 0 {
+		first, err := u.viewsRepo.MarkViewed(view.Viewer, view.ProductID, filter.DedupWindow)
+		if err != nil {
+			return false, "", err
+		}
+		if !first {
+			return false, models.ViewSkipDuplicate, nil
+		}
+	}
+
+	return true, "", nil
 }
 
-// Delete a product
-func (u *productsUC) Delete(id int64) error {
-	return u.productsRepo.Delete(id)
+// Check the User-Agent of the view against the bot list, an empty User-Agent is a bot when the list is set
+func (u *productsUC) isBotView(view *models.ProductView) bool {
+	bots := u.cfg.ViewFilter.BotUserAgents
+	if len(bots) == 0 {
+		return false
+	}
+
+	userAgent := strings.ToLower(view.UserAgent)
+	if userAgent == "" {
+		return true
+	}
+	for _, bot := range bots {
+		if bot = strings.ToLower(strings.TrimSpace(bot)); bot != "" && strings.Contains(userAgent, bot) {
+			return true
+		}
+	}
+
+	return false
 }
 
 // Send Kafka message
@@ -75,3 +375,296 @@ func (u *productsUC) SendToKafka(ctx context.Context, key string, message kf.Kaf
 
 	return nil
 }
+
+// Send a batch of Kafka messages with one write
+func (u *productsUC) SendBatchToKafka(ctx context.Context, events []kf.Event, writer *kafka.Writer) error {
+	messages := make([]kafka.Message, 0, len(events))
+	for _, event := range events {
+		messageValue, err := json.Marshal(event.Message)
+		if err != nil {
+			return err
+		}
+
+		messages = append(messages, kafka.Message{
+			Key:   []byte(event.Key),
+			Value: messageValue,
+		})
+	}
+
+	return writer.WriteMessages(ctx, messages...)
+}
+
+// Build a new product from the input and check it, tags are replaced by their canonical tags.
+// The product is active and priced in the default currency unless the input says otherwise.
+func (u *productsUC) newProduct(input *models.CreateProductDTO) (*models.Product, error) {
+	product := &models.Product{
+		Name:        strings.TrimSpace(input.Name),
+		Description: strings.TrimSpace(input.Description),
+		Price:       input.Price,
+		Currency:    strings.ToUpper(strings.TrimSpace(input.Currency)),
+		Category:    taxonomy.Normalize(input.Category),
+		Stock:       input.Stock,
+		Images:      trimAll(input.Images),
+		Status:      strings.TrimSpace(input.Status),
+		Version:     1,
+	}
+	if product.Currency == "" {
+		product.Currency = u.cfg.DefaultCurrency
+	}
+	if product.Status == "" {
+		product.Status = models.ProductStatusActive
+	}
+
+	canonical, err := u.canonicalTags(input.Tags)
+	if err != nil {
+		return nil, err
+	}
+	product.Tags = canonical
+
+	if err = validateProduct(product); err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+// Change the fields of the product present in the input and check it, tags are replaced by their canonical tags
+func (u *productsUC) applyUpdate(product *models.Product, input *models.UpdateProductDTO) error {
+	var err error
+
+	if input.Name != nil {
+		product.Name = strings.TrimSpace(*input.Name)
+	}
+	if input.Description != nil {
+		product.Description = strings.TrimSpace(*input.Description)
+	}
+	if input.Price != nil {
+		product.Price = *input.Price
+	}
+	if input.Currency != nil {
+		product.Currency = strings.ToUpper(strings.TrimSpace(*input.Currency))
+	}
+	if input.Category != nil {
+		product.Category = taxonomy.Normalize(*input.Category)
+	}
+	if input.Stock != nil {
+		product.Stock = input.Stock
+	}
+	if input.Images != nil {
+		product.Images = trimAll(input.Images)
+	}
+	if input.Status != nil {
+		product.Status = strings.TrimSpace(*input.Status)
+	}
+	if input.Tags != nil {
+		if product.Tags, err = u.canonicalTags(input.Tags); err != nil {
+			return err
+		}
+	}
+
+	return validateProduct(product)
+}
+
+// Normalize tags and map them and their aliases to canonical tags, every tag must be in the vocabulary
+func (u *productsUC) canonicalTags(tags []string) ([]string, error) {
+	normalized := taxonomy.NormalizeAll(tags)
+
+	resolved, err := u.tagsRepo.Resolve(normalized)
+	if err != nil {
+		return nil, err
+	}
+
+	var unknown []string
+	for _, tag := range normalized {
+		if _, ok := resolved[tag]; !ok {
+			unknown = append(unknown, tag)
+		}
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("%w: %s", products.ErrUnknownTags, strings.Join(unknown, ", "))
+	}
+
+	canonical := make([]string, 0, len(normalized))
+	for _, tag := range normalized {
+		canonical = append(canonical, resolved[tag])
+	}
+
+	return taxonomy.NormalizeAll(canonical), nil
+}
+
+// Check catalog fields of the product
+func validateProduct(product *models.Product) error {
+	switch {
+	case product.Name == "":
+		return fmt.Errorf("%w: name must not be empty", products.ErrInvalidProduct)
+	case len(product.Name) > maxNameLength:
+		return fmt.Errorf("%w: name must be at most %d bytes long", products.ErrInvalidProduct, maxNameLength)
+	case len(product.Description) > maxDescriptionLength:
+		return fmt.Errorf("%w: description must be at most %d bytes long", products.ErrInvalidProduct, maxDescriptionLength)
+	case product.Price < 0:
+		return fmt.Errorf("%w: price must not be negative", products.ErrInvalidProduct)
+	case !currencyRX.MatchString(product.Currency):
+		return fmt.Errorf("%w: currency must be a three-letter ISO 4217 code", products.ErrInvalidProduct)
+	case len(product.Category) > maxCategoryLength:
+		return fmt.Errorf("%w: category must be at most %d bytes long", products.ErrInvalidProduct, maxCategoryLength)
+	case product.Stock != nil && *product.Stock < 0:
+		return fmt.Errorf("%w: stock must not be negative", products.ErrInvalidProduct)
+	case len(product.Images) > maxImages:
+		return fmt.Errorf("%w: at most %d images are allowed", products.ErrInvalidProduct, maxImages)
+	}
+
+	switch product.Status {
+	case models.ProductStatusDraft, models.ProductStatusActive, models.ProductStatusArchived:
+	default:
+		return fmt.Errorf("%w: status must be one of draft, active, archived", products.ErrInvalidProduct)
+	}
+
+	for _, image := range product.Images {
+		if err := validateImageURL(image); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Check an image is an absolute http(s) URL
+func validateImageURL(image string) error {
+	if len(image) > maxImageURLLength {
+		return fmt.Errorf("%w: image URL must be at most %d bytes long", products.ErrInvalidProduct, maxImageURLLength)
+	}
+
+	imageURL, err := url.Parse(image)
+	if err != nil || (imageURL.Scheme != "http" && imageURL.Scheme != "https") || imageURL.Host == "" {
+		return fmt.Errorf("%w: image %q must be an http or https URL", products.ErrInvalidProduct, image)
+	}
+
+	return nil
+}
+
+// Create input from the fields present in an import row
+func createInput(input *models.UpdateProductDTO) *models.CreateProductDTO {
+	create := &models.CreateProductDTO{
+		Stock:  input.Stock,
+		Images: input.Images,
+		Tags:   input.Tags,
+	}
+	if input.Name != nil {
+		create.Name = *input.Name
+	}
+	if input.Description != nil {
+		create.Description = *input.Description
+	}
+	if input.Price != nil {
+		create.Price = *input.Price
+	}
+	if input.Currency != nil {
+		create.Currency = *input.Currency
+	}
+	if input.Category != nil {
+		create.Category = *input.Category
+	}
+	if input.Status != nil {
+		create.Status = *input.Status
+	}
+	return create
+}
+
+// Editable fields of a product, the document a merge patch applies to
+func patchFields(product *models.Product) models.PatchProductDTO {
+	return models.PatchProductDTO{
+		Name:        product.Name,
+		Description: product.Description,
+		Price:       product.Price,
+		Currency:    product.Currency,
+		Category:    product.Category,
+		Stock:       product.Stock,
+		Images:      product.Images,
+		Status:      product.Status,
+		Tags:        product.Tags,
+	}
+}
+
+// Store the edited product unless the edit changed nothing, the change is diffed against the state before the edit
+func (u *productsUC) save(before, product *models.Product, changedBy string) (*models.ProductChange, error) {
+	change := diffProducts(before, product)
+	if len(change.Fields) == 0 {
+		return change, nil
+	}
+
+	if err := u.productsRepo.Update(product, changedBy); err != nil {
+		return nil, err
+	}
+	product.Version++
+
+	return change, nil
+}
+
+// Change between two states of a product: the editable fields that differ and the tags added and removed
+func diffProducts(before, after *models.Product) *models.ProductChange {
+	fields := []struct {
+		name    string
+		changed bool
+	}{
+		{"name", before.Name != after.Name},
+		{"description", before.Description != after.Description},
+		{"price", before.Price != after.Price},
+		{"currency", before.Currency != after.Currency},
+		{"category", before.Category != after.Category},
+		{"stock", !equalStock(before.Stock, after.Stock)},
+		{"images", !slices.Equal(before.Images, after.Images)},
+		{"status", before.Status != after.Status},
+		{"tags", !slices.Equal(before.Tags, after.Tags)},
+	}
+
+	change := &models.ProductChange{
+		AddedTags:   missingTags(after.Tags, before.Tags),
+		RemovedTags: missingTags(before.Tags, after.Tags),
+	}
+	for _, field := range fields {
+		if field.changed {
+			change.Fields = append(change.Fields, field.name)
+		}
+	}
+	return change
+}
+
+// Tags of the first list missing from the second one, in the order of the first list
+func missingTags(tags, from []string) []string {
+	var missing []string
+	for _, tag := range tags {
+		if !slices.Contains(from, tag) {
+			missing = append(missing, tag)
+		}
+	}
+	return missing
+}
+
+// Check two stocks are equal, nil is an untracked stock
+func equalStock(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// An empty slice in place of nil, so the field is applied as a cleared list
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// Trim strings
+func trimAll(values []string) []string {
+	if values == nil {
+		return nil
+	}
+
+	trimmed := make([]string, len(values))
+	for i, value := range values {
+		trimmed[i] = strings.TrimSpace(value)
+	}
+	return trimmed
+}
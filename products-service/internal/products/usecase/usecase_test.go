@@ -3,6 +3,7 @@ package usecase
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/require"
@@ -10,7 +11,12 @@ import (
 
 	"cyansnbrst/products-service/config"
 	"cyansnbrst/products-service/internal/models"
+	"cyansnbrst/products-service/internal/products"
 	mock_products "cyansnbrst/products-service/internal/products/mock"
+	mock_tags "cyansnbrst/products-service/internal/tags/mock"
+	"cyansnbrst/products-service/pkg/db"
+	kf "cyansnbrst/products-service/pkg/kafka"
+	"cyansnbrst/products-service/pkg/mergepatch"
 )
 
 func TestProductsUseCase_Get(t *testing.T) {
@@ -22,7 +28,8 @@ func TestProductsUseCase_Get(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockProductsRepo := mock_products.NewMockRepository(ctrl)
-	productsUC := NewProductsUseCase(cfg, mockProductsRepo, logger)
+	mockTagsRepo := mock_tags.NewMockRepository(ctrl)
+	productsUC := NewProductsUseCase(cfg, mockProductsRepo, mockTagsRepo, nil, logger)
 
 	tests := []struct {
 		name         string
@@ -69,35 +76,327 @@ func TestProductsUseCase_Update(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockProductsRepo := mock_products.NewMockRepository(ctrl)
-	productsUC := NewProductsUseCase(cfg, mockProductsRepo, logger)
+	mockTagsRepo := mock_tags.NewMockRepository(ctrl)
+	productsUC := NewProductsUseCase(cfg, mockProductsRepo, mockTagsRepo, nil, logger)
+
+	canonical := map[string]string{"tag1": "tag1", "tags2": "tag2"}
+	newName := "newname"
+	archived := models.ProductStatusArchived
+	unknownStatus := "sold"
+	price := int64(129900)
+	stock := int64(0)
+	negativeStock := int64(-1)
+
+	stored := func() *models.Product {
+		return &models.Product{ID: 1, Name: "name", Currency: "RUB", Status: models.ProductStatusActive, Version: 1}
+	}
+	tagged := func() *models.Product {
+		return &models.Product{ID: 1, Name: "name", Currency: "RUB", Status: models.ProductStatusActive, Tags: []string{"tag1", "tag3"}, Version: 1}
+	}
+	sameName := "name"
+
+	staleVersion := int64(0)
+	currentVersion := int64(1)
+
+	tests := []struct {
+		name         string
+		version      *int64
+		input        *models.UpdateProductDTO
+		mockBehavior func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository)
+		want         *models.Product
+		wantChange   *models.ProductChange
+		wantErr      error
+	}{
+		{
+			name:    "update of the current version",
+			version: &currentVersion,
+			input:   &models.UpdateProductDTO{Name: &newName},
+			mockBehavior: func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository) {
+				mockRepo.EXPECT().GetByID(int64(1)).Return(stored(), nil)
+				mockRepo.EXPECT().Update(gomock.Any(), "admin").Return(nil)
+			},
+			want:       &models.Product{ID: 1, Name: "newname", Currency: "RUB", Status: models.ProductStatusActive, Version: 2},
+			wantChange: &models.ProductChange{Fields: []string{"name"}},
+		},
+		{
+			name:    "update of a stale version",
+			version: &staleVersion,
+			input:   &models.UpdateProductDTO{Name: &newName},
+			mockBehavior: func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository) {
+				mockRepo.EXPECT().GetByID(int64(1)).Return(stored(), nil)
+			},
+			wantErr: db.ErrEditConflict,
+		},
+		{
+			name:  "update product success",
+			input: &models.UpdateProductDTO{Name: &newName, Tags: []string{" Tag1", "TAGS2", "tag1"}},
+			mockBehavior: func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository) {
+				mockRepo.EXPECT().GetByID(int64(1)).Return(stored(), nil)
+				mockTagsRepo.EXPECT().Resolve([]string{"tag1", "tags2"}).Return(canonical, nil)
+				mockRepo.EXPECT().Update(&models.Product{
+					ID: 1, Name: "newname", Currency: "RUB", Status: models.ProductStatusActive, Tags: []string{"tag1", "tag2"}, Version: 1,
+				}, "admin").Return(nil)
+			},
+			want: &models.Product{
+				ID: 1, Name: "newname", Currency: "RUB", Status: models.ProductStatusActive, Tags: []string{"tag1", "tag2"}, Version: 2,
+			},
+			wantChange: &models.ProductChange{Fields: []string{"name", "tags"}, AddedTags: []string{"tag1", "tag2"}},
+		},
+		{
+			name:  "update of tags lists added and removed tags",
+			input: &models.UpdateProductDTO{Tags: []string{"tags2", "tag1"}},
+			mockBehavior: func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository) {
+				mockRepo.EXPECT().GetByID(int64(1)).Return(tagged(), nil)
+				mockTagsRepo.EXPECT().Resolve([]string{"tags2", "tag1"}).Return(canonical, nil)
+				mockRepo.EXPECT().Update(gomock.Any(), "admin").Return(nil)
+			},
+			want: &models.Product{
+				ID: 1, Name: "name", Currency: "RUB", Status: models.ProductStatusActive, Tags: []string{"tag2", "tag1"}, Version: 2,
+			},
+			wantChange: &models.ProductChange{Fields: []string{"tags"}, AddedTags: []string{"tag2"}, RemovedTags: []string{"tag3"}},
+		},
+		{
+			name:  "update without changes writes nothing",
+			input: &models.UpdateProductDTO{Name: &sameName, Tags: []string{"Tag1", "tag3"}},
+			mockBehavior: func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository) {
+				mockRepo.EXPECT().GetByID(int64(1)).Return(tagged(), nil)
+				mockTagsRepo.EXPECT().Resolve([]string{"tag1", "tag3"}).Return(map[string]string{"tag1": "tag1", "tag3": "tag3"}, nil)
+			},
+			want:       tagged(),
+			wantChange: &models.ProductChange{},
+		},
+		{
+			name:  "update catalog fields",
+			input: &models.UpdateProductDTO{Price: &price, Stock: &stock, Status: &archived, Images: []string{" https://cdn.example.com/1.png "}},
+			mockBehavior: func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository) {
+				mockRepo.EXPECT().GetByID(int64(1)).Return(stored(), nil)
+				mockRepo.EXPECT().Update(gomock.Any(), "admin").Return(nil)
+			},
+			want: &models.Product{
+				ID: 1, Name: "name", Price: 129900, Currency: "RUB", Stock: &stock,
+				Images: []string{"https://cdn.example.com/1.png"}, Status: models.ProductStatusArchived, Version: 2,
+			},
+			wantChange: &models.ProductChange{Fields: []string{"price", "stock", "images", "status"}},
+		},
+		{
+			name:  "update product unknown tag",
+			input: &models.UpdateProductDTO{Tags: []string{" Tag1", "TAGS2"}},
+			mockBehavior: func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository) {
+				mockRepo.EXPECT().GetByID(int64(1)).Return(stored(), nil)
+				mockTagsRepo.EXPECT().Resolve([]string{"tag1", "tags2"}).Return(map[string]string{"tag1": "tag1"}, nil)
+			},
+			wantErr: products.ErrUnknownTags,
+		},
+		{
+			name:  "update product unknown status",
+			input: &models.UpdateProductDTO{Status: &unknownStatus},
+			mockBehavior: func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository) {
+				mockRepo.EXPECT().GetByID(int64(1)).Return(stored(), nil)
+			},
+			wantErr: products.ErrInvalidProduct,
+		},
+		{
+			name:  "update product negative stock",
+			input: &models.UpdateProductDTO{Stock: &negativeStock},
+			mockBehavior: func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository) {
+				mockRepo.EXPECT().GetByID(int64(1)).Return(stored(), nil)
+			},
+			wantErr: products.ErrInvalidProduct,
+		},
+		{
+			name:  "update product edited concurrently",
+			input: &models.UpdateProductDTO{Name: &newName},
+			mockBehavior: func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository) {
+				mockRepo.EXPECT().GetByID(int64(1)).Return(stored(), nil)
+				mockRepo.EXPECT().Update(gomock.Any(), "admin").Return(db.ErrEditConflict)
+			},
+			wantErr: db.ErrEditConflict,
+		},
+		{
+			name:  "update product not found",
+			input: &models.UpdateProductDTO{Name: &newName},
+			mockBehavior: func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository) {
+				mockRepo.EXPECT().GetByID(int64(1)).Return(nil, db.ErrRecordNotFound)
+			},
+			wantErr: db.ErrRecordNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockProductsRepo, mockTagsRepo)
+
+			product, change, err := productsUC.Update(1, tt.version, tt.input, "admin")
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.want, product)
+				require.Equal(t, tt.wantChange, change)
+			}
+		})
+	}
+}
+
+func TestProductsUseCase_Create(t *testing.T) {
+	cfg := &config.Config{DefaultCurrency: "RUB"}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProductsRepo := mock_products.NewMockRepository(ctrl)
+	mockTagsRepo := mock_tags.NewMockRepository(ctrl)
+	productsUC := NewProductsUseCase(cfg, mockProductsRepo, mockTagsRepo, nil, logger)
+
+	errCreate := errors.New("create error")
+	resolved := map[string]string{"rock music": "rock", "rock": "rock"}
+	tags := []string{"Rock  Music", "rock"}
+
+	tests := []struct {
+		name         string
+		input        *models.CreateProductDTO
+		mockBehavior func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository)
+		want         *models.Product
+		wantErr      error
+	}{
+		{
+			name:  "create product success",
+			input: &models.CreateProductDTO{Name: " product ", Price: 4990, Currency: "usd", Category: " Vinyl", Tags: tags},
+			mockBehavior: func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository) {
+				mockTagsRepo.EXPECT().Resolve([]string{"rock music", "rock"}).Return(resolved, nil)
+				mockRepo.EXPECT().Create(gomock.Any(), "admin").DoAndReturn(func(product *models.Product, _ string) error {
+					product.ID = 1
+					return nil
+				})
+			},
+			want: &models.Product{
+				ID: 1, Name: "product", Price: 4990, Currency: "USD", Category: "vinyl",
+				Status: models.ProductStatusActive, Tags: []string{"rock"}, Version: 1,
+			},
+		},
+		{
+			name:  "create product defaults",
+			input: &models.CreateProductDTO{Name: "product", Tags: tags},
+			mockBehavior: func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository) {
+				mockTagsRepo.EXPECT().Resolve(gomock.Any()).Return(resolved, nil)
+				mockRepo.EXPECT().Create(&models.Product{
+					Name: "product", Currency: "RUB", Status: models.ProductStatusActive, Tags: []string{"rock"}, Version: 1,
+				}, "admin").Return(nil)
+			},
+			want: &models.Product{Name: "product", Currency: "RUB", Status: models.ProductStatusActive, Tags: []string{"rock"}, Version: 1},
+		},
+		{
+			name:  "create product unknown tag",
+			input: &models.CreateProductDTO{Name: "product", Tags: tags},
+			mockBehavior: func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository) {
+				mockTagsRepo.EXPECT().Resolve([]string{"rock music", "rock"}).Return(map[string]string{}, nil)
+			},
+			wantErr: products.ErrUnknownTags,
+		},
+		{
+			name:  "create product invalid currency",
+			input: &models.CreateProductDTO{Name: "product", Currency: "rubles", Tags: tags},
+			mockBehavior: func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository) {
+				mockTagsRepo.EXPECT().Resolve(gomock.Any()).Return(resolved, nil)
+			},
+			wantErr: products.ErrInvalidProduct,
+		},
+		{
+			name:  "create product negative price",
+			input: &models.CreateProductDTO{Name: "product", Price: -1, Tags: tags},
+			mockBehavior: func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository) {
+				mockTagsRepo.EXPECT().Resolve(gomock.Any()).Return(resolved, nil)
+			},
+			wantErr: products.ErrInvalidProduct,
+		},
+		{
+			name:  "create product invalid image",
+			input: &models.CreateProductDTO{Name: "product", Images: []string{"ftp://cdn.example.com/1.png"}, Tags: tags},
+			mockBehavior: func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository) {
+				mockTagsRepo.EXPECT().Resolve(gomock.Any()).Return(resolved, nil)
+			},
+			wantErr: products.ErrInvalidProduct,
+		},
+		{
+			name:  "create product repository error",
+			input: &models.CreateProductDTO{Name: "product", Tags: tags},
+			mockBehavior: func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository) {
+				mockTagsRepo.EXPECT().Resolve(gomock.Any()).Return(resolved, nil)
+				mockRepo.EXPECT().Create(gomock.Any(), "admin").Return(errCreate)
+			},
+			wantErr: errCreate,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockProductsRepo, mockTagsRepo)
+
+			product, err := productsUC.Create(tt.input, "admin")
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.want, product)
+			}
+		})
+	}
+}
+
+func TestProductsUseCase_Delete(t *testing.T) {
+	cfg := &config.Config{}
+	logger := zap.NewNop()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProductsRepo := mock_products.NewMockRepository(ctrl)
+	mockTagsRepo := mock_tags.NewMockRepository(ctrl)
+	productsUC := NewProductsUseCase(cfg, mockProductsRepo, mockTagsRepo, nil, logger)
+
+	staleVersion := int64(1)
+	errDelete := errors.New("delete error")
 
 	tests := []struct {
 		name         string
+		version      *int64
 		mockBehavior func(mockRepo *mock_products.MockRepository)
-		wantErr      bool
+		wantErr      error
 	}{
 		{
-			name: "update product success",
+			name: "delete product success",
 			mockBehavior: func(mockRepo *mock_products.MockRepository) {
-				mockRepo.EXPECT().GetByID(int64(1)).Return(&models.Product{ID: 1, Name: "name"}, nil)
-				mockRepo.EXPECT().Update(gomock.Any()).Return(nil)
+				mockRepo.EXPECT().GetByID(int64(1)).Return(&models.Product{ID: 1, Version: 2}, nil)
+				mockRepo.EXPECT().Delete(int64(1), int64(2), "admin").Return(nil)
 			},
-			wantErr: false,
 		},
 		{
-			name: "update product repository error",
+			name:    "delete of a stale version",
+			version: &staleVersion,
 			mockBehavior: func(mockRepo *mock_products.MockRepository) {
-				mockRepo.EXPECT().GetByID(int64(1)).Return(&models.Product{ID: 1, Name: "name"}, nil)
-				mockRepo.EXPECT().Update(gomock.Any()).Return(errors.New("update error"))
+				mockRepo.EXPECT().GetByID(int64(1)).Return(&models.Product{ID: 1, Version: 2}, nil)
 			},
-			wantErr: true,
+			wantErr: db.ErrEditConflict,
 		},
 		{
-			name: "update product not found",
+			name: "delete product not found",
 			mockBehavior: func(mockRepo *mock_products.MockRepository) {
-				mockRepo.EXPECT().GetByID(int64(1)).Return(nil, errors.New("get error"))
+				mockRepo.EXPECT().GetByID(int64(1)).Return(nil, db.ErrRecordNotFound)
 			},
-			wantErr: true,
+			wantErr: db.ErrRecordNotFound,
+		},
+		{
+			name: "delete product repository error",
+			mockBehavior: func(mockRepo *mock_products.MockRepository) {
+				mockRepo.EXPECT().GetByID(int64(1)).Return(&models.Product{ID: 1, Version: 2}, nil)
+				mockRepo.EXPECT().Delete(int64(1), int64(2), "admin").Return(errDelete)
+			},
+			wantErr: errDelete,
 		},
 	}
 
@@ -105,11 +404,10 @@ func TestProductsUseCase_Update(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mockBehavior(mockProductsRepo)
 
-			newName := "newname"
-			err := productsUC.Update(1, &newName, []string{"tag1", "tag2"})
+			err := productsUC.Delete(1, tt.version, "admin")
 
-			if tt.wantErr {
-				require.Error(t, err)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
 			} else {
 				require.NoError(t, err)
 			}
@@ -117,35 +415,35 @@ func TestProductsUseCase_Update(t *testing.T) {
 	}
 }
 
-func TestProductsUseCase_Create(t *testing.T) {
+func TestProductsUseCase_Restore(t *testing.T) {
 	cfg := &config.Config{}
-
 	logger := zap.NewNop()
-
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockProductsRepo := mock_products.NewMockRepository(ctrl)
-	productsUC := NewProductsUseCase(cfg, mockProductsRepo, logger)
+	mockTagsRepo := mock_tags.NewMockRepository(ctrl)
+	productsUC := NewProductsUseCase(cfg, mockProductsRepo, mockTagsRepo, nil, logger)
 
 	tests := []struct {
 		name         string
 		mockBehavior func(mockRepo *mock_products.MockRepository)
-		wantErr      bool
+		wantProduct  *models.Product
+		wantErr      error
 	}{
 		{
-			name: "create product success",
+			name: "restore product success",
 			mockBehavior: func(mockRepo *mock_products.MockRepository) {
-				mockRepo.EXPECT().Create("product", []string{"tag1", "tag2"}).Return(int64(1), nil)
+				mockRepo.EXPECT().Restore(int64(1), "admin").Return(&models.Product{ID: 1, Name: "product1", Tags: []string{"tag1"}}, nil)
 			},
-			wantErr: false,
+			wantProduct: &models.Product{ID: 1, Name: "product1", Tags: []string{"tag1"}},
 		},
 		{
-			name: "create product repository error",
+			name: "product is not deleted",
 			mockBehavior: func(mockRepo *mock_products.MockRepository) {
-				mockRepo.EXPECT().Create("product", []string{"tag1", "tag2"}).Return(int64(0), errors.New("create error"))
+				mockRepo.EXPECT().Restore(int64(1), "admin").Return(nil, db.ErrRecordNotFound)
 			},
-			wantErr: true,
+			wantErr: db.ErrRecordNotFound,
 		},
 	}
 
@@ -153,42 +451,56 @@ func TestProductsUseCase_Create(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mockBehavior(mockProductsRepo)
 
-			_, err := productsUC.Create("product", []string{"tag1", "tag2"})
+			product, err := productsUC.Restore(1, "admin")
 
-			if tt.wantErr {
-				require.Error(t, err)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				require.Nil(t, product)
 			} else {
 				require.NoError(t, err)
+				require.Equal(t, tt.wantProduct, product)
 			}
 		})
 	}
 }
 
-func TestProductsUseCase_Delete(t *testing.T) {
-	cfg := &config.Config{}
+func TestProductsUseCase_PurgeDeleted(t *testing.T) {
 	logger := zap.NewNop()
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockProductsRepo := mock_products.NewMockRepository(ctrl)
-	productsUC := NewProductsUseCase(cfg, mockProductsRepo, logger)
+	mockTagsRepo := mock_tags.NewMockRepository(ctrl)
 
 	tests := []struct {
 		name         string
+		retention    time.Duration
 		mockBehavior func(mockRepo *mock_products.MockRepository)
+		wantPurged   int64
 		wantErr      bool
 	}{
 		{
-			name: "delete product success",
+			name:      "purge products deleted before retention",
+			retention: 24 * time.Hour,
 			mockBehavior: func(mockRepo *mock_products.MockRepository) {
-				mockRepo.EXPECT().Delete(int64(1)).Return(nil)
+				mockRepo.EXPECT().Purge(gomock.Any()).DoAndReturn(func(deletedBefore time.Time) (int64, error) {
+					require.WithinDuration(t, time.Now().Add(-24*time.Hour), deletedBefore, time.Minute)
+					return 3, nil
+				})
 			},
-			wantErr: false,
+			wantPurged: 3,
 		},
 		{
-			name: "delete product repository error",
+			name:         "purge disabled",
+			retention:    0,
+			mockBehavior: func(mockRepo *mock_products.MockRepository) {},
+			wantPurged:   0,
+		},
+		{
+			name:      "purge repository error",
+			retention: time.Hour,
 			mockBehavior: func(mockRepo *mock_products.MockRepository) {
-				mockRepo.EXPECT().Delete(int64(1)).Return(errors.New("delete error"))
+				mockRepo.EXPECT().Purge(gomock.Any()).Return(int64(0), errors.New("purge error"))
 			},
 			wantErr: true,
 		},
@@ -196,15 +508,549 @@ func TestProductsUseCase_Delete(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.Purge.Retention = tt.retention
+			productsUC := NewProductsUseCase(cfg, mockProductsRepo, mockTagsRepo, nil, logger)
+
 			tt.mockBehavior(mockProductsRepo)
 
-			err := productsUC.Delete(1)
+			purged, err := productsUC.PurgeDeleted()
 
 			if tt.wantErr {
 				require.Error(t, err)
 			} else {
 				require.NoError(t, err)
+				require.Equal(t, tt.wantPurged, purged)
+			}
+		})
+	}
+}
+
+func TestProductsUseCase_History(t *testing.T) {
+	cfg := &config.Config{}
+	logger := zap.NewNop()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProductsRepo := mock_products.NewMockRepository(ctrl)
+	mockTagsRepo := mock_tags.NewMockRepository(ctrl)
+	productsUC := NewProductsUseCase(cfg, mockProductsRepo, mockTagsRepo, nil, logger)
+
+	revisions := []models.ProductRevision{
+		{ProductID: 1, Version: 2, Product: models.Product{ID: 1, Name: "renamed", Version: 2}, ChangedBy: "admin"},
+		{ProductID: 1, Version: 1, Product: models.Product{ID: 1, Name: "product", Version: 1}, ChangedBy: "admin"},
+	}
+
+	tests := []struct {
+		name          string
+		mockBehavior  func(mockRepo *mock_products.MockRepository)
+		wantRevisions []models.ProductRevision
+		wantErr       error
+	}{
+		{
+			name: "get history success",
+			mockBehavior: func(mockRepo *mock_products.MockRepository) {
+				mockRepo.EXPECT().GetRevisions(int64(1)).Return(revisions, nil)
+			},
+			wantRevisions: revisions,
+		},
+		{
+			name: "product without history",
+			mockBehavior: func(mockRepo *mock_products.MockRepository) {
+				mockRepo.EXPECT().GetRevisions(int64(1)).Return([]models.ProductRevision{}, nil)
+			},
+			wantErr: db.ErrRecordNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockProductsRepo)
+
+			history, err := productsUC.History(1)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				require.Nil(t, history)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.wantRevisions, history)
+			}
+		})
+	}
+}
+
+func TestProductsUseCase_Rollback(t *testing.T) {
+	cfg := &config.Config{}
+	logger := zap.NewNop()
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProductsRepo := mock_products.NewMockRepository(ctrl)
+	mockTagsRepo := mock_tags.NewMockRepository(ctrl)
+	productsUC := NewProductsUseCase(cfg, mockProductsRepo, mockTagsRepo, nil, logger)
+
+	stock := int64(3)
+	current := func() *models.Product {
+		return &models.Product{
+			ID: 1, Name: "renamed", Price: 500, Currency: "RUB", Status: models.ProductStatusArchived, Tags: []string{"jazz"}, Version: 3,
+		}
+	}
+	revision := &models.ProductRevision{
+		ProductID: 1,
+		Version:   1,
+		Product: models.Product{
+			ID: 1, Name: "product", Price: 4990, Currency: "RUB", Stock: &stock, Status: models.ProductStatusActive, Tags: []string{"rock"}, Version: 1,
+		},
+		ChangedBy: "editor",
+	}
+
+	tests := []struct {
+		name         string
+		mockBehavior func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository)
+		want         *models.Product
+		wantChange   *models.ProductChange
+		wantErr      error
+	}{
+		{
+			name: "rollback success",
+			mockBehavior: func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository) {
+				mockRepo.EXPECT().GetByID(int64(1)).Return(current(), nil)
+				mockRepo.EXPECT().GetRevision(int64(1), int64(1)).Return(revision, nil)
+				mockTagsRepo.EXPECT().Resolve([]string{"rock"}).Return(map[string]string{"rock": "rock"}, nil)
+				mockRepo.EXPECT().Update(&models.Product{
+					ID: 1, Name: "product", Price: 4990, Currency: "RUB", Stock: &stock, Status: models.ProductStatusActive, Tags: []string{"rock"}, Version: 3,
+				}, "admin").Return(nil)
+			},
+			want: &models.Product{
+				ID: 1, Name: "product", Price: 4990, Currency: "RUB", Stock: &stock, Status: models.ProductStatusActive, Tags: []string{"rock"}, Version: 4,
+			},
+			wantChange: &models.ProductChange{
+				Fields:    []string{"name", "price", "stock", "status", "tags"},
+				AddedTags: []string{"rock"}, RemovedTags: []string{"jazz"},
+			},
+		},
+		{
+			name: "tag removed from vocabulary",
+			mockBehavior: func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository) {
+				mockRepo.EXPECT().GetByID(int64(1)).Return(current(), nil)
+				mockRepo.EXPECT().GetRevision(int64(1), int64(1)).Return(revision, nil)
+				mockTagsRepo.EXPECT().Resolve([]string{"rock"}).Return(map[string]string{}, nil)
+			},
+			wantErr: products.ErrUnknownTags,
+		},
+		{
+			name: "version not found",
+			mockBehavior: func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository) {
+				mockRepo.EXPECT().GetByID(int64(1)).Return(current(), nil)
+				mockRepo.EXPECT().GetRevision(int64(1), int64(1)).Return(nil, db.ErrRecordNotFound)
+			},
+			wantErr: db.ErrRecordNotFound,
+		},
+		{
+			name: "product deleted",
+			mockBehavior: func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository) {
+				mockRepo.EXPECT().GetByID(int64(1)).Return(nil, db.ErrRecordNotFound)
+			},
+			wantErr: db.ErrRecordNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockProductsRepo, mockTagsRepo)
+
+			product, change, err := productsUC.Rollback(1, 1, "admin")
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				require.Nil(t, product)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.want, product)
+				require.Equal(t, tt.wantChange, change)
+			}
+		})
+	}
+}
+
+func TestProductsUseCase_Import(t *testing.T) {
+	cfg := &config.Config{DefaultCurrency: "RUB"}
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProductsRepo := mock_products.NewMockRepository(ctrl)
+	mockTagsRepo := mock_tags.NewMockRepository(ctrl)
+	productsUC := NewProductsUseCase(cfg, mockProductsRepo, mockTagsRepo, nil, logger)
+
+	name := " vinyl "
+	price := int64(4990)
+	negative := int64(-1)
+	rock := map[string]string{"rock": "rock"}
+
+	tests := []struct {
+		name         string
+		input        *models.ImportProductDTO
+		dryRun       bool
+		mockBehavior func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository)
+		want         *models.Product
+		wantChange   *models.ProductChange
+		wantErr      error
+	}{
+		{
+			name: "create row",
+			input: &models.ImportProductDTO{
+				UpdateProductDTO: models.UpdateProductDTO{Name: &name, Price: &price, Tags: []string{"Rock"}},
+			},
+			mockBehavior: func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository) {
+				mockTagsRepo.EXPECT().Resolve([]string{"rock"}).Return(rock, nil)
+				mockRepo.EXPECT().Create(gomock.Any(), "admin").DoAndReturn(func(product *models.Product, _ string) error {
+					product.ID = 1
+					return nil
+				})
+			},
+			want: &models.Product{
+				ID: 1, Name: "vinyl", Price: 4990, Currency: "RUB",
+				Status: models.ProductStatusActive, Tags: []string{"rock"}, Version: 1,
+			},
+		},
+		{
+			name: "dry run create row",
+			input: &models.ImportProductDTO{
+				UpdateProductDTO: models.UpdateProductDTO{Name: &name, Tags: []string{"rock"}},
+			},
+			dryRun: true,
+			mockBehavior: func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository) {
+				mockTagsRepo.EXPECT().Resolve([]string{"rock"}).Return(rock, nil)
+			},
+			want: &models.Product{
+				Name: "vinyl", Currency: "RUB", Status: models.ProductStatusActive, Tags: []string{"rock"}, Version: 1,
+			},
+		},
+		{
+			name: "update row",
+			input: &models.ImportProductDTO{
+				ID:               7,
+				UpdateProductDTO: models.UpdateProductDTO{Price: &price},
+			},
+			mockBehavior: func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository) {
+				stored := &models.Product{ID: 7, Name: "vinyl", Currency: "RUB", Status: models.ProductStatusActive, Version: 2}
+				mockRepo.EXPECT().GetByID(int64(7)).Return(stored, nil)
+				mockRepo.EXPECT().Update(gomock.Any(), "admin").Return(nil)
+			},
+			want:       &models.Product{ID: 7, Name: "vinyl", Price: 4990, Currency: "RUB", Status: models.ProductStatusActive, Version: 3},
+			wantChange: &models.ProductChange{Fields: []string{"price"}},
+		},
+		{
+			name: "dry run update row",
+			input: &models.ImportProductDTO{
+				ID:               7,
+				UpdateProductDTO: models.UpdateProductDTO{Price: &price},
+			},
+			dryRun: true,
+			mockBehavior: func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository) {
+				stored := &models.Product{ID: 7, Name: "vinyl", Currency: "RUB", Status: models.ProductStatusActive, Version: 2}
+				mockRepo.EXPECT().GetByID(int64(7)).Return(stored, nil)
+			},
+			want:       &models.Product{ID: 7, Name: "vinyl", Price: 4990, Currency: "RUB", Status: models.ProductStatusActive, Version: 2},
+			wantChange: &models.ProductChange{Fields: []string{"price"}},
+		},
+		{
+			name: "dry run invalid update row",
+			input: &models.ImportProductDTO{
+				ID:               7,
+				UpdateProductDTO: models.UpdateProductDTO{Price: &negative},
+			},
+			dryRun: true,
+			mockBehavior: func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository) {
+				stored := &models.Product{ID: 7, Name: "vinyl", Currency: "RUB", Status: models.ProductStatusActive, Version: 2}
+				mockRepo.EXPECT().GetByID(int64(7)).Return(stored, nil)
+			},
+			wantErr: products.ErrInvalidProduct,
+		},
+		{
+			name: "update row of a missing product",
+			input: &models.ImportProductDTO{
+				ID:               9,
+				UpdateProductDTO: models.UpdateProductDTO{Price: &price},
+			},
+			dryRun: true,
+			mockBehavior: func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository) {
+				mockRepo.EXPECT().GetByID(int64(9)).Return(nil, db.ErrRecordNotFound)
+			},
+			wantErr: db.ErrRecordNotFound,
+		},
+		{
+			name: "unknown tags",
+			input: &models.ImportProductDTO{
+				UpdateProductDTO: models.UpdateProductDTO{Name: &name, Tags: []string{"polka"}},
+			},
+			mockBehavior: func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository) {
+				mockTagsRepo.EXPECT().Resolve([]string{"polka"}).Return(map[string]string{}, nil)
+			},
+			wantErr: products.ErrUnknownTags,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockProductsRepo, mockTagsRepo)
+
+			product, change, err := productsUC.Import(tt.input, tt.dryRun, "admin")
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.want, product)
+				require.Equal(t, tt.wantChange, change)
+			}
+		})
+	}
+}
+
+func TestProductsUseCase_Patch(t *testing.T) {
+	cfg := &config.Config{}
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProductsRepo := mock_products.NewMockRepository(ctrl)
+	mockTagsRepo := mock_tags.NewMockRepository(ctrl)
+	productsUC := NewProductsUseCase(cfg, mockProductsRepo, mockTagsRepo, nil, logger)
+
+	stock := int64(3)
+	staleVersion := int64(1)
+
+	stored := func() *models.Product {
+		return &models.Product{
+			ID: 7, Name: "vinyl", Price: 4990, Currency: "RUB", Stock: &stock,
+			Images: []string{"https://cdn.example.com/1.png"}, Status: models.ProductStatusActive,
+			Tags: []string{"rock"}, Version: 2,
+		}
+	}
+
+	tests := []struct {
+		name         string
+		version      *int64
+		patch        string
+		mockBehavior func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository)
+		want         *models.Product
+		wantChange   *models.ProductChange
+		wantErr      error
+	}{
+		{
+			name:  "null clears stock and images",
+			patch: `{"stock":null,"images":null,"price":5990}`,
+			mockBehavior: func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository) {
+				mockRepo.EXPECT().GetByID(int64(7)).Return(stored(), nil)
+				mockRepo.EXPECT().Update(gomock.Any(), "admin").Return(nil)
+			},
+			want: &models.Product{
+				ID: 7, Name: "vinyl", Price: 5990, Currency: "RUB", Images: []string{},
+				Status: models.ProductStatusActive, Tags: []string{"rock"}, Version: 3,
+			},
+			wantChange: &models.ProductChange{Fields: []string{"price", "stock", "images"}},
+		},
+		{
+			name:  "tags are mapped to canonical tags",
+			patch: `{"tags":["Rock Music","jazz"]}`,
+			mockBehavior: func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository) {
+				mockRepo.EXPECT().GetByID(int64(7)).Return(stored(), nil)
+				mockTagsRepo.EXPECT().Resolve([]string{"rock music", "jazz"}).
+					Return(map[string]string{"rock music": "rock", "jazz": "jazz"}, nil)
+				mockRepo.EXPECT().Update(gomock.Any(), "admin").Return(nil)
+			},
+			want: &models.Product{
+				ID: 7, Name: "vinyl", Price: 4990, Currency: "RUB", Stock: &stock,
+				Images: []string{"https://cdn.example.com/1.png"}, Status: models.ProductStatusActive,
+				Tags: []string{"rock", "jazz"}, Version: 3,
+			},
+			wantChange: &models.ProductChange{Fields: []string{"tags"}, AddedTags: []string{"jazz"}},
+		},
+		{
+			name:  "patch without changes writes nothing",
+			patch: `{"name":"vinyl","tags":["Rock"]}`,
+			mockBehavior: func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository) {
+				mockRepo.EXPECT().GetByID(int64(7)).Return(stored(), nil)
+				mockTagsRepo.EXPECT().Resolve([]string{"rock"}).Return(map[string]string{"rock": "rock"}, nil)
+			},
+			want:       stored(),
+			wantChange: &models.ProductChange{},
+		},
+		{
+			name:  "null name is invalid",
+			patch: `{"name":null}`,
+			mockBehavior: func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository) {
+				mockRepo.EXPECT().GetByID(int64(7)).Return(stored(), nil)
+			},
+			wantErr: products.ErrInvalidProduct,
+		},
+		{
+			name:  "read-only field",
+			patch: `{"version":10}`,
+			mockBehavior: func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository) {
+				mockRepo.EXPECT().GetByID(int64(7)).Return(stored(), nil)
+			},
+			wantErr: mergepatch.ErrInvalidPatch,
+		},
+		{
+			name:  "wrong type",
+			patch: `{"price":"free"}`,
+			mockBehavior: func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository) {
+				mockRepo.EXPECT().GetByID(int64(7)).Return(stored(), nil)
+			},
+			wantErr: mergepatch.ErrInvalidPatch,
+		},
+		{
+			name:    "stale version",
+			version: &staleVersion,
+			patch:   `{"price":5990}`,
+			mockBehavior: func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository) {
+				mockRepo.EXPECT().GetByID(int64(7)).Return(stored(), nil)
+			},
+			wantErr: db.ErrEditConflict,
+		},
+		{
+			name:  "product not found",
+			patch: `{"price":5990}`,
+			mockBehavior: func(mockRepo *mock_products.MockRepository, mockTagsRepo *mock_tags.MockRepository) {
+				mockRepo.EXPECT().GetByID(int64(7)).Return(nil, db.ErrRecordNotFound)
+			},
+			wantErr: db.ErrRecordNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockProductsRepo, mockTagsRepo)
+
+			product, change, err := productsUC.Patch(7, tt.version, []byte(tt.patch), "admin")
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.want, product)
+				require.Equal(t, tt.wantChange, change)
 			}
 		})
 	}
 }
+
+func TestProductsUseCase_FilterViews(t *testing.T) {
+	cfg := &config.Config{
+		ViewFilter: config.ViewFilter{
+			DedupWindow:   30 * time.Minute,
+			RateLimit:     2,
+			RateWindow:    time.Minute,
+			BotUserAgents: []string{"bot", " Curl "},
+		},
+	}
+
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProductsRepo := mock_products.NewMockRepository(ctrl)
+	mockTagsRepo := mock_tags.NewMockRepository(ctrl)
+	mockViewsRepo := mock_products.NewMockRedisRepository(ctrl)
+	uc := NewProductsUseCase(cfg, mockProductsRepo, mockTagsRepo, mockViewsRepo, logger).(*productsUC)
+
+	browser := "Mozilla/5.0 (X11; Linux x86_64) Firefox/128.0"
+
+	tests := []struct {
+		name         string
+		userAgent    string
+		mockBehavior func(mockViewsRepo *mock_products.MockRedisRepository)
+		wantCounted  bool
+		wantReason   string
+	}{
+		{
+			name:      "first view is counted",
+			userAgent: browser,
+			mockBehavior: func(mockViewsRepo *mock_products.MockRedisRepository) {
+				mockViewsRepo.EXPECT().CountViews("user", time.Minute).Return(int64(1), nil)
+				mockViewsRepo.EXPECT().MarkViewed("user", int64(1), 30*time.Minute).Return(true, nil)
+			},
+			wantCounted: true,
+		},
+		{
+			name:      "repeated view is a duplicate",
+			userAgent: browser,
+			mockBehavior: func(mockViewsRepo *mock_products.MockRedisRepository) {
+				mockViewsRepo.EXPECT().CountViews("user", time.Minute).Return(int64(2), nil)
+				mockViewsRepo.EXPECT().MarkViewed("user", int64(1), 30*time.Minute).Return(false, nil)
+			},
+			wantReason: models.ViewSkipDuplicate,
+		},
+		{
+			name:      "user over the rate limit",
+			userAgent: browser,
+			mockBehavior: func(mockViewsRepo *mock_products.MockRedisRepository) {
+				mockViewsRepo.EXPECT().CountViews("user", time.Minute).Return(int64(3), nil)
+			},
+			wantReason: models.ViewSkipRate,
+		},
+		{
+			name:         "bot user agent",
+			userAgent:    "Googlebot/2.1 (+http://www.google.com/bot.html)",
+			mockBehavior: func(mockViewsRepo *mock_products.MockRedisRepository) {},
+			wantReason:   models.ViewSkipBot,
+		},
+		{
+			name:         "bot user agent matched ignoring case",
+			userAgent:    "curl/8.5.0",
+			mockBehavior: func(mockViewsRepo *mock_products.MockRedisRepository) {},
+			wantReason:   models.ViewSkipBot,
+		},
+		{
+			name:         "empty user agent",
+			mockBehavior: func(mockViewsRepo *mock_products.MockRedisRepository) {},
+			wantReason:   models.ViewSkipBot,
+		},
+		{
+			name:      "view is counted when redis fails",
+			userAgent: browser,
+			mockBehavior: func(mockViewsRepo *mock_products.MockRedisRepository) {
+				mockViewsRepo.EXPECT().CountViews("user", time.Minute).Return(int64(0), errors.New("redis is down"))
+			},
+			wantCounted: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockViewsRepo)
+
+			events := []kf.Event{{Key: "1", Viewer: "user", UserAgent: tt.userAgent}}
+			uc.filterViews(events)
+
+			require.Equal(t, tt.wantCounted, *events[0].Message.Counted)
+			require.Equal(t, tt.wantReason, events[0].Message.SkipReason)
+		})
+	}
+
+	t.Run("rest of the batch skips redis after a failure", func(t *testing.T) {
+		mockViewsRepo.EXPECT().CountViews("user", time.Minute).Return(int64(0), errors.New("redis is down"))
+
+		events := []kf.Event{
+			{Key: "1", Viewer: "user", UserAgent: browser},
+			{Key: "2", Viewer: "user", UserAgent: browser},
+			{Key: "3", Viewer: "user", UserAgent: "curl/8.5.0"},
+		}
+		uc.filterViews(events)
+
+		require.True(t, *events[0].Message.Counted)
+		require.True(t, *events[1].Message.Counted)
+		require.False(t, *events[2].Message.Counted)
+		require.Equal(t, models.ViewSkipBot, events[2].Message.SkipReason)
+	})
+}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/julienschmidt/httprouter"
@@ -8,22 +9,42 @@ import (
 
 	"cyansnbrst/products-service/internal/middleware"
 	productsHttp "cyansnbrst/products-service/internal/products/delivery/http"
+	"cyansnbrst/products-service/internal/products/delivery/purge"
 	productsRepository "cyansnbrst/products-service/internal/products/repository"
 	productsUseCase "cyansnbrst/products-service/internal/products/usecase"
+	tagsHttp "cyansnbrst/products-service/internal/tags/delivery/http"
+	tagsRepository "cyansnbrst/products-service/internal/tags/repository"
+	tagsUseCase "cyansnbrst/products-service/internal/tags/usecase"
+	"cyansnbrst/products-service/pkg/kafka"
+	"cyansnbrst/products-service/pkg/metric"
 )
 
 // Register server handlers
-func (s *Server) RegisterHandlers() http.Handler {
+func (s *Server) RegisterHandlers(ctx context.Context, metrics metric.Metrics) http.Handler {
 	router := httprouter.New()
 
 	// Init repository
 	productsRepo := productsRepository.NewProductsRepository(s.config, s.db)
+	tagsRepo := tagsRepository.NewTagsRepository(s.config, s.db)
+	productsRedisRepo := productsRepository.NewProductsRedisRepository(s.config, s.redisClient)
 
 	// Init use case
-	productsUC := productsUseCase.NewProductsUseCase(s.config, productsRepo, s.logger)
+	productsUC := productsUseCase.NewProductsUseCase(s.config, productsRepo, tagsRepo, productsRedisRepo, s.logger)
+	tagsUC := tagsUseCase.NewTagsUseCase(s.config, tagsRepo, s.logger)
+
+	// Run buffer of product view events
+	s.viewEvents = kafka.NewBuffer(s.config.Views, "views", func(ctx context.Context, events []kafka.Event) error {
+		return productsUC.SendViewsToKafka(ctx, events, s.kafkaProductWriter)
+	}, metrics, s.logger)
+	go s.viewEvents.Run(ctx)
 
 	// Init handlers
-	productsHandlers := productsHttp.NewProductsHandlers(s.config, productsUC, s.logger, s.kafkaUserWriter, s.kafkaProductWriter)
+	productsHandlers := productsHttp.NewProductsHandlers(s.config, productsUC, s.logger, s.kafkaUserWriter, s.kafkaProductWriter, s.viewEvents)
+	tagsHandlers := tagsHttp.NewTagsHandlers(s.config, tagsUC, s.logger, s.kafkaTagWriter)
+
+	// Run purger of deleted products
+	purger := purge.NewPurger(s.config, productsUC, s.logger)
+	go purger.Run(ctx)
 
 	// Init middleware
 	mw := middleware.NewMiddlewareManager(s.config, s.logger)
@@ -31,6 +52,9 @@ func (s *Server) RegisterHandlers() http.Handler {
 	// Register products routes
 	productsHttp.RegisterProductsRoutes(router, productsHandlers, mw)
 
+	// Register tags routes
+	tagsHttp.RegisterTagsRoutes(router, tagsHandlers, mw)
+
 	// Swagger
 	router.ServeFiles("/products/docs/*filepath", http.Dir("docs"))
 	router.HandlerFunc(http.MethodGet, "/products/swagger/*action", httpSwagger.Handler(
@@ -10,10 +10,13 @@ import (
 	"os/signal"
 	"syscall"
 
+	"github.com/go-redis/redis/v8"
 	"github.com/segmentio/kafka-go"
 	"go.uber.org/zap"
 
 	"cyansnbrst/products-service/config"
+	kf "cyansnbrst/products-service/pkg/kafka"
+	"cyansnbrst/products-service/pkg/metric"
 )
 
 // Server struct
@@ -21,27 +24,45 @@ type Server struct {
 	config             *config.Config
 	logger             *zap.Logger
 	db                 *sql.DB
+	redisClient        *redis.Client
 	kafkaUserWriter    *kafka.Writer
 	kafkaProductWriter *kafka.Writer
+	kafkaTagWriter     *kafka.Writer
+	viewEvents         *kf.Buffer
 }
 
 // New server constructor
-func NewServer(cfg *config.Config, logger *zap.Logger, db *sql.DB, kafkaUserWriter *kafka.Writer, kafkaProductWriter *kafka.Writer) *Server {
+func NewServer(cfg *config.Config, logger *zap.Logger, db *sql.DB, redisClient *redis.Client, kafkaUserWriter *kafka.Writer, kafkaProductWriter *kafka.Writer, kafkaTagWriter *kafka.Writer) *Server {
 	return &Server{
 		config:             cfg,
 		logger:             logger,
 		db:                 db,
+		redisClient:        redisClient,
 		kafkaUserWriter:    kafkaUserWriter,
 		kafkaProductWriter: kafkaProductWriter,
+		kafkaTagWriter:     kafkaTagWriter,
 	}
 }
 
 // Run server
 func (s *Server) Run() error {
+	metrics, err := metric.CreateMetrics(s.config.Metrics.URL, s.config.Metrics.ServiceName)
+	if err != nil {
+		return err
+	}
+	err = metric.RegisterKafkaWriters(s.config.Metrics.ServiceName, s.kafkaUserWriter, s.kafkaProductWriter, s.kafkaTagWriter)
+	if err != nil {
+		return err
+	}
+
+	// Background workers stop after the server has shut down
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
 	addr := fmt.Sprintf(":%d", s.config.Port)
 	server := &http.Server{
 		Addr:         addr,
-		Handler:      s.RegisterHandlers(),
+		Handler:      s.RegisterHandlers(workersCtx, metrics),
 		IdleTimeout:  s.config.Timeout.ServerIdle,
 		ReadTimeout:  s.config.Timeout.ServerRead,
 		WriteTimeout: s.config.Timeout.ServerWrite,
@@ -65,9 +86,12 @@ func (s *Server) Run() error {
 		err := server.Shutdown(ctx)
 		if err != nil {
 			shutDownError <- err
+			return
 		}
 
-		shutDownError <- nil
+		// No request adds view events any more, publish the buffered ones
+		stopWorkers()
+		shutDownError <- s.viewEvents.Wait(ctx)
 	}()
 
 	s.logger.Info("starting server",
@@ -75,7 +99,7 @@ func (s *Server) Run() error {
 		zap.String("env", s.config.Env),
 	)
 
-	err := server.ListenAndServe()
+	err = server.ListenAndServe()
 	if !errors.Is(err, http.ErrServerClosed) {
 		return err
 	}
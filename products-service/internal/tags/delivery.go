@@ -0,0 +1,12 @@
+package tags
+
+import "net/http"
+
+// Tags handlers interface
+type Handlers interface {
+	List() http.HandlerFunc
+	Get() http.HandlerFunc
+	Create() http.HandlerFunc
+	Update() http.HandlerFunc
+	Delete() http.HandlerFunc
+}
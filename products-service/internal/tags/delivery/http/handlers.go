@@ -0,0 +1,261 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+
+	"cyansnbrst/products-service/config"
+	"cyansnbrst/products-service/internal/models"
+	"cyansnbrst/products-service/internal/tags"
+	"cyansnbrst/products-service/pkg/db"
+	erp "cyansnbrst/products-service/pkg/error_responses"
+	kf "cyansnbrst/products-service/pkg/kafka"
+	"cyansnbrst/products-service/pkg/taxonomy"
+	"cyansnbrst/products-service/pkg/utils"
+)
+
+// Tags handlers
+type tagsHandlers struct {
+	cfg            *config.Config
+	tagsUC         tags.UseCase
+	logger         *zap.Logger
+	kafkaTagWriter *kafka.Writer
+}
+
+// Tags handlers constructor
+func NewTagsHandlers(cfg *config.Config, tagsUC tags.UseCase, logger *zap.Logger, kafkaTagWriter *kafka.Writer) tags.Handlers {
+	return &tagsHandlers{
+		cfg:            cfg,
+		tagsUC:         tagsUC,
+		logger:         logger,
+		kafkaTagWriter: kafkaTagWriter,
+	}
+}
+
+//	@Summary		List tags
+//	@Description	Retrieves the tag vocabulary: canonical tags with their parents and aliases.
+//	@Tags			tags
+//	@Produce		json
+//	@Security		cookieAuth
+//	@Security		bearerAuth
+//	@Success		200	{object}	models.TagsResponse		"success response with tags"
+//	@Failure		500	{object}	models.ErrorResponse	"internal server error"
+//	@Router			/tags [get]
+func (h *tagsHandlers) List() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tagList, err := h.tagsUC.List()
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+			return
+		}
+
+		err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+			"tags": tagList,
+		}, nil)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+		}
+	}
+}
+
+//	@Summary		Get tag
+//	@Description	Retrieves a canonical tag.
+//	@Tags			tags
+//	@Produce		json
+//	@Security		cookieAuth
+//	@Security		bearerAuth
+//	@Param			name	path		string					true	"Tag name"
+//	@Success		200		{object}	models.TagResponse		"success response with tag"
+//	@Failure		404		{object}	models.ErrorResponse	"not found error"
+//	@Failure		500		{object}	models.ErrorResponse	"internal server error"
+//	@Router			/tags/{name} [get]
+func (h *tagsHandlers) Get() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := httprouter.ParamsFromContext(r.Context()).ByName("name")
+
+		tag, err := h.tagsUC.Get(name)
+		if err != nil {
+			if errors.Is(err, db.ErrRecordNotFound) {
+				erp.NotFoundResponse(w, r, h.logger)
+			} else {
+				erp.ServerErrorResponse(w, r, h.logger, err)
+			}
+			return
+		}
+
+		err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+			"tag": tag,
+		}, nil)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+		}
+	}
+}
+
+//	@Summary		Create tag
+//	@Description	Creates a canonical tag with an optional parent and aliases (requires tags:manage). Names are lower-cased and whitespace is collapsed.
+//	@Tags			tags
+//	@Accept			json
+//	@Produce		json
+//	@Security		cookieAuth
+//	@Security		bearerAuth
+//	@Param			tag	body		models.CreateTagDTO		true	"tag"
+//	@Success		201	{object}	models.TagResponse		"success response with tag"
+//	@Failure		400	{object}	models.ErrorResponse	"bad request error"
+//	@Failure		409	{object}	models.ErrorResponse	"tag or alias already exists"
+//	@Failure		500	{object}	models.ErrorResponse	"internal server error"
+//	@Router			/tags [post]
+func (h *tagsHandlers) Create() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var requestData models.CreateTagDTO
+
+		if err := utils.ReadJSON(w, r, &requestData); err != nil {
+			erp.BadRequestResponse(w, r, h.logger, err)
+			return
+		}
+
+		tag, err := h.tagsUC.Create(&requestData)
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+
+		if err = h.sendTagUpdate(r, tag); err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+			return
+		}
+
+		err = utils.WriteJSON(w, http.StatusCreated, utils.Envelope{
+			"tag": tag,
+		}, nil)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+		}
+	}
+}
+
+//	@Summary		Update tag
+//	@Description	Changes the parent and replaces the aliases of a tag (requires tags:manage). An empty parent makes the tag a root.
+//	@Tags			tags
+//	@Accept			json
+//	@Produce		json
+//	@Security		cookieAuth
+//	@Security		bearerAuth
+//	@Param			name	path		string					true	"Tag name"
+//	@Param			tag		body		models.UpdateTagDTO		true	"tag fields to update"
+//	@Success		200		{object}	models.TagResponse		"success response with tag"
+//	@Failure		400		{object}	models.ErrorResponse	"bad request error"
+//	@Failure		404		{object}	models.ErrorResponse	"not found error"
+//	@Failure		409		{object}	models.ErrorResponse	"alias already exists"
+//	@Failure		500		{object}	models.ErrorResponse	"internal server error"
+//	@Router			/tags/{name} [put]
+func (h *tagsHandlers) Update() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := httprouter.ParamsFromContext(r.Context()).ByName("name")
+
+		var requestData models.UpdateTagDTO
+
+		if err := utils.ReadJSON(w, r, &requestData); err != nil {
+			erp.BadRequestResponse(w, r, h.logger, err)
+			return
+		}
+
+		tag, err := h.tagsUC.Update(name, &requestData)
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+
+		if err = h.sendTagUpdate(r, tag); err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+			return
+		}
+
+		err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+			"tag": tag,
+		}, nil)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+		}
+	}
+}
+
+//	@Summary		Delete tag
+//	@Description	Deletes a tag with its aliases (requires tags:manage). Tags used by products can't be deleted, children of the tag become roots.
+//	@Tags			tags
+//	@Produce		json
+//	@Security		cookieAuth
+//	@Security		bearerAuth
+//	@Param			name	path		string					true	"Tag name"
+//	@Success		200		{object}	models.SuccessResponse	"success"
+//	@Failure		404		{object}	models.ErrorResponse	"not found error"
+//	@Failure		409		{object}	models.ErrorResponse	"tag is used by products"
+//	@Failure		500		{object}	models.ErrorResponse	"internal server error"
+//	@Router			/tags/{name} [delete]
+func (h *tagsHandlers) Delete() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := taxonomy.Normalize(httprouter.ParamsFromContext(r.Context()).ByName("name"))
+
+		err := h.tagsUC.Delete(name)
+		if err != nil {
+			h.writeError(w, r, err)
+			return
+		}
+
+		messagePayload := kf.KafkaMessage{
+			Action: "tag_delete",
+			Time:   time.Now().Format(time.RFC3339),
+			Tag:    name,
+		}
+
+		err = h.tagsUC.SendToKafka(r.Context(), name, messagePayload, h.kafkaTagWriter)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+			return
+		}
+
+		err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+			"message": "tag deleted successfully",
+		}, nil)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+		}
+	}
+}
+
+// Publish the tag_update event with the tag's parent and aliases
+func (h *tagsHandlers) sendTagUpdate(r *http.Request, tag *models.Tag) error {
+	messagePayload := kf.KafkaMessage{
+		Action:  "tag_update",
+		Time:    time.Now().Format(time.RFC3339),
+		Tag:     tag.Name,
+		Aliases: tag.Aliases,
+	}
+	if tag.Parent != nil {
+		messagePayload.Parent = *tag.Parent
+	}
+
+	return h.tagsUC.SendToKafka(r.Context(), tag.Name, messagePayload, h.kafkaTagWriter)
+}
+
+// Write the response for a tags usecase error
+func (h *tagsHandlers) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, db.ErrRecordNotFound):
+		erp.NotFoundResponse(w, r, h.logger)
+	case errors.Is(err, tags.ErrInvalidTag),
+		errors.Is(err, tags.ErrUnknownParent),
+		errors.Is(err, tags.ErrCycle):
+		erp.BadRequestResponse(w, r, h.logger, err)
+	case errors.Is(err, tags.ErrTagExists),
+		errors.Is(err, tags.ErrTagInUse):
+		erp.ConflictResponse(w, r, h.logger, err)
+	default:
+		erp.ServerErrorResponse(w, r, h.logger, err)
+	}
+}
@@ -0,0 +1,161 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/julienschmidt/httprouter"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"cyansnbrst/products-service/config"
+	"cyansnbrst/products-service/internal/models"
+	"cyansnbrst/products-service/internal/tags"
+	mock_tags "cyansnbrst/products-service/internal/tags/mock"
+	"cyansnbrst/products-service/pkg/db"
+	kf "cyansnbrst/products-service/pkg/kafka"
+)
+
+func TestTagsHandlers_Create(t *testing.T) {
+	cfg := &config.Config{}
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTagsUC := mock_tags.NewMockUseCase(ctrl)
+	kafkaWriter := &kafka.Writer{}
+	tagsHandlers := NewTagsHandlers(cfg, mockTagsUC, logger, kafkaWriter)
+
+	music := "music"
+
+	tests := []struct {
+		name         string
+		requestBody  string
+		mockBehavior func(mockTagsUC *mock_tags.MockUseCase)
+		wantStatus   int
+	}{
+		{
+			name:        "success",
+			requestBody: `{"name":"Rock","parent":"music","aliases":["rock music"]}`,
+			mockBehavior: func(mockTagsUC *mock_tags.MockUseCase) {
+				tag := &models.Tag{Name: "rock", Parent: &music, Aliases: []string{"rock music"}}
+				mockTagsUC.EXPECT().Create(gomock.Any()).Return(tag, nil)
+				mockTagsUC.EXPECT().SendToKafka(gomock.Any(), "rock", gomock.Any(), kafkaWriter).
+					DoAndReturn(func(_ context.Context, _ string, message kf.KafkaMessage, _ *kafka.Writer) error {
+						require.Equal(t, "tag_update", message.Action)
+						require.Equal(t, "music", message.Parent)
+						require.Equal(t, []string{"rock music"}, message.Aliases)
+						return nil
+					})
+			},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:        "tag exists",
+			requestBody: `{"name":"rock"}`,
+			mockBehavior: func(mockTagsUC *mock_tags.MockUseCase) {
+				mockTagsUC.EXPECT().Create(gomock.Any()).Return(nil, tags.ErrTagExists)
+			},
+			wantStatus: http.StatusConflict,
+		},
+		{
+			name:        "invalid tag",
+			requestBody: `{"name":""}`,
+			mockBehavior: func(mockTagsUC *mock_tags.MockUseCase) {
+				mockTagsUC.EXPECT().Create(gomock.Any()).Return(nil, tags.ErrInvalidTag)
+			},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid JSON",
+			requestBody:  `{"name":}`,
+			mockBehavior: func(mockTagsUC *mock_tags.MockUseCase) {},
+			wantStatus:   http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockTagsUC)
+
+			req := httptest.NewRequest(http.MethodPost, "/products/tags", strings.NewReader(tt.requestBody))
+			rr := httptest.NewRecorder()
+
+			tagsHandlers.Create().ServeHTTP(rr, req)
+			require.Equal(t, tt.wantStatus, rr.Code)
+		})
+	}
+}
+
+func TestTagsHandlers_Delete(t *testing.T) {
+	cfg := &config.Config{}
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTagsUC := mock_tags.NewMockUseCase(ctrl)
+	kafkaWriter := &kafka.Writer{}
+	tagsHandlers := NewTagsHandlers(cfg, mockTagsUC, logger, kafkaWriter)
+
+	tests := []struct {
+		name         string
+		tag          string
+		mockBehavior func(mockTagsUC *mock_tags.MockUseCase)
+		wantStatus   int
+	}{
+		{
+			name: "success",
+			tag:  "Jazz",
+			mockBehavior: func(mockTagsUC *mock_tags.MockUseCase) {
+				mockTagsUC.EXPECT().Delete("jazz").Return(nil)
+				mockTagsUC.EXPECT().SendToKafka(gomock.Any(), "jazz", gomock.Any(), kafkaWriter).Return(nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name: "not found",
+			tag:  "jazz",
+			mockBehavior: func(mockTagsUC *mock_tags.MockUseCase) {
+				mockTagsUC.EXPECT().Delete("jazz").Return(db.ErrRecordNotFound)
+			},
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name: "used by products",
+			tag:  "rock",
+			mockBehavior: func(mockTagsUC *mock_tags.MockUseCase) {
+				mockTagsUC.EXPECT().Delete("rock").Return(tags.ErrTagInUse)
+			},
+			wantStatus: http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockTagsUC)
+
+			req := httptest.NewRequest(http.MethodDelete, "/products/tags/"+tt.tag, nil)
+			params := httprouter.Params{
+				httprouter.Param{
+					Key:   "name",
+					Value: tt.tag,
+				},
+			}
+			ctx := context.WithValue(req.Context(), httprouter.ParamsKey, params)
+			req = req.WithContext(ctx)
+			rr := httptest.NewRecorder()
+
+			tagsHandlers.Delete().ServeHTTP(rr, req)
+			require.Equal(t, tt.wantStatus, rr.Code)
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+
+	"cyansnbrst/products-service/internal/middleware"
+	"cyansnbrst/products-service/internal/tags"
+)
+
+// Register tags routes
+func RegisterTagsRoutes(router *httprouter.Router, h tags.Handlers, mw *middleware.MiddlewareManager) {
+	router.HandlerFunc(http.MethodGet, "/products/tags", mw.RequireAuthenticatedUser(h.List()))
+	router.HandlerFunc(http.MethodGet, "/products/tags/:name", mw.RequireAuthenticatedUser(h.Get()))
+	router.HandlerFunc(http.MethodPost, "/products/tags", mw.RequirePermission("tags:manage")(h.Create()))
+	router.HandlerFunc(http.MethodPut, "/products/tags/:name", mw.RequirePermission("tags:manage")(h.Update()))
+	router.HandlerFunc(http.MethodDelete, "/products/tags/:name", mw.RequirePermission("tags:manage")(h.Delete()))
+}
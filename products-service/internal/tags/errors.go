@@ -0,0 +1,12 @@
+package tags
+
+import "errors"
+
+// Tags usecase errors
+var (
+	ErrInvalidTag    = errors.New("invalid tag")
+	ErrTagExists     = errors.New("tag or alias with this name already exists")
+	ErrUnknownParent = errors.New("parent tag does not exist")
+	ErrCycle         = errors.New("parent tag is a descendant of the tag")
+	ErrTagInUse      = errors.New("tag is used by products")
+)
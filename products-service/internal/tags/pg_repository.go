@@ -0,0 +1,15 @@
+package tags
+
+import "cyansnbrst/products-service/internal/models"
+
+// Tags repository interface
+type Repository interface {
+	List() ([]models.Tag, error)
+	Get(name string) (*models.Tag, error)
+	Create(tag *models.Tag) error
+	Update(tag *models.Tag) error
+	Delete(name string) error
+	Resolve(names []string) (map[string]string, error)
+	Ancestors(name string) ([]string, error)
+	IsUsed(name string) (bool, error)
+}
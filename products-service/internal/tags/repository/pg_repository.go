@@ -0,0 +1,292 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+
+	"cyansnbrst/products-service/config"
+	"cyansnbrst/products-service/internal/models"
+	"cyansnbrst/products-service/internal/tags"
+	"cyansnbrst/products-service/pkg/db"
+)
+
+// PostgreSQL error codes
+const uniqueViolation = "23505"
+
+// Tags repository
+type tagsRepo struct {
+	cfg *config.Config
+	db  *sql.DB
+}
+
+// Tags repository constructor
+func NewTagsRepository(cfg *config.Config, db *sql.DB) tags.Repository {
+	return &tagsRepo{cfg: cfg, db: db}
+}
+
+// Get all tags with their aliases
+func (r *tagsRepo) List() ([]models.Tag, error) {
+	query := `
+		SELECT t.name, t.parent, t.created_at, t.updated_at,
+			ARRAY(SELECT a.alias FROM tag_aliases a WHERE a.tag = t.name ORDER BY a.alias)
+		FROM tags t
+		ORDER BY t.name`
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tagList := []models.Tag{}
+	for rows.Next() {
+		var tag models.Tag
+		if err := rows.Scan(&tag.Name, &tag.Parent, &tag.CreatedAt, &tag.UpdatedAt, pq.Array(&tag.Aliases)); err != nil {
+			return nil, err
+		}
+		tagList = append(tagList, tag)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return tagList, nil
+}
+
+// Get tag by name
+func (r *tagsRepo) Get(name string) (*models.Tag, error) {
+	query := `
+		SELECT t.name, t.parent, t.created_at, t.updated_at,
+			ARRAY(SELECT a.alias FROM tag_aliases a WHERE a.tag = t.name ORDER BY a.alias)
+		FROM tags t
+		WHERE t.name = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	var tag models.Tag
+	err := r.db.QueryRowContext(ctx, query, name).Scan(&tag.Name, &tag.Parent, &tag.CreatedAt, &tag.UpdatedAt, pq.Array(&tag.Aliases))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, db.ErrRecordNotFound
+		}
+		return nil, err
+	}
+
+	return &tag, nil
+}
+
+// Insert a new tag with its aliases
+func (r *tagsRepo) Create(tag *models.Tag) error {
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO tags (name, parent)
+		VALUES ($1, $2)
+		RETURNING created_at, updated_at`
+
+	err = tx.QueryRowContext(ctx, query, tag.Name, tag.Parent).Scan(&tag.CreatedAt, &tag.UpdatedAt)
+	if err != nil {
+		return uniqueError(err)
+	}
+
+	if err = insertAliases(ctx, tx, tag.Name, tag.Aliases); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Update tag parent and replace its aliases
+func (r *tagsRepo) Update(tag *models.Tag) error {
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE tags
+		SET parent = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE name = $2
+		RETURNING updated_at`
+
+	err = tx.QueryRowContext(ctx, query, tag.Parent, tag.Name).Scan(&tag.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return db.ErrRecordNotFound
+		}
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM tag_aliases WHERE tag = $1`, tag.Name)
+	if err != nil {
+		return err
+	}
+
+	if err = insertAliases(ctx, tx, tag.Name, tag.Aliases); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Delete tag, its aliases are deleted and its children become roots
+func (r *tagsRepo) Delete(name string) error {
+	query := `
+		DELETE FROM tags
+		WHERE name = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, query, name)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return db.ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Map tag names and aliases to canonical tags, unknown names are left out
+func (r *tagsRepo) Resolve(names []string) (map[string]string, error) {
+	query := `
+		SELECT name, name FROM tags WHERE name = ANY($1)
+		UNION ALL
+		SELECT alias, tag FROM tag_aliases WHERE alias = ANY($1)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(names))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	canonical := make(map[string]string, len(names))
+	for rows.Next() {
+		var name, tag string
+		if err := rows.Scan(&name, &tag); err != nil {
+			return nil, err
+		}
+		canonical[name] = tag
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return canonical, nil
+}
+
+// Get ancestors of the tag from its parent up to the root
+func (r *tagsRepo) Ancestors(name string) ([]string, error) {
+	query := `
+		WITH RECURSIVE ancestors (name, depth) AS (
+			SELECT parent, 1 FROM tags WHERE name = $1 AND parent IS NOT NULL
+			UNION
+			SELECT t.parent, a.depth + 1
+			FROM tags t
+			JOIN ancestors a ON t.name = a.name
+			WHERE t.parent IS NOT NULL AND a.depth < 100
+		)
+		SELECT name FROM ancestors ORDER BY depth`
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ancestors []string
+	for rows.Next() {
+		var ancestor string
+		if err := rows.Scan(&ancestor); err != nil {
+			return nil, err
+		}
+		ancestors = append(ancestors, ancestor)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ancestors, nil
+}
+
+// Check whether any product has the tag
+func (r *tagsRepo) IsUsed(name string) (bool, error) {
+	query := `
+		SELECT EXISTS(SELECT 1 FROM products WHERE $1 = ANY(tags))`
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	var used bool
+	if err := r.db.QueryRowContext(ctx, query, name).Scan(&used); err != nil {
+		return false, err
+	}
+
+	return used, nil
+}
+
+// Insert aliases of the tag
+func insertAliases(ctx context.Context, tx *sql.Tx, tag string, aliases []string) error {
+	query := `
+		INSERT INTO tag_aliases (alias, tag)
+		SELECT unnest($1::TEXT[]), $2`
+
+	if len(aliases) == 0 {
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, query, pq.Array(aliases), tag); err != nil {
+		return uniqueError(err)
+	}
+
+	return nil
+}
+
+// Map unique violations of tag and alias names to database errors
+func uniqueError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		switch pqErr.Constraint {
+		case "tags_pkey":
+			return db.ErrDuplicateTag
+		case "tag_aliases_pkey":
+			return db.ErrDuplicateAlias
+		}
+	}
+	return err
+}
@@ -0,0 +1,20 @@
+package tags
+
+import (
+	"context"
+
+	"github.com/segmentio/kafka-go"
+
+	"cyansnbrst/products-service/internal/models"
+	kf "cyansnbrst/products-service/pkg/kafka"
+)
+
+// Tags usecase interface
+type UseCase interface {
+	List() ([]models.Tag, error)
+	Get(name string) (*models.Tag, error)
+	Create(input *models.CreateTagDTO) (*models.Tag, error)
+	Update(name string, input *models.UpdateTagDTO) (*models.Tag, error)
+	Delete(name string) error
+	SendToKafka(ctx context.Context, key string, message kf.KafkaMessage, writer *kafka.Writer) error
+}
@@ -0,0 +1,213 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+
+	"cyansnbrst/products-service/config"
+	"cyansnbrst/products-service/internal/models"
+	"cyansnbrst/products-service/internal/tags"
+	"cyansnbrst/products-service/pkg/db"
+	kf "cyansnbrst/products-service/pkg/kafka"
+	"cyansnbrst/products-service/pkg/taxonomy"
+)
+
+// Longest tag or alias name
+const maxTagLength = 64
+
+// Tags usecase struct
+type tagsUC struct {
+	cfg      *config.Config
+	tagsRepo tags.Repository
+	logger   *zap.Logger
+}
+
+// Tags usecase constructor
+func NewTagsUseCase(cfg *config.Config, tagsRepo tags.Repository, logger *zap.Logger) tags.UseCase {
+	return &tagsUC{cfg: cfg, tagsRepo: tagsRepo, logger: logger}
+}
+
+// Get all tags
+func (u *tagsUC) List() ([]models.Tag, error) {
+	return u.tagsRepo.List()
+}
+
+// Get a tag by name
+func (u *tagsUC) Get(name string) (*models.Tag, error) {
+	return u.tagsRepo.Get(taxonomy.Normalize(name))
+}
+
+// Create a canonical tag, its name and aliases must not be taken by another tag or alias
+func (u *tagsUC) Create(input *models.CreateTagDTO) (*models.Tag, error) {
+	tag := &models.Tag{Name: taxonomy.Normalize(input.Name)}
+	if err := validateName(tag.Name); err != nil {
+		return nil, err
+	}
+
+	aliases, err := normalizeAliases(tag.Name, input.Aliases)
+	if err != nil {
+		return nil, err
+	}
+	tag.Aliases = aliases
+
+	taken, err := u.tagsRepo.Resolve(append([]string{tag.Name}, aliases...))
+	if err != nil {
+		return nil, err
+	}
+	for name := range taken {
+		return nil, fmt.Errorf("%w: %q", tags.ErrTagExists, name)
+	}
+
+	if input.Parent != nil {
+		if tag.Parent, err = u.parent(tag.Name, *input.Parent); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = u.tagsRepo.Create(tag); err != nil {
+		if errors.Is(err, db.ErrDuplicateTag) || errors.Is(err, db.ErrDuplicateAlias) {
+			return nil, tags.ErrTagExists
+		}
+		return nil, err
+	}
+
+	return tag, nil
+}
+
+// Update parent and aliases of a tag, only the fields present in the input are changed
+func (u *tagsUC) Update(name string, input *models.UpdateTagDTO) (*models.Tag, error) {
+	tag, err := u.tagsRepo.Get(taxonomy.Normalize(name))
+	if err != nil {
+		return nil, err
+	}
+
+	if input.Parent != nil {
+		if tag.Parent, err = u.parent(tag.Name, *input.Parent); err != nil {
+			return nil, err
+		}
+	}
+
+	if input.Aliases != nil {
+		aliases, err := normalizeAliases(tag.Name, input.Aliases)
+		if err != nil {
+			return nil, err
+		}
+
+		taken, err := u.tagsRepo.Resolve(aliases)
+		if err != nil {
+			return nil, err
+		}
+		for alias, owner := range taken {
+			if owner != tag.Name {
+				return nil, fmt.Errorf("%w: %q", tags.ErrTagExists, alias)
+			}
+		}
+		tag.Aliases = aliases
+	}
+
+	if err = u.tagsRepo.Update(tag); err != nil {
+		if errors.Is(err, db.ErrDuplicateAlias) {
+			return nil, tags.ErrTagExists
+		}
+		return nil, err
+	}
+
+	return tag, nil
+}
+
+// Delete a tag that no product uses
+func (u *tagsUC) Delete(name string) error {
+	name = taxonomy.Normalize(name)
+
+	used, err := u.tagsRepo.IsUsed(name)
+	if err != nil {
+		return err
+	}
+	if used {
+		return tags.ErrTagInUse
+	}
+
+	return u.tagsRepo.Delete(name)
+}
+
+// Send Kafka message
+func (u *tagsUC) SendToKafka(ctx context.Context, key string, message kf.KafkaMessage, writer *kafka.Writer) error {
+	messageValue, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	kafkaMessage := kafka.Message{
+		Key:   []byte(key),
+		Value: messageValue,
+	}
+
+	if err = writer.WriteMessages(ctx, kafkaMessage); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Resolve the parent of a tag to a canonical tag, an empty parent makes the tag a root.
+// The parent must exist and must not be the tag itself or one of its descendants.
+func (u *tagsUC) parent(name, parent string) (*string, error) {
+	parent = taxonomy.Normalize(parent)
+	if parent == "" {
+		return nil, nil
+	}
+
+	canonical, err := u.tagsRepo.Resolve([]string{parent})
+	if err != nil {
+		return nil, err
+	}
+	resolved, ok := canonical[parent]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", tags.ErrUnknownParent, parent)
+	}
+
+	if resolved == name {
+		return nil, tags.ErrCycle
+	}
+	ancestors, err := u.tagsRepo.Ancestors(resolved)
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(ancestors, name) {
+		return nil, tags.ErrCycle
+	}
+
+	return &resolved, nil
+}
+
+// Check a tag or alias name is not empty and not too long
+func validateName(name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("%w: name must not be empty", tags.ErrInvalidTag)
+	case len(name) > maxTagLength:
+		return fmt.Errorf("%w: %q must be at most %d bytes long", tags.ErrInvalidTag, name, maxTagLength)
+	}
+	return nil
+}
+
+// Normalize aliases of a tag, an alias equal to the tag name is dropped
+func normalizeAliases(name string, aliases []string) ([]string, error) {
+	normalized := slices.DeleteFunc(taxonomy.NormalizeAll(aliases), func(alias string) bool {
+		return alias == name
+	})
+
+	for _, alias := range normalized {
+		if err := validateName(alias); err != nil {
+			return nil, err
+		}
+	}
+
+	return normalized, nil
+}
@@ -0,0 +1,234 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"cyansnbrst/products-service/config"
+	"cyansnbrst/products-service/internal/models"
+	"cyansnbrst/products-service/internal/tags"
+	mock_tags "cyansnbrst/products-service/internal/tags/mock"
+	"cyansnbrst/products-service/pkg/db"
+)
+
+func TestTagsUseCase_Create(t *testing.T) {
+	cfg := &config.Config{}
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTagsRepo := mock_tags.NewMockRepository(ctrl)
+	tagsUC := NewTagsUseCase(cfg, mockTagsRepo, logger)
+
+	music := "Music "
+
+	tests := []struct {
+		name         string
+		input        *models.CreateTagDTO
+		mockBehavior func(mockTagsRepo *mock_tags.MockRepository)
+		wantTag      *models.Tag
+		wantErr      error
+	}{
+		{
+			name:  "success",
+			input: &models.CreateTagDTO{Name: " Rock", Parent: &music, Aliases: []string{"Rock  Music", "rock", "rock music"}},
+			mockBehavior: func(mockTagsRepo *mock_tags.MockRepository) {
+				mockTagsRepo.EXPECT().Resolve([]string{"rock", "rock music"}).Return(map[string]string{}, nil)
+				mockTagsRepo.EXPECT().Resolve([]string{"music"}).Return(map[string]string{"music": "music"}, nil)
+				mockTagsRepo.EXPECT().Ancestors("music").Return(nil, nil)
+				mockTagsRepo.EXPECT().Create(gomock.Any()).Return(nil)
+			},
+			wantTag: &models.Tag{Name: "rock", Parent: stringPtr("music"), Aliases: []string{"rock music"}},
+		},
+		{
+			name:         "empty name",
+			input:        &models.CreateTagDTO{Name: "  "},
+			mockBehavior: func(mockTagsRepo *mock_tags.MockRepository) {},
+			wantErr:      tags.ErrInvalidTag,
+		},
+		{
+			name:  "alias taken",
+			input: &models.CreateTagDTO{Name: "rock", Aliases: []string{"metal"}},
+			mockBehavior: func(mockTagsRepo *mock_tags.MockRepository) {
+				mockTagsRepo.EXPECT().Resolve([]string{"rock", "metal"}).Return(map[string]string{"metal": "metal"}, nil)
+			},
+			wantErr: tags.ErrTagExists,
+		},
+		{
+			name:  "unknown parent",
+			input: &models.CreateTagDTO{Name: "rock", Parent: &music},
+			mockBehavior: func(mockTagsRepo *mock_tags.MockRepository) {
+				mockTagsRepo.EXPECT().Resolve([]string{"rock"}).Return(map[string]string{}, nil)
+				mockTagsRepo.EXPECT().Resolve([]string{"music"}).Return(map[string]string{}, nil)
+			},
+			wantErr: tags.ErrUnknownParent,
+		},
+		{
+			name:  "created concurrently",
+			input: &models.CreateTagDTO{Name: "rock"},
+			mockBehavior: func(mockTagsRepo *mock_tags.MockRepository) {
+				mockTagsRepo.EXPECT().Resolve([]string{"rock"}).Return(map[string]string{}, nil)
+				mockTagsRepo.EXPECT().Create(gomock.Any()).Return(db.ErrDuplicateTag)
+			},
+			wantErr: tags.ErrTagExists,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockTagsRepo)
+
+			tag, err := tagsUC.Create(tt.input)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				require.Nil(t, tag)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.wantTag, tag)
+			}
+		})
+	}
+}
+
+func TestTagsUseCase_Update(t *testing.T) {
+	cfg := &config.Config{}
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTagsRepo := mock_tags.NewMockRepository(ctrl)
+	tagsUC := NewTagsUseCase(cfg, mockTagsRepo, logger)
+
+	noParent := ""
+	hardRock := "hard rock"
+
+	tests := []struct {
+		name         string
+		tag          string
+		input        *models.UpdateTagDTO
+		mockBehavior func(mockTagsRepo *mock_tags.MockRepository)
+		wantTag      *models.Tag
+		wantErr      error
+	}{
+		{
+			name:  "replace aliases and clear parent",
+			tag:   "Rock",
+			input: &models.UpdateTagDTO{Parent: &noParent, Aliases: []string{"rock music", "rock'n'roll"}},
+			mockBehavior: func(mockTagsRepo *mock_tags.MockRepository) {
+				tag := &models.Tag{Name: "rock", Parent: stringPtr("music"), Aliases: []string{"rock music"}}
+				mockTagsRepo.EXPECT().Get("rock").Return(tag, nil)
+				mockTagsRepo.EXPECT().Resolve([]string{"rock music", "rock'n'roll"}).Return(map[string]string{"rock music": "rock"}, nil)
+				mockTagsRepo.EXPECT().Update(gomock.Any()).Return(nil)
+			},
+			wantTag: &models.Tag{Name: "rock", Aliases: []string{"rock music", "rock'n'roll"}},
+		},
+		{
+			name:  "alias of another tag",
+			tag:   "rock",
+			input: &models.UpdateTagDTO{Aliases: []string{"pop music"}},
+			mockBehavior: func(mockTagsRepo *mock_tags.MockRepository) {
+				mockTagsRepo.EXPECT().Get("rock").Return(&models.Tag{Name: "rock"}, nil)
+				mockTagsRepo.EXPECT().Resolve([]string{"pop music"}).Return(map[string]string{"pop music": "pop"}, nil)
+			},
+			wantErr: tags.ErrTagExists,
+		},
+		{
+			name:  "descendant as parent",
+			tag:   "rock",
+			input: &models.UpdateTagDTO{Parent: &hardRock},
+			mockBehavior: func(mockTagsRepo *mock_tags.MockRepository) {
+				mockTagsRepo.EXPECT().Get("rock").Return(&models.Tag{Name: "rock"}, nil)
+				mockTagsRepo.EXPECT().Resolve([]string{"hard rock"}).Return(map[string]string{"hard rock": "hard rock"}, nil)
+				mockTagsRepo.EXPECT().Ancestors("hard rock").Return([]string{"rock", "music"}, nil)
+			},
+			wantErr: tags.ErrCycle,
+		},
+		{
+			name:  "not found",
+			tag:   "jazz",
+			input: &models.UpdateTagDTO{},
+			mockBehavior: func(mockTagsRepo *mock_tags.MockRepository) {
+				mockTagsRepo.EXPECT().Get("jazz").Return(nil, db.ErrRecordNotFound)
+			},
+			wantErr: db.ErrRecordNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockTagsRepo)
+
+			tag, err := tagsUC.Update(tt.tag, tt.input)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				require.Nil(t, tag)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.wantTag, tag)
+			}
+		})
+	}
+}
+
+func TestTagsUseCase_Delete(t *testing.T) {
+	cfg := &config.Config{}
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockTagsRepo := mock_tags.NewMockRepository(ctrl)
+	tagsUC := NewTagsUseCase(cfg, mockTagsRepo, logger)
+
+	tests := []struct {
+		name         string
+		tag          string
+		mockBehavior func(mockTagsRepo *mock_tags.MockRepository)
+		wantErr      error
+	}{
+		{
+			name: "success",
+			tag:  "Jazz",
+			mockBehavior: func(mockTagsRepo *mock_tags.MockRepository) {
+				mockTagsRepo.EXPECT().IsUsed("jazz").Return(false, nil)
+				mockTagsRepo.EXPECT().Delete("jazz").Return(nil)
+			},
+		},
+		{
+			name: "used by products",
+			tag:  "rock",
+			mockBehavior: func(mockTagsRepo *mock_tags.MockRepository) {
+				mockTagsRepo.EXPECT().IsUsed("rock").Return(true, nil)
+			},
+			wantErr: tags.ErrTagInUse,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockTagsRepo)
+
+			err := tagsUC.Delete(tt.tag)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
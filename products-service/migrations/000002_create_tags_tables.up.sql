@@ -0,0 +1,27 @@
+CREATE TABLE tags (
+    name TEXT PRIMARY KEY,
+    parent TEXT REFERENCES tags(name) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (parent <> name)
+);
+
+CREATE INDEX tags_parent_idx ON tags (parent);
+
+CREATE TABLE tag_aliases (
+    alias TEXT PRIMARY KEY,
+    tag TEXT NOT NULL REFERENCES tags(name) ON DELETE CASCADE
+);
+
+CREATE INDEX tag_aliases_tag_idx ON tag_aliases (tag);
+
+UPDATE products
+SET tags = ARRAY(
+    SELECT DISTINCT lower(regexp_replace(btrim(tag), '\s+', ' ', 'g'))
+    FROM unnest(tags) AS tag
+    WHERE btrim(tag) <> ''
+);
+
+INSERT INTO tags (name)
+SELECT DISTINCT unnest(tags) FROM products
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,13 @@
+DROP INDEX IF EXISTS products_status_idx;
+DROP INDEX IF EXISTS products_category_idx;
+
+ALTER TABLE products
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS created_at,
+    DROP COLUMN IF EXISTS status,
+    DROP COLUMN IF EXISTS images,
+    DROP COLUMN IF EXISTS stock,
+    DROP COLUMN IF EXISTS category,
+    DROP COLUMN IF EXISTS currency,
+    DROP COLUMN IF EXISTS price,
+    DROP COLUMN IF EXISTS description;
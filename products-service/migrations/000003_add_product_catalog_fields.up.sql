@@ -0,0 +1,13 @@
+ALTER TABLE products
+    ADD COLUMN description TEXT NOT NULL DEFAULT '',
+    ADD COLUMN price BIGINT NOT NULL DEFAULT 0 CHECK (price >= 0),
+    ADD COLUMN currency TEXT NOT NULL DEFAULT 'RUB',
+    ADD COLUMN category TEXT NOT NULL DEFAULT '',
+    ADD COLUMN stock BIGINT CHECK (stock >= 0),
+    ADD COLUMN images TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN status TEXT NOT NULL DEFAULT 'active' CHECK (status IN ('draft', 'active', 'archived')),
+    ADD COLUMN created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ADD COLUMN updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+CREATE INDEX products_category_idx ON products (category);
+CREATE INDEX products_status_idx ON products (status);
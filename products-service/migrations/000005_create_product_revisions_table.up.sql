@@ -0,0 +1,12 @@
+CREATE TABLE product_revisions (
+    product_id BIGINT NOT NULL,
+    version integer NOT NULL,
+    product JSONB NOT NULL,
+    changed_by TEXT NOT NULL DEFAULT '',
+    changed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (product_id, version)
+);
+
+INSERT INTO product_revisions (product_id, version, product, changed_at)
+SELECT p.id, p.version, to_jsonb(p), p.updated_at
+FROM products p;
@@ -6,4 +6,6 @@ import "errors"
 var (
 	ErrRecordNotFound = errors.New("record not found")
 	ErrEditConflict   = errors.New("edit conflict")
+	ErrDuplicateTag   = errors.New("duplicate tag")
+	ErrDuplicateAlias = errors.New("duplicate tag alias")
 )
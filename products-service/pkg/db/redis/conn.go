@@ -0,0 +1,23 @@
+package redis
+
+import (
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"cyansnbrst/products-service/config"
+)
+
+// Returns new redis client
+func NewRedisClient(cfg *config.Config) *redis.Client {
+	client := redis.NewClient(&redis.Options{
+		Addr:         cfg.Redis.RedisAddr,
+		MinIdleConns: cfg.Redis.MinIdleConns,
+		PoolSize:     cfg.Redis.PoolSize,
+		PoolTimeout:  time.Duration(cfg.Redis.PoolTimeout) * time.Second,
+		Password:     cfg.Redis.Password, // no password set
+		DB:           cfg.Redis.DB,       // use default DB
+	})
+
+	return client
+}
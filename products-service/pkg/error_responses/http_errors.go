@@ -61,3 +61,17 @@ func NotPermittedResponse(w http.ResponseWriter, r *http.Request, l *zap.Logger)
 	message := "your user account doesnt't have the necessare permissions to access this resource"
 	errorResponse(w, r, http.StatusForbidden, message, l)
 }
+
+func ConflictResponse(w http.ResponseWriter, r *http.Request, l *zap.Logger, err error) {
+	errorResponse(w, r, http.StatusConflict, err.Error(), l)
+}
+
+func EditConflictResponse(w http.ResponseWriter, r *http.Request, l *zap.Logger) {
+	message := "unable to update the record due to an edit conflict, please try again"
+	errorResponse(w, r, http.StatusConflict, message, l)
+}
+
+func UnsupportedMediaTypeResponse(w http.ResponseWriter, r *http.Request, l *zap.Logger) {
+	message := "the request content type is not supported, send a merge patch as application/merge-patch+json"
+	errorResponse(w, r, http.StatusUnsupportedMediaType, message, l)
+}
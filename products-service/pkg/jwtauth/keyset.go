@@ -0,0 +1,161 @@
+package jwtauth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Key set errors
+var (
+	ErrUnknownKey        = errors.New("unknown key id")
+	ErrKeySetUnavailable = errors.New("key set unavailable")
+)
+
+const (
+	// Minimum time between fetches caused by unknown key IDs
+	minRefreshInterval = 10 * time.Second
+	// Timeout of a single key set request
+	requestTimeout = 5 * time.Second
+)
+
+// JSON Web Key
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// Remote key set fetched from the auth service JWKS endpoint and cached for ttl.
+// An unknown key ID triggers an early refresh so rotated keys are picked up without waiting for ttl.
+type KeySet struct {
+	url    string
+	ttl    time.Duration
+	client *http.Client
+
+	mu        sync.RWMutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+// Key set constructor
+func NewKeySet(url string, ttl time.Duration) *KeySet {
+	return &KeySet{
+		url:    url,
+		ttl:    ttl,
+		client: &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// Get public key by ID
+func (s *KeySet) Key(kid string) (crypto.PublicKey, error) {
+	s.mu.RLock()
+	key, ok := s.keys[kid]
+	fresh := time.Since(s.fetchedAt) < s.ttl
+	s.mu.RUnlock()
+
+	if ok && fresh {
+		return key, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Another request may have refreshed the set while we waited for the lock
+	key, ok = s.keys[kid]
+	if ok && time.Since(s.fetchedAt) < s.ttl {
+		return key, nil
+	}
+	if !ok && time.Since(s.fetchedAt) < minRefreshInterval {
+		return nil, ErrUnknownKey
+	}
+
+	if err := s.refresh(); err != nil {
+		// Keep serving the stale key while the auth service is unreachable
+		if ok {
+			return key, nil
+		}
+		return nil, fmt.Errorf("%w: %v", ErrKeySetUnavailable, err)
+	}
+
+	key, ok = s.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return key, nil
+}
+
+// Fetch and parse the key set, must be called with the write lock held
+func (s *KeySet) refresh() error {
+	resp, err := s.client.Get(s.url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	var body struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return err
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(body.Keys))
+	for _, k := range body.Keys {
+		key, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = key
+	}
+
+	s.keys = keys
+	s.fetchedAt = time.Now()
+
+	return nil
+}
+
+// Decode the public key of a JSON Web Key
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch {
+	case k.Kty == "OKP" && k.Crv == "Ed25519":
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+	case k.Kty == "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+	}
+}
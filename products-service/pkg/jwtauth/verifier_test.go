@@ -0,0 +1,345 @@
+package jwtauth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+type testKey struct {
+	id      string
+	private ed25519.PrivateKey
+}
+
+func newTestKey(t *testing.T, id string) testKey {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return testKey{id: id, private: private}
+}
+
+func (k testKey) sign(t *testing.T, claims jwt.Claims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodEdDSA, claims)
+	token.Header["kid"] = k.id
+	signed, err := token.SignedString(k.private)
+	require.NoError(t, err)
+	return signed
+}
+
+// Serve a JWKS with the given keys and count the requests
+func newJWKSServer(t *testing.T, keys *[]testKey, hits *int32) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(hits, 1)
+
+		body := struct {
+			Keys []jwk `json:"keys"`
+		}{}
+		for _, k := range *keys {
+			body.Keys = append(body.Keys, jwk{
+				Kty: "OKP",
+				Kid: k.id,
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(k.private.Public().(ed25519.PublicKey)),
+			})
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	active := newTestKey(t, "active")
+	foreign := newTestKey(t, "foreign")
+	keys := []testKey{active}
+
+	var hits int32
+	server := newJWKSServer(t, &keys, &hits)
+	verifier := NewVerifier(NewKeySet(server.URL, time.Minute), nil)
+
+	validClaims := Claims{
+		UserUID:   "5748",
+		IsAdmin:   true,
+		SessionID: "sid",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}
+	expiredClaims := validClaims
+	expiredClaims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+	unlimitedClaims := validClaims
+	unlimitedClaims.ExpiresAt = nil
+
+	tests := []struct {
+		name      string
+		token     string
+		wantUID   string
+		wantAdmin bool
+		wantErr   error
+	}{
+		{
+			name:      "valid token",
+			token:     active.sign(t, validClaims),
+			wantUID:   "5748",
+			wantAdmin: true,
+		},
+		{
+			name:    "expired token",
+			token:   active.sign(t, expiredClaims),
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "token without expiration",
+			token:   active.sign(t, unlimitedClaims),
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "unknown key",
+			token:   foreign.sign(t, validClaims),
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "malformed token",
+			token:   "wrong token",
+			wantErr: ErrInvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := verifier.Verify(tt.token)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				require.Nil(t, claims)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.wantUID, claims.UserUID)
+				require.Equal(t, tt.wantAdmin, claims.IsAdmin)
+			}
+		})
+	}
+
+	// The key set is fetched once, the unknown key does not trigger a refetch right away
+	require.Equal(t, int32(1), atomic.LoadInt32(&hits))
+}
+
+func TestVerifier_VerifyService(t *testing.T) {
+	active := newTestKey(t, "active")
+	keys := []testKey{active}
+
+	var hits int32
+	server := newJWKSServer(t, &keys, &hits)
+	verifier := NewVerifier(NewKeySet(server.URL, time.Minute), nil)
+
+	serviceClaims := func(audience string, expiresIn time.Duration) ServiceClaims {
+		return ServiceClaims{
+			TokenUse: "service",
+			RegisteredClaims: jwt.RegisteredClaims{
+				Subject:   "recommendations",
+				Audience:  jwt.ClaimStrings{audience},
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiresIn)),
+			},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		token      string
+		wantCaller string
+		wantErr    error
+	}{
+		{
+			name:       "valid token",
+			token:      active.sign(t, serviceClaims("products", time.Minute)),
+			wantCaller: "recommendations",
+		},
+		{
+			name:    "other audience",
+			token:   active.sign(t, serviceClaims("profiles", time.Minute)),
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name:    "expired token",
+			token:   active.sign(t, serviceClaims("products", -time.Minute)),
+			wantErr: ErrInvalidToken,
+		},
+		{
+			name: "user token",
+			token: active.sign(t, Claims{
+				UserUID: "5748",
+				RegisteredClaims: jwt.RegisteredClaims{
+					Audience:  jwt.ClaimStrings{"products"},
+					ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+				},
+			}),
+			wantErr: ErrInvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := verifier.VerifyService(tt.token, "products")
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				require.Nil(t, claims)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.wantCaller, claims.Subject)
+			}
+		})
+	}
+
+	// Service tokens are not accepted as access tokens
+	_, err := verifier.Verify(active.sign(t, serviceClaims("products", time.Minute)))
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	oldKey := newTestKey(t, "old")
+	newKey := newTestKey(t, "new")
+	keys := []testKey{oldKey}
+
+	var hits int32
+	server := newJWKSServer(t, &keys, &hits)
+	keySet := NewKeySet(server.URL, time.Minute)
+
+	_, err := keySet.Key("old")
+	require.NoError(t, err)
+
+	// Rotate the key and pretend the last fetch happened before the refresh interval
+	keys = []testKey{newKey, oldKey}
+	keySet.fetchedAt = time.Now().Add(-minRefreshInterval)
+
+	_, err = keySet.Key("new")
+	require.NoError(t, err)
+	require.Equal(t, int32(2), atomic.LoadInt32(&hits))
+
+	_, err = keySet.Key("old")
+	require.NoError(t, err)
+	require.Equal(t, int32(2), atomic.LoadInt32(&hits))
+}
+
+func TestKeySet_Unavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	verifier := NewVerifier(NewKeySet(server.URL, time.Minute), nil)
+	token := newTestKey(t, "active").sign(t, Claims{UserUID: "5748"})
+
+	_, err := verifier.Verify(token)
+	require.ErrorIs(t, err, ErrKeySetUnavailable)
+}
+
+// Revoked sessions of the auth service
+type testRevocations struct {
+	revoked map[string]bool
+	err     error
+}
+
+func (r testRevocations) IsRevoked(sessionID string) (bool, error) {
+	return r.revoked[sessionID], r.err
+}
+
+func TestVerifier_Revocations(t *testing.T) {
+	active := newTestKey(t, "active")
+	keys := []testKey{active}
+
+	var hits int32
+	server := newJWKSServer(t, &keys, &hits)
+	keySet := NewKeySet(server.URL, time.Minute)
+
+	claims := func(sessionID string) Claims {
+		return Claims{
+			UserUID:   "5748",
+			SessionID: sessionID,
+			RegisteredClaims: jwt.RegisteredClaims{
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+			},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		revocations RevocationChecker
+		sessionID   string
+		wantErr     error
+	}{
+		{
+			name:        "active session",
+			revocations: testRevocations{revoked: map[string]bool{"revoked": true}},
+			sessionID:   "sid",
+		},
+		{
+			name:        "revoked session",
+			revocations: testRevocations{revoked: map[string]bool{"revoked": true}},
+			sessionID:   "revoked",
+			wantErr:     ErrRevokedToken,
+		},
+		{
+			name:        "revocations unavailable",
+			revocations: testRevocations{err: errors.New("auth service is down")},
+			sessionID:   "sid",
+			wantErr:     ErrRevocationsUnavailable,
+		},
+		{
+			name:      "revocations not checked",
+			sessionID: "revoked",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := NewVerifier(keySet, tt.revocations)
+
+			got, err := verifier.Verify(active.sign(t, claims(tt.sessionID)))
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				require.Nil(t, got)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.sessionID, got.SessionID)
+			}
+		})
+	}
+}
+
+func TestHasPermission(t *testing.T) {
+	tests := []struct {
+		name        string
+		permissions []string
+		permission  string
+		want        bool
+	}{
+		{name: "exact match", permissions: []string{"products:write"}, permission: "products:write", want: true},
+		{name: "wildcard", permissions: []string{"*"}, permission: "products:delete", want: true},
+		{name: "prefix wildcard", permissions: []string{"products:*"}, permission: "products:delete", want: true},
+		{name: "other resource", permissions: []string{"products:*"}, permission: "analytics:read", want: false},
+		{name: "no permissions", permissions: nil, permission: "products:write", want: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, HasPermission(tt.permissions, tt.permission))
+		})
+	}
+}
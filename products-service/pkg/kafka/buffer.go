@@ -0,0 +1,143 @@
+package kafka
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"cyansnbrst/products-service/config"
+	"cyansnbrst/products-service/pkg/metric"
+)
+
+// Overflow policies of a full buffer
+const (
+	OverflowDrop  = "drop"
+	OverflowBlock = "block"
+)
+
+// Publisher of a batch of events
+type BatchSender func(ctx context.Context, events []Event) error
+
+// Buffer of events published asynchronously in batches, so callers do not wait for Kafka.
+// A full buffer drops new events or makes the caller wait for space, depending on the overflow policy.
+type Buffer struct {
+	cfg     config.EventBuffer
+	name    string
+	send    BatchSender
+	metrics metric.Metrics
+	logger  *zap.Logger
+	events  chan Event
+	done    chan struct{}
+}
+
+// Buffer constructor, the name labels the buffer metrics
+func NewBuffer(cfg config.EventBuffer, name string, send BatchSender, metrics metric.Metrics, logger *zap.Logger) *Buffer {
+	if cfg.BatchSize < 1 {
+		cfg.BatchSize = 1
+	}
+
+	return &Buffer{
+		cfg:     cfg,
+		name:    name,
+		send:    send,
+		metrics: metrics,
+		logger:  logger,
+		events:  make(chan Event, cfg.Size),
+		done:    make(chan struct{}),
+	}
+}
+
+// Enqueue an event, false if the buffer is full and the event was dropped
+func (b *Buffer) Enqueue(event Event) bool {
+	select {
+	case b.events <- event:
+		return true
+	default:
+	}
+
+	if b.cfg.Overflow == OverflowBlock && b.cfg.BlockTimeout > 0 {
+		timer := time.NewTimer(b.cfg.BlockTimeout)
+		defer timer.Stop()
+
+		select {
+		case b.events <- event:
+			return true
+		case <-timer.C:
+		}
+	}
+
+	b.metrics.AddDroppedEvents(b.name, "overflow", 1)
+	return false
+}
+
+// Run publishing until the context is cancelled, then publish the events left in the buffer
+func (b *Buffer) Run(ctx context.Context) {
+	defer close(b.done)
+
+	b.logger.Info("starting events buffer", zap.String("buffer", b.name))
+
+	ticker := time.NewTicker(b.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Event, 0, b.cfg.BatchSize)
+	for {
+		select {
+		case event := <-b.events:
+			batch = append(batch, event)
+			if len(batch) >= b.cfg.BatchSize {
+				batch = b.publish(batch)
+			}
+		case <-ticker.C:
+			batch = b.publish(batch)
+		case <-ctx.Done():
+			for {
+				select {
+				case event := <-b.events:
+					batch = append(batch, event)
+					if len(batch) >= b.cfg.BatchSize {
+						batch = b.publish(batch)
+					}
+				default:
+					b.publish(batch)
+					b.logger.Info("events buffer flushed", zap.String("buffer", b.name))
+					return
+				}
+			}
+		}
+	}
+}
+
+// Wait until the buffer is flushed after its run is cancelled
+func (b *Buffer) Wait(ctx context.Context) error {
+	select {
+	case <-b.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Publish a batch and return an empty batch for the next events, a batch that fails to publish is dropped
+func (b *Buffer) publish(batch []Event) []Event {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), b.cfg.WriteTimeout)
+	defer cancel()
+
+	if err := b.send(ctx, batch); err != nil {
+		b.logger.Warn("failed to publish buffered events",
+			zap.String("buffer", b.name),
+			zap.Int("count", len(batch)),
+			zap.Error(err),
+		)
+		b.metrics.AddDroppedEvents(b.name, "publish", len(batch))
+	} else {
+		b.metrics.AddPublishedEvents(b.name, len(batch))
+	}
+	b.metrics.SetQueuedEvents(b.name, len(b.events))
+
+	return make([]Event, 0, b.cfg.BatchSize)
+}
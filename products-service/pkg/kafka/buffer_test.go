@@ -0,0 +1,152 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"cyansnbrst/products-service/config"
+)
+
+// Buffer metrics counting events by outcome
+type bufferMetrics struct {
+	mu        sync.Mutex
+	dropped   map[string]int
+	published int
+}
+
+func (m *bufferMetrics) AddDroppedEvents(_, reason string, count int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dropped[reason] += count
+}
+
+func (m *bufferMetrics) AddPublishedEvents(_ string, count int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.published += count
+}
+
+func (m *bufferMetrics) SetQueuedEvents(string, int) {}
+
+// Sender recording the size of each published batch
+type batchRecorder struct {
+	mu      sync.Mutex
+	batches []int
+	err     error
+}
+
+func (r *batchRecorder) send(_ context.Context, events []Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.batches = append(r.batches, len(events))
+	return nil
+}
+
+func TestBuffer(t *testing.T) {
+	tests := []struct {
+		name          string
+		cfg           config.EventBuffer
+		sendErr       error
+		events        int
+		wantAccepted  int
+		wantBatches   []int
+		wantPublished int
+		wantDropped   map[string]int
+	}{
+		{
+			name:          "batches by size and flushes the rest on shutdown",
+			cfg:           config.EventBuffer{Size: 10, BatchSize: 2, FlushInterval: time.Hour, Overflow: OverflowDrop},
+			events:        5,
+			wantAccepted:  5,
+			wantBatches:   []int{2, 2, 1},
+			wantPublished: 5,
+			wantDropped:   map[string]int{},
+		},
+		{
+			name:          "drops events of a full buffer",
+			cfg:           config.EventBuffer{Size: 3, BatchSize: 10, FlushInterval: time.Hour, Overflow: OverflowDrop},
+			events:        5,
+			wantAccepted:  3,
+			wantBatches:   []int{3},
+			wantPublished: 3,
+			wantDropped:   map[string]int{"overflow": 2},
+		},
+		{
+			name:          "drops events of a full buffer after the block timeout",
+			cfg:           config.EventBuffer{Size: 1, BatchSize: 10, FlushInterval: time.Hour, Overflow: OverflowBlock, BlockTimeout: time.Millisecond},
+			events:        2,
+			wantAccepted:  1,
+			wantBatches:   []int{1},
+			wantPublished: 1,
+			wantDropped:   map[string]int{"overflow": 1},
+		},
+		{
+			name:         "drops batches that fail to publish",
+			cfg:          config.EventBuffer{Size: 10, BatchSize: 2, FlushInterval: time.Hour, Overflow: OverflowDrop},
+			sendErr:      errors.New("kafka is down"),
+			events:       3,
+			wantAccepted: 3,
+			wantDropped:  map[string]int{"publish": 3},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := &bufferMetrics{dropped: map[string]int{}}
+			recorder := &batchRecorder{err: tt.sendErr}
+			buffer := NewBuffer(tt.cfg, "test", recorder.send, metrics, zap.NewNop())
+
+			accepted := 0
+			for i := 0; i < tt.events; i++ {
+				if buffer.Enqueue(Event{Key: "1", Message: KafkaMessage{Action: "view_products"}}) {
+					accepted++
+				}
+			}
+			require.Equal(t, tt.wantAccepted, accepted)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			buffer.Run(ctx)
+			require.NoError(t, buffer.Wait(context.Background()))
+
+			require.Equal(t, tt.wantBatches, recorder.batches)
+			require.Equal(t, tt.wantPublished, metrics.published)
+			require.Equal(t, tt.wantDropped, metrics.dropped)
+		})
+	}
+}
+
+func TestBuffer_FlushInterval(t *testing.T) {
+	metrics := &bufferMetrics{dropped: map[string]int{}}
+	published := make(chan int, 1)
+	buffer := NewBuffer(config.EventBuffer{Size: 10, BatchSize: 10, FlushInterval: 10 * time.Millisecond, Overflow: OverflowDrop},
+		"test", func(_ context.Context, events []Event) error {
+			published <- len(events)
+			return nil
+		}, metrics, zap.NewNop())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go buffer.Run(ctx)
+
+	require.True(t, buffer.Enqueue(Event{Key: "1", Message: KafkaMessage{Action: "view_products"}}))
+
+	select {
+	case count := <-published:
+		require.Equal(t, 1, count)
+	case <-time.After(time.Second):
+		t.Fatal("events were not published on flush interval")
+	}
+
+	cancel()
+	require.NoError(t, buffer.Wait(context.Background()))
+}
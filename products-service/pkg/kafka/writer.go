@@ -1,33 +1,187 @@
 package kafka
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"os"
+	"strings"
 
 	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
 
 	"cyansnbrst/products-service/config"
 )
 
 // Kafka message struct
 type KafkaMessage struct {
-	Action string   `json:"action"`
-	Time   string   `json:"time"`
-	Tags   []string `json:"tags"`
+	Action      string   `json:"action"`
+	Time        string   `json:"time"`
+	Tags        []string `json:"tags"`
+	Status      string   `json:"status,omitempty"`
+	Stock       *int64   `json:"stock,omitempty"`
+	Tag         string   `json:"tag,omitempty"`
+	Parent      string   `json:"parent,omitempty"`
+	Aliases     []string `json:"aliases,omitempty"`
+	Changed     []string `json:"changed,omitempty"`
+	AddedTags   []string `json:"added_tags,omitempty"`
+	RemovedTags []string `json:"removed_tags,omitempty"`
+	Counted     *bool    `json:"counted,omitempty"`
+	SkipReason  string   `json:"skip_reason,omitempty"`
 }
 
-// Init kafka producer with given topic
+// Kafka message with its key, an item of a batch.
+// Viewer and user agent of a view are not published, the batch sender uses them to decide whether the view is counted.
+type Event struct {
+	Key       string
+	Message   KafkaMessage
+	Viewer    string
+	UserAgent string
+}
+
+// Init kafka producer with given topic.
+// Messages are partitioned by the hash of their key, so the events of one product or user are consumed in the order they were written.
 func InitKafkaWriter(cfg *config.Config, topicKey string) (*kafka.Writer, error) {
 	topic, exists := cfg.Kafka.Topics[topicKey]
 	if !exists {
 		return nil, fmt.Errorf("topic key '%s' not found in configuration", topicKey)
 	}
 
+	acks, err := requiredAcks(cfg.Kafka.RequiredAcks)
+	if err != nil {
+		return nil, err
+	}
+
+	compression, err := compressionCodec(cfg.Kafka.Compression)
+	if err != nil {
+		return nil, err
+	}
+
+	transport, err := newTransport(cfg.Kafka)
+	if err != nil {
+		return nil, err
+	}
+
 	writer := &kafka.Writer{
-		Addr:        kafka.TCP(cfg.Kafka.Brokers...),
-		Topic:       topic,
-		Balancer:    &kafka.LeastBytes{},
-		MaxAttempts: cfg.Kafka.MaxAttempts,
+		Addr:            kafka.TCP(cfg.Kafka.Brokers...),
+		Topic:           topic,
+		Balancer:        &kafka.Hash{},
+		MaxAttempts:     cfg.Kafka.MaxAttempts,
+		WriteBackoffMin: cfg.Kafka.WriteBackoffMin,
+		WriteBackoffMax: cfg.Kafka.WriteBackoffMax,
+		RequiredAcks:    acks,
+		BatchSize:       cfg.Kafka.BatchSize,
+		BatchBytes:      cfg.Kafka.BatchBytes,
+		BatchTimeout:    cfg.Kafka.BatchTimeout,
+		Compression:     compression,
+	}
+	if transport != nil {
+		writer.Transport = transport
 	}
 
 	return writer, nil
 }
+
+// Parse required acks, all replicas acknowledge a write by default
+func requiredAcks(value string) (kafka.RequiredAcks, error) {
+	switch strings.ToLower(value) {
+	case "", "all":
+		return kafka.RequireAll, nil
+	case "leader", "one":
+		return kafka.RequireOne, nil
+	case "none":
+		return kafka.RequireNone, nil
+	default:
+		return 0, fmt.Errorf("unknown kafka required acks '%s'", value)
+	}
+}
+
+// Parse compression codec, messages are not compressed by default
+func compressionCodec(value string) (kafka.Compression, error) {
+	switch strings.ToLower(value) {
+	case "", "none":
+		return 0, nil
+	case "gzip":
+		return kafka.Gzip, nil
+	case "snappy":
+		return kafka.Snappy, nil
+	case "lz4":
+		return kafka.Lz4, nil
+	case "zstd":
+		return kafka.Zstd, nil
+	default:
+		return 0, fmt.Errorf("unknown kafka compression '%s'", value)
+	}
+}
+
+// Transport with TLS and SASL, nil when neither is configured and the default transport is used
+func newTransport(cfg config.Kafka) (*kafka.Transport, error) {
+	if !cfg.TLS.Enabled && cfg.SASL.Mechanism == "" {
+		return nil, nil
+	}
+
+	transport := &kafka.Transport{}
+
+	if cfg.TLS.Enabled {
+		tlsConfig, err := newTLSConfig(cfg.TLS)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLS = tlsConfig
+	}
+
+	if cfg.SASL.Mechanism != "" {
+		mechanism, err := saslMechanism(cfg.SASL)
+		if err != nil {
+			return nil, err
+		}
+		transport.SASL = mechanism
+	}
+
+	return transport, nil
+}
+
+// Build TLS config from the CA and client certificate files
+func newTLSConfig(cfg config.KafkaTLS) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if cfg.CAFile != "" {
+		ca, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read kafka CA file: %w", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates in kafka CA file '%s'", cfg.CAFile)
+		}
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load kafka client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// Build SASL mechanism
+func saslMechanism(cfg config.KafkaSASL) (sasl.Mechanism, error) {
+	switch strings.ToLower(cfg.Mechanism) {
+	case "plain":
+		return plain.Mechanism{Username: cfg.Username, Password: cfg.Password}, nil
+	case "scram-sha-256":
+		return scram.Mechanism(scram.SHA256, cfg.Username, cfg.Password)
+	case "scram-sha-512":
+		return scram.Mechanism(scram.SHA512, cfg.Username, cfg.Password)
+	default:
+		return nil, fmt.Errorf("unknown kafka SASL mechanism '%s'", cfg.Mechanism)
+	}
+}
@@ -0,0 +1,130 @@
+package kafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/stretchr/testify/require"
+
+	"cyansnbrst/products-service/config"
+)
+
+func TestInitKafkaWriter(t *testing.T) {
+	tests := []struct {
+		name   string
+		kafka  config.Kafka
+		topic  string
+		check  func(t *testing.T, writer *kafka.Writer)
+		hasErr bool
+	}{
+		{
+			name:  "defaults",
+			topic: "product",
+			check: func(t *testing.T, writer *kafka.Writer) {
+				require.Equal(t, "product_updates", writer.Topic)
+				require.IsType(t, &kafka.Hash{}, writer.Balancer)
+				require.Equal(t, kafka.RequireAll, writer.RequiredAcks)
+				require.Equal(t, kafka.Compression(0), writer.Compression)
+				require.Nil(t, writer.Transport)
+			},
+		},
+		{
+			name:  "configured writer",
+			topic: "product",
+			kafka: config.Kafka{
+				MaxAttempts:     5,
+				WriteBackoffMin: 100 * time.Millisecond,
+				WriteBackoffMax: time.Second,
+				RequiredAcks:    "leader",
+				BatchSize:       50,
+				BatchBytes:      1 << 20,
+				BatchTimeout:    10 * time.Millisecond,
+				Compression:     "LZ4",
+			},
+			check: func(t *testing.T, writer *kafka.Writer) {
+				require.Equal(t, 5, writer.MaxAttempts)
+				require.Equal(t, 100*time.Millisecond, writer.WriteBackoffMin)
+				require.Equal(t, time.Second, writer.WriteBackoffMax)
+				require.Equal(t, kafka.RequireOne, writer.RequiredAcks)
+				require.Equal(t, 50, writer.BatchSize)
+				require.Equal(t, int64(1<<20), writer.BatchBytes)
+				require.Equal(t, 10*time.Millisecond, writer.BatchTimeout)
+				require.Equal(t, kafka.Lz4, writer.Compression)
+			},
+		},
+		{
+			name:  "TLS and SASL",
+			topic: "product",
+			kafka: config.Kafka{
+				TLS:  config.KafkaTLS{Enabled: true},
+				SASL: config.KafkaSASL{Mechanism: "plain", Username: "products", Password: "secret"},
+			},
+			check: func(t *testing.T, writer *kafka.Writer) {
+				transport, ok := writer.Transport.(*kafka.Transport)
+				require.True(t, ok)
+				require.NotNil(t, transport.TLS)
+				require.Equal(t, plain.Mechanism{Username: "products", Password: "secret"}, transport.SASL)
+			},
+		},
+		{
+			name:  "SCRAM",
+			topic: "product",
+			kafka: config.Kafka{SASL: config.KafkaSASL{Mechanism: "scram-sha-512", Username: "products", Password: "secret"}},
+			check: func(t *testing.T, writer *kafka.Writer) {
+				transport, ok := writer.Transport.(*kafka.Transport)
+				require.True(t, ok)
+				require.Nil(t, transport.TLS)
+				require.Equal(t, "SCRAM-SHA-512", transport.SASL.Name())
+			},
+		},
+		{
+			name:   "unknown topic",
+			topic:  "order",
+			hasErr: true,
+		},
+		{
+			name:   "unknown required acks",
+			topic:  "product",
+			kafka:  config.Kafka{RequiredAcks: "some"},
+			hasErr: true,
+		},
+		{
+			name:   "unknown compression",
+			topic:  "product",
+			kafka:  config.Kafka{Compression: "brotli"},
+			hasErr: true,
+		},
+		{
+			name:   "unknown SASL mechanism",
+			topic:  "product",
+			kafka:  config.Kafka{SASL: config.KafkaSASL{Mechanism: "gssapi"}},
+			hasErr: true,
+		},
+		{
+			name:   "missing CA file",
+			topic:  "product",
+			kafka:  config.Kafka{TLS: config.KafkaTLS{Enabled: true, CAFile: "testdata/missing.pem"}},
+			hasErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Kafka: tt.kafka}
+			cfg.Kafka.Brokers = []string{"kafka:9092"}
+			cfg.Kafka.Topics = map[string]string{"product": "product_updates"}
+
+			writer, err := InitKafkaWriter(cfg, tt.topic)
+
+			if tt.hasErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				tt.check(t, writer)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+// Package mergepatch applies JSON Merge Patch documents (RFC 7396) to resources
+package mergepatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+)
+
+// Media type of a JSON merge patch
+const ContentType = "application/merge-patch+json"
+
+// Error of a patch that is not an object or does not fit the resource
+var ErrInvalidPatch = errors.New("invalid merge patch")
+
+// Check the content type of a request is a merge patch, plain JSON is accepted as a merge patch too
+func IsContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == ContentType || mediaType == "application/json"
+}
+
+// Apply a merge patch to the JSON form of doc and decode the result into dst.
+// Members set to null are removed and decode to zero values, objects are merged recursively and arrays are replaced as a whole.
+// Unknown members and members of a wrong type are rejected with the name of the field.
+func Apply(doc interface{}, patch []byte, dst interface{}) error {
+	var patchObject map[string]interface{}
+	if err := decode(patch, &patchObject); err != nil || patchObject == nil {
+		return fmt.Errorf("%w: patch must be a JSON object", ErrInvalidPatch)
+	}
+
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	var target map[string]interface{}
+	if err = decode(data, &target); err != nil {
+		return err
+	}
+
+	merged, err := json.Marshal(merge(target, patchObject))
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(merged))
+	dec.DisallowUnknownFields()
+
+	if err = dec.Decode(dst); err != nil {
+		var unmarshalTypeError *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &unmarshalTypeError):
+			return fmt.Errorf("%w: incorrect JSON type for field %q", ErrInvalidPatch, unmarshalTypeError.Field)
+		case strings.HasPrefix(err.Error(), "json: unknown field "):
+			return fmt.Errorf("%w: unknown field %s", ErrInvalidPatch, strings.TrimPrefix(err.Error(), "json: unknown field "))
+		default:
+			return fmt.Errorf("%w: %s", ErrInvalidPatch, err)
+		}
+	}
+
+	return nil
+}
+
+// Merge a patch into a target as RFC 7396 describes
+func merge(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{}, len(patchObject))
+	}
+
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+			continue
+		}
+		targetObject[name] = merge(targetObject[name], value)
+	}
+
+	return targetObject
+}
+
+// Decode JSON keeping numbers exact
+func decode(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
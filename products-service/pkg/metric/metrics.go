@@ -0,0 +1,97 @@
+package metric
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// App metrics interface
+type Metrics interface {
+	AddDroppedEvents(buffer, reason string, count int)
+	AddPublishedEvents(buffer string, count int)
+	SetQueuedEvents(buffer string, count int)
+}
+
+// Prometheus metrics struct
+type PrometheusMetrics struct {
+	DroppedEvents   *prometheus.CounterVec
+	PublishedEvents *prometheus.CounterVec
+	QueuedEvents    *prometheus.GaugeVec
+}
+
+// Create metrics with address and name
+func CreateMetrics(address string, name string) (Metrics, error) {
+	var metr PrometheusMetrics
+	metr.DroppedEvents = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: name + "_dropped_events_total",
+			Help: "Buffered events dropped before they were published",
+		},
+		[]string{"buffer", "reason"},
+	)
+
+	if err := prometheus.Register(metr.DroppedEvents); err != nil {
+		return nil, err
+	}
+
+	metr.PublishedEvents = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: name + "_published_events_total",
+			Help: "Buffered events published to Kafka",
+		},
+		[]string{"buffer"},
+	)
+
+	if err := prometheus.Register(metr.PublishedEvents); err != nil {
+		return nil, err
+	}
+
+	metr.QueuedEvents = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: name + "_queued_events",
+			Help: "Buffered events waiting to be published",
+		},
+		[]string{"buffer"},
+	)
+
+	if err := prometheus.Register(metr.QueuedEvents); err != nil {
+		return nil, err
+	}
+
+	if err := prometheus.Register(collectors.NewBuildInfoCollector()); err != nil {
+		return nil, err
+	}
+
+	go func() {
+		router := httprouter.New()
+		router.GET("/metrics", func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+			promhttp.Handler().ServeHTTP(w, r)
+		})
+		log.Printf("metrics server is running on port: %s", address)
+		if err := http.ListenAndServe(address, router); err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	return &metr, nil
+}
+
+// AddDroppedEvents
+func (metr *PrometheusMetrics) AddDroppedEvents(buffer, reason string, count int) {
+	metr.DroppedEvents.WithLabelValues(buffer, reason).Add(float64(count))
+}
+
+// AddPublishedEvents
+func (metr *PrometheusMetrics) AddPublishedEvents(buffer string, count int) {
+	metr.PublishedEvents.WithLabelValues(buffer).Add(float64(count))
+}
+
+// SetQueuedEvents
+func (metr *PrometheusMetrics) SetQueuedEvents(buffer string, count int) {
+	metr.QueuedEvents.WithLabelValues(buffer).Set(float64(count))
+}
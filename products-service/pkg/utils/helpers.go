@@ -24,6 +24,42 @@ func ReadIDParam(r *http.Request) (int64, error) {
 	return id, nil
 }
 
+// Read version param
+func ReadVersionParam(r *http.Request) (int64, error) {
+	params := httprouter.ParamsFromContext(r.Context())
+
+	version, err := strconv.ParseInt(params.ByName("version"), 10, 64)
+	if err != nil || version < 1 {
+		return 0, errors.New("invalid version parameter")
+	}
+
+	return version, nil
+}
+
+// Format a version as an entity tag
+func VersionETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// Read the version the client expects from the If-Match header, nil means any version matches
+func ReadIfMatch(r *http.Request) (*int64, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return nil, nil
+	}
+
+	if len(value) < 2 || !strings.HasPrefix(value, `"`) || !strings.HasSuffix(value, `"`) {
+		return nil, errors.New("invalid If-Match header")
+	}
+
+	version, err := strconv.ParseInt(value[1:len(value)-1], 10, 64)
+	if err != nil || version < 1 {
+		return nil, errors.New("invalid If-Match header")
+	}
+
+	return &version, nil
+}
+
 // JSON envelope
 type Envelope map[string]interface{}
 
@@ -22,6 +22,11 @@ import (
 // @securityDefinitions.apikey	cookieAuth
 // @in							cookie
 // @name						token
+
+// @securityDefinitions.apikey	bearerAuth
+// @in							header
+// @name						Authorization
+// @description				"Bearer <access token or API key>"
 func main() {
 	log.Println("starting profiles server")
 
@@ -11,14 +11,19 @@ import (
 
 // App config struct
 type Config struct {
-	Port             int
-	Env              string
-	AuthURL          string
-	DefaultLocation  string
-	DefaultInterests string
-	PostgreSQL       PostgreSQL
-	Kafka            Kafka
-	Timeout          Timeout
+	Port               int
+	Env                string
+	AuthURL            string
+	JWKSURL            string
+	RevokedSessionsURL string
+	DefaultLocation    string
+	DefaultInterests   string
+	PostgreSQL         PostgreSQL
+	Kafka              Kafka
+	Metrics            Metrics
+	AuthBreaker        AuthBreaker
+	ServiceAuth        ServiceAuth
+	Timeout            Timeout
 }
 
 // PostgreSQL config struct
@@ -36,9 +41,54 @@ type PostgreSQL struct {
 
 // Kafka config struct
 type Kafka struct {
-	Brokers     []string
-	Topics      map[string]string
-	MaxAttempts int
+	Brokers         []string
+	Topics          map[string]string
+	GroupID         string
+	MaxAttempts     int
+	WriteBackoffMin time.Duration
+	WriteBackoffMax time.Duration
+	RequiredAcks    string // none, leader or all
+	BatchSize       int
+	BatchBytes      int64
+	BatchTimeout    time.Duration
+	Compression     string // none, gzip, snappy, lz4 or zstd
+	TLS             KafkaTLS
+	SASL            KafkaSASL
+}
+
+// Kafka TLS config struct, the CA file overrides the system roots and the certificate enables client authentication
+type KafkaTLS struct {
+	Enabled            bool
+	CAFile             string
+	CertFile           string
+	KeyFile            string
+	InsecureSkipVerify bool
+}
+
+// Kafka SASL config struct, an empty mechanism disables SASL
+type KafkaSASL struct {
+	Mechanism string // plain, scram-sha-256 or scram-sha-512
+	Username  string
+	Password  string
+}
+
+// Metrics config struct
+type Metrics struct {
+	URL         string
+	ServiceName string
+}
+
+// Service-to-service authentication config struct
+type ServiceAuth struct {
+	ClientID     string // name of this service, also the audience of tokens for its internal routes
+	ClientSecret string
+	TokenURL     string
+}
+
+// Auth service circuit breaker config struct
+type AuthBreaker struct {
+	Threshold int
+	Cooldown  time.Duration
 }
 
 // Timeouts config struct
@@ -49,6 +99,10 @@ type Timeout struct {
 	ServerRead       time.Duration
 	ServerWrite      time.Duration
 	ServerShutdown   time.Duration
+	JWKSCache        time.Duration
+	AuthRequest      time.Duration
+	AuthCache        time.Duration
+	RevocationsCache time.Duration
 }
 
 // Load config file from given path
@@ -82,6 +136,13 @@ func ParseConfig(v *viper.Viper) (*Config, error) {
 	c.Port = v.GetInt("port")
 	c.Env = v.GetString("env")
 	c.AuthURL = v.GetString("auth_url")
+	c.JWKSURL = v.GetString("jwks_url")
+	c.RevokedSessionsURL = v.GetString("revoked_sessions_url")
+
+	// Service auth config
+	c.ServiceAuth.ClientID = v.GetString("service_client_id")
+	c.ServiceAuth.ClientSecret = v.GetString("service_client_secret")
+	c.ServiceAuth.TokenURL = v.GetString("service_token_url")
 	c.DefaultLocation = v.GetString("default_location")
 	c.DefaultInterests = v.GetString("default_interests")
 
@@ -110,7 +171,36 @@ func ParseConfig(v *viper.Viper) (*Config, error) {
 			c.Kafka.Topics[topicName] = v.GetString(key)
 		}
 	}
+	c.Kafka.GroupID = v.GetString("kafka_group_id")
 	c.Kafka.MaxAttempts = v.GetInt("kafka_max_attempts")
+	c.Kafka.WriteBackoffMin, err = parseTimeout(v, "kafka_write_backoff_min")
+	if err != nil {
+		return nil, err
+	}
+	c.Kafka.WriteBackoffMax, err = parseTimeout(v, "kafka_write_backoff_max")
+	if err != nil {
+		return nil, err
+	}
+	c.Kafka.RequiredAcks = v.GetString("kafka_required_acks")
+	c.Kafka.BatchSize = v.GetInt("kafka_batch_size")
+	c.Kafka.BatchBytes = v.GetInt64("kafka_batch_bytes")
+	c.Kafka.BatchTimeout, err = parseTimeout(v, "kafka_batch_timeout")
+	if err != nil {
+		return nil, err
+	}
+	c.Kafka.Compression = v.GetString("kafka_compression")
+	c.Kafka.TLS.Enabled = v.GetBool("kafka_tls_enabled")
+	c.Kafka.TLS.CAFile = v.GetString("kafka_tls_ca_file")
+	c.Kafka.TLS.CertFile = v.GetString("kafka_tls_cert_file")
+	c.Kafka.TLS.KeyFile = v.GetString("kafka_tls_key_file")
+	c.Kafka.TLS.InsecureSkipVerify = v.GetBool("kafka_tls_insecure_skip_verify")
+	c.Kafka.SASL.Mechanism = v.GetString("kafka_sasl_mechanism")
+	c.Kafka.SASL.Username = v.GetString("kafka_sasl_username")
+	c.Kafka.SASL.Password = v.GetString("kafka_sasl_password")
+
+	// Metrics config
+	c.Metrics.URL = v.GetString("metrics_url")
+	c.Metrics.ServiceName = v.GetString("metrics_service_name")
 
 	// Timeout config
 	c.Timeout.PostgreSQLConn, err = parseTimeout(v, "timeout_postgresql_conn")
@@ -137,6 +227,29 @@ func ParseConfig(v *viper.Viper) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	c.Timeout.JWKSCache, err = parseTimeout(v, "timeout_jwks_cache")
+	if err != nil {
+		return nil, err
+	}
+	c.Timeout.AuthRequest, err = parseTimeout(v, "timeout_auth_request")
+	if err != nil {
+		return nil, err
+	}
+	c.Timeout.AuthCache, err = parseTimeout(v, "timeout_auth_cache")
+	if err != nil {
+		return nil, err
+	}
+	c.Timeout.RevocationsCache, err = parseTimeout(v, "timeout_revocations_cache")
+	if err != nil {
+		return nil, err
+	}
+
+	// Auth service circuit breaker config
+	c.AuthBreaker.Threshold = v.GetInt("auth_breaker_threshold")
+	c.AuthBreaker.Cooldown, err = parseTimeout(v, "auth_breaker_cooldown")
+	if err != nil {
+		return nil, err
+	}
 
 	return &c, nil
 }
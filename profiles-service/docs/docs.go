@@ -20,9 +20,12 @@ const docTemplate = `{
                 "security": [
                     {
                         "cookieAuth": []
+                    },
+                    {
+                        "bearerAuth": []
                     }
                 ],
-                "description": "Retrieves user's profile info, including location and preferences.",
+                "description": "Retrieves user's profile info, including location and preferences. The ETag header holds the profile version to send in If-Match when editing it.",
                 "produces": [
                     "application/json"
                 ],
@@ -35,6 +38,12 @@ const docTemplate = `{
                         "description": "success response with profile",
                         "schema": {
                             "$ref": "#/definitions/models.ProfileResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "profile version"
+                            }
                         }
                     },
                     "404": {
@@ -57,9 +66,12 @@ const docTemplate = `{
                 "security": [
                     {
                         "cookieAuth": []
+                    },
+                    {
+                        "bearerAuth": []
                     }
                 ],
-                "description": "Retrieves user's profile info, including location and preferences.",
+                "description": "Updates the fields present in the request: location, weighted interests (a plain tag gets weight 1), disliked interests, age range (0 clears a bound) and language. Interests changes are published to recommendations service. With If-Match the profile is only changed if it still has the given version.",
                 "consumes": [
                     "application/json"
                 ],
@@ -70,11 +82,110 @@ const docTemplate = `{
                     "profiles"
                 ],
                 "summary": "Edit user's profile info",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ETag of the edited profile version",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "profile fields to update",
+                        "name": "profile",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.EditProfileDTO"
+                        }
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "success",
                         "schema": {
                             "$ref": "#/definitions/models.SuccessResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "new profile version"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "bad request error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "not found error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "edit conflict error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "cookieAuth": []
+                    },
+                    {
+                        "bearerAuth": []
+                    }
+                ],
+                "description": "Applies a JSON merge patch (RFC 7396) to the profile. Fields present in the patch are replaced, fields set to null are cleared (interests, age bounds, language) and other fields are kept. Unknown fields and fields of a wrong type are rejected. A patch that changes nothing creates no new version, a user_update event is published only when interests or disliked interests change and lists the changed fields. With If-Match the profile is only changed if it still has the given version.",
+                "consumes": [
+                    "application/merge-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profiles"
+                ],
+                "summary": "Patch user's profile info",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ETag of the patched profile version",
+                        "name": "If-Match",
+                        "in": "header"
+                    },
+                    {
+                        "description": "merge patch of profile fields",
+                        "name": "patch",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PatchProfileDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "success response with the patched profile",
+                        "schema": {
+                            "$ref": "#/definitions/models.ProfileResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "profile version"
+                            }
                         }
                     },
                     "400": {
@@ -89,6 +200,85 @@ const docTemplate = `{
                             "$ref": "#/definitions/models.ErrorResponse"
                         }
                     },
+                    "409": {
+                        "description": "edit conflict error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "unsupported media type error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{uid}": {
+            "get": {
+                "security": [
+                    {
+                        "cookieAuth": []
+                    },
+                    {
+                        "bearerAuth": []
+                    }
+                ],
+                "description": "Retrieves the profile of the given user (requires profiles:read).",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "profiles"
+                ],
+                "summary": "Get another user's profile info",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "success response with profile",
+                        "schema": {
+                            "$ref": "#/definitions/models.ProfileResponse"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "profile version"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "authentication required",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "not permitted",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "not found error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "internal server error",
                         "schema": {
@@ -100,6 +290,35 @@ const docTemplate = `{
         }
     },
     "definitions": {
+        "models.EditProfileDTO": {
+            "type": "object",
+            "properties": {
+                "age_max": {
+                    "type": "integer"
+                },
+                "age_min": {
+                    "type": "integer"
+                },
+                "disliked_interests": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "interests": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Interest"
+                    }
+                },
+                "language": {
+                    "type": "string"
+                },
+                "location": {
+                    "type": "string"
+                }
+            }
+        },
         "models.ErrorResponse": {
             "type": "object",
             "properties": {
@@ -108,15 +327,70 @@ const docTemplate = `{
                 }
             }
         },
-        "models.Profile": {
+        "models.Interest": {
+            "type": "object",
+            "properties": {
+                "tag": {
+                    "type": "string"
+                },
+                "weight": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.PatchProfileDTO": {
             "type": "object",
             "properties": {
+                "age_max": {
+                    "type": "integer"
+                },
+                "age_min": {
+                    "type": "integer"
+                },
+                "disliked_interests": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "interests": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Interest"
+                    }
+                },
+                "language": {
+                    "type": "string"
+                },
+                "location": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Profile": {
+            "type": "object",
+            "properties": {
+                "age_max": {
+                    "type": "integer"
+                },
+                "age_min": {
+                    "type": "integer"
+                },
+                "disliked_interests": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
+                "interests": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Interest"
+                    }
+                },
+                "language": {
+                    "type": "string"
+                },
                 "location": {
                     "type": "string"
                 },
@@ -125,6 +399,9 @@ const docTemplate = `{
                 },
                 "user_uid": {
                     "type": "string"
+                },
+                "version": {
+                    "type": "integer"
                 }
             }
         },
@@ -146,6 +423,12 @@ const docTemplate = `{
         }
     },
     "securityDefinitions": {
+        "bearerAuth": {
+            "description": "\"Bearer \u003caccess token or API key\u003e\"",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        },
         "cookieAuth": {
             "type": "apiKey",
             "name": "token",
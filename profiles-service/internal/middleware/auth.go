@@ -1,52 +1,93 @@
 package middleware
 
 import (
-	"encoding/json"
+	"errors"
 	"net/http"
+	"strings"
 
+	"go.uber.org/zap"
+
+	"cyansnbrst/profiles-service/pkg/authclient"
 	erp "cyansnbrst/profiles-service/pkg/error_responses"
+	"cyansnbrst/profiles-service/pkg/jwtauth"
 )
 
-// Authentication middleware
+// Authentication middleware. Credentials are taken from the Authorization bearer header, which
+// carries an access token or an API key, or else from the token cookie. Access tokens are verified
+// locally with the auth service key set and revoked sessions list, API keys and tokens that can't
+// be verified locally are validated by the auth service.
 func (mw *MiddlewareManager) Authenticate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Add("Vary", "Authorization")
 
-		req, err := http.NewRequest("GET", mw.cfg.AuthURL, nil)
-		if err != nil {
-			erp.ServerErrorResponse(w, r, mw.logger, err)
-			return
-		}
+		identity := &authclient.Identity{}
+		var err error
 
-		for _, cookie := range r.Cookies() {
-			req.AddCookie(cookie)
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && token != "" {
+			if authclient.IsAPIKey(token) {
+				identity, err = mw.identifyAPIKey(r, token)
+			} else {
+				identity, err = mw.identify(r, token)
+			}
+		} else if cookie, cookieErr := r.Cookie("token"); cookieErr == nil {
+			identity, err = mw.identify(r, cookie.Value)
 		}
-
-		client := &http.Client{}
-		resp, err := client.Do(req)
-		if err != nil {
-			erp.ServerErrorResponse(w, r, mw.logger, err)
-			return
-		}
-		defer resp.Body.Close()
-
-		var envelope struct {
-			UserUID string `json:"user_uid"`
-			IsAdmin bool   `json:"is_admin"`
-		}
-
-		err = json.NewDecoder(resp.Body).Decode(&envelope)
 		if err != nil {
 			erp.ServerErrorResponse(w, r, mw.logger, err)
 			return
 		}
 
-		r = ContextSetUserUID(r, envelope.UserUID)
+		r = ContextSetUserUID(r, identity.UserUID)
+		r = ContextSetIsAdmin(r, identity.IsAdmin)
+		r = ContextSetPermissions(r, identity.Permissions)
 
 		next.ServeHTTP(w, r)
 	})
 }
 
+// Resolve the user from the access token, an invalid token means an anonymous user
+func (mw *MiddlewareManager) identify(r *http.Request, token string) (*authclient.Identity, error) {
+	claims, err := mw.verifier.Verify(token)
+	if err == nil {
+		return &authclient.Identity{
+			UserUID:     claims.UserUID,
+			IsAdmin:     claims.IsAdmin,
+			Roles:       claims.Roles,
+			Permissions: claims.Permissions,
+		}, nil
+	}
+	if !errors.Is(err, jwtauth.ErrKeySetUnavailable) && !errors.Is(err, jwtauth.ErrRevocationsUnavailable) {
+		mw.logger.Debug("invalid access token", zap.Error(err))
+		return &authclient.Identity{}, nil
+	}
+
+	mw.logger.Warn("token can't be verified locally, validating token with auth service", zap.Error(err))
+
+	identity, err := mw.authClient.Authenticate(r.Context(), token)
+	if errors.Is(err, authclient.ErrUnauthenticated) {
+		return &authclient.Identity{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return identity, nil
+}
+
+// Resolve the user from an API key, an invalid key means an anonymous user
+func (mw *MiddlewareManager) identifyAPIKey(r *http.Request, key string) (*authclient.Identity, error) {
+	identity, err := mw.authClient.AuthenticateAPIKey(r.Context(), key)
+	if errors.Is(err, authclient.ErrUnauthenticated) {
+		mw.logger.Debug("invalid api key")
+		return &authclient.Identity{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return identity, nil
+}
+
 // Require authentication middleware
 func (mw *MiddlewareManager) RequireAuthenticatedUser(next http.HandlerFunc) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -58,3 +99,22 @@ func (mw *MiddlewareManager) RequireAuthenticatedUser(next http.HandlerFunc) htt
 		next.ServeHTTP(w, r)
 	})
 }
+
+// Require permission middleware
+func (mw *MiddlewareManager) RequirePermission(permission string) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if ContextGetUserUID(r) == "" {
+				erp.AuthenticationRequiredResponse(w, r, mw.logger)
+				return
+			}
+
+			if !jwtauth.HasPermission(ContextGetPermissions(r), permission) {
+				erp.NotPermittedResponse(w, r, mw.logger)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
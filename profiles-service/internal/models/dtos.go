@@ -2,13 +2,23 @@ package models
 
 // Edit profile DTO struct
 type EditProfileDTO struct {
-	Location  *string  `json:"location"`
-	Interests []string `json:"interests"`
+	Location          *string    `json:"location"`
+	Interests         []Interest `json:"interests"`
+	DislikedInterests []string   `json:"disliked_interests"`
+	AgeMin            *int       `json:"age_min"`
+	AgeMax            *int       `json:"age_max"`
+	Language          *string    `json:"language"`
 }
 
-// Create profile DTO struct
-type CreateProfileDTO struct {
-	Name string `json:"name"`
+// Patch profile DTO struct, the editable fields of a profile a JSON merge patch applies to.
+// A field set to null in the patch decodes to its zero value: no interests, no age bound, no language.
+type PatchProfileDTO struct {
+	Location          string     `json:"location"`
+	Interests         []Interest `json:"interests"`
+	DislikedInterests []string   `json:"disliked_interests"`
+	AgeMin            *int       `json:"age_min"`
+	AgeMax            *int       `json:"age_max"`
+	Language          string     `json:"language"`
 }
 
 // Profile response
@@ -1,9 +1,46 @@
 package models
 
+import "encoding/json"
+
 // User's profile model
 type Profile struct {
-	UserUID   string   `json:"user_uid"`
-	Name      string   `json:"name"`
-	Location  string   `json:"location"`
-	Interests []string `json:"interests,omitempty"`
+	UserUID           string     `json:"user_uid"`
+	Name              string     `json:"name"`
+	Location          string     `json:"location"`
+	Interests         []Interest `json:"interests,omitempty"`
+	DislikedInterests []string   `json:"disliked_interests,omitempty"`
+	AgeMin            *int       `json:"age_min,omitempty"`
+	AgeMax            *int       `json:"age_max,omitempty"`
+	Language          string     `json:"language,omitempty"`
+	Version           int64      `json:"version"`
+}
+
+// Interest with its weight, from 0 (exclusive) to 1
+type Interest struct {
+	Tag    string  `json:"tag"`
+	Weight float64 `json:"weight"`
+}
+
+// Decode an interest, a plain tag or an object without weight gets the full weight
+func (i *Interest) UnmarshalJSON(data []byte) error {
+	var tag string
+	if err := json.Unmarshal(data, &tag); err == nil {
+		*i = Interest{Tag: tag, Weight: 1}
+		return nil
+	}
+
+	var aux struct {
+		Tag    string   `json:"tag"`
+		Weight *float64 `json:"weight"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	*i = Interest{Tag: aux.Tag, Weight: 1}
+	if aux.Weight != nil {
+		i.Weight = *aux.Weight
+	}
+
+	return nil
 }
@@ -5,6 +5,7 @@ import "net/http"
 // Profiles handlers interface
 type Handlers interface {
 	GetInfo() http.HandlerFunc
+	GetUserInfo() http.HandlerFunc
 	EditData() http.HandlerFunc
-	CreateProfile() http.HandlerFunc
+	PatchData() http.HandlerFunc
 }
@@ -0,0 +1,121 @@
+package consumers
+
+import (
+	"encoding/json"
+
+	"github.com/segmentio/kafka-go"
+	"go.uber.org/zap"
+
+	"cyansnbrst/profiles-service/config"
+	"cyansnbrst/profiles-service/internal/profiles"
+	kf "cyansnbrst/profiles-service/pkg/kafka"
+)
+
+// Kafka message handlers struct
+type KafkaMessageHandlers struct {
+	cfg        *config.Config
+	profilesUC profiles.UseCase
+	logger     *zap.Logger
+}
+
+// Kafka message handlers constructor
+func NewKafkaMessageHandlers(cfg *config.Config, profilesUC profiles.UseCase, logger *zap.Logger) *KafkaMessageHandlers {
+	return &KafkaMessageHandlers{
+		cfg:        cfg,
+		profilesUC: profilesUC,
+		logger:     logger,
+	}
+}
+
+// Kafka user message handler
+func (h *KafkaMessageHandlers) HandleUserMessage(msg kafka.Message) error {
+	var payload kf.KafkaMessage
+
+	err := json.Unmarshal(msg.Value, &payload)
+	if err != nil {
+		// Retrying can't fix a malformed message, so it is dropped instead of blocking the partition
+		h.logger.Warn("dropping unparsable Kafka message",
+			zap.String("topic", msg.Topic),
+			zap.Int("partition", msg.Partition),
+			zap.Int64("offset", msg.Offset),
+			zap.ByteString("value", msg.Value),
+			zap.Error(err),
+		)
+		return nil
+	}
+
+	userUID := string(msg.Key)
+
+	switch payload.Action {
+	case "user_registered":
+		h.logger.Info("kafka message received",
+			zap.String("key", userUID),
+			zap.String("action", payload.Action),
+			zap.String("time", payload.Time),
+		)
+		err := h.profilesUC.CreateProfile(userUID, payload.Name)
+		if err != nil {
+			h.logger.Error("failed to create profile", zap.Error(err))
+			return err
+		}
+	case "user_delete":
+		h.logger.Info("kafka message received",
+			zap.String("key", userUID),
+			zap.String("action", payload.Action),
+			zap.String("time", payload.Time),
+		)
+		err := h.profilesUC.Delete(userUID)
+		if err != nil {
+			h.logger.Error("failed to delete profile", zap.Error(err))
+			return err
+		}
+	default:
+		// The topic also carries profile updates produced by this service
+		h.logger.Debug("skipping kafka message", zap.String("action", payload.Action))
+	}
+
+	return nil
+}
+
+// Kafka tag message handler
+func (h *KafkaMessageHandlers) HandleTagMessage(msg kafka.Message) error {
+	var payload kf.KafkaMessage
+
+	err := json.Unmarshal(msg.Value, &payload)
+	if err != nil {
+		// Retrying can't fix a malformed message, so it is dropped instead of blocking the partition
+		h.logger.Warn("dropping unparsable Kafka message",
+			zap.String("topic", msg.Topic),
+			zap.Int("partition", msg.Partition),
+			zap.Int64("offset", msg.Offset),
+			zap.ByteString("value", msg.Value),
+			zap.Error(err),
+		)
+		return nil
+	}
+
+	h.logger.Info("kafka message received",
+		zap.String("key", string(msg.Key)),
+		zap.String("action", payload.Action),
+		zap.String("time", payload.Time),
+	)
+
+	switch payload.Action {
+	case "tag_update":
+		err := h.profilesUC.ApplyTagUpdate(payload.Tag, payload.Aliases)
+		if err != nil {
+			h.logger.Error("failed to update tag aliases", zap.Error(err))
+			return err
+		}
+	case "tag_delete":
+		err := h.profilesUC.ApplyTagDelete(payload.Tag)
+		if err != nil {
+			h.logger.Error("failed to delete tag aliases", zap.Error(err))
+			return err
+		}
+	default:
+		h.logger.Warn("unknown action", zap.String("action", payload.Action))
+	}
+
+	return nil
+}
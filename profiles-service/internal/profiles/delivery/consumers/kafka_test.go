@@ -0,0 +1,184 @@
+package consumers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"cyansnbrst/profiles-service/config"
+	mock_profiles "cyansnbrst/profiles-service/internal/profiles/mock"
+)
+
+func TestKafkaMessageHandlers_HandleUserMessage(t *testing.T) {
+	cfg := &config.Config{}
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProfilesUC := mock_profiles.NewMockUseCase(ctrl)
+	kafkaHandlers := NewKafkaMessageHandlers(cfg, mockProfilesUC, logger)
+
+	tests := []struct {
+		name         string
+		message      kafka.Message
+		mockBehavior func(mockProfilesUC *mock_profiles.MockUseCase)
+		wantErr      bool
+	}{
+		{
+			name: "valid user delete message",
+			message: kafka.Message{
+				Key:   []byte("user1234"),
+				Value: []byte(`{"action":"user_delete","time":"2023-01-01T12:00:00Z"}`),
+			},
+			mockBehavior: func(mockProfilesUC *mock_profiles.MockUseCase) {
+				mockProfilesUC.EXPECT().Delete("user1234").Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid user registered message",
+			message: kafka.Message{
+				Key:   []byte("user1234"),
+				Value: []byte(`{"action":"user_registered","name":"user","time":"2023-01-01T12:00:00Z"}`),
+			},
+			mockBehavior: func(mockProfilesUC *mock_profiles.MockUseCase) {
+				mockProfilesUC.EXPECT().CreateProfile("user1234", "user").Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "user registered error",
+			message: kafka.Message{
+				Key:   []byte("user1234"),
+				Value: []byte(`{"action":"user_registered","name":"user","time":"2023-01-01T12:00:00Z"}`),
+			},
+			mockBehavior: func(mockProfilesUC *mock_profiles.MockUseCase) {
+				mockProfilesUC.EXPECT().CreateProfile("user1234", "user").Return(errors.New("db error"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "user update message is skipped",
+			message: kafka.Message{
+				Key:   []byte("user1234"),
+				Value: []byte(`{"action":"user_update","tags":["tag1"],"time":"2023-01-01T12:00:00Z"}`),
+			},
+			mockBehavior: func(mockProfilesUC *mock_profiles.MockUseCase) {},
+			wantErr:      false,
+		},
+		{
+			name: "invalid JSON format is dropped",
+			message: kafka.Message{
+				Key:   []byte("user1234"),
+				Value: []byte("invalid_json"),
+			},
+			mockBehavior: func(mockProfilesUC *mock_profiles.MockUseCase) {},
+			wantErr:      false,
+		},
+		{
+			name: "user delete error",
+			message: kafka.Message{
+				Key:   []byte("user1234"),
+				Value: []byte(`{"action":"user_delete","time":"2023-01-01T12:00:00Z"}`),
+			},
+			mockBehavior: func(mockProfilesUC *mock_profiles.MockUseCase) {
+				mockProfilesUC.EXPECT().Delete("user1234").Return(errors.New("db error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockProfilesUC)
+
+			err := kafkaHandlers.HandleUserMessage(tt.message)
+
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestKafkaMessageHandlers_HandleTagMessage(t *testing.T) {
+	cfg := &config.Config{}
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProfilesUC := mock_profiles.NewMockUseCase(ctrl)
+	kafkaHandlers := NewKafkaMessageHandlers(cfg, mockProfilesUC, logger)
+
+	tests := []struct {
+		name         string
+		message      kafka.Message
+		mockBehavior func(mockProfilesUC *mock_profiles.MockUseCase)
+		wantErr      bool
+	}{
+		{
+			name: "valid tag update message",
+			message: kafka.Message{
+				Key:   []byte("rock"),
+				Value: []byte(`{"action":"tag_update","tag":"rock","parent":"music","aliases":["rock music"],"time":"2023-01-01T12:00:00Z"}`),
+			},
+			mockBehavior: func(mockProfilesUC *mock_profiles.MockUseCase) {
+				mockProfilesUC.EXPECT().ApplyTagUpdate("rock", []string{"rock music"}).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid tag delete message",
+			message: kafka.Message{
+				Key:   []byte("rock"),
+				Value: []byte(`{"action":"tag_delete","tag":"rock","time":"2023-01-01T12:00:00Z"}`),
+			},
+			mockBehavior: func(mockProfilesUC *mock_profiles.MockUseCase) {
+				mockProfilesUC.EXPECT().ApplyTagDelete("rock").Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "tag update error",
+			message: kafka.Message{
+				Key:   []byte("rock"),
+				Value: []byte(`{"action":"tag_update","tag":"rock","time":"2023-01-01T12:00:00Z"}`),
+			},
+			mockBehavior: func(mockProfilesUC *mock_profiles.MockUseCase) {
+				mockProfilesUC.EXPECT().ApplyTagUpdate("rock", nil).Return(errors.New("db error"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid JSON format is dropped",
+			message: kafka.Message{
+				Key:   []byte("rock"),
+				Value: []byte("invalid_json"),
+			},
+			mockBehavior: func(mockProfilesUC *mock_profiles.MockUseCase) {},
+			wantErr:      false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockProfilesUC)
+
+			err := kafkaHandlers.HandleTagMessage(tt.message)
+
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -1,10 +1,13 @@
 package http
 
 import (
+	"encoding/json"
 	"errors"
 	"net/http"
+	"slices"
 	"time"
 
+	"github.com/julienschmidt/httprouter"
 	"github.com/segmentio/kafka-go"
 	"go.uber.org/zap"
 
@@ -15,12 +18,10 @@ import (
 	"cyansnbrst/profiles-service/pkg/db"
 	erp "cyansnbrst/profiles-service/pkg/error_responses"
 	kf "cyansnbrst/profiles-service/pkg/kafka"
+	"cyansnbrst/profiles-service/pkg/mergepatch"
 	"cyansnbrst/profiles-service/pkg/utils"
 )
 
-// Validation errors
-var errNameRequired = errors.New("name is required")
-
 // Profiles handlers
 type profilesHandlers struct {
 	cfg         *config.Config
@@ -40,11 +41,13 @@ func NewProfilesHandlers(cfg *config.Config, profilesUC profiles.UseCase, logger
 }
 
 // @Summary		Get user's profile info
-// @Description	Retrieves user's profile info, including location and preferences.
+// @Description	Retrieves user's profile info, including location and preferences. The ETag header holds the profile version to send in If-Match when editing it.
 // @Tags			profiles
 // @Produce		json
 // @Security		cookieAuth
+// @Security		bearerAuth
 // @Success		200	{object}	models.ProfileResponse	"success response with profile"
+// @Header			200	{string}	ETag					"profile version"
 // @Failure		404	{object}	models.ErrorResponse	"not found error"
 // @Failure		500	{object}	models.ErrorResponse	"internal server error"
 // @Router			/ [get]
@@ -58,9 +61,52 @@ func (h *profilesHandlers) GetInfo() http.HandlerFunc {
 			return
 		}
 
+		headers := make(http.Header)
+		headers.Set("ETag", utils.VersionETag(profile.Version))
+
+		err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+			"profile": profile,
+		}, headers)
+		if err != nil {
+			erp.ServerErrorResponse(w, r, h.logger, err)
+		}
+	}
+}
+
+// @Summary		Get another user's profile info
+// @Description	Retrieves the profile of the given user (requires profiles:read).
+// @Tags			profiles
+// @Produce		json
+// @Security		cookieAuth
+// @Security		bearerAuth
+// @Param			uid	path		string					true	"User UID"
+// @Success		200	{object}	models.ProfileResponse	"success response with profile"
+// @Header			200	{string}	ETag					"profile version"
+// @Failure		401	{object}	models.ErrorResponse	"authentication required"
+// @Failure		403	{object}	models.ErrorResponse	"not permitted"
+// @Failure		404	{object}	models.ErrorResponse	"not found error"
+// @Failure		500	{object}	models.ErrorResponse	"internal server error"
+// @Router			/users/{uid} [get]
+func (h *profilesHandlers) GetUserInfo() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userUID := httprouter.ParamsFromContext(r.Context()).ByName("uid")
+
+		profile, err := h.profilesUC.Get(userUID)
+		if err != nil {
+			if errors.Is(err, db.ErrRecordNotFound) {
+				erp.NotFoundResponse(w, r, h.logger)
+			} else {
+				erp.ServerErrorResponse(w, r, h.logger, err)
+			}
+			return
+		}
+
+		headers := make(http.Header)
+		headers.Set("ETag", utils.VersionETag(profile.Version))
+
 		err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{
 			"profile": profile,
-		}, nil)
+		}, headers)
 		if err != nil {
 			erp.ServerErrorResponse(w, r, h.logger, err)
 		}
@@ -68,43 +114,55 @@ func (h *profilesHandlers) GetInfo() http.HandlerFunc {
 }
 
 // @Summary		Edit user's profile info
-// @Description	Retrieves user's profile info, including location and preferences.
+// @Description	Updates the fields present in the request: location, weighted interests (a plain tag gets weight 1), disliked interests, age range (0 clears a bound) and language. Interests changes are published to recommendations service. With If-Match the profile is only changed if it still has the given version.
 // @Tags			profiles
 // @Accept			json
 // @Produce		json
+// @Param			If-Match	header		string					false	"ETag of the edited profile version"
+// @Param			profile		body		models.EditProfileDTO	true	"profile fields to update"
 // @Security		cookieAuth
+// @Security		bearerAuth
 // @Success		200	{object}	models.SuccessResponse	"success"
+// @Header			200	{string}	ETag					"new profile version"
 // @Failure		400	{object}	models.ErrorResponse	"bad request error"
 // @Failure		404	{object}	models.ErrorResponse	"not found error"
+// @Failure		409	{object}	models.ErrorResponse	"edit conflict error"
 // @Failure		500	{object}	models.ErrorResponse	"internal server error"
 // @Router			/edit [put]
 func (h *profilesHandlers) EditData() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		userUID := r.Context().Value(middleware.UserContextKey).(string)
 
+		version, err := utils.ReadIfMatch(r)
+		if err != nil {
+			erp.BadRequestResponse(w, r, h.logger, err)
+			return
+		}
+
 		var requestData models.EditProfileDTO
 
-		if err := utils.ReadJSON(w, r, &requestData); err != nil {
+		if err = utils.ReadJSON(w, r, &requestData); err != nil {
 			erp.BadRequestResponse(w, r, h.logger, err)
 			return
 		}
 
-		err := h.profilesUC.Update(userUID, requestData.Location, requestData.Interests)
+		profile, err := h.profilesUC.Update(userUID, version, &requestData)
 		if err != nil {
-			if errors.Is(err, db.ErrRecordNotFound) {
+			switch {
+			case errors.Is(err, db.ErrRecordNotFound):
 				erp.NotFoundResponse(w, r, h.logger)
-			} else {
+			case errors.Is(err, db.ErrEditConflict):
+				erp.EditConflictResponse(w, r, h.logger)
+			case errors.Is(err, profiles.ErrInvalidProfile):
+				erp.BadRequestResponse(w, r, h.logger, err)
+			default:
 				erp.ServerErrorResponse(w, r, h.logger, err)
 			}
 			return
 		}
 
-		if requestData.Interests != nil {
-			messagePayload := kf.KafkaMessage{
-				Action: "user_update",
-				Time:   time.Now().Format(time.RFC3339),
-				Tags:   requestData.Interests,
-			}
+		if requestData.Interests != nil || requestData.DislikedInterests != nil {
+			messagePayload := userUpdateMessage(profile)
 
 			err = h.profilesUC.SendToKafka(r.Context(), userUID, messagePayload, h.kafkaWriter)
 			if err != nil {
@@ -113,46 +171,111 @@ func (h *profilesHandlers) EditData() http.HandlerFunc {
 			}
 		}
 
+		headers := make(http.Header)
+		headers.Set("ETag", utils.VersionETag(profile.Version))
+
 		err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{
 			"message": "profile updated successfully",
-		}, nil)
+		}, headers)
 		if err != nil {
 			erp.ServerErrorResponse(w, r, h.logger, err)
 		}
 	}
 }
 
-// Create new user profile
-func (h *profilesHandlers) CreateProfile() http.HandlerFunc {
+// @Summary		Patch user's profile info
+// @Description	Applies a JSON merge patch (RFC 7396) to the profile. Fields present in the patch are replaced, fields set to null are cleared (interests, age bounds, language) and other fields are kept. Unknown fields and fields of a wrong type are rejected. A patch that changes nothing creates no new version, a user_update event is published only when interests or disliked interests change and lists the changed fields. With If-Match the profile is only changed if it still has the given version.
+// @Tags			profiles
+// @Accept			application/merge-patch+json
+// @Produce		json
+// @Param			If-Match	header		string					false	"ETag of the patched profile version"
+// @Param			patch		body		models.PatchProfileDTO	true	"merge patch of profile fields"
+// @Security		cookieAuth
+// @Security		bearerAuth
+// @Success		200	{object}	models.ProfileResponse	"success response with the patched profile"
+// @Header			200	{string}	ETag					"profile version"
+// @Failure		400	{object}	models.ErrorResponse	"bad request error"
+// @Failure		404	{object}	models.ErrorResponse	"not found error"
+// @Failure		409	{object}	models.ErrorResponse	"edit conflict error"
+// @Failure		415	{object}	models.ErrorResponse	"unsupported media type error"
+// @Failure		500	{object}	models.ErrorResponse	"internal server error"
+// @Router			/edit [patch]
+func (h *profilesHandlers) PatchData() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		userUID := utils.ReadUIDParam(r)
-		if userUID == "" {
-			erp.NotFoundResponse(w, r, h.logger)
+		userUID := r.Context().Value(middleware.UserContextKey).(string)
+
+		if !mergepatch.IsContentType(r.Header.Get("Content-Type")) {
+			erp.UnsupportedMediaTypeResponse(w, r, h.logger)
 			return
 		}
 
-		var requestData models.CreateProfileDTO
-
-		if err := utils.ReadJSON(w, r, &requestData); err != nil {
+		version, err := utils.ReadIfMatch(r)
+		if err != nil {
 			erp.BadRequestResponse(w, r, h.logger, err)
 			return
 		}
 
-		if requestData.Name == "" {
-			erp.BadRequestResponse(w, r, h.logger, errNameRequired)
+		var patch json.RawMessage
+
+		if err = utils.ReadJSON(w, r, &patch); err != nil {
+			erp.BadRequestResponse(w, r, h.logger, err)
 			return
 		}
 
-		err := h.profilesUC.CreateProfile(userUID, requestData.Name)
+		profile, changed, err := h.profilesUC.Patch(userUID, version, patch)
 		if err != nil {
-			erp.ServerErrorResponse(w, r, h.logger, err)
+			switch {
+			case errors.Is(err, db.ErrRecordNotFound):
+				erp.NotFoundResponse(w, r, h.logger)
+			case errors.Is(err, db.ErrEditConflict):
+				erp.EditConflictResponse(w, r, h.logger)
+			case errors.Is(err, mergepatch.ErrInvalidPatch),
+				errors.Is(err, profiles.ErrInvalidProfile):
+				erp.BadRequestResponse(w, r, h.logger, err)
+			default:
+				erp.ServerErrorResponse(w, r, h.logger, err)
+			}
+			return
+		}
+
+		if slices.Contains(changed, "interests") || slices.Contains(changed, "disliked_interests") {
+			messagePayload := userUpdateMessage(profile)
+			messagePayload.Changed = changed
+
+			err = h.profilesUC.SendToKafka(r.Context(), userUID, messagePayload, h.kafkaWriter)
+			if err != nil {
+				erp.ServerErrorResponse(w, r, h.logger, err)
+				return
+			}
 		}
 
-		err = utils.WriteJSON(w, http.StatusCreated, utils.Envelope{
-			"message": "profile created successfully",
-		}, nil)
+		headers := make(http.Header)
+		headers.Set("ETag", utils.VersionETag(profile.Version))
+
+		err = utils.WriteJSON(w, http.StatusOK, utils.Envelope{
+			"profile": profile,
+		}, headers)
 		if err != nil {
 			erp.ServerErrorResponse(w, r, h.logger, err)
 		}
 	}
 }
+
+// Build the user_update event with the profile's weighted and disliked interests,
+// tags list the liked interests for consumers unaware of weights
+func userUpdateMessage(profile *models.Profile) kf.KafkaMessage {
+	tags := make([]string, len(profile.Interests))
+	interests := make([]kf.WeightedTag, len(profile.Interests))
+	for i, interest := range profile.Interests {
+		tags[i] = interest.Tag
+		interests[i] = kf.WeightedTag{Tag: interest.Tag, Weight: interest.Weight}
+	}
+
+	return kf.KafkaMessage{
+		Action:       "user_update",
+		Time:         time.Now().Format(time.RFC3339),
+		Tags:         tags,
+		Interests:    interests,
+		DislikedTags: profile.DislikedInterests,
+	}
+}
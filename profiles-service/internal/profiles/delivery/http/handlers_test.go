@@ -3,6 +3,7 @@ package http
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -17,7 +18,11 @@ import (
 	"cyansnbrst/profiles-service/config"
 	"cyansnbrst/profiles-service/internal/middleware"
 	"cyansnbrst/profiles-service/internal/models"
+	"cyansnbrst/profiles-service/internal/profiles"
 	mock_profiles "cyansnbrst/profiles-service/internal/profiles/mock"
+	"cyansnbrst/profiles-service/pkg/db"
+	kf "cyansnbrst/profiles-service/pkg/kafka"
+	"cyansnbrst/profiles-service/pkg/mergepatch"
 )
 
 func TestProfilesHandlers_GetInfo(t *testing.T) {
@@ -70,6 +75,66 @@ func TestProfilesHandlers_GetInfo(t *testing.T) {
 	}
 }
 
+func TestProfilesHandlers_GetUserInfo(t *testing.T) {
+	cfg := &config.Config{}
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProfilesUC := mock_profiles.NewMockUseCase(ctrl)
+	mockKafkaWriter := &kafka.Writer{}
+	profilesHandlers := NewProfilesHandlers(cfg, mockProfilesUC, logger, mockKafkaWriter)
+
+	tests := []struct {
+		name         string
+		userUID      string
+		mockBehavior func(mockProfilesUC *mock_profiles.MockUseCase)
+		expectStatus int
+	}{
+		{
+			name:    "success",
+			userUID: "53345",
+			mockBehavior: func(mockProfilesUC *mock_profiles.MockUseCase) {
+				mockProfilesUC.EXPECT().Get("53345").Return(&models.Profile{UserUID: "53345", Version: 2}, nil)
+			},
+			expectStatus: http.StatusOK,
+		},
+		{
+			name:    "not found",
+			userUID: "532",
+			mockBehavior: func(mockProfilesUC *mock_profiles.MockUseCase) {
+				mockProfilesUC.EXPECT().Get("532").Return(nil, db.ErrRecordNotFound)
+			},
+			expectStatus: http.StatusNotFound,
+		},
+		{
+			name:    "database error",
+			userUID: "533",
+			mockBehavior: func(mockProfilesUC *mock_profiles.MockUseCase) {
+				mockProfilesUC.EXPECT().Get("533").Return(nil, errors.New("db error"))
+			},
+			expectStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockProfilesUC)
+
+			req := httptest.NewRequest(http.MethodGet, "/profiles/users/"+tt.userUID, nil)
+			req = req.WithContext(context.WithValue(req.Context(), httprouter.ParamsKey, httprouter.Params{
+				{Key: "uid", Value: tt.userUID},
+			}))
+
+			rr := httptest.NewRecorder()
+			profilesHandlers.GetUserInfo().ServeHTTP(rr, req)
+			require.Equal(t, tt.expectStatus, rr.Code)
+		})
+	}
+}
+
 func TestProfilesHandlers_EditData(t *testing.T) {
 	cfg := &config.Config{}
 	logger := zap.NewNop()
@@ -82,10 +147,12 @@ func TestProfilesHandlers_EditData(t *testing.T) {
 	profilesHandlers := NewProfilesHandlers(cfg, mockProfilesUC, logger, mockKafkaWriter)
 
 	newLocation := "Obninsk"
+	version := int64(3)
 
 	tests := []struct {
 		name         string
 		userUID      string
+		ifMatch      string
 		requestBody  string
 		mockBehavior func(mockProfilesUC *mock_profiles.MockUseCase)
 		expectStatus int
@@ -93,13 +160,86 @@ func TestProfilesHandlers_EditData(t *testing.T) {
 		{
 			name:        "success",
 			userUID:     "234",
-			requestBody: `{"location":"Obninsk","interests":["music","sports"]}`,
+			requestBody: `{"location":"Obninsk","interests":["music",{"tag":"sports","weight":0.5}],"disliked_interests":["horror"]}`,
 			mockBehavior: func(mockProfilesUC *mock_profiles.MockUseCase) {
-				mockProfilesUC.EXPECT().Update("234", &newLocation, []string{"music", "sports"}).Return(nil)
-				mockProfilesUC.EXPECT().SendToKafka(gomock.Any(), gomock.Any(), gomock.Any(), mockKafkaWriter).Return(nil)
+				input := &models.EditProfileDTO{
+					Location:          &newLocation,
+					Interests:         []models.Interest{{Tag: "music", Weight: 1}, {Tag: "sports", Weight: 0.5}},
+					DislikedInterests: []string{"horror"},
+				}
+				profile := &models.Profile{
+					UserUID:           "234",
+					Location:          newLocation,
+					Interests:         input.Interests,
+					DislikedInterests: input.DislikedInterests,
+				}
+				mockProfilesUC.EXPECT().Update("234", nil, input).Return(profile, nil)
+				mockProfilesUC.EXPECT().SendToKafka(gomock.Any(), "234", gomock.Any(), mockKafkaWriter).
+					DoAndReturn(func(_ context.Context, _ string, message kf.KafkaMessage, _ *kafka.Writer) error {
+						require.Equal(t, "user_update", message.Action)
+						require.Equal(t, []string{"music", "sports"}, message.Tags)
+						require.Equal(t, []kf.WeightedTag{{Tag: "music", Weight: 1}, {Tag: "sports", Weight: 0.5}}, message.Interests)
+						require.Equal(t, []string{"horror"}, message.DislikedTags)
+						return nil
+					})
 			},
 			expectStatus: http.StatusOK,
 		},
+		{
+			name:        "location only",
+			userUID:     "234",
+			requestBody: `{"location":"Obninsk"}`,
+			mockBehavior: func(mockProfilesUC *mock_profiles.MockUseCase) {
+				mockProfilesUC.EXPECT().Update("234", nil, &models.EditProfileDTO{Location: &newLocation}).Return(&models.Profile{UserUID: "234"}, nil)
+			},
+			expectStatus: http.StatusOK,
+		},
+		{
+			name:        "invalid profile",
+			userUID:     "234",
+			requestBody: `{"interests":[{"tag":"music","weight":2}]}`,
+			mockBehavior: func(mockProfilesUC *mock_profiles.MockUseCase) {
+				mockProfilesUC.EXPECT().Update("234", nil, gomock.Any()).Return(nil, profiles.ErrInvalidProfile)
+			},
+			expectStatus: http.StatusBadRequest,
+		},
+		{
+			name:        "not found",
+			userUID:     "235",
+			requestBody: `{"location":"Obninsk"}`,
+			mockBehavior: func(mockProfilesUC *mock_profiles.MockUseCase) {
+				mockProfilesUC.EXPECT().Update("235", nil, gomock.Any()).Return(nil, db.ErrRecordNotFound)
+			},
+			expectStatus: http.StatusNotFound,
+		},
+		{
+			name:        "edited version is current",
+			userUID:     "234",
+			ifMatch:     `"3"`,
+			requestBody: `{"location":"Obninsk"}`,
+			mockBehavior: func(mockProfilesUC *mock_profiles.MockUseCase) {
+				mockProfilesUC.EXPECT().Update("234", &version, &models.EditProfileDTO{Location: &newLocation}).Return(&models.Profile{UserUID: "234", Version: 4}, nil)
+			},
+			expectStatus: http.StatusOK,
+		},
+		{
+			name:        "profile changed since it was read",
+			userUID:     "234",
+			ifMatch:     `"3"`,
+			requestBody: `{"location":"Obninsk"}`,
+			mockBehavior: func(mockProfilesUC *mock_profiles.MockUseCase) {
+				mockProfilesUC.EXPECT().Update("234", &version, gomock.Any()).Return(nil, db.ErrEditConflict)
+			},
+			expectStatus: http.StatusConflict,
+		},
+		{
+			name:         "invalid If-Match header",
+			userUID:      "234",
+			ifMatch:      "3",
+			requestBody:  `{"location":"Obninsk"}`,
+			mockBehavior: func(mockProfilesUC *mock_profiles.MockUseCase) {},
+			expectStatus: http.StatusBadRequest,
+		},
 		{
 			name:         "invalid JSON",
 			userUID:      "54",
@@ -116,6 +256,9 @@ func TestProfilesHandlers_EditData(t *testing.T) {
 
 			req := httptest.NewRequest(http.MethodPut, "/profiles/edit", strings.NewReader(tt.requestBody))
 			req = req.WithContext(context.WithValue(req.Context(), middleware.UserContextKey, tt.userUID))
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
 
 			rr := httptest.NewRecorder()
 			profilesHandlers.EditData().ServeHTTP(rr, req)
@@ -124,7 +267,7 @@ func TestProfilesHandlers_EditData(t *testing.T) {
 	}
 }
 
-func TestProfilesHandlers_CreateProfile(t *testing.T) {
+func TestProfilesHandlers_PatchData(t *testing.T) {
 	cfg := &config.Config{}
 	logger := zap.NewNop()
 
@@ -135,29 +278,75 @@ func TestProfilesHandlers_CreateProfile(t *testing.T) {
 	mockKafkaWriter := &kafka.Writer{}
 	profilesHandlers := NewProfilesHandlers(cfg, mockProfilesUC, logger, mockKafkaWriter)
 
+	version := int64(3)
+
 	tests := []struct {
 		name         string
-		userUID      string
+		ifMatch      string
+		contentType  string
 		requestBody  string
 		mockBehavior func(mockProfilesUC *mock_profiles.MockUseCase)
 		expectStatus int
+		expectETag   string
 	}{
 		{
-			name:        "success",
-			userUID:     "543",
-			requestBody: `{"name":"user"}`,
+			name:        "patch of interests publishes the changed fields",
+			ifMatch:     `"3"`,
+			contentType: "application/merge-patch+json",
+			requestBody: `{"interests":["music"],"age_min":null}`,
 			mockBehavior: func(mockProfilesUC *mock_profiles.MockUseCase) {
-				mockProfilesUC.EXPECT().CreateProfile("543", "user").Return(nil)
+				profile := &models.Profile{UserUID: "234", Interests: []models.Interest{{Tag: "music", Weight: 1}}, Version: 4}
+				mockProfilesUC.EXPECT().Patch("234", &version, []byte(`{"interests":["music"],"age_min":null}`)).
+					Return(profile, []string{"interests", "age_min"}, nil)
+				mockProfilesUC.EXPECT().SendToKafka(gomock.Any(), "234", gomock.Any(), mockKafkaWriter).
+					DoAndReturn(func(_ context.Context, _ string, message kf.KafkaMessage, _ *kafka.Writer) error {
+						require.Equal(t, "user_update", message.Action)
+						require.Equal(t, []string{"music"}, message.Tags)
+						require.Equal(t, []string{"interests", "age_min"}, message.Changed)
+						return nil
+					})
 			},
-			expectStatus: http.StatusCreated,
+			expectStatus: http.StatusOK,
+			expectETag:   `"4"`,
 		},
 		{
-			name:         "missing name",
-			userUID:      "12345",
-			requestBody:  `{}`,
-			mockBehavior: func(mockProfilesUC *mock_profiles.MockUseCase) {},
+			name:        "patch outside interests publishes nothing",
+			contentType: "application/json",
+			requestBody: `{"location":"Obninsk"}`,
+			mockBehavior: func(mockProfilesUC *mock_profiles.MockUseCase) {
+				profile := &models.Profile{UserUID: "234", Location: "Obninsk", Version: 4}
+				mockProfilesUC.EXPECT().Patch("234", nil, gomock.Any()).Return(profile, []string{"location"}, nil)
+			},
+			expectStatus: http.StatusOK,
+			expectETag:   `"4"`,
+		},
+		{
+			name:        "invalid patch",
+			contentType: "application/merge-patch+json",
+			requestBody: `{"age_min":"young"}`,
+			mockBehavior: func(mockProfilesUC *mock_profiles.MockUseCase) {
+				mockProfilesUC.EXPECT().Patch("234", nil, gomock.Any()).
+					Return(nil, nil, fmt.Errorf("%w: incorrect JSON type for field \"age_min\"", mergepatch.ErrInvalidPatch))
+			},
 			expectStatus: http.StatusBadRequest,
 		},
+		{
+			name:        "edit conflict",
+			ifMatch:     `"3"`,
+			contentType: "application/merge-patch+json",
+			requestBody: `{"location":"Obninsk"}`,
+			mockBehavior: func(mockProfilesUC *mock_profiles.MockUseCase) {
+				mockProfilesUC.EXPECT().Patch("234", &version, gomock.Any()).Return(nil, nil, db.ErrEditConflict)
+			},
+			expectStatus: http.StatusConflict,
+		},
+		{
+			name:         "unsupported content type",
+			contentType:  "text/plain",
+			requestBody:  `{"location":"Obninsk"}`,
+			mockBehavior: func(mockProfilesUC *mock_profiles.MockUseCase) {},
+			expectStatus: http.StatusUnsupportedMediaType,
+		},
 	}
 
 	for _, tt := range tests {
@@ -165,23 +354,19 @@ func TestProfilesHandlers_CreateProfile(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mockBehavior(mockProfilesUC)
 
-			req := httptest.NewRequest(http.MethodPost, "/profiles/create/"+tt.userUID, strings.NewReader(tt.requestBody))
-
-			params := httprouter.Params{
-				httprouter.Param{
-					Key:   "uid",
-					Value: tt.userUID,
-				},
+			req := httptest.NewRequest(http.MethodPatch, "/profiles/edit", strings.NewReader(tt.requestBody))
+			req = req.WithContext(context.WithValue(req.Context(), middleware.UserContextKey, "234"))
+			req.Header.Set("Content-Type", tt.contentType)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
 			}
 
-			ctx := context.WithValue(req.Context(), httprouter.ParamsKey, params)
-			req = req.WithContext(ctx)
-			req = req.WithContext(context.WithValue(req.Context(), middleware.UserContextKey, tt.userUID))
-
 			rr := httptest.NewRecorder()
-			profilesHandlers.CreateProfile().ServeHTTP(rr, req)
+			profilesHandlers.PatchData().ServeHTTP(rr, req)
 			require.Equal(t, tt.expectStatus, rr.Code)
+			if tt.expectETag != "" {
+				require.Equal(t, tt.expectETag, rr.Header().Get("ETag"))
+			}
 		})
 	}
-
 }
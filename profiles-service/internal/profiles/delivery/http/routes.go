@@ -12,6 +12,7 @@ import (
 // Register profile routes
 func RegisterProfileRoutes(router *httprouter.Router, h profiles.Handlers, mw *middleware.MiddlewareManager) {
 	router.HandlerFunc(http.MethodGet, "/profiles", mw.RequireAuthenticatedUser(h.GetInfo()))
+	router.HandlerFunc(http.MethodGet, "/profiles/users/:uid", mw.RequirePermission("profiles:read")(h.GetUserInfo()))
 	router.HandlerFunc(http.MethodPut, "/profiles/edit", mw.RequireAuthenticatedUser(h.EditData()))
-	router.HandlerFunc(http.MethodPost, "/profiles/create/:uid", h.CreateProfile())
+	router.HandlerFunc(http.MethodPatch, "/profiles/edit", mw.RequireAuthenticatedUser(h.PatchData()))
 }
@@ -0,0 +1,8 @@
+package profiles
+
+import "errors"
+
+// Profiles usecase errors
+var (
+	ErrInvalidProfile = errors.New("invalid profile")
+)
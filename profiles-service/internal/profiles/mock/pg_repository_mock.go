@@ -35,7 +35,7 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 }
 
 // CreateProfile mocks base method.
-func (m *MockRepository) CreateProfile(uid, name, defaultLocation string, defaultInterests []string) error {
+func (m *MockRepository) CreateProfile(uid, name, defaultLocation string, defaultInterests []models.Interest) error {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "CreateProfile", uid, name, defaultLocation, defaultInterests)
 	ret0, _ := ret[0].(error)
@@ -48,6 +48,34 @@ func (mr *MockRepositoryMockRecorder) CreateProfile(uid, name, defaultLocation,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateProfile", reflect.TypeOf((*MockRepository)(nil).CreateProfile), uid, name, defaultLocation, defaultInterests)
 }
 
+// Delete mocks base method.
+func (m *MockRepository) Delete(uid string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", uid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockRepositoryMockRecorder) Delete(uid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockRepository)(nil).Delete), uid)
+}
+
+// DeleteTagAliases mocks base method.
+func (m *MockRepository) DeleteTagAliases(tag string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTagAliases", tag)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTagAliases indicates an expected call of DeleteTagAliases.
+func (mr *MockRepositoryMockRecorder) DeleteTagAliases(tag interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTagAliases", reflect.TypeOf((*MockRepository)(nil).DeleteTagAliases), tag)
+}
+
 // Get mocks base method.
 func (m *MockRepository) Get(uid string) (*models.Profile, error) {
 	m.ctrl.T.Helper()
@@ -63,6 +91,35 @@ func (mr *MockRepositoryMockRecorder) Get(uid interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockRepository)(nil).Get), uid)
 }
 
+// ReplaceTagAliases mocks base method.
+func (m *MockRepository) ReplaceTagAliases(tag string, aliases []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplaceTagAliases", tag, aliases)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReplaceTagAliases indicates an expected call of ReplaceTagAliases.
+func (mr *MockRepositoryMockRecorder) ReplaceTagAliases(tag, aliases interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplaceTagAliases", reflect.TypeOf((*MockRepository)(nil).ReplaceTagAliases), tag, aliases)
+}
+
+// ResolveAliases mocks base method.
+func (m *MockRepository) ResolveAliases(tags []string) (map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveAliases", tags)
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResolveAliases indicates an expected call of ResolveAliases.
+func (mr *MockRepositoryMockRecorder) ResolveAliases(tags interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveAliases", reflect.TypeOf((*MockRepository)(nil).ResolveAliases), tags)
+}
+
 // Update mocks base method.
 func (m *MockRepository) Update(arg0 *models.Profile) error {
 	m.ctrl.T.Helper()
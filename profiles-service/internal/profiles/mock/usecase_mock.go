@@ -37,6 +37,34 @@ func (m *MockUseCase) EXPECT() *MockUseCaseMockRecorder {
 	return m.recorder
 }
 
+// ApplyTagDelete mocks base method.
+func (m *MockUseCase) ApplyTagDelete(tag string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyTagDelete", tag)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ApplyTagDelete indicates an expected call of ApplyTagDelete.
+func (mr *MockUseCaseMockRecorder) ApplyTagDelete(tag interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyTagDelete", reflect.TypeOf((*MockUseCase)(nil).ApplyTagDelete), tag)
+}
+
+// ApplyTagUpdate mocks base method.
+func (m *MockUseCase) ApplyTagUpdate(tag string, aliases []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApplyTagUpdate", tag, aliases)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ApplyTagUpdate indicates an expected call of ApplyTagUpdate.
+func (mr *MockUseCaseMockRecorder) ApplyTagUpdate(tag, aliases interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyTagUpdate", reflect.TypeOf((*MockUseCase)(nil).ApplyTagUpdate), tag, aliases)
+}
+
 // CreateProfile mocks base method.
 func (m *MockUseCase) CreateProfile(uid, name string) error {
 	m.ctrl.T.Helper()
@@ -51,6 +79,20 @@ func (mr *MockUseCaseMockRecorder) CreateProfile(uid, name interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateProfile", reflect.TypeOf((*MockUseCase)(nil).CreateProfile), uid, name)
 }
 
+// Delete mocks base method.
+func (m *MockUseCase) Delete(uid string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", uid)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockUseCaseMockRecorder) Delete(uid interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockUseCase)(nil).Delete), uid)
+}
+
 // Get mocks base method.
 func (m *MockUseCase) Get(uid string) (*models.Profile, error) {
 	m.ctrl.T.Helper()
@@ -66,6 +108,22 @@ func (mr *MockUseCaseMockRecorder) Get(uid interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockUseCase)(nil).Get), uid)
 }
 
+// Patch mocks base method.
+func (m *MockUseCase) Patch(uid string, version *int64, patch []byte) (*models.Profile, []string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Patch", uid, version, patch)
+	ret0, _ := ret[0].(*models.Profile)
+	ret1, _ := ret[1].([]string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Patch indicates an expected call of Patch.
+func (mr *MockUseCaseMockRecorder) Patch(uid, version, patch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Patch", reflect.TypeOf((*MockUseCase)(nil).Patch), uid, version, patch)
+}
+
 // SendToKafka mocks base method.
 func (m *MockUseCase) SendToKafka(ctx context.Context, key string, message kafka.KafkaMessage, writer *kafka0.Writer) error {
 	m.ctrl.T.Helper()
@@ -81,15 +139,16 @@ func (mr *MockUseCaseMockRecorder) SendToKafka(ctx, key, message, writer interfa
 }
 
 // Update mocks base method.
-func (m *MockUseCase) Update(uid string, location *string, interests []string) error {
+func (m *MockUseCase) Update(uid string, version *int64, input *models.EditProfileDTO) (*models.Profile, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", uid, location, interests)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "Update", uid, version, input)
+	ret0, _ := ret[0].(*models.Profile)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // Update indicates an expected call of Update.
-func (mr *MockUseCaseMockRecorder) Update(uid, location, interests interface{}) *gomock.Call {
+func (mr *MockUseCaseMockRecorder) Update(uid, version, input interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockUseCase)(nil).Update), uid, location, interests)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockUseCase)(nil).Update), uid, version, input)
 }
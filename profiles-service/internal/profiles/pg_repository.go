@@ -6,5 +6,9 @@ import "cyansnbrst/profiles-service/internal/models"
 type Repository interface {
 	Get(uid string) (*models.Profile, error)
 	Update(*models.Profile) error
-	CreateProfile(uid string, name string, defaultLocation string, defaultInterests []string) error
+	CreateProfile(uid string, name string, defaultLocation string, defaultInterests []models.Interest) error
+	Delete(uid string) error
+	ResolveAliases(tags []string) (map[string]string, error)
+	ReplaceTagAliases(tag string, aliases []string) error
+	DeleteTagAliases(tag string) error
 }
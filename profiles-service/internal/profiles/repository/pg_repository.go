@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 
 	"github.com/lib/pq"
@@ -27,7 +28,7 @@ func NewProfilesRepository(cfg *config.Config, db *sql.DB) profiles.Repository {
 // Get profile info by UID
 func (r *profilesRepo) Get(uid string) (*models.Profile, error) {
 	query := `
-		SELECT user_uid, name, location, interests
+		SELECT user_uid, name, location, interests, disliked_interests, age_min, age_max, language, version
 		FROM profiles
 		WHERE user_uid = $1`
 
@@ -35,11 +36,17 @@ func (r *profilesRepo) Get(uid string) (*models.Profile, error) {
 	defer cancel()
 
 	var profile models.Profile
+	var interests []byte
 	err := r.db.QueryRowContext(ctx, query, uid).Scan(
 		&profile.UserUID,
 		&profile.Name,
 		&profile.Location,
-		pq.Array(&profile.Interests),
+		&interests,
+		pq.Array(&profile.DislikedInterests),
+		&profile.AgeMin,
+		&profile.AgeMax,
+		&profile.Language,
+		&profile.Version,
 	)
 
 	if err != nil {
@@ -51,20 +58,35 @@ func (r *profilesRepo) Get(uid string) (*models.Profile, error) {
 		}
 	}
 
+	if err = json.Unmarshal(interests, &profile.Interests); err != nil {
+		return nil, err
+	}
+
 	return &profile, nil
 }
 
-// Update profile data (location and interests)
+// Update profile data (location, interests and preferences), the profile must still have the version it was read with
 func (r *profilesRepo) Update(profile *models.Profile) error {
 	query := `
 		UPDATE profiles
-		SET location = $1, interests = $2
-		WHERE user_uid = $3`
+		SET location = $1, interests = $2, disliked_interests = $3, age_min = $4, age_max = $5, language = $6,
+			version = version + 1
+		WHERE user_uid = $7 AND version = $8`
+
+	interests, err := marshalInterests(profile.Interests)
+	if err != nil {
+		return err
+	}
 
 	args := []interface{}{
 		profile.Location,
-		pq.Array(profile.Interests),
+		interests,
+		pq.Array(nonNilTags(profile.DislikedInterests)),
+		profile.AgeMin,
+		profile.AgeMax,
+		profile.Language,
 		profile.UserUID,
+		profile.Version,
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
@@ -81,31 +103,157 @@ func (r *profilesRepo) Update(profile *models.Profile) error {
 	}
 
 	if rowsAffected == 0 {
-		return db.ErrRecordNotFound
+		return db.ErrEditConflict
 	}
 
 	return nil
 }
 
-func (r *profilesRepo) CreateProfile(uid string, name string, defaultLocation string, defaultInterests []string) error {
+// Create profile, does nothing if the profile already exists
+func (r *profilesRepo) CreateProfile(uid string, name string, defaultLocation string, defaultInterests []models.Interest) error {
 	query := `
 		INSERT INTO profiles (user_uid, name, location, interests)
-		VALUES ($1, $2, $3, $4)`
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_uid) DO NOTHING`
+
+	interests, err := marshalInterests(defaultInterests)
+	if err != nil {
+		return err
+	}
 
 	args := []interface{}{
 		uid,
 		name,
 		defaultLocation,
-		pq.Array(defaultInterests),
+		interests,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	_, err = r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
 	}
 
+	return nil
+}
+
+// Delete profile by UID
+func (r *profilesRepo) Delete(uid string) error {
+	query := `
+		DELETE FROM profiles
+		WHERE user_uid = $1`
+
 	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
 	defer cancel()
 
-	_, err := r.db.ExecContext(ctx, query, args...)
+	result, err := r.db.ExecContext(ctx, query, uid)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return err
 	}
 
+	if rowsAffected == 0 {
+		return db.ErrRecordNotFound
+	}
+
 	return nil
 }
+
+// Map aliases among the tags to their canonical tags, other tags are left out
+func (r *profilesRepo) ResolveAliases(tags []string) (map[string]string, error) {
+	query := `
+		SELECT alias, tag
+		FROM tag_aliases
+		WHERE alias = ANY($1)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(tags))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	canonical := make(map[string]string)
+	for rows.Next() {
+		var alias, tag string
+		if err := rows.Scan(&alias, &tag); err != nil {
+			return nil, err
+		}
+		canonical[alias] = tag
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return canonical, nil
+}
+
+// Replace aliases of the tag, an alias that belonged to another tag moves to this one
+func (r *profilesRepo) ReplaceTagAliases(tag string, aliases []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		DELETE FROM tag_aliases
+		WHERE tag = $1 OR alias = ANY($2)`
+
+	if _, err = tx.ExecContext(ctx, query, tag, pq.Array(aliases)); err != nil {
+		return err
+	}
+
+	if len(aliases) > 0 {
+		query = `
+			INSERT INTO tag_aliases (alias, tag)
+			SELECT unnest($1::TEXT[]), $2`
+
+		if _, err = tx.ExecContext(ctx, query, pq.Array(aliases), tag); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Delete aliases of the tag
+func (r *profilesRepo) DeleteTagAliases(tag string) error {
+	query := `
+		DELETE FROM tag_aliases
+		WHERE tag = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, query, tag)
+	return err
+}
+
+// Encode interests for the JSONB column, no interests are stored as an empty array
+func marshalInterests(interests []models.Interest) ([]byte, error) {
+	if interests == nil {
+		interests = []models.Interest{}
+	}
+	return json.Marshal(interests)
+}
+
+// Tags for a NOT NULL array column
+func nonNilTags(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
@@ -12,7 +12,11 @@ import (
 // Profiles usecase interface
 type UseCase interface {
 	Get(uid string) (*models.Profile, error)
-	Update(uid string, location *string, interests []string) error
+	Update(uid string, version *int64, input *models.EditProfileDTO) (*models.Profile, error)
+	Patch(uid string, version *int64, patch []byte) (*models.Profile, []string, error)
 	CreateProfile(uid string, name string) error
+	Delete(uid string) error
+	ApplyTagUpdate(tag string, aliases []string) error
+	ApplyTagDelete(tag string) error
 	SendToKafka(ctx context.Context, key string, message kf.KafkaMessage, writer *kafka.Writer) error
 }
@@ -3,6 +3,11 @@ package usecase
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
 
 	"github.com/segmentio/kafka-go"
 	"go.uber.org/zap"
@@ -10,9 +15,22 @@ import (
 	"cyansnbrst/profiles-service/config"
 	"cyansnbrst/profiles-service/internal/models"
 	"cyansnbrst/profiles-service/internal/profiles"
+	"cyansnbrst/profiles-service/pkg/db"
 	kf "cyansnbrst/profiles-service/pkg/kafka"
+	"cyansnbrst/profiles-service/pkg/mergepatch"
+	"cyansnbrst/profiles-service/pkg/taxonomy"
 )
 
+// Profile limits
+const (
+	maxInterests = 50
+	maxTagLength = 64
+	maxAge       = 120
+)
+
+// ISO 639-1 language code
+var languageRX = regexp.MustCompile(`^[a-z]{2}$`)
+
 // Profiles usecase struct
 type profilesUC struct {
 	cfg          *config.Config
@@ -30,30 +48,144 @@ func (u *profilesUC) Get(uid string) (*models.Profile, error) {
 	return u.profilesRepo.Get(uid)
 }
 
-// Update profile data
-func (u *profilesUC) Update(uid string, location *string, interests []string) error {
+// Update profile data, only the fields present in the input are changed.
+// A non-nil version is the version the client edited, the update fails with an edit conflict if the profile has changed since.
+func (u *profilesUC) Update(uid string, version *int64, input *models.EditProfileDTO) (*models.Profile, error) {
 	profile, err := u.profilesRepo.Get(uid)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	if version != nil && *version != profile.Version {
+		return nil, db.ErrEditConflict
+	}
+
+	if err = u.applyEdit(profile, input); err != nil {
+		return nil, err
+	}
+
+	if err = u.profilesRepo.Update(profile); err != nil {
+		return nil, err
+	}
+	profile.Version++
+
+	return profile, nil
+}
+
+// Apply a JSON merge patch to a profile, a field set to null is cleared.
+// Returns the profile and the fields the patch changed, a patch that changes nothing writes no new version.
+func (u *profilesUC) Patch(uid string, version *int64, patch []byte) (*models.Profile, []string, error) {
+	profile, err := u.profilesRepo.Get(uid)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if version != nil && *version != profile.Version {
+		return nil, nil, db.ErrEditConflict
+	}
+
+	var patched models.PatchProfileDTO
+	if err = mergepatch.Apply(patchFields(profile), patch, &patched); err != nil {
+		return nil, nil, err
+	}
+
+	// Canonicalization edits the slices in place, so the snapshot gets its own copies
+	before := *profile
+	before.Interests = slices.Clone(profile.Interests)
+	before.DislikedInterests = slices.Clone(profile.DislikedInterests)
+
+	input := &models.EditProfileDTO{
+		Location: &patched.Location,
+		AgeMin:   ageInput(patched.AgeMin),
+		AgeMax:   ageInput(patched.AgeMax),
+		Language: &patched.Language,
+	}
+	// Interests are only normalized and resolved again when the patch touches them
+	if !slices.Equal(patched.Interests, profile.Interests) {
+		input.Interests = patched.Interests
+		if input.Interests == nil {
+			input.Interests = []models.Interest{}
+		}
+	}
+	if !slices.Equal(patched.DislikedInterests, profile.DislikedInterests) {
+		input.DislikedInterests = patched.DislikedInterests
+		if input.DislikedInterests == nil {
+			input.DislikedInterests = []string{}
+		}
 	}
 
-	if location != nil {
-		profile.Location = *location
+	if err = u.applyEdit(profile, input); err != nil {
+		return nil, nil, err
 	}
-	if interests != nil {
-		profile.Interests = interests
+
+	changed := changedFields(&before, profile)
+	if len(changed) == 0 {
+		return profile, nil, nil
+	}
+
+	if err = u.profilesRepo.Update(profile); err != nil {
+		return nil, nil, err
+	}
+	profile.Version++
+
+	return profile, changed, nil
+}
+
+// Apply the fields present in the input to the profile and validate it
+func (u *profilesUC) applyEdit(profile *models.Profile, input *models.EditProfileDTO) error {
+	if input.Location != nil {
+		profile.Location = *input.Location
+	}
+	if input.Interests != nil {
+		profile.Interests = normalizeInterests(input.Interests)
+	}
+	if input.DislikedInterests != nil {
+		profile.DislikedInterests = normalizeTags(input.DislikedInterests)
+	}
+	if input.Interests != nil || input.DislikedInterests != nil {
+		if err := u.canonicalize(profile); err != nil {
+			return err
+		}
+	}
+	if input.AgeMin != nil {
+		profile.AgeMin = ageBound(*input.AgeMin)
+	}
+	if input.AgeMax != nil {
+		profile.AgeMax = ageBound(*input.AgeMax)
+	}
+	if input.Language != nil {
+		profile.Language = strings.ToLower(strings.TrimSpace(*input.Language))
 	}
 
-	return u.profilesRepo.Update(profile)
+	return validateProfile(profile)
 }
 
-// Create profile
+// Create profile with default location and interests, an existing profile is left unchanged
 func (u *profilesUC) CreateProfile(uid string, name string) error {
 	defaultLocation := u.cfg.DefaultLocation
-	defaultInterests := []string{u.cfg.DefaultInterests}
+	defaultInterests := []models.Interest{{Tag: u.cfg.DefaultInterests, Weight: 1}}
 	return u.profilesRepo.CreateProfile(uid, name, defaultLocation, defaultInterests)
 }
 
+// Delete profile, a missing profile is not an error
+func (u *profilesUC) Delete(uid string) error {
+	err := u.profilesRepo.Delete(uid)
+	if err != nil && !errors.Is(err, db.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+// Store aliases of a tag from the tag vocabulary
+func (u *profilesUC) ApplyTagUpdate(tag string, aliases []string) error {
+	return u.profilesRepo.ReplaceTagAliases(taxonomy.Normalize(tag), taxonomy.NormalizeAll(aliases))
+}
+
+// Forget aliases of a tag deleted from the tag vocabulary
+func (u *profilesUC) ApplyTagDelete(tag string) error {
+	return u.profilesRepo.DeleteTagAliases(taxonomy.Normalize(tag))
+}
+
 // Send message to kafka
 func (u *profilesUC) SendToKafka(ctx context.Context, key string, message kf.KafkaMessage, writer *kafka.Writer) error {
 	messageValue, err := json.Marshal(message)
@@ -72,3 +204,162 @@ func (u *profilesUC) SendToKafka(ctx context.Context, key string, message kf.Kaf
 
 	return nil
 }
+
+// Replace aliases among interests and disliked interests of the profile with canonical tags
+func (u *profilesUC) canonicalize(profile *models.Profile) error {
+	tags := make([]string, 0, len(profile.Interests)+len(profile.DislikedInterests))
+	for _, interest := range profile.Interests {
+		tags = append(tags, interest.Tag)
+	}
+	tags = append(tags, profile.DislikedInterests...)
+	if len(tags) == 0 {
+		return nil
+	}
+
+	canonical, err := u.profilesRepo.ResolveAliases(tags)
+	if err != nil {
+		return err
+	}
+
+	for i, interest := range profile.Interests {
+		if tag, ok := canonical[interest.Tag]; ok {
+			profile.Interests[i].Tag = tag
+		}
+	}
+	for i, tag := range profile.DislikedInterests {
+		if tag, ok := canonical[tag]; ok {
+			profile.DislikedInterests[i] = tag
+		}
+	}
+
+	return nil
+}
+
+// Check interests, age range and language of the profile
+func validateProfile(profile *models.Profile) error {
+	if len(profile.Interests) > maxInterests || len(profile.DislikedInterests) > maxInterests {
+		return fmt.Errorf("%w: at most %d interests and %d disliked interests are allowed", profiles.ErrInvalidProfile, maxInterests, maxInterests)
+	}
+
+	seen := make(map[string]bool, len(profile.Interests)+len(profile.DislikedInterests))
+	for _, interest := range profile.Interests {
+		if err := validateTag(interest.Tag, seen); err != nil {
+			return err
+		}
+		if interest.Weight <= 0 || interest.Weight > 1 {
+			return fmt.Errorf("%w: weight of interest %q must be greater than 0 and at most 1", profiles.ErrInvalidProfile, interest.Tag)
+		}
+	}
+	for _, tag := range profile.DislikedInterests {
+		if err := validateTag(tag, seen); err != nil {
+			return err
+		}
+	}
+
+	for _, age := range []*int{profile.AgeMin, profile.AgeMax} {
+		if age != nil && (*age < 1 || *age > maxAge) {
+			return fmt.Errorf("%w: age must be between 1 and %d", profiles.ErrInvalidProfile, maxAge)
+		}
+	}
+	if profile.AgeMin != nil && profile.AgeMax != nil && *profile.AgeMin > *profile.AgeMax {
+		return fmt.Errorf("%w: age_min must not be greater than age_max", profiles.ErrInvalidProfile)
+	}
+
+	if profile.Language != "" && !languageRX.MatchString(profile.Language) {
+		return fmt.Errorf("%w: language must be a two-letter ISO 639-1 code", profiles.ErrInvalidProfile)
+	}
+
+	return nil
+}
+
+// Check a tag is not empty, not too long and not listed twice among interests and disliked interests
+func validateTag(tag string, seen map[string]bool) error {
+	switch {
+	case tag == "":
+		return fmt.Errorf("%w: interest must not be empty", profiles.ErrInvalidProfile)
+	case len(tag) > maxTagLength:
+		return fmt.Errorf("%w: interest must be at most %d bytes long", profiles.ErrInvalidProfile, maxTagLength)
+	case seen[tag]:
+		return fmt.Errorf("%w: interest %q is listed more than once", profiles.ErrInvalidProfile, tag)
+	}
+	seen[tag] = true
+	return nil
+}
+
+// Normalize interest tags
+func normalizeInterests(interests []models.Interest) []models.Interest {
+	normalized := make([]models.Interest, len(interests))
+	for i, interest := range interests {
+		normalized[i] = models.Interest{Tag: taxonomy.Normalize(interest.Tag), Weight: interest.Weight}
+	}
+	return normalized
+}
+
+// Normalize tags
+func normalizeTags(tags []string) []string {
+	normalized := make([]string, len(tags))
+	for i, tag := range tags {
+		normalized[i] = taxonomy.Normalize(tag)
+	}
+	return normalized
+}
+
+// Editable fields of a profile, the document a merge patch applies to
+func patchFields(profile *models.Profile) models.PatchProfileDTO {
+	return models.PatchProfileDTO{
+		Location:          profile.Location,
+		Interests:         profile.Interests,
+		DislikedInterests: profile.DislikedInterests,
+		AgeMin:            profile.AgeMin,
+		AgeMax:            profile.AgeMax,
+		Language:          profile.Language,
+	}
+}
+
+// Names of the editable fields that differ between two states of a profile
+func changedFields(before, after *models.Profile) []string {
+	fields := []struct {
+		name    string
+		changed bool
+	}{
+		{"location", before.Location != after.Location},
+		{"interests", !slices.Equal(before.Interests, after.Interests)},
+		{"disliked_interests", !slices.Equal(before.DislikedInterests, after.DislikedInterests)},
+		{"age_min", !equalAge(before.AgeMin, after.AgeMin)},
+		{"age_max", !equalAge(before.AgeMax, after.AgeMax)},
+		{"language", before.Language != after.Language},
+	}
+
+	var changed []string
+	for _, field := range fields {
+		if field.changed {
+			changed = append(changed, field.name)
+		}
+	}
+	return changed
+}
+
+// Check two age bounds are equal, nil is no bound
+func equalAge(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// Age of the edit input for a patched age bound, a cleared bound is zero
+func ageInput(age *int) *int {
+	if age == nil {
+		noAge := 0
+		return &noAge
+	}
+	return age
+}
+
+// Age bound of the profile, zero clears it
+func ageBound(age int) *int {
+	if age == 0 {
+		return nil
+	}
+	return &age
+}
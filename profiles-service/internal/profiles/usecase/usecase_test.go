@@ -10,7 +10,10 @@ import (
 
 	"cyansnbrst/profiles-service/config"
 	"cyansnbrst/profiles-service/internal/models"
+	"cyansnbrst/profiles-service/internal/profiles"
 	mock_profiles "cyansnbrst/profiles-service/internal/profiles/mock"
+	"cyansnbrst/profiles-service/pkg/db"
+	"cyansnbrst/profiles-service/pkg/mergepatch"
 )
 
 func TestProfilesUseCase_Get(t *testing.T) {
@@ -77,36 +80,131 @@ func TestProfilesUseCase_Update(t *testing.T) {
 	profilesUC := NewProfilesUseCase(cfg, mockProfilesRepo, logger)
 
 	newLocaton := "Obninsk"
+	language := " EN "
+	invalidLanguage := "english"
+	ageMin, ageMax, noAge := 25, 34, 0
+	storedAge := 40
+
+	staleVersion := int64(1)
 
 	tests := []struct {
 		name         string
 		uid          string
-		location     *string
-		interests    []string
+		version      *int64
+		input        *models.EditProfileDTO
 		mockBehavior func(mockProfilesRepo *mock_profiles.MockRepository)
-		wantErr      bool
+		want         *models.Profile
+		wantErr      error
 	}{
 		{
-			name:      "success",
-			uid:       "12345",
-			location:  &newLocaton,
-			interests: []string{"music", "sports"},
+			name:    "stale version",
+			uid:     "12345",
+			version: &staleVersion,
+			input:   &models.EditProfileDTO{Location: &newLocaton},
+			mockBehavior: func(mockProfilesRepo *mock_profiles.MockRepository) {
+				mockProfilesRepo.EXPECT().Get("12345").Return(&models.Profile{UserUID: "12345", Location: "Moscow", Version: 2}, nil)
+			},
+			wantErr: db.ErrEditConflict,
+		},
+		{
+			name: "success",
+			uid:  "12345",
+			input: &models.EditProfileDTO{
+				Location:          &newLocaton,
+				Interests:         []models.Interest{{Tag: " Music ", Weight: 1}, {Tag: "sports", Weight: 0.4}},
+				DislikedInterests: []string{"Horror  Movies"},
+				AgeMin:            &ageMin,
+				AgeMax:            &ageMax,
+				Language:          &language,
+			},
 			mockBehavior: func(mockProfilesRepo *mock_profiles.MockRepository) {
-				profile := &models.Profile{UserUID: "12345"}
+				profile := &models.Profile{UserUID: "12345", Version: 1}
 				mockProfilesRepo.EXPECT().Get("12345").Return(profile, nil)
-				mockProfilesRepo.EXPECT().Update(&models.Profile{UserUID: "12345", Location: newLocaton, Interests: []string{"music", "sports"}}).Return(nil)
+				mockProfilesRepo.EXPECT().ResolveAliases([]string{"music", "sports", "horror movies"}).
+					Return(map[string]string{"horror movies": "horror"}, nil)
+				mockProfilesRepo.EXPECT().Update(gomock.Any()).Return(nil)
+			},
+			want: &models.Profile{
+				UserUID:           "12345",
+				Location:          newLocaton,
+				Interests:         []models.Interest{{Tag: "music", Weight: 1}, {Tag: "sports", Weight: 0.4}},
+				DislikedInterests: []string{"horror"},
+				AgeMin:            &ageMin,
+				AgeMax:            &ageMax,
+				Language:          "en",
+				Version:           2,
 			},
-			wantErr: false,
 		},
 		{
-			name:      "profile not found",
-			uid:       "67890",
-			location:  &newLocaton,
-			interests: []string{"reading"},
+			name:  "zero age clears the bound",
+			uid:   "12345",
+			input: &models.EditProfileDTO{AgeMax: &noAge},
 			mockBehavior: func(mockProfilesRepo *mock_profiles.MockRepository) {
-				mockProfilesRepo.EXPECT().Get("67890").Return(nil, errors.New("not found"))
+				profile := &models.Profile{UserUID: "12345", AgeMin: &storedAge, AgeMax: &storedAge, Version: 1}
+				mockProfilesRepo.EXPECT().Get("12345").Return(profile, nil)
+				mockProfilesRepo.EXPECT().Update(gomock.Any()).Return(nil)
 			},
-			wantErr: true,
+			want: &models.Profile{UserUID: "12345", AgeMin: &storedAge, Version: 2},
+		},
+		{
+			name:  "profile not found",
+			uid:   "67890",
+			input: &models.EditProfileDTO{Location: &newLocaton},
+			mockBehavior: func(mockProfilesRepo *mock_profiles.MockRepository) {
+				mockProfilesRepo.EXPECT().Get("67890").Return(nil, db.ErrRecordNotFound)
+			},
+			wantErr: db.ErrRecordNotFound,
+		},
+		{
+			name:  "weight out of range",
+			uid:   "12345",
+			input: &models.EditProfileDTO{Interests: []models.Interest{{Tag: "music", Weight: 1.5}}},
+			mockBehavior: func(mockProfilesRepo *mock_profiles.MockRepository) {
+				mockProfilesRepo.EXPECT().Get("12345").Return(&models.Profile{UserUID: "12345"}, nil)
+				mockProfilesRepo.EXPECT().ResolveAliases([]string{"music"}).Return(map[string]string{}, nil)
+			},
+			wantErr: profiles.ErrInvalidProfile,
+		},
+		{
+			name:  "empty interest",
+			uid:   "12345",
+			input: &models.EditProfileDTO{Interests: []models.Interest{{Tag: "  ", Weight: 1}}},
+			mockBehavior: func(mockProfilesRepo *mock_profiles.MockRepository) {
+				mockProfilesRepo.EXPECT().Get("12345").Return(&models.Profile{UserUID: "12345"}, nil)
+				mockProfilesRepo.EXPECT().ResolveAliases([]string{""}).Return(map[string]string{}, nil)
+			},
+			wantErr: profiles.ErrInvalidProfile,
+		},
+		{
+			name:  "disliked interest is an alias of a liked one",
+			uid:   "12345",
+			input: &models.EditProfileDTO{DislikedInterests: []string{"songs"}},
+			mockBehavior: func(mockProfilesRepo *mock_profiles.MockRepository) {
+				profile := &models.Profile{UserUID: "12345", Interests: []models.Interest{{Tag: "music", Weight: 1}}}
+				mockProfilesRepo.EXPECT().Get("12345").Return(profile, nil)
+				mockProfilesRepo.EXPECT().ResolveAliases([]string{"music", "songs"}).Return(map[string]string{"songs": "music"}, nil)
+			},
+			wantErr: profiles.ErrInvalidProfile,
+		},
+		{
+			name:  "age range against the stored bound",
+			uid:   "12345",
+			input: &models.EditProfileDTO{AgeMin: &ageMin},
+			mockBehavior: func(mockProfilesRepo *mock_profiles.MockRepository) {
+				tooYoung := 20
+				profile := &models.Profile{UserUID: "12345", AgeMax: &tooYoung}
+				mockProfilesRepo.EXPECT().Get("12345").Return(profile, nil)
+			},
+			wantErr: profiles.ErrInvalidProfile,
+		},
+		{
+			name:  "invalid language",
+			uid:   "12345",
+			input: &models.EditProfileDTO{Language: &invalidLanguage},
+			mockBehavior: func(mockProfilesRepo *mock_profiles.MockRepository) {
+				mockProfilesRepo.EXPECT().Get("12345").Return(&models.Profile{UserUID: "12345"}, nil)
+			},
+			wantErr: profiles.ErrInvalidProfile,
 		},
 	}
 
@@ -114,12 +212,162 @@ func TestProfilesUseCase_Update(t *testing.T) {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mockBehavior(mockProfilesRepo)
-			err := profilesUC.Update(tt.uid, tt.location, tt.interests)
+			profile, err := profilesUC.Update(tt.uid, tt.version, tt.input)
 
-			if tt.wantErr {
-				require.Error(t, err)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				require.Nil(t, profile)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.want, profile)
+			}
+		})
+	}
+}
+
+func TestProfilesUseCase_Patch(t *testing.T) {
+	cfg := &config.Config{}
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProfilesRepo := mock_profiles.NewMockRepository(ctrl)
+	profilesUC := NewProfilesUseCase(cfg, mockProfilesRepo, logger)
+
+	ageMin, ageMax := 25, 34
+	staleVersion := int64(1)
+
+	stored := func() *models.Profile {
+		return &models.Profile{
+			UserUID:           "12345",
+			Location:          "Moscow",
+			Interests:         []models.Interest{{Tag: "music", Weight: 1}},
+			DislikedInterests: []string{"horror"},
+			AgeMin:            &ageMin,
+			AgeMax:            &ageMax,
+			Language:          "en",
+			Version:           2,
+		}
+	}
+
+	tests := []struct {
+		name         string
+		version      *int64
+		patch        string
+		mockBehavior func(mockProfilesRepo *mock_profiles.MockRepository)
+		want         *models.Profile
+		wantChanged  []string
+		wantErr      error
+	}{
+		{
+			name:  "null clears fields",
+			patch: `{"disliked_interests":null,"age_max":null,"language":null}`,
+			mockBehavior: func(mockProfilesRepo *mock_profiles.MockRepository) {
+				mockProfilesRepo.EXPECT().Get("12345").Return(stored(), nil)
+				mockProfilesRepo.EXPECT().ResolveAliases([]string{"music"}).Return(map[string]string{}, nil)
+				mockProfilesRepo.EXPECT().Update(gomock.Any()).Return(nil)
+			},
+			want: &models.Profile{
+				UserUID:           "12345",
+				Location:          "Moscow",
+				Interests:         []models.Interest{{Tag: "music", Weight: 1}},
+				DislikedInterests: []string{},
+				AgeMin:            &ageMin,
+				Version:           3,
+			},
+			wantChanged: []string{"disliked_interests", "age_max", "language"},
+		},
+		{
+			name:  "interests are replaced and canonicalized",
+			patch: `{"interests":["Songs",{"tag":"sports","weight":0.5}]}`,
+			mockBehavior: func(mockProfilesRepo *mock_profiles.MockRepository) {
+				mockProfilesRepo.EXPECT().Get("12345").Return(stored(), nil)
+				mockProfilesRepo.EXPECT().ResolveAliases([]string{"songs", "sports", "horror"}).
+					Return(map[string]string{"songs": "music"}, nil)
+				mockProfilesRepo.EXPECT().Update(gomock.Any()).Return(nil)
+			},
+			want: &models.Profile{
+				UserUID:           "12345",
+				Location:          "Moscow",
+				Interests:         []models.Interest{{Tag: "music", Weight: 1}, {Tag: "sports", Weight: 0.5}},
+				DislikedInterests: []string{"horror"},
+				AgeMin:            &ageMin,
+				AgeMax:            &ageMax,
+				Language:          "en",
+				Version:           3,
+			},
+			wantChanged: []string{"interests"},
+		},
+		{
+			name:  "stored interests renamed by canonicalization are reported",
+			patch: `{"disliked_interests":["gore"]}`,
+			mockBehavior: func(mockProfilesRepo *mock_profiles.MockRepository) {
+				mockProfilesRepo.EXPECT().Get("12345").Return(stored(), nil)
+				mockProfilesRepo.EXPECT().ResolveAliases([]string{"music", "gore"}).
+					Return(map[string]string{"music": "songs"}, nil)
+				mockProfilesRepo.EXPECT().Update(gomock.Any()).Return(nil)
+			},
+			want: &models.Profile{
+				UserUID:           "12345",
+				Location:          "Moscow",
+				Interests:         []models.Interest{{Tag: "songs", Weight: 1}},
+				DislikedInterests: []string{"gore"},
+				AgeMin:            &ageMin,
+				AgeMax:            &ageMax,
+				Language:          "en",
+				Version:           3,
+			},
+			wantChanged: []string{"interests", "disliked_interests"},
+		},
+		{
+			name:  "patch without changes writes nothing",
+			patch: `{"location":"Moscow","language":"EN"}`,
+			mockBehavior: func(mockProfilesRepo *mock_profiles.MockRepository) {
+				mockProfilesRepo.EXPECT().Get("12345").Return(stored(), nil)
+			},
+			want: stored(),
+		},
+		{
+			name:  "invalid age range",
+			patch: `{"age_min":40}`,
+			mockBehavior: func(mockProfilesRepo *mock_profiles.MockRepository) {
+				mockProfilesRepo.EXPECT().Get("12345").Return(stored(), nil)
+			},
+			wantErr: profiles.ErrInvalidProfile,
+		},
+		{
+			name:  "read-only field",
+			patch: `{"user_uid":"67890"}`,
+			mockBehavior: func(mockProfilesRepo *mock_profiles.MockRepository) {
+				mockProfilesRepo.EXPECT().Get("12345").Return(stored(), nil)
+			},
+			wantErr: mergepatch.ErrInvalidPatch,
+		},
+		{
+			name:    "stale version",
+			version: &staleVersion,
+			patch:   `{"location":"Obninsk"}`,
+			mockBehavior: func(mockProfilesRepo *mock_profiles.MockRepository) {
+				mockProfilesRepo.EXPECT().Get("12345").Return(stored(), nil)
+			},
+			wantErr: db.ErrEditConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockProfilesRepo)
+			profile, changed, err := profilesUC.Patch("12345", tt.version, []byte(tt.patch))
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				require.Nil(t, profile)
 			} else {
 				require.NoError(t, err)
+				require.Equal(t, tt.want, profile)
+				require.Equal(t, tt.wantChanged, changed)
 			}
 		})
 	}
@@ -150,7 +398,7 @@ func TestProfilesUseCase_CreateProfile(t *testing.T) {
 			uid:   "12345",
 			uname: "user",
 			mockBehavior: func(mockProfilesRepo *mock_profiles.MockRepository) {
-				mockProfilesRepo.EXPECT().CreateProfile("12345", "user", "Moscow", []string{"all"}).Return(nil)
+				mockProfilesRepo.EXPECT().CreateProfile("12345", "user", "Moscow", []models.Interest{{Tag: "all", Weight: 1}}).Return(nil)
 			},
 			wantErr: false,
 		},
@@ -170,3 +418,60 @@ func TestProfilesUseCase_CreateProfile(t *testing.T) {
 		})
 	}
 }
+
+func TestProfilesUseCase_Delete(t *testing.T) {
+	cfg := &config.Config{}
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProfilesRepo := mock_profiles.NewMockRepository(ctrl)
+	profilesUC := NewProfilesUseCase(cfg, mockProfilesRepo, logger)
+
+	tests := []struct {
+		name         string
+		uid          string
+		mockBehavior func(mockProfilesRepo *mock_profiles.MockRepository)
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			uid:  "53453",
+			mockBehavior: func(mockProfilesRepo *mock_profiles.MockRepository) {
+				mockProfilesRepo.EXPECT().Delete("53453").Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "already deleted",
+			uid:  "654",
+			mockBehavior: func(mockProfilesRepo *mock_profiles.MockRepository) {
+				mockProfilesRepo.EXPECT().Delete("654").Return(db.ErrRecordNotFound)
+			},
+			wantErr: false,
+		},
+		{
+			name: "db error",
+			uid:  "777",
+			mockBehavior: func(mockProfilesRepo *mock_profiles.MockRepository) {
+				mockProfilesRepo.EXPECT().Delete("777").Return(errors.New("db error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockProfilesRepo)
+			err := profilesUC.Delete(tt.uid)
+
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
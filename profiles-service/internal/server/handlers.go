@@ -5,8 +5,11 @@ import (
 
 	"github.com/julienschmidt/httprouter"
 	httpSwagger "github.com/swaggo/http-swagger/v2"
+	"go.uber.org/zap"
 
+	"cyansnbrst/profiles-service/internal/client"
 	"cyansnbrst/profiles-service/internal/middleware"
+	"cyansnbrst/profiles-service/internal/profiles/delivery/consumers"
 	profilesHttp "cyansnbrst/profiles-service/internal/profiles/delivery/http"
 	profilesRepository "cyansnbrst/profiles-service/internal/profiles/repository"
 	profilesUseCase "cyansnbrst/profiles-service/internal/profiles/usecase"
@@ -31,6 +34,18 @@ func (s *Server) RegisterHandlers() http.Handler {
 	// Register profiles routes
 	profilesHttp.RegisterProfileRoutes(router, profilesHandlers, mw)
 
+	// Init kafka consumers
+	kafkaClient := client.NewKafkaClient(s.config, s.logger)
+	kafkaHandlers := consumers.NewKafkaMessageHandlers(s.config, profilesUC, s.logger)
+
+	if err := kafkaClient.AddReader("user", s.config.Kafka.GroupID, kafkaHandlers.HandleUserMessage); err != nil {
+		s.logger.Error("failed to add kafka reader", zap.Error(err))
+	}
+	if err := kafkaClient.AddReader("tag", s.config.Kafka.GroupID, kafkaHandlers.HandleTagMessage); err != nil {
+		s.logger.Error("failed to add kafka reader", zap.Error(err))
+	}
+	kafkaClient.Run()
+
 	// Swagger
 	router.ServeFiles("/profiles/docs/*filepath", http.Dir("docs"))
 	router.HandlerFunc(http.MethodGet, "/profiles/swagger/*action", httpSwagger.Handler(
@@ -15,6 +15,7 @@ import (
 	"go.uber.org/zap"
 
 	"cyansnbrst/profiles-service/config"
+	"cyansnbrst/profiles-service/pkg/metric"
 )
 
 // Server struct
@@ -37,6 +38,13 @@ func NewServer(cfg *config.Config, logger *zap.Logger, db *sql.DB, kafkaWriter *
 
 // Run server
 func (s *Server) Run() error {
+	if err := metric.RegisterKafkaWriters(s.config.Metrics.ServiceName, s.kafkaWriter); err != nil {
+		return err
+	}
+	if err := metric.ServeMetrics(s.config.Metrics.URL); err != nil {
+		return err
+	}
+
 	addr := fmt.Sprintf(":%d", s.config.Port)
 	server := &http.Server{
 		Addr:         addr,
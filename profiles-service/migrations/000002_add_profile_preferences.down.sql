@@ -0,0 +1,14 @@
+ALTER TABLE profiles
+    DROP CONSTRAINT IF EXISTS profiles_age_range_check,
+    DROP COLUMN IF EXISTS language,
+    DROP COLUMN IF EXISTS age_max,
+    DROP COLUMN IF EXISTS age_min,
+    DROP COLUMN IF EXISTS disliked_interests;
+
+ALTER TABLE profiles ADD COLUMN flat_interests TEXT[] NOT NULL DEFAULT '{}';
+
+UPDATE profiles
+SET flat_interests = ARRAY(SELECT jsonb_array_elements(interests) ->> 'tag');
+
+ALTER TABLE profiles DROP COLUMN interests;
+ALTER TABLE profiles RENAME COLUMN flat_interests TO interests;
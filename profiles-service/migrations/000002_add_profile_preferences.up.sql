@@ -0,0 +1,17 @@
+ALTER TABLE profiles ADD COLUMN weighted_interests JSONB NOT NULL DEFAULT '[]';
+
+UPDATE profiles
+SET weighted_interests = COALESCE(
+    (SELECT jsonb_agg(jsonb_build_object('tag', tag, 'weight', 1)) FROM unnest(interests) AS tag),
+    '[]'
+);
+
+ALTER TABLE profiles DROP COLUMN interests;
+ALTER TABLE profiles RENAME COLUMN weighted_interests TO interests;
+
+ALTER TABLE profiles
+    ADD COLUMN disliked_interests TEXT[] NOT NULL DEFAULT '{}',
+    ADD COLUMN age_min SMALLINT,
+    ADD COLUMN age_max SMALLINT,
+    ADD COLUMN language TEXT NOT NULL DEFAULT '',
+    ADD CONSTRAINT profiles_age_range_check CHECK (age_min IS NULL OR age_max IS NULL OR age_min <= age_max);
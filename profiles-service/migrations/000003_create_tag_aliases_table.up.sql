@@ -0,0 +1,6 @@
+CREATE TABLE tag_aliases (
+    alias TEXT PRIMARY KEY,
+    tag TEXT NOT NULL
+);
+
+CREATE INDEX tag_aliases_tag_idx ON tag_aliases (tag);
@@ -0,0 +1 @@
+ALTER TABLE profiles ADD COLUMN version integer NOT NULL DEFAULT 1;
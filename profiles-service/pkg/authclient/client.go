@@ -0,0 +1,215 @@
+package authclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Auth client errors
+var (
+	ErrUnauthenticated = errors.New("unauthenticated")
+	ErrCircuitOpen     = errors.New("auth service circuit open")
+)
+
+// Maximum number of cached validation results
+const maxCacheEntries = 10000
+
+// API keys are told from access tokens by the prefix and sent to the auth service in a header
+const (
+	APIKeyPrefix = "rk_"
+	apiKeyHeader = "X-API-Key"
+)
+
+// Check if the bearer credential is an API key
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
+
+// Authenticated user
+type Identity struct {
+	UserUID     string   `json:"user_uid"`
+	IsAdmin     bool     `json:"is_admin"`
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
+
+// Client options
+type Options struct {
+	URL              string
+	RequestTimeout   time.Duration
+	CacheTTL         time.Duration
+	BreakerThreshold int
+	BreakerCooldown  time.Duration
+	Tokens           *TokenSource // service tokens for the auth service, optional
+}
+
+// Cached validation result
+type cacheEntry struct {
+	identity  *Identity
+	err       error
+	expiresAt time.Time
+}
+
+// Client of the auth service token validation endpoint.
+// It reuses connections, caches results for a short TTL and stops calling
+// the auth service for a cooldown period after consecutive failures.
+type Client struct {
+	url     string
+	ttl     time.Duration
+	client  *http.Client
+	breaker *breaker
+	tokens  *TokenSource
+	cacheMu sync.Mutex
+	cache   map[string]cacheEntry
+	nowFunc func() time.Time
+}
+
+// Client constructor
+func NewClient(opts Options) *Client {
+	return &Client{
+		url: opts.URL,
+		ttl: opts.CacheTTL,
+		client: &http.Client{
+			Timeout: opts.RequestTimeout,
+			Transport: &http.Transport{
+				MaxIdleConns:        100,
+				MaxIdleConnsPerHost: 100,
+				IdleConnTimeout:     90 * time.Second,
+			},
+		},
+		breaker: newBreaker(opts.BreakerThreshold, opts.BreakerCooldown),
+		tokens:  opts.Tokens,
+		cache:   make(map[string]cacheEntry),
+		nowFunc: time.Now,
+	}
+}
+
+// Validate the access token with the auth service
+func (c *Client) Authenticate(ctx context.Context, token string) (*Identity, error) {
+	return c.authenticate(ctx, cacheKey(token), func(req *http.Request) {
+		req.AddCookie(&http.Cookie{Name: "token", Value: token})
+	})
+}
+
+// Validate the API key with the auth service
+func (c *Client) AuthenticateAPIKey(ctx context.Context, key string) (*Identity, error) {
+	return c.authenticate(ctx, cacheKey(key), func(req *http.Request) {
+		req.Header.Set(apiKeyHeader, key)
+	})
+}
+
+// Validate credentials set by setCredentials, results are cached under key
+func (c *Client) authenticate(ctx context.Context, key string, setCredentials func(req *http.Request)) (*Identity, error) {
+	if entry, ok := c.cached(key); ok {
+		return entry.identity, entry.err
+	}
+
+	if !c.breaker.allow(c.nowFunc()) {
+		return nil, ErrCircuitOpen
+	}
+
+	identity, err := c.validate(ctx, setCredentials)
+	if err != nil && !errors.Is(err, ErrUnauthenticated) {
+		c.breaker.failure(c.nowFunc())
+		return nil, err
+	}
+	c.breaker.success()
+
+	c.store(key, identity, err)
+
+	return identity, err
+}
+
+// Call the auth service
+func (c *Client) validate(ctx context.Context, setCredentials func(req *http.Request)) (*Identity, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	setCredentials(req)
+
+	if c.tokens != nil {
+		serviceToken, err := c.tokens.Token(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get service token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+serviceToken)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		return nil, ErrUnauthenticated
+	case http.StatusForbidden:
+		// The service token was rejected, e.g. after key rotation
+		if c.tokens != nil {
+			c.tokens.Invalidate()
+		}
+		return nil, fmt.Errorf("service token rejected: %s", resp.Status)
+	default:
+		return nil, fmt.Errorf("unexpected auth service status: %s", resp.Status)
+	}
+
+	var identity Identity
+	if err = json.NewDecoder(resp.Body).Decode(&identity); err != nil {
+		return nil, err
+	}
+
+	return &identity, nil
+}
+
+// Get cached validation result
+func (c *Client) cached(key string) (cacheEntry, bool) {
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+
+	entry, ok := c.cache[key]
+	if !ok || c.nowFunc().After(entry.expiresAt) {
+		return cacheEntry{}, false
+	}
+	return entry, true
+}
+
+// Store validation result, expired entries are dropped once the cache is full
+func (c *Client) store(key string, identity *Identity, err error) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+
+	now := c.nowFunc()
+	if len(c.cache) >= maxCacheEntries {
+		for k, entry := range c.cache {
+			if now.After(entry.expiresAt) {
+				delete(c.cache, k)
+			}
+		}
+		if len(c.cache) >= maxCacheEntries {
+			c.cache = make(map[string]cacheEntry)
+		}
+	}
+
+	c.cache[key] = cacheEntry{identity: identity, err: err, expiresAt: now.Add(c.ttl)}
+}
+
+// Tokens are not kept in memory as is
+func cacheKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,232 @@
+package authclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T, status *int32, hits *int32) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(hits, 1)
+
+		cookie, err := r.Cookie("token")
+		if err != nil || cookie.Value != "valid" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		code := int(atomic.LoadInt32(status))
+		w.WriteHeader(code)
+		if code == http.StatusOK {
+			w.Write([]byte(`{"user_uid":"5748","is_admin":true}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClient_Authenticate(t *testing.T) {
+	status := int32(http.StatusOK)
+	var hits int32
+	server := newTestServer(t, &status, &hits)
+
+	client := NewClient(Options{
+		URL:              server.URL,
+		RequestTimeout:   time.Second,
+		CacheTTL:         time.Minute,
+		BreakerThreshold: 2,
+		BreakerCooldown:  time.Minute,
+	})
+
+	tests := []struct {
+		name         string
+		token        string
+		wantIdentity *Identity
+		wantErr      error
+		wantHits     int32
+	}{
+		{
+			name:         "valid token",
+			token:        "valid",
+			wantIdentity: &Identity{UserUID: "5748", IsAdmin: true},
+			wantHits:     1,
+		},
+		{
+			name:         "valid token is cached",
+			token:        "valid",
+			wantIdentity: &Identity{UserUID: "5748", IsAdmin: true},
+			wantHits:     1,
+		},
+		{
+			name:     "invalid token",
+			token:    "invalid",
+			wantErr:  ErrUnauthenticated,
+			wantHits: 2,
+		},
+		{
+			name:     "invalid token is cached",
+			token:    "invalid",
+			wantErr:  ErrUnauthenticated,
+			wantHits: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity, err := client.Authenticate(context.Background(), tt.token)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				require.Nil(t, identity)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.wantIdentity, identity)
+			}
+			require.Equal(t, tt.wantHits, atomic.LoadInt32(&hits))
+		})
+	}
+}
+
+func TestClient_CircuitBreaker(t *testing.T) {
+	status := int32(http.StatusInternalServerError)
+	var hits int32
+	server := newTestServer(t, &status, &hits)
+
+	client := NewClient(Options{
+		URL:              server.URL,
+		RequestTimeout:   time.Second,
+		BreakerThreshold: 2,
+		BreakerCooldown:  time.Minute,
+	})
+	now := time.Now()
+	client.nowFunc = func() time.Time { return now }
+
+	// Failures open the circuit
+	for i := 0; i < 2; i++ {
+		_, err := client.Authenticate(context.Background(), "valid")
+		require.Error(t, err)
+		require.NotErrorIs(t, err, ErrCircuitOpen)
+	}
+
+	_, err := client.Authenticate(context.Background(), "valid")
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	require.Equal(t, int32(2), atomic.LoadInt32(&hits))
+
+	// Unauthorized responses do not count as failures, a probe after cooldown closes the circuit
+	atomic.StoreInt32(&status, http.StatusOK)
+	now = now.Add(time.Minute)
+
+	identity, err := client.Authenticate(context.Background(), "valid")
+	require.NoError(t, err)
+	require.Equal(t, "5748", identity.UserUID)
+
+	_, err = client.Authenticate(context.Background(), "invalid")
+	require.ErrorIs(t, err, ErrUnauthenticated)
+	require.Equal(t, int32(4), atomic.LoadInt32(&hits))
+}
+
+func TestClient_ServiceToken(t *testing.T) {
+	var tokenHits int32
+	var rejectService int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/service-token":
+			n := atomic.AddInt32(&tokenHits, 1)
+
+			var body map[string]string
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			require.Equal(t, "products", body["client_id"])
+			require.Equal(t, "auth", body["audience"])
+
+			w.Write([]byte(fmt.Sprintf(`{"token":"service-%d","expires_at":%q}`, n, time.Now().Add(time.Hour).Format(time.RFC3339))))
+		case "/auth/authenticate":
+			if atomic.LoadInt32(&rejectService) == 1 || !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer service-") {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Write([]byte(`{"user_uid":"5748"}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewClient(Options{
+		URL:              server.URL + "/auth/authenticate",
+		RequestTimeout:   time.Second,
+		BreakerThreshold: 10,
+		BreakerCooldown:  time.Minute,
+		Tokens: NewTokenSource(TokenSourceOptions{
+			URL:            server.URL + "/auth/service-token",
+			ClientID:       "products",
+			ClientSecret:   "secret",
+			Audience:       "auth",
+			RequestTimeout: time.Second,
+		}),
+	})
+
+	for _, token := range []string{"first", "second"} {
+		identity, err := client.Authenticate(context.Background(), token)
+		require.NoError(t, err)
+		require.Equal(t, "5748", identity.UserUID)
+	}
+	require.Equal(t, int32(1), atomic.LoadInt32(&tokenHits))
+
+	// A rejected service token is not an unauthenticated user and is fetched again
+	atomic.StoreInt32(&rejectService, 1)
+	_, err := client.Authenticate(context.Background(), "third")
+	require.Error(t, err)
+	require.NotErrorIs(t, err, ErrUnauthenticated)
+
+	atomic.StoreInt32(&rejectService, 0)
+	_, err = client.Authenticate(context.Background(), "fourth")
+	require.NoError(t, err)
+	require.Equal(t, int32(2), atomic.LoadInt32(&tokenHits))
+}
+
+func TestClient_AuthenticateAPIKey(t *testing.T) {
+	var hits int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+
+		if _, err := r.Cookie("token"); err == nil {
+			t.Error("api key request must not carry the token cookie")
+		}
+		if r.Header.Get("X-API-Key") != "rk_valid" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"user_uid":"5748","permissions":["products:write"]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewClient(Options{
+		URL:              server.URL,
+		RequestTimeout:   time.Second,
+		CacheTTL:         time.Minute,
+		BreakerThreshold: 2,
+		BreakerCooldown:  time.Minute,
+	})
+
+	for i := 0; i < 2; i++ {
+		identity, err := client.AuthenticateAPIKey(context.Background(), "rk_valid")
+		require.NoError(t, err)
+		require.Equal(t, &Identity{UserUID: "5748", Permissions: []string{"products:write"}}, identity)
+	}
+	require.Equal(t, int32(1), atomic.LoadInt32(&hits))
+
+	_, err := client.AuthenticateAPIKey(context.Background(), "rk_revoked")
+	require.ErrorIs(t, err, ErrUnauthenticated)
+
+	require.True(t, IsAPIKey("rk_valid"))
+	require.False(t, IsAPIKey("eyJhbGciOiJSUzI1NiJ9.e30.sig"))
+}
@@ -0,0 +1,161 @@
+package authclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Minimum time between attempts to refresh the list after a failure
+const revocationsRetryInterval = 5 * time.Second
+
+// Revoked sessions list options
+type RevocationsOptions struct {
+	URL            string
+	RequestTimeout time.Duration
+	CacheTTL       time.Duration
+	Tokens         *TokenSource // service tokens for the auth service, optional
+}
+
+// Sessions revoked by the auth service while their access tokens may still be valid,
+// e.g. after logout, password change or reset and account deletion.
+// The list is cached for ttl and refreshed in the background, the stale list is served
+// while the refresh is in flight and kept while the auth service is unreachable.
+type Revocations struct {
+	opts      RevocationsOptions
+	client    *http.Client
+	mu        sync.RWMutex
+	revoked   map[string]struct{}
+	fetchErr  error
+	fetchedAt time.Time
+	triedAt   time.Time
+	done      chan struct{} // closed when the refresh in flight completes, nil when there is none
+	nowFunc   func() time.Time
+}
+
+// Revoked sessions list constructor
+func NewRevocations(opts RevocationsOptions) *Revocations {
+	return &Revocations{
+		opts:    opts,
+		client:  &http.Client{Timeout: opts.RequestTimeout},
+		nowFunc: time.Now,
+	}
+}
+
+// Check if the session was revoked, fails only until the list is loaded for the first time
+func (r *Revocations) IsRevoked(sessionID string) (bool, error) {
+	revoked, done := r.list()
+	if revoked == nil && done != nil {
+		// There is nothing to serve before the first load, so wait for it
+		<-done
+		revoked, _ = r.list()
+	}
+
+	if revoked == nil {
+		r.mu.RLock()
+		err := r.fetchErr
+		r.mu.RUnlock()
+		return false, fmt.Errorf("revoked sessions are not loaded yet: %w", err)
+	}
+
+	_, ok := revoked[sessionID]
+	return ok, nil
+}
+
+// Current list and the refresh in flight, a refresh is started when the list is stale
+func (r *Revocations) list() (map[string]struct{}, <-chan struct{}) {
+	r.mu.RLock()
+	now := r.nowFunc()
+	revoked, done, stale := r.revoked, r.done, r.stale(now)
+	r.mu.RUnlock()
+
+	if !stale {
+		return revoked, done
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Another check could have started the refresh since the list was read
+	if r.stale(now) {
+		r.triedAt = now
+		r.done = make(chan struct{})
+		go r.refresh(now, r.done)
+	}
+
+	return r.revoked, r.done
+}
+
+// Check if the list should be refreshed, must be called with the lock held
+func (r *Revocations) stale(now time.Time) bool {
+	return r.done == nil && now.Sub(r.fetchedAt) >= r.opts.CacheTTL && now.Sub(r.triedAt) >= revocationsRetryInterval
+}
+
+// Fetch the list and replace the cached one, the stale list is kept on failure
+func (r *Revocations) refresh(startedAt time.Time, done chan struct{}) {
+	revoked, err := r.fetch()
+
+	r.mu.Lock()
+	if err == nil {
+		r.revoked, r.fetchedAt = revoked, startedAt
+	}
+	r.fetchErr = err
+	r.done = nil
+	r.mu.Unlock()
+
+	close(done)
+}
+
+// Request the list from the auth service
+func (r *Revocations) fetch() (map[string]struct{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.opts.RequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.opts.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.opts.Tokens != nil {
+		serviceToken, err := r.opts.Tokens.Token(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get service token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+serviceToken)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusForbidden:
+		// The service token was rejected, e.g. after key rotation
+		if r.opts.Tokens != nil {
+			r.opts.Tokens.Invalidate()
+		}
+		return nil, fmt.Errorf("service token rejected: %s", resp.Status)
+	default:
+		return nil, fmt.Errorf("unexpected auth service status: %s", resp.Status)
+	}
+
+	var body struct {
+		SessionIDs []string `json:"session_ids"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+
+	revoked := make(map[string]struct{}, len(body.SessionIDs))
+	for _, id := range body.SessionIDs {
+		revoked[id] = struct{}{}
+	}
+
+	return revoked, nil
+}
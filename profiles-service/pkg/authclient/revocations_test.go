@@ -0,0 +1,111 @@
+package authclient
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevocations_IsRevoked(t *testing.T) {
+	status := int32(http.StatusOK)
+	var hits int32
+	gate := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-gate
+		atomic.AddInt32(&hits, 1)
+
+		code := int(atomic.LoadInt32(&status))
+		w.WriteHeader(code)
+		if code == http.StatusOK {
+			w.Write([]byte(`{"session_ids":["revoked"]}`))
+		}
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	revocations := NewRevocations(RevocationsOptions{
+		URL:            server.URL,
+		RequestTimeout: time.Second,
+		CacheTTL:       10 * time.Second,
+	})
+	revocations.nowFunc = func() time.Time { return now }
+
+	// Checks before the first load wait for a single fetch
+	results := make(chan error, 5)
+	for i := 0; i < cap(results); i++ {
+		go func() {
+			revoked, err := revocations.IsRevoked("revoked")
+			if err == nil && !revoked {
+				err = errors.New("session is not revoked")
+			}
+			results <- err
+		}()
+	}
+	gate <- struct{}{}
+	for i := 0; i < cap(results); i++ {
+		require.NoError(t, <-results)
+	}
+	require.Equal(t, int32(1), atomic.LoadInt32(&hits))
+
+	// The list is cached
+	revoked, err := revocations.IsRevoked("active")
+	require.NoError(t, err)
+	require.False(t, revoked)
+	require.Equal(t, int32(1), atomic.LoadInt32(&hits))
+
+	// The stale list is served while the refresh is in flight and kept when it fails
+	atomic.StoreInt32(&status, http.StatusInternalServerError)
+	now = now.Add(time.Minute)
+
+	revoked, err = revocations.IsRevoked("revoked")
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	gate <- struct{}{}
+	waitRefresh(revocations)
+	require.Equal(t, int32(2), atomic.LoadInt32(&hits))
+
+	revoked, err = revocations.IsRevoked("revoked")
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	// Failed refreshes are not retried on every check
+	waitRefresh(revocations)
+	require.Equal(t, int32(2), atomic.LoadInt32(&hits))
+}
+
+func TestRevocations_Unavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	revocations := NewRevocations(RevocationsOptions{
+		URL:            server.URL,
+		RequestTimeout: time.Second,
+		CacheTTL:       10 * time.Second,
+	})
+
+	_, err := revocations.IsRevoked("sid")
+	require.Error(t, err)
+
+	// The list was never loaded, so it can't answer until the next attempt
+	_, err = revocations.IsRevoked("sid")
+	require.Error(t, err)
+}
+
+// Wait for the refresh in flight, if any
+func waitRefresh(r *Revocations) {
+	r.mu.RLock()
+	done := r.done
+	r.mu.RUnlock()
+
+	if done != nil {
+		<-done
+	}
+}
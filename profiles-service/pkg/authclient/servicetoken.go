@@ -0,0 +1,107 @@
+package authclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Tokens are renewed this long before they expire
+const tokenRefreshMargin = 30 * time.Second
+
+// Service token source options
+type TokenSourceOptions struct {
+	URL            string
+	ClientID       string
+	ClientSecret   string
+	Audience       string
+	RequestTimeout time.Duration
+}
+
+// Source of service tokens issued by the auth service for internal calls,
+// the token is cached until shortly before it expires
+type TokenSource struct {
+	opts    TokenSourceOptions
+	client  *http.Client
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+	nowFunc func() time.Time
+}
+
+// Token source constructor
+func NewTokenSource(opts TokenSourceOptions) *TokenSource {
+	return &TokenSource{
+		opts:    opts,
+		client:  &http.Client{Timeout: opts.RequestTimeout},
+		nowFunc: time.Now,
+	}
+}
+
+// Get a valid service token
+func (s *TokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && s.nowFunc().Add(tokenRefreshMargin).Before(s.expires) {
+		return s.token, nil
+	}
+
+	token, expires, err := s.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	s.token, s.expires = token, expires
+	return token, nil
+}
+
+// Drop the cached token, the next call fetches a new one
+func (s *TokenSource) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.token = ""
+}
+
+// Request a new token from the auth service
+func (s *TokenSource) fetch(ctx context.Context) (string, time.Time, error) {
+	body, err := json.Marshal(map[string]string{
+		"client_id":     s.opts.ClientID,
+		"client_secret": s.opts.ClientSecret,
+		"audience":      s.opts.Audience,
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.opts.URL, bytes.NewReader(body))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", time.Time{}, fmt.Errorf("unexpected service token status: %s", resp.Status)
+	}
+
+	var result struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", time.Time{}, err
+	}
+
+	return result.Token, result.ExpiresAt, nil
+}
@@ -5,4 +5,5 @@ import "errors"
 // Database errors
 var (
 	ErrRecordNotFound = errors.New("record not found")
+	ErrEditConflict   = errors.New("edit conflict")
 )
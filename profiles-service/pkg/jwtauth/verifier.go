@@ -0,0 +1,130 @@
+package jwtauth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Verifier errors
+var (
+	// Returned for malformed, expired or badly signed tokens
+	ErrInvalidToken = errors.New("invalid token")
+	// Returned for tokens of sessions revoked by the auth service
+	ErrRevokedToken = errors.New("token revoked")
+	// Returned while revoked sessions can't be loaded, the token should be validated by the auth service
+	ErrRevocationsUnavailable = errors.New("revoked sessions unavailable")
+)
+
+// Source of sessions revoked by the auth service
+type RevocationChecker interface {
+	IsRevoked(sessionID string) (bool, error)
+}
+
+// Access token claims issued by the auth service
+type Claims struct {
+	UserUID     string   `json:"user_uid"`
+	IsAdmin     bool     `json:"is_admin"`
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+	SessionID   string   `json:"sid"`
+	TokenUse    string   `json:"token_use"`
+	jwt.RegisteredClaims
+}
+
+// Service token claims issued by the auth service, the subject is the calling service
+type ServiceClaims struct {
+	TokenUse string `json:"token_use"`
+	jwt.RegisteredClaims
+}
+
+// Token use claim of service tokens
+const serviceTokenUse = "service"
+
+// Local access token verifier
+type Verifier struct {
+	keys        *KeySet
+	revocations RevocationChecker
+}
+
+// Verifier constructor, without revocations tokens of revoked sessions stay valid until they expire
+func NewVerifier(keys *KeySet, revocations RevocationChecker) *Verifier {
+	return &Verifier{keys: keys, revocations: revocations}
+}
+
+// Verify token signature, expiration and session revocation and return its claims
+func (v *Verifier) Verify(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	if err := v.parse(tokenString, claims, jwt.WithExpirationRequired()); err != nil {
+		return nil, err
+	}
+
+	// Service tokens never identify a user
+	if claims.TokenUse == serviceTokenUse {
+		return nil, ErrInvalidToken
+	}
+
+	if v.revocations != nil {
+		revoked, err := v.revocations.IsRevoked(claims.SessionID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrRevocationsUnavailable, err)
+		}
+		if revoked {
+			return nil, ErrRevokedToken
+		}
+	}
+
+	return claims, nil
+}
+
+// Verify a service token issued for the audience and return its claims
+func (v *Verifier) VerifyService(tokenString, audience string) (*ServiceClaims, error) {
+	claims := &ServiceClaims{}
+	err := v.parse(tokenString, claims, jwt.WithAudience(audience), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.TokenUse != serviceTokenUse || claims.Subject == "" {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// Parse the token into claims and verify its signature with the key set
+func (v *Verifier) parse(tokenString string, claims jwt.Claims, opts ...jwt.ParserOption) error {
+	opts = append(opts, jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg(), jwt.SigningMethodRS256.Alg()}))
+
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return v.keys.Key(kid)
+	}, opts...)
+	if err != nil {
+		if errors.Is(err, ErrKeySetUnavailable) {
+			return err
+		}
+		return ErrInvalidToken
+	}
+
+	if !token.Valid {
+		return ErrInvalidToken
+	}
+
+	return nil
+}
+
+// Check if the permissions grant the permission, "*" grants everything and "products:*" grants every products permission
+func HasPermission(permissions []string, permission string) bool {
+	for _, p := range permissions {
+		if p == "*" || p == permission {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(p, "*"); ok && strings.HasPrefix(permission, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"cyansnbrst/profiles-service/config"
+)
+
+// Init kafka consumer for the given topic
+func InitKafkaReader(cfg *config.Config, topicKey string, groupID string) (*kafka.Reader, error) {
+	topic, exists := cfg.Kafka.Topics[topicKey]
+	if !exists {
+		return nil, fmt.Errorf("topic key '%s' not found in configuration", topicKey)
+	}
+
+	dialer, err := newDialer(cfg.Kafka)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers: cfg.Kafka.Brokers,
+		Topic:   topic,
+		GroupID: groupID,
+		Dialer:  dialer,
+	})
+
+	return reader, nil
+}
+
+// Dialer with the TLS and SASL of the producers, nil when neither is configured and the default dialer is used
+func newDialer(cfg config.Kafka) (*kafka.Dialer, error) {
+	transport, err := newTransport(cfg)
+	if err != nil || transport == nil {
+		return nil, err
+	}
+
+	return &kafka.Dialer{
+		Timeout:       10 * time.Second,
+		DualStack:     true,
+		TLS:           transport.TLS,
+		SASLMechanism: transport.SASL,
+	}, nil
+}
+
+// Backoff between attempts to handle a failed message
+var (
+	retryBackoffMin = 500 * time.Millisecond
+	retryBackoffMax = 30 * time.Second
+)
+
+// ConsumeMessages listens for messages from the Kafka topic and passes them to the handler.
+// A failed message is retried with backoff until it is handled or the context is cancelled, onRetry is called before each retry.
+// Offsets are committed only after the handler succeeds, so a message that was not handled before shutdown is redelivered on restart.
+func ConsumeMessages(ctx context.Context, reader *kafka.Reader, handler func(kafka.Message) error, onRetry func(err error, backoff time.Duration)) error {
+	defer reader.Close()
+
+	for {
+		m, err := reader.FetchMessage(ctx)
+		if err != nil {
+			return fmt.Errorf("error reading message: %w", err)
+		}
+
+		if err := handleWithRetry(ctx, m, handler, onRetry); err != nil {
+			return fmt.Errorf("error handling message: %w", err)
+		}
+
+		if reader.Config().GroupID != "" {
+			if err := reader.CommitMessages(context.Background(), m); err != nil {
+				return fmt.Errorf("error committing offset: %w", err)
+			}
+		}
+	}
+}
+
+// Call the handler until it succeeds, doubling the wait between attempts up to the maximum backoff
+func handleWithRetry(ctx context.Context, m kafka.Message, handler func(kafka.Message) error, onRetry func(err error, backoff time.Duration)) error {
+	backoff := retryBackoffMin
+	for {
+		err := handler(m)
+		if err == nil {
+			return nil
+		}
+
+		if onRetry != nil {
+			onRetry(err, backoff)
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+		case <-timer.C:
+		}
+
+		backoff *= 2
+		if backoff > retryBackoffMax {
+			backoff = retryBackoffMax
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package mergepatch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type document struct {
+	Name   string            `json:"name"`
+	Count  *int64            `json:"count"`
+	Tags   []string          `json:"tags"`
+	Labels map[string]string `json:"labels"`
+}
+
+func TestApply(t *testing.T) {
+	count := int64(3)
+	newCount := int64(9007199254740993)
+	doc := document{Name: "vinyl", Count: &count, Tags: []string{"rock", "jazz"}, Labels: map[string]string{"a": "1", "b": "2"}}
+
+	tests := []struct {
+		name    string
+		patch   string
+		want    document
+		wantErr bool
+	}{
+		{
+			name:  "empty patch",
+			patch: `{}`,
+			want:  doc,
+		},
+		{
+			name:  "replace members",
+			patch: `{"name":"tape","count":9007199254740993}`,
+			want:  document{Name: "tape", Count: &newCount, Tags: doc.Tags, Labels: doc.Labels},
+		},
+		{
+			name:  "null clears members",
+			patch: `{"count":null,"tags":null}`,
+			want:  document{Name: "vinyl", Labels: doc.Labels},
+		},
+		{
+			name:  "arrays are replaced",
+			patch: `{"tags":["pop"]}`,
+			want:  document{Name: "vinyl", Count: &count, Tags: []string{"pop"}, Labels: doc.Labels},
+		},
+		{
+			name:  "objects are merged",
+			patch: `{"labels":{"a":null,"c":"3"}}`,
+			want:  document{Name: "vinyl", Count: &count, Tags: doc.Tags, Labels: map[string]string{"b": "2", "c": "3"}},
+		},
+		{
+			name:    "unknown member",
+			patch:   `{"colour":"black"}`,
+			wantErr: true,
+		},
+		{
+			name:    "wrong type",
+			patch:   `{"count":"many"}`,
+			wantErr: true,
+		},
+		{
+			name:    "not an object",
+			patch:   `["name"]`,
+			wantErr: true,
+		},
+		{
+			name:    "null patch",
+			patch:   `null`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var got document
+			err := Apply(doc, []byte(tt.patch), &got)
+
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidPatch)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestIsContentType(t *testing.T) {
+	require.True(t, IsContentType("application/merge-patch+json"))
+	require.True(t, IsContentType("application/json; charset=utf-8"))
+	require.False(t, IsContentType("application/json-patch+json"))
+	require.False(t, IsContentType(""))
+}
@@ -0,0 +1,104 @@
+package metric
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/segmentio/kafka-go"
+)
+
+// Collector of Kafka writer stats labelled by topic.
+// Writer counters reset on every read, so they are accumulated between scrapes.
+type writerCollector struct {
+	mu       sync.Mutex
+	writers  []*kafka.Writer
+	counters map[string]*writerCounters
+
+	writes      *prometheus.Desc
+	messages    *prometheus.Desc
+	bytes       *prometheus.Desc
+	errors      *prometheus.Desc
+	retries     *prometheus.Desc
+	batchTime   *prometheus.Desc
+	writeTime   *prometheus.Desc
+	waitTime    *prometheus.Desc
+	batchSize   *prometheus.Desc
+	batchBytes  *prometheus.Desc
+	maxAttempts *prometheus.Desc
+}
+
+// Totals of a writer counters
+type writerCounters struct {
+	writes, messages, bytes, errors, retries float64
+}
+
+// Register stats of the Kafka writers
+func RegisterKafkaWriters(name string, writers ...*kafka.Writer) error {
+	return prometheus.Register(NewKafkaWritersCollector(name, writers...))
+}
+
+// Create collector of the Kafka writers stats
+func NewKafkaWritersCollector(name string, writers ...*kafka.Writer) prometheus.Collector {
+	desc := func(metric, help string) *prometheus.Desc {
+		return prometheus.NewDesc(name+"_kafka_writer_"+metric, help, []string{"topic"}, nil)
+	}
+
+	return &writerCollector{
+		writers:     writers,
+		counters:    make(map[string]*writerCounters, len(writers)),
+		writes:      desc("writes_total", "Kafka write requests"),
+		messages:    desc("messages_total", "Messages written to Kafka"),
+		bytes:       desc("message_bytes_total", "Bytes of messages written to Kafka"),
+		errors:      desc("errors_total", "Failed Kafka writes"),
+		retries:     desc("retries_total", "Retried Kafka writes"),
+		batchTime:   desc("batch_seconds_avg", "Average time to fill a batch since the last scrape"),
+		writeTime:   desc("write_seconds_avg", "Average time to write a batch since the last scrape"),
+		waitTime:    desc("wait_seconds_avg", "Average wait for a write response since the last scrape"),
+		batchSize:   desc("batch_size_avg", "Average messages in a batch since the last scrape"),
+		batchBytes:  desc("batch_bytes_avg", "Average bytes in a batch since the last scrape"),
+		maxAttempts: desc("attempts_max", "Attempts to deliver a batch before it fails"),
+	}
+}
+
+// Describe
+func (c *writerCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		c.writes, c.messages, c.bytes, c.errors, c.retries,
+		c.batchTime, c.writeTime, c.waitTime, c.batchSize, c.batchBytes, c.maxAttempts,
+	} {
+		ch <- desc
+	}
+}
+
+// Collect
+func (c *writerCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, writer := range c.writers {
+		stats := writer.Stats()
+
+		totals, ok := c.counters[stats.Topic]
+		if !ok {
+			totals = &writerCounters{}
+			c.counters[stats.Topic] = totals
+		}
+		totals.writes += float64(stats.Writes)
+		totals.messages += float64(stats.Messages)
+		totals.bytes += float64(stats.Bytes)
+		totals.errors += float64(stats.Errors)
+		totals.retries += float64(stats.Retries)
+
+		ch <- prometheus.MustNewConstMetric(c.writes, prometheus.CounterValue, totals.writes, stats.Topic)
+		ch <- prometheus.MustNewConstMetric(c.messages, prometheus.CounterValue, totals.messages, stats.Topic)
+		ch <- prometheus.MustNewConstMetric(c.bytes, prometheus.CounterValue, totals.bytes, stats.Topic)
+		ch <- prometheus.MustNewConstMetric(c.errors, prometheus.CounterValue, totals.errors, stats.Topic)
+		ch <- prometheus.MustNewConstMetric(c.retries, prometheus.CounterValue, totals.retries, stats.Topic)
+		ch <- prometheus.MustNewConstMetric(c.batchTime, prometheus.GaugeValue, stats.BatchTime.Avg.Seconds(), stats.Topic)
+		ch <- prometheus.MustNewConstMetric(c.writeTime, prometheus.GaugeValue, stats.WriteTime.Avg.Seconds(), stats.Topic)
+		ch <- prometheus.MustNewConstMetric(c.waitTime, prometheus.GaugeValue, stats.WaitTime.Avg.Seconds(), stats.Topic)
+		ch <- prometheus.MustNewConstMetric(c.batchSize, prometheus.GaugeValue, float64(stats.BatchSize.Avg), stats.Topic)
+		ch <- prometheus.MustNewConstMetric(c.batchBytes, prometheus.GaugeValue, float64(stats.BatchBytes.Avg), stats.Topic)
+		ch <- prometheus.MustNewConstMetric(c.maxAttempts, prometheus.GaugeValue, float64(stats.MaxAttempts), stats.Topic)
+	}
+}
@@ -0,0 +1,31 @@
+package metric
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Serve registered metrics with the build info on the address
+func ServeMetrics(address string) error {
+	if err := prometheus.Register(collectors.NewBuildInfoCollector()); err != nil {
+		return err
+	}
+
+	go func() {
+		router := httprouter.New()
+		router.GET("/metrics", func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+			promhttp.Handler().ServeHTTP(w, r)
+		})
+		log.Printf("metrics server is running on port: %s", address)
+		if err := http.ListenAndServe(address, router); err != nil {
+			log.Fatal(err)
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,24 @@
+// Package taxonomy normalizes tag spellings before they are matched against the tag vocabulary
+package taxonomy
+
+import "strings"
+
+// Normalize a tag: lower case, trimmed, inner whitespace collapsed to single spaces
+func Normalize(tag string) string {
+	return strings.Join(strings.Fields(strings.ToLower(tag)), " ")
+}
+
+// Normalize tags, empty tags are dropped and duplicates are kept once in the original order
+func NormalizeAll(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = Normalize(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
@@ -0,0 +1,32 @@
+package taxonomy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		tag  string
+		want string
+	}{
+		{name: "lower case", tag: "Music", want: "music"},
+		{name: "trimmed", tag: " music \n", want: "music"},
+		{name: "inner whitespace", tag: "Rock   \tMusic", want: "rock music"},
+		{name: "empty", tag: "   ", want: ""},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, Normalize(tt.tag))
+		})
+	}
+}
+
+func TestNormalizeAll(t *testing.T) {
+	got := NormalizeAll([]string{"Music", "music ", "", "Rock", " ROCK"})
+	require.Equal(t, []string{"music", "rock"}, got)
+}
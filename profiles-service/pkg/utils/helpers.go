@@ -6,16 +6,32 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
-
-	"github.com/julienschmidt/httprouter"
 )
 
-// Read UID param
-func ReadUIDParam(r *http.Request) string {
-	params := httprouter.ParamsFromContext(r.Context())
-	uid := params.ByName("uid")
-	return uid
+// Format a version as an entity tag
+func VersionETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// Read the version the client expects from the If-Match header, nil means any version matches
+func ReadIfMatch(r *http.Request) (*int64, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" || value == "*" {
+		return nil, nil
+	}
+
+	if len(value) < 2 || !strings.HasPrefix(value, `"`) || !strings.HasSuffix(value, `"`) {
+		return nil, errors.New("invalid If-Match header")
+	}
+
+	version, err := strconv.ParseInt(value[1:len(value)-1], 10, 64)
+	if err != nil || version < 1 {
+		return nil, errors.New("invalid If-Match header")
+	}
+
+	return &version, nil
 }
 
 // JSON envelope
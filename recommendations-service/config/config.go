@@ -11,14 +11,18 @@ import (
 
 // App config struct
 type Config struct {
-	Port       int
-	Env        string
-	AuthURL    string
-	PostgreSQL PostgreSQL
-	Kafka      Kafka
-	Timeout    Timeout
-	Redis      Redis
-	Metrics    Metrics
+	Port               int
+	Env                string
+	AuthURL            string
+	JWKSURL            string
+	RevokedSessionsURL string
+	PostgreSQL         PostgreSQL
+	Kafka              Kafka
+	AuthBreaker        AuthBreaker
+	ServiceAuth        ServiceAuth
+	Timeout            Timeout
+	Redis              Redis
+	Metrics            Metrics
 }
 
 // PostgreSQL config struct
@@ -41,6 +45,19 @@ type Kafka struct {
 	GroupID string
 }
 
+// Service-to-service authentication config struct
+type ServiceAuth struct {
+	ClientID     string // name of this service, also the audience of tokens for its internal routes
+	ClientSecret string
+	TokenURL     string
+}
+
+// Auth service circuit breaker config struct
+type AuthBreaker struct {
+	Threshold int
+	Cooldown  time.Duration
+}
+
 // Timeouts config struct
 type Timeout struct {
 	PostgreSQLConn   time.Duration
@@ -49,6 +66,10 @@ type Timeout struct {
 	ServerRead       time.Duration
 	ServerWrite      time.Duration
 	ServerShutdown   time.Duration
+	JWKSCache        time.Duration
+	AuthRequest      time.Duration
+	AuthCache        time.Duration
+	RevocationsCache time.Duration
 	RedisAction      time.Duration
 	RedisCache       time.Duration
 }
@@ -104,6 +125,13 @@ func ParseConfig(v *viper.Viper) (*Config, error) {
 	c.Port = v.GetInt("port")
 	c.Env = v.GetString("env")
 	c.AuthURL = v.GetString("auth_url")
+	c.JWKSURL = v.GetString("jwks_url")
+	c.RevokedSessionsURL = v.GetString("revoked_sessions_url")
+
+	// Service auth config
+	c.ServiceAuth.ClientID = v.GetString("service_client_id")
+	c.ServiceAuth.ClientSecret = v.GetString("service_client_secret")
+	c.ServiceAuth.TokenURL = v.GetString("service_token_url")
 
 	// PostgreSQL config
 	c.PostgreSQL.Host = v.GetString("postgresql_host")
@@ -157,6 +185,29 @@ func ParseConfig(v *viper.Viper) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	c.Timeout.JWKSCache, err = parseTimeout(v, "timeout_jwks_cache")
+	if err != nil {
+		return nil, err
+	}
+	c.Timeout.AuthRequest, err = parseTimeout(v, "timeout_auth_request")
+	if err != nil {
+		return nil, err
+	}
+	c.Timeout.AuthCache, err = parseTimeout(v, "timeout_auth_cache")
+	if err != nil {
+		return nil, err
+	}
+	c.Timeout.RevocationsCache, err = parseTimeout(v, "timeout_revocations_cache")
+	if err != nil {
+		return nil, err
+	}
+
+	// Auth service circuit breaker config
+	c.AuthBreaker.Threshold = v.GetInt("auth_breaker_threshold")
+	c.AuthBreaker.Cooldown, err = parseTimeout(v, "auth_breaker_cooldown")
+	if err != nil {
+		return nil, err
+	}
 	c.Timeout.RedisAction, err = parseTimeout(v, "timeout_redis_action")
 	if err != nil {
 		return nil, err
@@ -20,6 +20,9 @@ const docTemplate = `{
                 "security": [
                     {
                         "cookieAuth": []
+                    },
+                    {
+                        "bearerAuth": []
                     }
                 ],
                 "description": "Retrieves personalized recommendations for the authenticated user.",
@@ -89,6 +92,12 @@ const docTemplate = `{
         }
     },
     "securityDefinitions": {
+        "bearerAuth": {
+            "description": "\"Bearer \u003caccess token or API key\u003e\"",
+            "type": "apiKey",
+            "name": "Authorization",
+            "in": "header"
+        },
         "cookieAuth": {
             "type": "apiKey",
             "name": "token",
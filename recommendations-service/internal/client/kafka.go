@@ -6,6 +6,7 @@ import (
 	"os/signal"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/segmentio/kafka-go"
 	"go.uber.org/zap"
@@ -63,7 +64,14 @@ func (kc *KafkaClient) Run() {
 		kc.wg.Add(1)
 		go func(rg *readerGroup) {
 			defer kc.wg.Done()
-			err := kf.ConsumeMessages(kc.ctx, rg.reader, rg.handler)
+			err := kf.ConsumeMessages(kc.ctx, rg.reader, rg.handler,
+				func(err error, backoff time.Duration) {
+					kc.logger.Warn("failed to handle message, retrying",
+						zap.String("topic", rg.reader.Config().Topic),
+						zap.Duration("backoff", backoff),
+						zap.Error(err),
+					)
+				})
 			if err != nil {
 				kc.logger.Error("error consuming messages", zap.Error(err))
 			}
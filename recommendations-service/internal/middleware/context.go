@@ -7,7 +7,11 @@ import (
 
 type contextKey string
 
-const UserContextKey = contextKey("user_uid")
+const (
+	UserContextKey  = contextKey("user_uid")
+	AdminContextKey = contextKey("is_admin")
+	PermissionsKey  = contextKey("permissions")
+)
 
 // Set user's UID into the context
 func ContextSetUserUID(r *http.Request, userUID string) *http.Request {
@@ -24,3 +28,27 @@ func ContextGetUserUID(r *http.Request) string {
 
 	return userUID
 }
+
+// Set user's admin flag into the context
+func ContextSetIsAdmin(r *http.Request, isAdmin bool) *http.Request {
+	ctx := context.WithValue(r.Context(), AdminContextKey, isAdmin)
+	return r.WithContext(ctx)
+}
+
+// Get user's admin flag from the context, false if it was not set
+func ContextGetIsAdmin(r *http.Request) bool {
+	isAdmin, _ := r.Context().Value(AdminContextKey).(bool)
+	return isAdmin
+}
+
+// Set user's permissions into the context
+func ContextSetPermissions(r *http.Request, permissions []string) *http.Request {
+	ctx := context.WithValue(r.Context(), PermissionsKey, permissions)
+	return r.WithContext(ctx)
+}
+
+// Get user's permissions from the context
+func ContextGetPermissions(r *http.Request) []string {
+	permissions, _ := r.Context().Value(PermissionsKey).([]string)
+	return permissions
+}
@@ -2,9 +2,33 @@ package models
 
 // Kafka message DTO
 type KafkaMessageDTO struct {
-	Action string   `json:"action"`
-	Time   string   `json:"time"`
-	Tags   []string `json:"tags"`
+	Action       string     `json:"action"`
+	Time         string     `json:"time"`
+	Tags         []string   `json:"tags"`
+	Interests    []Interest `json:"interests"`
+	DislikedTags []string   `json:"disliked_tags"`
+	Status       string     `json:"status"`
+	Stock        *int64     `json:"stock"`
+	Tag          string     `json:"tag"`
+	Parent       string     `json:"parent"`
+	Changed      []string   `json:"changed"`
+	AddedTags    []string   `json:"added_tags"`
+	RemovedTags  []string   `json:"removed_tags"`
+	Counted      *bool      `json:"counted"`
+	SkipReason   string     `json:"skip_reason"`
+}
+
+// Weighted interests of a user message, messages without weights give every tag the full weight
+func (m KafkaMessageDTO) WeightedInterests() []Interest {
+	if m.Interests != nil {
+		return m.Interests
+	}
+
+	interests := make([]Interest, len(m.Tags))
+	for i, tag := range m.Tags {
+		interests[i] = Interest{Tag: tag, Weight: 1}
+	}
+	return interests
 }
 
 // Recommendations response
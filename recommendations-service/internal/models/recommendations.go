@@ -9,8 +9,15 @@ type Recommendation struct {
 
 // User interests model
 type User struct {
-	UserUID   string   `json:"user_uid"`
-	Interests []string `json:"interests"`
+	UserUID      string     `json:"user_uid"`
+	Interests    []Interest `json:"interests"`
+	DislikedTags []string   `json:"disliked_tags"`
+}
+
+// Tag with the weight of the user's interest in it
+type Interest struct {
+	Tag    string  `json:"tag"`
+	Weight float64 `json:"weight"`
 }
 
 // Product rating model
@@ -2,6 +2,7 @@ package consumers
 
 import (
 	"encoding/json"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -35,8 +36,15 @@ func (h *KafkaMessageHandlers) HandleProductMessage(msg kafka.Message) error {
 
 	err := json.Unmarshal(msg.Value, &payload)
 	if err != nil {
-		h.logger.Error("failed to unmarshal Kafka message", zap.Error(err))
-		return err
+		// Retrying can't fix a malformed message, so it is dropped instead of blocking the partition
+		h.logger.Warn("dropping unparsable Kafka message",
+			zap.String("topic", msg.Topic),
+			zap.Int("partition", msg.Partition),
+			zap.Int64("offset", msg.Offset),
+			zap.ByteString("value", msg.Value),
+			zap.Error(err),
+		)
+		return nil
 	}
 
 	h.logger.Info("kafka message received",
@@ -52,28 +60,65 @@ func (h *KafkaMessageHandlers) HandleProductMessage(msg kafka.Message) error {
 
 	switch payload.Action {
 	case "view_products":
+		// Duplicate views and views of bots are not counted, views published before filtering have no counted flag
+		if payload.Counted != nil && !*payload.Counted {
+			h.logger.Debug("view not counted in popularity",
+				zap.Int("product_id", productID),
+				zap.String("reason", payload.SkipReason),
+			)
+			return nil
+		}
 		err := h.recommendationsUC.IncrementPopularity(int64(productID))
 		if err != nil {
 			h.logger.Error("failed to increment popularity", zap.Error(err))
 			return err
 		}
-	case "product_create":
+	case "product_create", "product_restore":
 		err := h.recommendationsUC.InsertProduct(int64(productID), tags)
 		if err != nil {
 			h.logger.Error("failed to create a product", zap.Error(err))
 			return err
 		}
-		err = h.recommendationsUC.UpdateRecommendationsForProduct(int64(productID), tags)
+		err = h.recommendationsUC.UpdateProductAvailability(int64(productID), payload.Status, payload.Stock)
 		if err != nil {
-			h.logger.Error("failed to generate product recommendations", zap.Error(err))
+			h.logger.Error("failed to update product availability", zap.Error(err))
 			return err
 		}
-	case "product_update":
 		err = h.recommendationsUC.UpdateRecommendationsForProduct(int64(productID), tags)
 		if err != nil {
 			h.logger.Error("failed to generate product recommendations", zap.Error(err))
 			return err
 		}
+	case "product_update":
+		// Events without changed fields come from producers that don't diff products, they carry the whole state
+		if payload.Changed == nil {
+			err = h.recommendationsUC.UpdateProductAvailability(int64(productID), payload.Status, payload.Stock)
+			if err != nil {
+				h.logger.Error("failed to update product availability", zap.Error(err))
+				return err
+			}
+			err = h.recommendationsUC.UpdateRecommendationsForProduct(int64(productID), tags)
+			if err != nil {
+				h.logger.Error("failed to generate product recommendations", zap.Error(err))
+				return err
+			}
+			break
+		}
+
+		if slices.Contains(payload.Changed, "status") || slices.Contains(payload.Changed, "stock") {
+			err = h.recommendationsUC.UpdateProductAvailability(int64(productID), payload.Status, payload.Stock)
+			if err != nil {
+				h.logger.Error("failed to update product availability", zap.Error(err))
+				return err
+			}
+		}
+		if slices.Contains(payload.Changed, "tags") {
+			err = h.recommendationsUC.UpdateProductTags(int64(productID), tags, payload.AddedTags, payload.RemovedTags)
+			if err != nil {
+				h.logger.Error("failed to update product tags", zap.Error(err))
+				return err
+			}
+		}
 	case "product_delete":
 		err = h.recommendationsUC.DeleteProduct(int64(productID))
 		if err != nil {
@@ -87,14 +132,21 @@ func (h *KafkaMessageHandlers) HandleProductMessage(msg kafka.Message) error {
 	return nil
 }
 
-// Kafka product message handler
+// Kafka user message handler
 func (h *KafkaMessageHandlers) HandleUserMessage(msg kafka.Message) error {
 	var payload models.KafkaMessageDTO
 
 	err := json.Unmarshal(msg.Value, &payload)
 	if err != nil {
-		h.logger.Error("failed to unmarshal Kafka message", zap.Error(err))
-		return err
+		// Retrying can't fix a malformed message, so it is dropped instead of blocking the partition
+		h.logger.Warn("dropping unparsable Kafka message",
+			zap.String("topic", msg.Topic),
+			zap.Int("partition", msg.Partition),
+			zap.Int64("offset", msg.Offset),
+			zap.ByteString("value", msg.Value),
+			zap.Error(err),
+		)
+		return nil
 	}
 
 	h.logger.Info("kafka message received",
@@ -105,15 +157,66 @@ func (h *KafkaMessageHandlers) HandleUserMessage(msg kafka.Message) error {
 	)
 
 	userUID := msg.Key
-	tags := payload.Tags
 
 	switch payload.Action {
 	case "user_update":
-		err := h.recommendationsUC.GenerateRecommendationsForUser(string(userUID), tags)
+		err := h.recommendationsUC.GenerateRecommendationsForUser(string(userUID), payload.WeightedInterests(), payload.DislikedTags)
 		if err != nil {
 			h.logger.Error("failed to generate recommendations", zap.Error(err))
 			return err
 		}
+	case "user_delete":
+		err := h.recommendationsUC.DeleteUser(string(userUID))
+		if err != nil {
+			h.logger.Error("failed to delete user", zap.Error(err))
+			return err
+		}
+	case "user_registered":
+		// Recommendations are generated once the profile reports the user's interests
+	default:
+		h.logger.Warn("unrecognized action", zap.String("action", payload.Action))
+	}
+
+	return nil
+}
+
+// Kafka tag message handler
+func (h *KafkaMessageHandlers) HandleTagMessage(msg kafka.Message) error {
+	var payload models.KafkaMessageDTO
+
+	err := json.Unmarshal(msg.Value, &payload)
+	if err != nil {
+		// Retrying can't fix a malformed message, so it is dropped instead of blocking the partition
+		h.logger.Warn("dropping unparsable Kafka message",
+			zap.String("topic", msg.Topic),
+			zap.Int("partition", msg.Partition),
+			zap.Int64("offset", msg.Offset),
+			zap.ByteString("value", msg.Value),
+			zap.Error(err),
+		)
+		return nil
+	}
+
+	h.logger.Info("kafka message received",
+		zap.ByteString("key", msg.Key),
+		zap.String("action", payload.Action),
+		zap.String("parent", payload.Parent),
+		zap.String("time", payload.Time),
+	)
+
+	switch payload.Action {
+	case "tag_update":
+		err := h.recommendationsUC.UpsertTag(payload.Tag, payload.Parent)
+		if err != nil {
+			h.logger.Error("failed to update tag", zap.Error(err))
+			return err
+		}
+	case "tag_delete":
+		err := h.recommendationsUC.DeleteTag(payload.Tag)
+		if err != nil {
+			h.logger.Error("failed to delete tag", zap.Error(err))
+			return err
+		}
 	default:
 		h.logger.Warn("unrecognized action", zap.String("action", payload.Action))
 	}
@@ -10,6 +10,7 @@ import (
 	"go.uber.org/zap"
 
 	"cyansnbrst/recommendations-service/config"
+	"cyansnbrst/recommendations-service/internal/models"
 	mock_recommendations "cyansnbrst/recommendations-service/internal/recommendations/mock"
 )
 
@@ -33,22 +34,24 @@ func TestKafkaMessageHandlers_HandleProductMessage(t *testing.T) {
 			name: "valid product create message",
 			message: kafka.Message{
 				Key:   []byte("1234"),
-				Value: []byte(`{"action":"product_create","tags":["tag1"],"time":"2023-01-01T12:00:00Z"}`),
+				Value: []byte(`{"action":"product_create","tags":["tag1"],"status":"active","stock":5,"time":"2023-01-01T12:00:00Z"}`),
 			},
 			mockBehavior: func(mockRecommendationsUC *mock_recommendations.MockUseCase) {
+				stock := int64(5)
 				mockRecommendationsUC.EXPECT().InsertProduct(int64(1234), []string{"tag1"}).Return(nil)
+				mockRecommendationsUC.EXPECT().UpdateProductAvailability(int64(1234), "active", &stock).Return(nil)
 				mockRecommendationsUC.EXPECT().UpdateRecommendationsForProduct(int64(1234), []string{"tag1"}).Return(nil)
 			},
 			wantErr: false,
 		},
 		{
-			name: "invalid JSON format",
+			name: "invalid JSON format is dropped",
 			message: kafka.Message{
 				Key:   []byte("1234"),
 				Value: []byte("invalid_json"),
 			},
 			mockBehavior: func(mockRecommendationsUC *mock_recommendations.MockUseCase) {},
-			wantErr:      true,
+			wantErr:      false,
 		},
 		{
 			name: "product create error",
@@ -61,6 +64,19 @@ func TestKafkaMessageHandlers_HandleProductMessage(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "valid product restore message",
+			message: kafka.Message{
+				Key:   []byte("1234"),
+				Value: []byte(`{"action":"product_restore","tags":["tag1"],"status":"active","time":"2023-01-01T12:00:00Z"}`),
+			},
+			mockBehavior: func(mockRecommendationsUC *mock_recommendations.MockUseCase) {
+				mockRecommendationsUC.EXPECT().InsertProduct(int64(1234), []string{"tag1"}).Return(nil)
+				mockRecommendationsUC.EXPECT().UpdateProductAvailability(int64(1234), "active", nil).Return(nil)
+				mockRecommendationsUC.EXPECT().UpdateRecommendationsForProduct(int64(1234), []string{"tag1"}).Return(nil)
+			},
+			wantErr: false,
+		},
 		{
 			name: "valid product update message",
 			message: kafka.Message{
@@ -68,10 +84,87 @@ func TestKafkaMessageHandlers_HandleProductMessage(t *testing.T) {
 				Value: []byte(`{"action":"product_update","tags":["tag1"],"time":"2023-01-01T12:00:00Z"}`),
 			},
 			mockBehavior: func(mockRecommendationsUC *mock_recommendations.MockUseCase) {
+				mockRecommendationsUC.EXPECT().UpdateProductAvailability(int64(1234), "", nil).Return(nil)
 				mockRecommendationsUC.EXPECT().UpdateRecommendationsForProduct(int64(1234), []string{"tag1"}).Return(nil)
 			},
 			wantErr: false,
 		},
+		{
+			name: "product archived",
+			message: kafka.Message{
+				Key:   []byte("1234"),
+				Value: []byte(`{"action":"product_update","tags":["tag1"],"status":"archived","time":"2023-01-01T12:00:00Z"}`),
+			},
+			mockBehavior: func(mockRecommendationsUC *mock_recommendations.MockUseCase) {
+				mockRecommendationsUC.EXPECT().UpdateProductAvailability(int64(1234), "archived", nil).Return(errors.New("db error"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "tag change updates the product tags",
+			message: kafka.Message{
+				Key:   []byte("1234"),
+				Value: []byte(`{"action":"product_update","tags":["tag1","tag3"],"status":"active","changed":["name","tags"],"added_tags":["tag3"],"removed_tags":["tag2"],"time":"2023-01-01T12:00:00Z"}`),
+			},
+			mockBehavior: func(mockRecommendationsUC *mock_recommendations.MockUseCase) {
+				mockRecommendationsUC.EXPECT().UpdateProductTags(int64(1234), []string{"tag1", "tag3"}, []string{"tag3"}, []string{"tag2"}).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "stock change updates only availability",
+			message: kafka.Message{
+				Key:   []byte("1234"),
+				Value: []byte(`{"action":"product_update","tags":["tag1"],"status":"active","stock":0,"changed":["stock"],"time":"2023-01-01T12:00:00Z"}`),
+			},
+			mockBehavior: func(mockRecommendationsUC *mock_recommendations.MockUseCase) {
+				stock := int64(0)
+				mockRecommendationsUC.EXPECT().UpdateProductAvailability(int64(1234), "active", &stock).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "product tags update error",
+			message: kafka.Message{
+				Key:   []byte("1234"),
+				Value: []byte(`{"action":"product_update","tags":["tag1"],"changed":["tags"],"added_tags":["tag1"],"time":"2023-01-01T12:00:00Z"}`),
+			},
+			mockBehavior: func(mockRecommendationsUC *mock_recommendations.MockUseCase) {
+				mockRecommendationsUC.EXPECT().UpdateProductTags(int64(1234), []string{"tag1"}, []string{"tag1"}, nil).Return(errors.New("db error"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "counted view increments popularity",
+			message: kafka.Message{
+				Key:   []byte("1234"),
+				Value: []byte(`{"action":"view_products","tags":null,"counted":true,"time":"2023-01-01T12:00:00Z"}`),
+			},
+			mockBehavior: func(mockRecommendationsUC *mock_recommendations.MockUseCase) {
+				mockRecommendationsUC.EXPECT().IncrementPopularity(int64(1234)).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "view without counted flag increments popularity",
+			message: kafka.Message{
+				Key:   []byte("1234"),
+				Value: []byte(`{"action":"view_products","tags":null,"time":"2023-01-01T12:00:00Z"}`),
+			},
+			mockBehavior: func(mockRecommendationsUC *mock_recommendations.MockUseCase) {
+				mockRecommendationsUC.EXPECT().IncrementPopularity(int64(1234)).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "duplicate view is not counted",
+			message: kafka.Message{
+				Key:   []byte("1234"),
+				Value: []byte(`{"action":"view_products","tags":null,"counted":false,"skip_reason":"duplicate","time":"2023-01-01T12:00:00Z"}`),
+			},
+			mockBehavior: func(mockRecommendationsUC *mock_recommendations.MockUseCase) {},
+			wantErr:      false,
+		},
 		{
 			name: "valid product delete message",
 			message: kafka.Message{
@@ -123,18 +216,30 @@ func TestKafkaMessageHandlers_HandleUserMessage(t *testing.T) {
 				Value: []byte(`{"action":"user_update","tags":["tag1"],"time":"2023-01-01T12:00:00Z"}`),
 			},
 			mockBehavior: func(mockRecommendationsUC *mock_recommendations.MockUseCase) {
-				mockRecommendationsUC.EXPECT().GenerateRecommendationsForUser("user1234", []string{"tag1"}).Return(nil)
+				mockRecommendationsUC.EXPECT().GenerateRecommendationsForUser("user1234", []models.Interest{{Tag: "tag1", Weight: 1}}, nil).Return(nil)
 			},
 			wantErr: false,
 		},
 		{
-			name: "invalid JSON format",
+			name: "valid weighted user update message",
+			message: kafka.Message{
+				Key:   []byte("user1234"),
+				Value: []byte(`{"action":"user_update","tags":["tag1","tag2"],"interests":[{"tag":"tag1","weight":1},{"tag":"tag2","weight":0.3}],"disliked_tags":["tag3"],"time":"2023-01-01T12:00:00Z"}`),
+			},
+			mockBehavior: func(mockRecommendationsUC *mock_recommendations.MockUseCase) {
+				interests := []models.Interest{{Tag: "tag1", Weight: 1}, {Tag: "tag2", Weight: 0.3}}
+				mockRecommendationsUC.EXPECT().GenerateRecommendationsForUser("user1234", interests, []string{"tag3"}).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid JSON format is dropped",
 			message: kafka.Message{
 				Key:   []byte("user1234"),
 				Value: []byte("invalid_json"),
 			},
 			mockBehavior: func(mockRecommendationsUC *mock_recommendations.MockUseCase) {},
-			wantErr:      true,
+			wantErr:      false,
 		},
 		{
 			name: "user update error",
@@ -143,7 +248,29 @@ func TestKafkaMessageHandlers_HandleUserMessage(t *testing.T) {
 				Value: []byte(`{"action":"user_update","tags":["tag1"],"time":"2023-01-01T12:00:00Z"}`),
 			},
 			mockBehavior: func(mockRecommendationsUC *mock_recommendations.MockUseCase) {
-				mockRecommendationsUC.EXPECT().GenerateRecommendationsForUser("user1234", []string{"tag1"}).Return(errors.New("db error"))
+				mockRecommendationsUC.EXPECT().GenerateRecommendationsForUser("user1234", []models.Interest{{Tag: "tag1", Weight: 1}}, nil).Return(errors.New("db error"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid user delete message",
+			message: kafka.Message{
+				Key:   []byte("user1234"),
+				Value: []byte(`{"action":"user_delete","time":"2023-01-01T12:00:00Z"}`),
+			},
+			mockBehavior: func(mockRecommendationsUC *mock_recommendations.MockUseCase) {
+				mockRecommendationsUC.EXPECT().DeleteUser("user1234").Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "user delete error",
+			message: kafka.Message{
+				Key:   []byte("user1234"),
+				Value: []byte(`{"action":"user_delete","time":"2023-01-01T12:00:00Z"}`),
+			},
+			mockBehavior: func(mockRecommendationsUC *mock_recommendations.MockUseCase) {
+				mockRecommendationsUC.EXPECT().DeleteUser("user1234").Return(errors.New("db error"))
 			},
 			wantErr: true,
 		},
@@ -163,3 +290,78 @@ func TestKafkaMessageHandlers_HandleUserMessage(t *testing.T) {
 		})
 	}
 }
+
+func TestKafkaMessageHandlers_HandleTagMessage(t *testing.T) {
+	cfg := &config.Config{}
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRecommendationsUC := mock_recommendations.NewMockUseCase(ctrl)
+	kafkaHandlers := NewKafkaMessageHandlers(cfg, mockRecommendationsUC, logger)
+
+	tests := []struct {
+		name         string
+		message      kafka.Message
+		mockBehavior func(mockRecommendationsUC *mock_recommendations.MockUseCase)
+		wantErr      bool
+	}{
+		{
+			name: "valid tag update message",
+			message: kafka.Message{
+				Key:   []byte("rock"),
+				Value: []byte(`{"action":"tag_update","tag":"rock","parent":"music","aliases":["rock music"],"time":"2023-01-01T12:00:00Z"}`),
+			},
+			mockBehavior: func(mockRecommendationsUC *mock_recommendations.MockUseCase) {
+				mockRecommendationsUC.EXPECT().UpsertTag("rock", "music").Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid tag delete message",
+			message: kafka.Message{
+				Key:   []byte("rock"),
+				Value: []byte(`{"action":"tag_delete","tag":"rock","time":"2023-01-01T12:00:00Z"}`),
+			},
+			mockBehavior: func(mockRecommendationsUC *mock_recommendations.MockUseCase) {
+				mockRecommendationsUC.EXPECT().DeleteTag("rock").Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "tag update error",
+			message: kafka.Message{
+				Key:   []byte("rock"),
+				Value: []byte(`{"action":"tag_update","tag":"rock","time":"2023-01-01T12:00:00Z"}`),
+			},
+			mockBehavior: func(mockRecommendationsUC *mock_recommendations.MockUseCase) {
+				mockRecommendationsUC.EXPECT().UpsertTag("rock", "").Return(errors.New("db error"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid JSON format is dropped",
+			message: kafka.Message{
+				Key:   []byte("rock"),
+				Value: []byte("invalid_json"),
+			},
+			mockBehavior: func(mockRecommendationsUC *mock_recommendations.MockUseCase) {},
+			wantErr:      false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockRecommendationsUC)
+
+			err := kafkaHandlers.HandleTagMessage(tt.message)
+
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -33,6 +33,7 @@ func NewRecommendationsHandlers(cfg *config.Config, recommendationsUC recommenda
 //	@Tags			recommendations
 //	@Produce		json
 //	@Security		cookieAuth
+//	@Security		bearerAuth
 //	@Success		200	{object}	models.RecommendationResponse	"success response with recommendations"
 //	@Failure		404	{object}	models.ErrorResponse			"not found error if recommendations are unavailable"
 //	@Failure		500	{object}	models.ErrorResponse			"internal server error"
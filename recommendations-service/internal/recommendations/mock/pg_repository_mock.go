@@ -35,17 +35,17 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 }
 
 // CreateRecommendation mocks base method.
-func (m *MockRepository) CreateRecommendation(user_uid string, product_id int64) error {
+func (m *MockRepository) CreateRecommendation(user_uid string, product_id int64, score float64) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CreateRecommendation", user_uid, product_id)
+	ret := m.ctrl.Call(m, "CreateRecommendation", user_uid, product_id, score)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // CreateRecommendation indicates an expected call of CreateRecommendation.
-func (mr *MockRepositoryMockRecorder) CreateRecommendation(user_uid, product_id interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) CreateRecommendation(user_uid, product_id, score interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRecommendation", reflect.TypeOf((*MockRepository)(nil).CreateRecommendation), user_uid, product_id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateRecommendation", reflect.TypeOf((*MockRepository)(nil).CreateRecommendation), user_uid, product_id, score)
 }
 
 // DeleteProduct mocks base method.
@@ -62,6 +62,20 @@ func (mr *MockRepositoryMockRecorder) DeleteProduct(productID interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteProduct", reflect.TypeOf((*MockRepository)(nil).DeleteProduct), productID)
 }
 
+// DeleteRecommendation mocks base method.
+func (m *MockRepository) DeleteRecommendation(userUID string, productID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRecommendation", userUID, productID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRecommendation indicates an expected call of DeleteRecommendation.
+func (mr *MockRepositoryMockRecorder) DeleteRecommendation(userUID, productID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRecommendation", reflect.TypeOf((*MockRepository)(nil).DeleteRecommendation), userUID, productID)
+}
+
 // DeleteRecommendationsForProduct mocks base method.
 func (m *MockRepository) DeleteRecommendationsForProduct(productID int64) error {
 	m.ctrl.T.Helper()
@@ -90,6 +104,34 @@ func (mr *MockRepositoryMockRecorder) DeleteRecommendationsForUser(userUID inter
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRecommendationsForUser", reflect.TypeOf((*MockRepository)(nil).DeleteRecommendationsForUser), userUID)
 }
 
+// DeleteTag mocks base method.
+func (m *MockRepository) DeleteTag(name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTag", name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTag indicates an expected call of DeleteTag.
+func (mr *MockRepositoryMockRecorder) DeleteTag(name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTag", reflect.TypeOf((*MockRepository)(nil).DeleteTag), name)
+}
+
+// DeleteUser mocks base method.
+func (m *MockRepository) DeleteUser(userUID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", userUID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockRepositoryMockRecorder) DeleteUser(userUID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockRepository)(nil).DeleteUser), userUID)
+}
+
 // FindProductsByTags mocks base method.
 func (m *MockRepository) FindProductsByTags(tag string) ([]int64, error) {
 	m.ctrl.T.Helper()
@@ -105,6 +147,21 @@ func (mr *MockRepositoryMockRecorder) FindProductsByTags(tag interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindProductsByTags", reflect.TypeOf((*MockRepository)(nil).FindProductsByTags), tag)
 }
 
+// FindUsersByTags mocks base method.
+func (m *MockRepository) FindUsersByTags(tags []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindUsersByTags", tags)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindUsersByTags indicates an expected call of FindUsersByTags.
+func (mr *MockRepositoryMockRecorder) FindUsersByTags(tags interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindUsersByTags", reflect.TypeOf((*MockRepository)(nil).FindUsersByTags), tags)
+}
+
 // GetAllUsers mocks base method.
 func (m *MockRepository) GetAllUsers() ([]string, error) {
 	m.ctrl.T.Helper()
@@ -135,19 +192,34 @@ func (mr *MockRepositoryMockRecorder) GetRecommendationsByUser(user_uid interfac
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecommendationsByUser", reflect.TypeOf((*MockRepository)(nil).GetRecommendationsByUser), user_uid)
 }
 
-// GetUserInterests mocks base method.
-func (m *MockRepository) GetUserInterests(userUID string) ([]string, error) {
+// GetTagAncestors mocks base method.
+func (m *MockRepository) GetTagAncestors(tags []string) ([]string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserInterests", userUID)
+	ret := m.ctrl.Call(m, "GetTagAncestors", tags)
 	ret0, _ := ret[0].([]string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetUserInterests indicates an expected call of GetUserInterests.
-func (mr *MockRepositoryMockRecorder) GetUserInterests(userUID interface{}) *gomock.Call {
+// GetTagAncestors indicates an expected call of GetTagAncestors.
+func (mr *MockRepositoryMockRecorder) GetTagAncestors(tags interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTagAncestors", reflect.TypeOf((*MockRepository)(nil).GetTagAncestors), tags)
+}
+
+// GetUser mocks base method.
+func (m *MockRepository) GetUser(userUID string) (*models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUser", userUID)
+	ret0, _ := ret[0].(*models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUser indicates an expected call of GetUser.
+func (mr *MockRepositoryMockRecorder) GetUser(userUID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserInterests", reflect.TypeOf((*MockRepository)(nil).GetUserInterests), userUID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockRepository)(nil).GetUser), userUID)
 }
 
 // IncrementPopularity mocks base method.
@@ -179,17 +251,31 @@ func (mr *MockRepositoryMockRecorder) InsertProduct(product_id, tags interface{}
 }
 
 // InsertUser mocks base method.
-func (m *MockRepository) InsertUser(user_uid string, interests []string) error {
+func (m *MockRepository) InsertUser(user_uid string, interests []models.Interest, dislikedTags []string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "InsertUser", user_uid, interests)
+	ret := m.ctrl.Call(m, "InsertUser", user_uid, interests, dislikedTags)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // InsertUser indicates an expected call of InsertUser.
-func (mr *MockRepositoryMockRecorder) InsertUser(user_uid, interests interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) InsertUser(user_uid, interests, dislikedTags interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertUser", reflect.TypeOf((*MockRepository)(nil).InsertUser), user_uid, interests, dislikedTags)
+}
+
+// UpdateProductAvailability mocks base method.
+func (m *MockRepository) UpdateProductAvailability(productID int64, status string, stock *int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProductAvailability", productID, status, stock)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateProductAvailability indicates an expected call of UpdateProductAvailability.
+func (mr *MockRepositoryMockRecorder) UpdateProductAvailability(productID, status, stock interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertUser", reflect.TypeOf((*MockRepository)(nil).InsertUser), user_uid, interests)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProductAvailability", reflect.TypeOf((*MockRepository)(nil).UpdateProductAvailability), productID, status, stock)
 }
 
 // UpdateProductTags mocks base method.
@@ -207,15 +293,29 @@ func (mr *MockRepositoryMockRecorder) UpdateProductTags(product_id, tags interfa
 }
 
 // UpdateUserInterests mocks base method.
-func (m *MockRepository) UpdateUserInterests(user_uid string, interests []string) error {
+func (m *MockRepository) UpdateUserInterests(user_uid string, interests []models.Interest, dislikedTags []string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateUserInterests", user_uid, interests)
+	ret := m.ctrl.Call(m, "UpdateUserInterests", user_uid, interests, dislikedTags)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateUserInterests indicates an expected call of UpdateUserInterests.
-func (mr *MockRepositoryMockRecorder) UpdateUserInterests(user_uid, interests interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) UpdateUserInterests(user_uid, interests, dislikedTags interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserInterests", reflect.TypeOf((*MockRepository)(nil).UpdateUserInterests), user_uid, interests, dislikedTags)
+}
+
+// UpsertTag mocks base method.
+func (m *MockRepository) UpsertTag(name, parent string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertTag", name, parent)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpsertTag indicates an expected call of UpsertTag.
+func (mr *MockRepositoryMockRecorder) UpsertTag(name, parent interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserInterests", reflect.TypeOf((*MockRepository)(nil).UpdateUserInterests), user_uid, interests)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertTag", reflect.TypeOf((*MockRepository)(nil).UpsertTag), name, parent)
 }
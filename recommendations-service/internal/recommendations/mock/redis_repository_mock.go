@@ -34,6 +34,20 @@ func (m *MockRedisRepository) EXPECT() *MockRedisRepositoryMockRecorder {
 	return m.recorder
 }
 
+// DeleteRecommendations mocks base method.
+func (m *MockRedisRepository) DeleteRecommendations(key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRecommendations", key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteRecommendations indicates an expected call of DeleteRecommendations.
+func (mr *MockRedisRepositoryMockRecorder) DeleteRecommendations(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRecommendations", reflect.TypeOf((*MockRedisRepository)(nil).DeleteRecommendations), key)
+}
+
 // GetRecommendations mocks base method.
 func (m *MockRedisRepository) GetRecommendations(key string) ([]models.Recommendation, error) {
 	m.ctrl.T.Helper()
@@ -48,18 +48,46 @@ func (mr *MockUseCaseMockRecorder) DeleteProduct(productID interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteProduct", reflect.TypeOf((*MockUseCase)(nil).DeleteProduct), productID)
 }
 
+// DeleteTag mocks base method.
+func (m *MockUseCase) DeleteTag(name string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteTag", name)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteTag indicates an expected call of DeleteTag.
+func (mr *MockUseCaseMockRecorder) DeleteTag(name interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteTag", reflect.TypeOf((*MockUseCase)(nil).DeleteTag), name)
+}
+
+// DeleteUser mocks base method.
+func (m *MockUseCase) DeleteUser(userUID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUser", userUID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUser indicates an expected call of DeleteUser.
+func (mr *MockUseCaseMockRecorder) DeleteUser(userUID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUseCase)(nil).DeleteUser), userUID)
+}
+
 // GenerateRecommendationsForUser mocks base method.
-func (m *MockUseCase) GenerateRecommendationsForUser(userUID string, newInterests []string) error {
+func (m *MockUseCase) GenerateRecommendationsForUser(userUID string, interests []models.Interest, dislikedTags []string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GenerateRecommendationsForUser", userUID, newInterests)
+	ret := m.ctrl.Call(m, "GenerateRecommendationsForUser", userUID, interests, dislikedTags)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // GenerateRecommendationsForUser indicates an expected call of GenerateRecommendationsForUser.
-func (mr *MockUseCaseMockRecorder) GenerateRecommendationsForUser(userUID, newInterests interface{}) *gomock.Call {
+func (mr *MockUseCaseMockRecorder) GenerateRecommendationsForUser(userUID, interests, dislikedTags interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateRecommendationsForUser", reflect.TypeOf((*MockUseCase)(nil).GenerateRecommendationsForUser), userUID, newInterests)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateRecommendationsForUser", reflect.TypeOf((*MockUseCase)(nil).GenerateRecommendationsForUser), userUID, interests, dislikedTags)
 }
 
 // GetRecommendationsForUser mocks base method.
@@ -105,6 +133,34 @@ func (mr *MockUseCaseMockRecorder) InsertProduct(productID, tags interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertProduct", reflect.TypeOf((*MockUseCase)(nil).InsertProduct), productID, tags)
 }
 
+// UpdateProductAvailability mocks base method.
+func (m *MockUseCase) UpdateProductAvailability(productID int64, status string, stock *int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProductAvailability", productID, status, stock)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateProductAvailability indicates an expected call of UpdateProductAvailability.
+func (mr *MockUseCaseMockRecorder) UpdateProductAvailability(productID, status, stock interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProductAvailability", reflect.TypeOf((*MockUseCase)(nil).UpdateProductAvailability), productID, status, stock)
+}
+
+// UpdateProductTags mocks base method.
+func (m *MockUseCase) UpdateProductTags(productID int64, tags, addedTags, removedTags []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProductTags", productID, tags, addedTags, removedTags)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateProductTags indicates an expected call of UpdateProductTags.
+func (mr *MockUseCaseMockRecorder) UpdateProductTags(productID, tags, addedTags, removedTags interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProductTags", reflect.TypeOf((*MockUseCase)(nil).UpdateProductTags), productID, tags, addedTags, removedTags)
+}
+
 // UpdateRecommendationsForProduct mocks base method.
 func (m *MockUseCase) UpdateRecommendationsForProduct(productID int64, newTags []string) error {
 	m.ctrl.T.Helper()
@@ -118,3 +174,17 @@ func (mr *MockUseCaseMockRecorder) UpdateRecommendationsForProduct(productID, ne
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRecommendationsForProduct", reflect.TypeOf((*MockUseCase)(nil).UpdateRecommendationsForProduct), productID, newTags)
 }
+
+// UpsertTag mocks base method.
+func (m *MockUseCase) UpsertTag(name, parent string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpsertTag", name, parent)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpsertTag indicates an expected call of UpsertTag.
+func (mr *MockUseCaseMockRecorder) UpsertTag(name, parent interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpsertTag", reflect.TypeOf((*MockUseCase)(nil).UpsertTag), name, parent)
+}
@@ -4,17 +4,24 @@ import "cyansnbrst/recommendations-service/internal/models"
 
 // Recommendations repository interface
 type Repository interface {
-	CreateRecommendation(user_uid string, product_id int64) error
+	CreateRecommendation(user_uid string, product_id int64, score float64) error
 	GetRecommendationsByUser(user_uid string) ([]models.Recommendation, error)
-	InsertUser(user_uid string, interests []string) error
+	InsertUser(user_uid string, interests []models.Interest, dislikedTags []string) error
 	InsertProduct(product_id int64, tags []string) error
 	IncrementPopularity(product_id int64) error
 	UpdateProductTags(product_id int64, tags []string) error
-	UpdateUserInterests(user_uid string, interests []string) error
+	UpdateProductAvailability(productID int64, status string, stock *int64) error
+	UpdateUserInterests(user_uid string, interests []models.Interest, dislikedTags []string) error
 	FindProductsByTags(tag string) ([]int64, error)
+	FindUsersByTags(tags []string) ([]string, error)
 	DeleteRecommendationsForProduct(productID int64) error
+	DeleteRecommendation(userUID string, productID int64) error
 	GetAllUsers() ([]string, error)
-	GetUserInterests(userUID string) ([]string, error)
+	GetUser(userUID string) (*models.User, error)
 	DeleteRecommendationsForUser(userUID string) error
 	DeleteProduct(productID int64) error
+	DeleteUser(userUID string) error
+	UpsertTag(name string, parent string) error
+	DeleteTag(name string) error
+	GetTagAncestors(tags []string) ([]string, error)
 }
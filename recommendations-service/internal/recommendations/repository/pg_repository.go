@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 
 	"github.com/lib/pq"
 
@@ -22,13 +23,13 @@ func NewRecommendationsRepository(cfg *config.Config, db *sql.DB) recommendation
 	return &recommendationsRepo{cfg: cfg, db: db}
 }
 
-// Insert a new recommendation
-func (r *recommendationsRepo) CreateRecommendation(userUID string, productID int64) error {
+// Insert a new recommendation with its score
+func (r *recommendationsRepo) CreateRecommendation(userUID string, productID int64, score float64) error {
 	query := `
-		INSERT INTO recommendations (user_uid, product_id)
-		VALUES ($1, $2)`
+		INSERT INTO recommendations (user_uid, product_id, score)
+		VALUES ($1, $2, $3)`
 
-	args := []interface{}{userUID, productID}
+	args := []interface{}{userUID, productID, score}
 
 	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
 	defer cancel()
@@ -47,8 +48,8 @@ func (r *recommendationsRepo) GetRecommendationsByUser(userUID string) ([]models
         SELECT r.product_id
         FROM recommendations r
         JOIN products p ON r.product_id = p.product_id
-        WHERE r.user_uid = $1
-        ORDER BY p.popularity DESC`
+        WHERE r.user_uid = $1 AND p.status = 'active' AND (p.stock IS NULL OR p.stock > 0)
+        ORDER BY r.score DESC, p.popularity DESC`
 
 	var recommendations []models.Recommendation
 
@@ -77,17 +78,22 @@ func (r *recommendationsRepo) GetRecommendationsByUser(userUID string) ([]models
 }
 
 // Insert new user
-func (r *recommendationsRepo) InsertUser(userUID string, interests []string) error {
+func (r *recommendationsRepo) InsertUser(userUID string, interests []models.Interest, dislikedTags []string) error {
 	query := `
-        INSERT INTO users (user_uid, interests)
-        VALUES ($1, $2)`
+        INSERT INTO users (user_uid, interests, disliked_tags)
+        VALUES ($1, $2, $3)`
 
-	args := []interface{}{userUID, pq.Array(interests)}
+	weighted, err := marshalInterests(interests)
+	if err != nil {
+		return err
+	}
+
+	args := []interface{}{userUID, weighted, pq.Array(nonNilTags(dislikedTags))}
 
 	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
 	defer cancel()
 
-	_, err := r.db.ExecContext(ctx, query, args...)
+	_, err = r.db.ExecContext(ctx, query, args...)
 	if err != nil {
 		return err
 	}
@@ -95,11 +101,12 @@ func (r *recommendationsRepo) InsertUser(userUID string, interests []string) err
 	return nil
 }
 
-// Insert new product
+// Insert new product, a restored product that is still stored gets its tags overwritten
 func (r *recommendationsRepo) InsertProduct(productID int64, tags []string) error {
 	query := `
         INSERT INTO products (product_id, tags)
-        VALUES ($1, $2)`
+        VALUES ($1, $2)
+        ON CONFLICT (product_id) DO UPDATE SET tags = EXCLUDED.tags`
 
 	args := []interface{}{productID, pq.Array(tags)}
 
@@ -114,6 +121,26 @@ func (r *recommendationsRepo) InsertProduct(productID int64, tags []string) erro
 	return nil
 }
 
+// Update product status and stock, a nil stock means stock isn't tracked
+func (r *recommendationsRepo) UpdateProductAvailability(productID int64, status string, stock *int64) error {
+	query := `
+		UPDATE products
+		SET status = $1, stock = $2
+		WHERE product_id = $3`
+
+	args := []interface{}{status, stock, productID}
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // Increment popularity
 func (r *recommendationsRepo) IncrementPopularity(productID int64) error {
 	query := `
@@ -153,18 +180,23 @@ func (r *recommendationsRepo) UpdateProductTags(productID int64, tags []string)
 }
 
 // Update user interests
-func (r *recommendationsRepo) UpdateUserInterests(userUID string, interests []string) error {
+func (r *recommendationsRepo) UpdateUserInterests(userUID string, interests []models.Interest, dislikedTags []string) error {
 	query := `
         UPDATE users
-        SET interests = $1
-        WHERE user_uid = $2`
+        SET interests = $1, disliked_tags = $2
+        WHERE user_uid = $3`
 
-	args := []interface{}{pq.Array(interests), userUID}
+	weighted, err := marshalInterests(interests)
+	if err != nil {
+		return err
+	}
+
+	args := []interface{}{weighted, pq.Array(nonNilTags(dislikedTags)), userUID}
 
 	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
 	defer cancel()
 
-	_, err := r.db.ExecContext(ctx, query, args...)
+	_, err = r.db.ExecContext(ctx, query, args...)
 	if err != nil {
 		return err
 	}
@@ -172,12 +204,20 @@ func (r *recommendationsRepo) UpdateUserInterests(userUID string, interests []st
 	return nil
 }
 
-// Find products by tags
+// Find products with the tag or one of its descendant tags
 func (r *recommendationsRepo) FindProductsByTags(tag string) ([]int64, error) {
 	query := `
-        SELECT product_id
-        FROM products
-        WHERE $1 = ANY(tags)`
+		WITH RECURSIVE subtree (name, depth) AS (
+			SELECT $1::TEXT, 0
+			UNION
+			SELECT t.name, s.depth + 1
+			FROM tags t
+			JOIN subtree s ON t.parent = s.name
+			WHERE s.depth < 100
+		)
+		SELECT product_id
+		FROM products
+		WHERE tags && ARRAY(SELECT name FROM subtree)`
 
 	var productIDs []int64
 
@@ -205,6 +245,60 @@ func (r *recommendationsRepo) FindProductsByTags(tag string) ([]int64, error) {
 	return productIDs, nil
 }
 
+// Find users interested in or disliking one of the tags
+func (r *recommendationsRepo) FindUsersByTags(tags []string) ([]string, error) {
+	query := `
+		SELECT user_uid
+		FROM users
+		WHERE disliked_tags && $1
+			OR EXISTS (
+				SELECT 1 FROM jsonb_array_elements(interests) AS i
+				WHERE i->>'tag' = ANY($1)
+			)`
+
+	var users []string
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(tags))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userUID string
+		if err := rows.Scan(&userUID); err != nil {
+			return nil, err
+		}
+		users = append(users, userUID)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// Delete the recommendation of a product for a user
+func (r *recommendationsRepo) DeleteRecommendation(userUID string, productID int64) error {
+	query := `
+        DELETE FROM recommendations
+        WHERE user_uid = $1 AND product_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, query, userUID, productID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
 // Delete recommendations for a product
 func (r *recommendationsRepo) DeleteRecommendationsForProduct(productID int64) error {
 	query := `
@@ -254,27 +348,32 @@ func (r *recommendationsRepo) GetAllUsers() ([]string, error) {
 	return users, nil
 }
 
-// Get user interests
-func (r *recommendationsRepo) GetUserInterests(userUID string) ([]string, error) {
+// Get user with weighted and disliked interests, nil if the user is unknown
+func (r *recommendationsRepo) GetUser(userUID string) (*models.User, error) {
 	query := `
-        SELECT interests
+        SELECT user_uid, interests, disliked_tags
         FROM users
         WHERE user_uid = $1`
 
-	var interests []string
+	user := models.User{}
+	var interests []byte
 
 	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
 	defer cancel()
 
 	row := r.db.QueryRowContext(ctx, query, userUID)
-	if err := row.Scan(pq.Array(&interests)); err != nil {
+	if err := row.Scan(&user.UserUID, &interests, pq.Array(&user.DislikedTags)); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
 		return nil, err
 	}
 
-	return interests, nil
+	if err := json.Unmarshal(interests, &user.Interests); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
 }
 
 // Delete recommendations for a user
@@ -310,3 +409,114 @@ func (r *recommendationsRepo) DeleteProduct(productID int64) error {
 
 	return nil
 }
+
+// Delete user with their recommendations
+func (r *recommendationsRepo) DeleteUser(userUID string) error {
+	query := `
+        DELETE FROM users
+        WHERE user_uid = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, query, userUID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Insert a tag or change its parent, an empty parent makes the tag a root
+func (r *recommendationsRepo) UpsertTag(name string, parent string) error {
+	query := `
+		INSERT INTO tags (name, parent)
+		VALUES ($1, NULLIF($2, ''))
+		ON CONFLICT (name) DO UPDATE SET parent = EXCLUDED.parent`
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, query, name, parent)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Delete tag, its children become roots
+func (r *recommendationsRepo) DeleteTag(name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM tags WHERE name = $1`, name); err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, `UPDATE tags SET parent = NULL WHERE parent = $1`, name); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Get ancestors of the tags up to the roots
+func (r *recommendationsRepo) GetTagAncestors(tags []string) ([]string, error) {
+	query := `
+		WITH RECURSIVE ancestors (name, depth) AS (
+			SELECT parent, 1 FROM tags WHERE name = ANY($1) AND parent IS NOT NULL
+			UNION
+			SELECT t.parent, a.depth + 1
+			FROM tags t
+			JOIN ancestors a ON t.name = a.name
+			WHERE t.parent IS NOT NULL AND a.depth < 100
+		)
+		SELECT DISTINCT name FROM ancestors`
+
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.PostgreSQLAction)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(tags))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ancestors []string
+	for rows.Next() {
+		var ancestor string
+		if err := rows.Scan(&ancestor); err != nil {
+			return nil, err
+		}
+		ancestors = append(ancestors, ancestor)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return ancestors, nil
+}
+
+// Encode interests for the JSONB column, no interests are stored as an empty array
+func marshalInterests(interests []models.Interest) ([]byte, error) {
+	if interests == nil {
+		interests = []models.Interest{}
+	}
+	return json.Marshal(interests)
+}
+
+// Tags for a NOT NULL array column
+func nonNilTags(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
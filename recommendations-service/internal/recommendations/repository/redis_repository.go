@@ -56,3 +56,11 @@ func (r *recommendationsRedisRepo) SetRecommendations(key string, recommendation
 
 	return nil
 }
+
+// Delete cached recommendations for user
+func (r *recommendationsRedisRepo) DeleteRecommendations(key string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), r.cfg.Timeout.RedisAction)
+	defer cancel()
+
+	return r.redisClient.Del(ctx, key).Err()
+}
@@ -3,10 +3,15 @@ package recommendations
 import "cyansnbrst/recommendations-service/internal/models"
 
 type UseCase interface {
-	GenerateRecommendationsForUser(userUID string, newInterests []string) error
+	GenerateRecommendationsForUser(userUID string, interests []models.Interest, dislikedTags []string) error
 	UpdateRecommendationsForProduct(productID int64, newTags []string) error
+	UpdateProductTags(productID int64, tags, addedTags, removedTags []string) error
 	GetRecommendationsForUser(userUID string) ([]models.Recommendation, error)
 	IncrementPopularity(productID int64) error
 	InsertProduct(productID int64, tags []string) error
+	UpdateProductAvailability(productID int64, status string, stock *int64) error
 	DeleteProduct(productID int64) error
+	DeleteUser(userUID string) error
+	UpsertTag(name string, parent string) error
+	DeleteTag(name string) error
 }
@@ -1,6 +1,8 @@
 package usecase
 
 import (
+	"sort"
+
 	"go.uber.org/zap"
 
 	"cyansnbrst/recommendations-service/config"
@@ -8,6 +10,9 @@ import (
 	"cyansnbrst/recommendations-service/internal/recommendations"
 )
 
+// Status of products that can be recommended
+const productStatusActive = "active"
+
 // Recommendations UseCase struct
 type recommendationsUC struct {
 	cfg                 *config.Config
@@ -21,48 +26,70 @@ func NewRecommendationsUseCase(cfg *config.Config, recommendationsRepo recommend
 	return &recommendationsUC{cfg: cfg, recommendationsRepo: recommendationsRepo, redisRepo: redisRepo, logger: logger}
 }
 
-// Generate recommendations for user
-func (u *recommendationsUC) GenerateRecommendationsForUser(userUID string, newInterests []string) error {
-	interests, err := u.recommendationsRepo.GetUserInterests(userUID)
+// Generate recommendations for user from weighted interests, products with a disliked tag are left out
+func (u *recommendationsUC) GenerateRecommendationsForUser(userUID string, interests []models.Interest, dislikedTags []string) error {
+	user, err := u.recommendationsRepo.GetUser(userUID)
 	if err != nil {
 		return err
 	}
 
-	if interests != nil {
-		return u.refreshRecommendations(userUID, newInterests)
+	if user != nil {
+		err = u.recommendationsRepo.UpdateUserInterests(userUID, interests, dislikedTags)
+		if err != nil {
+			return err
+		}
+		return u.refreshRecommendations(userUID, interests, dislikedTags)
 	}
 
-	err = u.recommendationsRepo.InsertUser(userUID, newInterests)
+	err = u.recommendationsRepo.InsertUser(userUID, interests, dislikedTags)
 	if err != nil {
 		return err
 	}
 
-	return u.createRecommendations(userUID, newInterests)
+	return u.createRecommendations(userUID, interests, dislikedTags)
 }
 
 // Refresh existing recommendations
-func (u *recommendationsUC) refreshRecommendations(userUID string, newInterests []string) error {
+func (u *recommendationsUC) refreshRecommendations(userUID string, interests []models.Interest, dislikedTags []string) error {
 	err := u.recommendationsRepo.DeleteRecommendationsForUser(userUID)
 	if err != nil {
 		return err
 	}
 
-	return u.createRecommendations(userUID, newInterests)
+	return u.createRecommendations(userUID, interests, dislikedTags)
 }
 
-// Create recommendations for user
-func (u *recommendationsUC) createRecommendations(userUID string, interests []string) error {
-	var productIDs []int64
+// Create recommendations for user, a product scores the sum of weights of the interests it matches
+func (u *recommendationsUC) createRecommendations(userUID string, interests []models.Interest, dislikedTags []string) error {
+	scores := make(map[int64]float64)
 	for _, interest := range interests {
-		products, err := u.recommendationsRepo.FindProductsByTags(interest)
+		products, err := u.recommendationsRepo.FindProductsByTags(interest.Tag)
+		if err != nil {
+			return err
+		}
+		for _, productID := range products {
+			scores[productID] += interest.Weight
+		}
+	}
+
+	for _, tag := range dislikedTags {
+		products, err := u.recommendationsRepo.FindProductsByTags(tag)
 		if err != nil {
 			return err
 		}
-		productIDs = append(productIDs, products...)
+		for _, productID := range products {
+			delete(scores, productID)
+		}
 	}
 
+	productIDs := make([]int64, 0, len(scores))
+	for productID := range scores {
+		productIDs = append(productIDs, productID)
+	}
+	sort.Slice(productIDs, func(i, j int) bool { return productIDs[i] < productIDs[j] })
+
 	for _, productID := range productIDs {
-		err := u.recommendationsRepo.CreateRecommendation(userUID, productID)
+		err := u.recommendationsRepo.CreateRecommendation(userUID, productID, scores[productID])
 		if err != nil {
 			return err
 		}
@@ -71,26 +98,35 @@ func (u *recommendationsUC) createRecommendations(userUID string, interests []st
 	return nil
 }
 
-// Update product tags and recommendations
+// Update product tags and recommendations, the product also matches interests in ancestors of its tags
 func (u *recommendationsUC) UpdateRecommendationsForProduct(productID int64, newTags []string) error {
 	users, err := u.recommendationsRepo.GetAllUsers()
 	if err != nil {
 		return err
 	}
 
+	ancestors, err := u.recommendationsRepo.GetTagAncestors(newTags)
+	if err != nil {
+		return err
+	}
+	matchTags := append(append([]string{}, newTags...), ancestors...)
+
 	err = u.recommendationsRepo.DeleteRecommendationsForProduct(productID)
 	if err != nil {
 		return err
 	}
 
 	for _, userUID := range users {
-		userInterests, err := u.recommendationsRepo.GetUserInterests(userUID)
+		user, err := u.recommendationsRepo.GetUser(userUID)
 		if err != nil {
 			return err
 		}
+		if user == nil {
+			continue
+		}
 
-		if isUserInterestedInNewTags(userInterests, newTags) {
-			err := u.recommendationsRepo.CreateRecommendation(userUID, productID)
+		if score := productScore(user, matchTags); score > 0 {
+			err := u.recommendationsRepo.CreateRecommendation(userUID, productID, score)
 			if err != nil {
 				return err
 			}
@@ -100,6 +136,64 @@ func (u *recommendationsUC) UpdateRecommendationsForProduct(productID int64, new
 	return nil
 }
 
+// Store new product tags and rescore the product only for users the tag change can affect: users interested in
+// or disliking an added or removed tag or one of its ancestors. Scores of other users don't depend on the changed tags.
+func (u *recommendationsUC) UpdateProductTags(productID int64, tags, addedTags, removedTags []string) error {
+	if err := u.recommendationsRepo.UpdateProductTags(productID, tags); err != nil {
+		return err
+	}
+
+	changedTags := append(append([]string{}, addedTags...), removedTags...)
+	if len(changedTags) == 0 {
+		return nil
+	}
+
+	changedAncestors, err := u.recommendationsRepo.GetTagAncestors(changedTags)
+	if err != nil {
+		return err
+	}
+
+	users, err := u.recommendationsRepo.FindUsersByTags(append(changedTags, changedAncestors...))
+	if err != nil {
+		return err
+	}
+	if len(users) == 0 {
+		return nil
+	}
+
+	ancestors, err := u.recommendationsRepo.GetTagAncestors(tags)
+	if err != nil {
+		return err
+	}
+	matchTags := append(append([]string{}, tags...), ancestors...)
+
+	for _, userUID := range users {
+		user, err := u.recommendationsRepo.GetUser(userUID)
+		if err != nil {
+			return err
+		}
+		if user == nil {
+			continue
+		}
+
+		if err = u.recommendationsRepo.DeleteRecommendation(userUID, productID); err != nil {
+			return err
+		}
+
+		if score := productScore(user, matchTags); score > 0 {
+			if err = u.recommendationsRepo.CreateRecommendation(userUID, productID, score); err != nil {
+				return err
+			}
+		}
+
+		if err = u.redisRepo.DeleteRecommendations(userUID); err != nil {
+			u.logger.Error("redis repository", zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
 // Show user's recommendations
 func (u *recommendationsUC) GetRecommendationsForUser(userUID string) ([]models.Recommendation, error) {
 	recommendations, err := u.redisRepo.GetRecommendations(userUID)
@@ -133,19 +227,62 @@ func (u *recommendationsUC) InsertProduct(productID int64, tags []string) error
 	return u.recommendationsRepo.InsertProduct(productID, tags)
 }
 
+// Update product availability, events without a status come from products that are always active
+func (u *recommendationsUC) UpdateProductAvailability(productID int64, status string, stock *int64) error {
+	if status == "" {
+		status = productStatusActive
+	}
+	return u.recommendationsRepo.UpdateProductAvailability(productID, status, stock)
+}
+
 // Delete product
 func (u *recommendationsUC) DeleteProduct(productID int64) error {
 	return u.recommendationsRepo.DeleteProduct(productID)
 }
 
-// Check if the user is interested in any of the new tags
-func isUserInterestedInNewTags(userInterests []string, newTags []string) bool {
-	for _, newTag := range newTags {
-		for _, interest := range userInterests {
-			if interest == newTag {
-				return true
-			}
+// Delete user's data and cached recommendations
+func (u *recommendationsUC) DeleteUser(userUID string) error {
+	if err := u.recommendationsRepo.DeleteUser(userUID); err != nil {
+		return err
+	}
+
+	// The user is already deleted and the cached list expires on its own, so a cache error does not fail the deletion
+	if err := u.redisRepo.DeleteRecommendations(userUID); err != nil {
+		u.logger.Error("redis repository", zap.Error(err))
+	}
+
+	return nil
+}
+
+// Store a tag of the tag hierarchy
+func (u *recommendationsUC) UpsertTag(name string, parent string) error {
+	return u.recommendationsRepo.UpsertTag(name, parent)
+}
+
+// Delete a tag from the tag hierarchy
+func (u *recommendationsUC) DeleteTag(name string) error {
+	return u.recommendationsRepo.DeleteTag(name)
+}
+
+// Score of a product with the given tags for the user, zero if the user isn't interested
+// in any of the tags or dislikes one of them
+func productScore(user *models.User, tags []string) float64 {
+	tagSet := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tagSet[tag] = true
+	}
+
+	for _, tag := range user.DislikedTags {
+		if tagSet[tag] {
+			return 0
+		}
+	}
+
+	var score float64
+	for _, interest := range user.Interests {
+		if tagSet[interest.Tag] {
+			score += interest.Weight
 		}
 	}
-	return false
+	return score
 }
@@ -27,43 +27,50 @@ func TestRecommendationsUC_GenerateRecommendationsForUser(t *testing.T) {
 	tests := []struct {
 		name         string
 		userUID      string
-		newInterests []string
+		interests    []models.Interest
+		dislikedTags []string
 		mockBehavior func(mockRepo *mock_recommendations.MockRepository)
 		wantErr      bool
 	}{
 		{
-			name:         "success new user",
-			userUID:      "user1",
-			newInterests: []string{"tag1", "tag2"},
+			name:      "success new user",
+			userUID:   "user1",
+			interests: []models.Interest{{Tag: "tag1", Weight: 1}, {Tag: "tag2", Weight: 0.5}},
 			mockBehavior: func(mockRepo *mock_recommendations.MockRepository) {
-				mockRepo.EXPECT().GetUserInterests("user1").Return(nil, nil)
-				mockRepo.EXPECT().InsertUser("user1", []string{"tag1", "tag2"}).Return(nil)
+				interests := []models.Interest{{Tag: "tag1", Weight: 1}, {Tag: "tag2", Weight: 0.5}}
+				mockRepo.EXPECT().GetUser("user1").Return(nil, nil)
+				mockRepo.EXPECT().InsertUser("user1", interests, nil).Return(nil)
 				mockRepo.EXPECT().FindProductsByTags("tag1").Return([]int64{1, 2}, nil)
-				mockRepo.EXPECT().FindProductsByTags("tag2").Return([]int64{3}, nil)
-				mockRepo.EXPECT().CreateRecommendation("user1", int64(1)).Return(nil)
-				mockRepo.EXPECT().CreateRecommendation("user1", int64(2)).Return(nil)
-				mockRepo.EXPECT().CreateRecommendation("user1", int64(3)).Return(nil)
+				mockRepo.EXPECT().FindProductsByTags("tag2").Return([]int64{2, 3}, nil)
+				mockRepo.EXPECT().CreateRecommendation("user1", int64(1), 1.0).Return(nil)
+				mockRepo.EXPECT().CreateRecommendation("user1", int64(2), 1.5).Return(nil)
+				mockRepo.EXPECT().CreateRecommendation("user1", int64(3), 0.5).Return(nil)
 			},
 			wantErr: false,
 		},
 		{
 			name:         "success existing user",
 			userUID:      "user2",
-			newInterests: []string{"tag3"},
+			interests:    []models.Interest{{Tag: "tag3", Weight: 0.8}},
+			dislikedTags: []string{"tag4"},
 			mockBehavior: func(mockRepo *mock_recommendations.MockRepository) {
-				mockRepo.EXPECT().GetUserInterests("user2").Return([]string{"tag1"}, nil)
+				interests := []models.Interest{{Tag: "tag3", Weight: 0.8}}
+				user := &models.User{UserUID: "user2", Interests: []models.Interest{{Tag: "tag1", Weight: 1}}}
+				mockRepo.EXPECT().GetUser("user2").Return(user, nil)
+				mockRepo.EXPECT().UpdateUserInterests("user2", interests, []string{"tag4"}).Return(nil)
 				mockRepo.EXPECT().DeleteRecommendationsForUser("user2").Return(nil)
-				mockRepo.EXPECT().FindProductsByTags("tag3").Return([]int64{4}, nil)
-				mockRepo.EXPECT().CreateRecommendation("user2", int64(4)).Return(nil)
+				mockRepo.EXPECT().FindProductsByTags("tag3").Return([]int64{4, 5}, nil)
+				mockRepo.EXPECT().FindProductsByTags("tag4").Return([]int64{5}, nil)
+				mockRepo.EXPECT().CreateRecommendation("user2", int64(4), 0.8).Return(nil)
 			},
 			wantErr: false,
 		},
 		{
-			name:         "error get user interests",
-			userUID:      "user3",
-			newInterests: []string{"tag1"},
+			name:      "error get user",
+			userUID:   "user3",
+			interests: []models.Interest{{Tag: "tag1", Weight: 1}},
 			mockBehavior: func(mockRepo *mock_recommendations.MockRepository) {
-				mockRepo.EXPECT().GetUserInterests("user3").Return(nil, errors.New("db error"))
+				mockRepo.EXPECT().GetUser("user3").Return(nil, errors.New("db error"))
 			},
 			wantErr: true,
 		},
@@ -74,7 +81,7 @@ func TestRecommendationsUC_GenerateRecommendationsForUser(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mockBehavior(mockRepo)
 
-			err := recommendationsUC.GenerateRecommendationsForUser(tt.userUID, tt.newInterests)
+			err := recommendationsUC.GenerateRecommendationsForUser(tt.userUID, tt.interests, tt.dislikedTags)
 
 			if tt.wantErr {
 				require.Error(t, err)
@@ -170,10 +177,46 @@ func TestRecommendationsUC_UpdateRecommendationsForProduct(t *testing.T) {
 			productID: 1,
 			newTags:   []string{"tag1"},
 			mockBehavior: func(mockRepo *mock_recommendations.MockRepository) {
+				user := &models.User{UserUID: "user1", Interests: []models.Interest{{Tag: "tag1", Weight: 0.7}}}
 				mockRepo.EXPECT().GetAllUsers().Return([]string{"user1"}, nil)
-				mockRepo.EXPECT().GetUserInterests("user1").Return([]string{"tag1"}, nil)
+				mockRepo.EXPECT().GetTagAncestors([]string{"tag1"}).Return(nil, nil)
+				mockRepo.EXPECT().GetUser("user1").Return(user, nil)
 				mockRepo.EXPECT().DeleteRecommendationsForProduct(int64(1)).Return(nil)
-				mockRepo.EXPECT().CreateRecommendation("user1", int64(1)).Return(nil)
+				mockRepo.EXPECT().CreateRecommendation("user1", int64(1), 0.7).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:      "interest in an ancestor tag",
+			productID: 4,
+			newTags:   []string{"rock"},
+			mockBehavior: func(mockRepo *mock_recommendations.MockRepository) {
+				user := &models.User{
+					UserUID:   "user1",
+					Interests: []models.Interest{{Tag: "music", Weight: 0.5}, {Tag: "rock", Weight: 1}},
+				}
+				mockRepo.EXPECT().GetAllUsers().Return([]string{"user1"}, nil)
+				mockRepo.EXPECT().GetTagAncestors([]string{"rock"}).Return([]string{"music"}, nil)
+				mockRepo.EXPECT().GetUser("user1").Return(user, nil)
+				mockRepo.EXPECT().DeleteRecommendationsForProduct(int64(4)).Return(nil)
+				mockRepo.EXPECT().CreateRecommendation("user1", int64(4), 1.5).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:      "disliked tag",
+			productID: 3,
+			newTags:   []string{"tag1", "tag2"},
+			mockBehavior: func(mockRepo *mock_recommendations.MockRepository) {
+				user := &models.User{
+					UserUID:      "user1",
+					Interests:    []models.Interest{{Tag: "tag1", Weight: 1}},
+					DislikedTags: []string{"tag2"},
+				}
+				mockRepo.EXPECT().GetAllUsers().Return([]string{"user1"}, nil)
+				mockRepo.EXPECT().GetTagAncestors([]string{"tag1", "tag2"}).Return(nil, nil)
+				mockRepo.EXPECT().GetUser("user1").Return(user, nil)
+				mockRepo.EXPECT().DeleteRecommendationsForProduct(int64(3)).Return(nil)
 			},
 			wantErr: false,
 		},
@@ -204,6 +247,113 @@ func TestRecommendationsUC_UpdateRecommendationsForProduct(t *testing.T) {
 	}
 }
 
+func TestRecommendationsUC_UpdateProductTags(t *testing.T) {
+	cfg := &config.Config{}
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_recommendations.NewMockRepository(ctrl)
+	mockRedisRepo := mock_recommendations.NewMockRedisRepository(ctrl)
+	recommendationsUC := NewRecommendationsUseCase(cfg, mockRepo, mockRedisRepo, logger)
+
+	tests := []struct {
+		name         string
+		tags         []string
+		addedTags    []string
+		removedTags  []string
+		mockBehavior func(mockRepo *mock_recommendations.MockRepository, mockRedisRepo *mock_recommendations.MockRedisRepository)
+		wantErr      bool
+	}{
+		{
+			name:        "only affected users are rescored",
+			tags:        []string{"rock", "jazz"},
+			addedTags:   []string{"jazz"},
+			removedTags: []string{"pop"},
+			mockBehavior: func(mockRepo *mock_recommendations.MockRepository, mockRedisRepo *mock_recommendations.MockRedisRepository) {
+				fan := &models.User{UserUID: "fan", Interests: []models.Interest{{Tag: "music", Weight: 0.5}, {Tag: "jazz", Weight: 1}}}
+				popFan := &models.User{UserUID: "pop-fan", Interests: []models.Interest{{Tag: "pop", Weight: 1}}}
+
+				mockRepo.EXPECT().UpdateProductTags(int64(1), []string{"rock", "jazz"}).Return(nil)
+				mockRepo.EXPECT().GetTagAncestors([]string{"jazz", "pop"}).Return([]string{"music"}, nil)
+				mockRepo.EXPECT().FindUsersByTags([]string{"jazz", "pop", "music"}).Return([]string{"fan", "pop-fan"}, nil)
+				mockRepo.EXPECT().GetTagAncestors([]string{"rock", "jazz"}).Return([]string{"music"}, nil)
+
+				mockRepo.EXPECT().GetUser("fan").Return(fan, nil)
+				mockRepo.EXPECT().DeleteRecommendation("fan", int64(1)).Return(nil)
+				mockRepo.EXPECT().CreateRecommendation("fan", int64(1), 1.5).Return(nil)
+				mockRedisRepo.EXPECT().DeleteRecommendations("fan").Return(nil)
+
+				mockRepo.EXPECT().GetUser("pop-fan").Return(popFan, nil)
+				mockRepo.EXPECT().DeleteRecommendation("pop-fan", int64(1)).Return(nil)
+				mockRedisRepo.EXPECT().DeleteRecommendations("pop-fan").Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:      "disliked added tag",
+			tags:      []string{"rock", "horror"},
+			addedTags: []string{"horror"},
+			mockBehavior: func(mockRepo *mock_recommendations.MockRepository, mockRedisRepo *mock_recommendations.MockRedisRepository) {
+				user := &models.User{UserUID: "user1", Interests: []models.Interest{{Tag: "rock", Weight: 1}}, DislikedTags: []string{"horror"}}
+
+				mockRepo.EXPECT().UpdateProductTags(int64(1), []string{"rock", "horror"}).Return(nil)
+				mockRepo.EXPECT().GetTagAncestors([]string{"horror"}).Return(nil, nil)
+				mockRepo.EXPECT().FindUsersByTags([]string{"horror"}).Return([]string{"user1"}, nil)
+				mockRepo.EXPECT().GetTagAncestors([]string{"rock", "horror"}).Return(nil, nil)
+				mockRepo.EXPECT().GetUser("user1").Return(user, nil)
+				mockRepo.EXPECT().DeleteRecommendation("user1", int64(1)).Return(nil)
+				mockRedisRepo.EXPECT().DeleteRecommendations("user1").Return(errors.New("redis error"))
+			},
+			wantErr: false,
+		},
+		{
+			name:      "no users affected",
+			tags:      []string{"rock"},
+			addedTags: []string{"rock"},
+			mockBehavior: func(mockRepo *mock_recommendations.MockRepository, mockRedisRepo *mock_recommendations.MockRedisRepository) {
+				mockRepo.EXPECT().UpdateProductTags(int64(1), []string{"rock"}).Return(nil)
+				mockRepo.EXPECT().GetTagAncestors([]string{"rock"}).Return(nil, nil)
+				mockRepo.EXPECT().FindUsersByTags([]string{"rock"}).Return(nil, nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "tags unchanged",
+			tags: []string{"rock"},
+			mockBehavior: func(mockRepo *mock_recommendations.MockRepository, mockRedisRepo *mock_recommendations.MockRedisRepository) {
+				mockRepo.EXPECT().UpdateProductTags(int64(1), []string{"rock"}).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:      "error update product tags",
+			tags:      []string{"rock"},
+			addedTags: []string{"rock"},
+			mockBehavior: func(mockRepo *mock_recommendations.MockRepository, mockRedisRepo *mock_recommendations.MockRedisRepository) {
+				mockRepo.EXPECT().UpdateProductTags(int64(1), []string{"rock"}).Return(errors.New("db error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockRepo, mockRedisRepo)
+
+			err := recommendationsUC.UpdateProductTags(1, tt.tags, tt.addedTags, tt.removedTags)
+
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
 func TestRecommendationsUC_IncrementPopularity(t *testing.T) {
 	cfg := &config.Config{}
 	logger := zap.NewNop()
@@ -309,6 +459,69 @@ func TestRecommendationsUC_InsertProduct(t *testing.T) {
 	}
 }
 
+func TestRecommendationsUC_UpdateProductAvailability(t *testing.T) {
+	cfg := &config.Config{}
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_recommendations.NewMockRepository(ctrl)
+	mockRedisRepo := mock_recommendations.NewMockRedisRepository(ctrl)
+	recommendationsUC := NewRecommendationsUseCase(cfg, mockRepo, mockRedisRepo, logger)
+
+	stock := int64(0)
+
+	tests := []struct {
+		name         string
+		status       string
+		stock        *int64
+		mockBehavior func(mockRepo *mock_recommendations.MockRepository)
+		wantErr      bool
+	}{
+		{
+			name:   "out of stock",
+			status: "active",
+			stock:  &stock,
+			mockBehavior: func(mockRepo *mock_recommendations.MockRepository) {
+				mockRepo.EXPECT().UpdateProductAvailability(int64(1), "active", &stock).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:   "missing status",
+			status: "",
+			mockBehavior: func(mockRepo *mock_recommendations.MockRepository) {
+				mockRepo.EXPECT().UpdateProductAvailability(int64(1), "active", nil).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:   "db error",
+			status: "archived",
+			mockBehavior: func(mockRepo *mock_recommendations.MockRepository) {
+				mockRepo.EXPECT().UpdateProductAvailability(int64(1), "archived", nil).Return(errors.New("db error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockRepo)
+
+			err := recommendationsUC.UpdateProductAvailability(1, tt.status, tt.stock)
+
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
 func TestRecommendationsUC_DeleteProduct(t *testing.T) {
 	cfg := &config.Config{}
 	logger := zap.NewNop()
@@ -359,3 +572,64 @@ func TestRecommendationsUC_DeleteProduct(t *testing.T) {
 		})
 	}
 }
+
+func TestRecommendationsUC_DeleteUser(t *testing.T) {
+	cfg := &config.Config{}
+	logger := zap.NewNop()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_recommendations.NewMockRepository(ctrl)
+	mockRedisRepo := mock_recommendations.NewMockRedisRepository(ctrl)
+	recommendationsUC := NewRecommendationsUseCase(cfg, mockRepo, mockRedisRepo, logger)
+
+	tests := []struct {
+		name         string
+		userUID      string
+		mockBehavior func(mockRepo *mock_recommendations.MockRepository, mockRedisRepo *mock_recommendations.MockRedisRepository)
+		wantErr      bool
+	}{
+		{
+			name:    "success",
+			userUID: "user1",
+			mockBehavior: func(mockRepo *mock_recommendations.MockRepository, mockRedisRepo *mock_recommendations.MockRedisRepository) {
+				mockRepo.EXPECT().DeleteUser("user1").Return(nil)
+				mockRedisRepo.EXPECT().DeleteRecommendations("user1").Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:    "db error",
+			userUID: "user2",
+			mockBehavior: func(mockRepo *mock_recommendations.MockRepository, mockRedisRepo *mock_recommendations.MockRedisRepository) {
+				mockRepo.EXPECT().DeleteUser("user2").Return(errors.New("db error"))
+			},
+			wantErr: true,
+		},
+		{
+			name:    "redis error is only logged",
+			userUID: "user3",
+			mockBehavior: func(mockRepo *mock_recommendations.MockRepository, mockRedisRepo *mock_recommendations.MockRedisRepository) {
+				mockRepo.EXPECT().DeleteUser("user3").Return(nil)
+				mockRedisRepo.EXPECT().DeleteRecommendations("user3").Return(errors.New("redis error"))
+			},
+			wantErr: false,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockBehavior(mockRepo, mockRedisRepo)
+
+			err := recommendationsUC.DeleteUser(tt.userUID)
+
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
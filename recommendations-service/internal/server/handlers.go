@@ -47,6 +47,7 @@ func (s *Server) RegisterHandlers() http.Handler {
 
 	kafkaClient.AddReader("product", s.config.Kafka.GroupID, kafkaHandlers.HandleProductMessage)
 	kafkaClient.AddReader("user", s.config.Kafka.GroupID, kafkaHandlers.HandleUserMessage)
+	kafkaClient.AddReader("tag", s.config.Kafka.GroupID, kafkaHandlers.HandleTagMessage)
 	kafkaClient.Run()
 
 	// Swagger
@@ -0,0 +1,10 @@
+ALTER TABLE recommendations DROP COLUMN IF EXISTS score;
+
+ALTER TABLE users DROP COLUMN IF EXISTS disliked_tags;
+ALTER TABLE users ADD COLUMN flat_interests TEXT[];
+
+UPDATE users
+SET flat_interests = ARRAY(SELECT jsonb_array_elements(interests) ->> 'tag');
+
+ALTER TABLE users DROP COLUMN interests;
+ALTER TABLE users RENAME COLUMN flat_interests TO interests;
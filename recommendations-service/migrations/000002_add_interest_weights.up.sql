@@ -0,0 +1,13 @@
+ALTER TABLE users ADD COLUMN weighted_interests JSONB NOT NULL DEFAULT '[]';
+
+UPDATE users
+SET weighted_interests = COALESCE(
+    (SELECT jsonb_agg(jsonb_build_object('tag', tag, 'weight', 1)) FROM unnest(interests) AS tag),
+    '[]'
+);
+
+ALTER TABLE users DROP COLUMN interests;
+ALTER TABLE users RENAME COLUMN weighted_interests TO interests;
+ALTER TABLE users ADD COLUMN disliked_tags TEXT[] NOT NULL DEFAULT '{}';
+
+ALTER TABLE recommendations ADD COLUMN score DOUBLE PRECISION NOT NULL DEFAULT 1;
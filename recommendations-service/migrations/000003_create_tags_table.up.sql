@@ -0,0 +1,6 @@
+CREATE TABLE tags (
+    name TEXT PRIMARY KEY,
+    parent TEXT
+);
+
+CREATE INDEX tags_parent_idx ON tags (parent);
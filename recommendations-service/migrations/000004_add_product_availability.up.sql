@@ -0,0 +1,3 @@
+ALTER TABLE products
+    ADD COLUMN status TEXT NOT NULL DEFAULT 'active',
+    ADD COLUMN stock BIGINT;
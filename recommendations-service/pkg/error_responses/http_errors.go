@@ -56,3 +56,8 @@ func AuthenticationRequiredResponse(w http.ResponseWriter, r *http.Request, l *z
 	message := "you must be authenticated to access this resource"
 	errorResponse(w, r, http.StatusUnauthorized, message, l)
 }
+
+func NotPermittedResponse(w http.ResponseWriter, r *http.Request, l *zap.Logger) {
+	message := "your user account doesnt't have the necessare permissions to access this resource"
+	errorResponse(w, r, http.StatusForbidden, message, l)
+}